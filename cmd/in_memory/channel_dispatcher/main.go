@@ -36,7 +36,10 @@ func main() {
 		ctx = injection.WithNamespaceScope(ctx, ns)
 	}
 
+	ctx, waitDrained := inmemorychannel.WithDrainedSignal(ctx)
 	sharedmain.MainWithContext(ctx, "inmemorychannel-dispatcher",
 		inmemorychannel.NewController,
 	)
+	// Don't exit before the in-flight events are drained.
+	waitDrained()
 }
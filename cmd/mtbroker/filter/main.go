@@ -37,6 +37,7 @@ import (
 	tracingconfig "knative.dev/pkg/tracing/config"
 
 	broker "knative.dev/eventing/cmd/mtbroker"
+	"knative.dev/eventing/pkg/deliveryauth"
 	"knative.dev/eventing/pkg/mtbroker/filter"
 	"knative.dev/eventing/pkg/reconciler/names"
 
@@ -114,10 +115,12 @@ func main() {
 
 	// We are running both the receiver (takes messages in from the Broker) and the dispatcher (send
 	// the messages to the triggers' subscribers) in this binary.
-	handler, err := filter.NewHandler(logger, triggerInformer.Lister(), reporter, env.Port)
+	credentials := deliveryauth.NewResolverFromClient(kubeClient)
+	handler, err := filter.NewHandler(logger, triggerInformer.Lister(), reporter, env.Port, credentials)
 	if err != nil {
 		logger.Fatal("Error creating Handler", zap.Error(err))
 	}
+	triggerInformer.Informer().AddEventHandler(handler.TriggerEventHandler())
 
 	// configMapWatcher does not block, so start it first.
 	if err = configMapWatcher.Start(ctx.Done()); err != nil {
@@ -26,6 +26,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/kelseyhightower/envconfig"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
 
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/configmap"
@@ -42,6 +45,7 @@ import (
 
 	cmdbroker "knative.dev/eventing/cmd/mtbroker"
 	brokerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1/broker"
+	eventtypeinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1beta1/eventtype"
 	"knative.dev/eventing/pkg/kncloudevents"
 	broker "knative.dev/eventing/pkg/mtbroker"
 	"knative.dev/eventing/pkg/mtbroker/ingress"
@@ -67,6 +71,9 @@ type envConfig struct {
 	ContainerName string `envconfig:"CONTAINER_NAME" required:"true"`
 	Port          int    `envconfig:"INGRESS_PORT" default:"8080"`
 	MaxTTL        int    `envconfig:"MAX_TTL" default:"255"`
+	// DeduplicationCacheSize is the number of events remembered to deduplicate the events sent
+	// to the Brokers deduplicating them.
+	DeduplicationCacheSize int `envconfig:"DEDUPLICATION_CACHE_SIZE" default:"100000"`
 }
 
 func main() {
@@ -119,6 +126,23 @@ func main() {
 	// Watch the observability config map and dynamically update request logs.
 	configMapWatcher.Watch(logging.ConfigMapName(), logging.UpdateLevelFromConfigMap(sl, atomicLevel, component))
 
+	// Watch the rate limits config map, the events are not limited without it.
+	rateLimiter := ingress.NewRateLimiter(logger)
+	// The replicas of the ingress share the rate limits.
+	rateLimiter.WatchReplicas(ctx, kubeclient.Get(ctx), system.Namespace(), names.BrokerIngressName)
+	configMapWatcher.WatchWithDefault(corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ingress.RateLimitsConfigMap}},
+		rateLimiter.UpdateFromConfigMap)
+
+	// Index the EventTypes holding a schema, before the informers are started.
+	eventTypeInformer := eventtypeinformer.Get(ctx).Informer()
+	if err := eventTypeInformer.AddIndexers(ingress.EventTypeIndexers); err != nil {
+		logger.Fatal("Failed to index the EventTypes", zap.Error(err))
+	}
+	schemaValidator := ingress.NewSchemaValidator(eventTypeInformer.GetIndexer(), logger)
+	eventTypeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: schemaValidator.EventTypeDeleted,
+	})
+
 	bin := fmt.Sprintf("%s.%s", names.BrokerIngressName, system.Namespace())
 	if err = tracing.SetupDynamicPublishing(sl, configMapWatcher, bin, tracingconfig.ConfigName); err != nil {
 		logger.Fatal("Error setting up trace publishing", zap.Error(err))
@@ -137,12 +161,17 @@ func main() {
 	reporter := ingress.NewStatsReporter(env.ContainerName, kmeta.ChildName(env.PodName, uuid.New().String()))
 
 	h := &ingress.Handler{
-		Receiver:     kncloudevents.NewHTTPMessageReceiver(env.Port),
-		Sender:       sender,
-		Defaulter:    broker.TTLDefaulter(logger, int32(env.MaxTTL)),
-		Reporter:     reporter,
-		Logger:       logger,
-		BrokerLister: brokerLister,
+		Receiver:           kncloudevents.NewHTTPMessageReceiver(env.Port),
+		Sender:             sender,
+		Defaulter:          broker.TTLDefaulter(logger, int32(env.MaxTTL)),
+		Reporter:           reporter,
+		Logger:             logger,
+		BrokerLister:       brokerLister,
+		Authenticator:      ingress.NewAuthenticatorFromClient(kubeclient.Get(ctx)),
+		SchemaValidator:    schemaValidator,
+		EventTypeRecorder:  ingress.StartEventTypeRecorder(ctx, kubeclient.Get(ctx), logger),
+		DeduplicationStore: ingress.NewMemoryDeduplicationStore(env.DeduplicationCacheSize),
+		RateLimiter:        rateLimiter,
 	}
 
 	// configMapWatcher does not block, so start it first.
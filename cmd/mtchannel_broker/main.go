@@ -23,6 +23,7 @@ import (
 	"knative.dev/pkg/injection/sharedmain"
 
 	"knative.dev/eventing/pkg/reconciler/mtbroker"
+	mteventtype "knative.dev/eventing/pkg/reconciler/mtbroker/eventtype"
 	mttrigger "knative.dev/eventing/pkg/reconciler/mtbroker/trigger"
 )
 
@@ -37,5 +38,7 @@ func main() {
 		mtbroker.NewController,
 
 		mttrigger.NewController,
+
+		mteventtype.NewController,
 	)
 }
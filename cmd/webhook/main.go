@@ -78,9 +78,10 @@ var ourTypes = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
 	messagingv1beta1.SchemeGroupVersion.WithKind("Channel"):         &messagingv1beta1.Channel{},
 	messagingv1beta1.SchemeGroupVersion.WithKind("Subscription"):    &messagingv1beta1.Subscription{},
 	// v1
-	messagingv1.SchemeGroupVersion.WithKind("InMemoryChannel"): &messagingv1.InMemoryChannel{},
-	messagingv1.SchemeGroupVersion.WithKind("Channel"):         &messagingv1.Channel{},
-	messagingv1.SchemeGroupVersion.WithKind("Subscription"):    &messagingv1.Subscription{},
+	messagingv1.SchemeGroupVersion.WithKind("InMemoryChannel"):   &messagingv1.InMemoryChannel{},
+	messagingv1.SchemeGroupVersion.WithKind("Channel"):           &messagingv1.Channel{},
+	messagingv1.SchemeGroupVersion.WithKind("Subscription"):      &messagingv1.Subscription{},
+	messagingv1.SchemeGroupVersion.WithKind("PersistentChannel"): &messagingv1.PersistentChannel{},
 
 	// For group sources.knative.dev.
 	// v1alpha1
@@ -18,8 +18,10 @@ package v1
 
 import (
 	"context"
+	"regexp"
 
 	"github.com/rickb777/date/period"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 )
@@ -50,6 +52,101 @@ type DeliverySpec struct {
 	// For exponential policy, backoff delay is backoffDelay*2^<numberOfRetries>.
 	// +optional
 	BackoffDelay *string `json:"backoffDelay,omitempty"`
+
+	// BackoffMaxDelay is the upper bound of the delay before retrying, it caps
+	// the delay computed by the backoff policy, as well as the one requested by
+	// the destination with a Retry-After header. When unset, the latter is
+	// capped at 5 minutes.
+	// More information on Duration format:
+	//  - https://www.iso.org/iso-8601-date-and-time-format.html
+	//  - https://en.wikipedia.org/wiki/ISO_8601
+	// +optional
+	BackoffMaxDelay *string `json:"backoffMaxDelay,omitempty"`
+
+	// BackoffJitter is the randomization applied to the backoff delay, so that
+	// the senders retrying at the same time spread their retries (none, full, equal).
+	// For full jitter, backoff delay is a random value between 0 and the delay.
+	// For equal jitter, backoff delay is a random value between half the delay and the delay.
+	// +optional
+	BackoffJitter *BackoffJitterType `json:"backoffJitter,omitempty"`
+
+	// Timeout is the timeout of each single request sent to the destination.
+	// A request exceeding it is cancelled and counted as a failed attempt,
+	// which is retried according to Retry.
+	// More information on Duration format:
+	//  - https://www.iso.org/iso-8601-date-and-time-format.html
+	//  - https://en.wikipedia.org/wiki/ISO_8601
+	// +optional
+	Timeout *string `json:"timeout,omitempty"`
+
+	// RetryableStatusCodes is the list of response status codes (e.g. "429")
+	// or classes (e.g. "5xx") the sender retries on. When empty, every
+	// failed response is retried, except for the NonRetryableStatusCodes.
+	// When not empty, only the listed responses are retried.
+	// +optional
+	RetryableStatusCodes []string `json:"retryableStatusCodes,omitempty"`
+
+	// NonRetryableStatusCodes is the list of response status codes (e.g.
+	// "404") or classes (e.g. "4xx") the sender doesn't retry on, the event
+	// is moved to the dead letter sink right away.
+	// A status code takes precedence over a class, so that "4xx" can be
+	// non-retryable while "429" is retryable.
+	// +optional
+	NonRetryableStatusCodes []string `json:"nonRetryableStatusCodes,omitempty"`
+
+	// DeadLetterEnrichment, when true, adds CloudEvent extensions describing
+	// the failure to the events sent to the dead letter sink:
+	//  - knativeerrordest: the destination the event couldn't be delivered to
+	//  - knativeerrorcode: the last response status code, if any
+	//  - knativeerrorattempts: the number of delivery attempts
+	//  - knativeerrordata: the truncated response body or error, base64 encoded
+	// +optional
+	DeadLetterEnrichment *bool `json:"deadLetterEnrichment,omitempty"`
+
+	// Ordering is the delivery ordering (unordered, ordered). With ordered
+	// delivery, the events are sent to the destination one at a time, in the
+	// order they were received, the next event being sent once the previous
+	// one is delivered, retries included, or moved to the dead letter sink.
+	// +optional
+	Ordering *DeliveryOrderingType `json:"ordering,omitempty"`
+
+	// OrderingKey is the name of the CloudEvent extension partitioning the
+	// ordered delivery (e.g. "partitionkey"). When set, the ordering is only
+	// guaranteed between the events having the same value of the extension,
+	// the events having different values being delivered concurrently.
+	// The events without the extension are delivered in order with each
+	// other. Requires ordered delivery.
+	// +optional
+	OrderingKey *string `json:"orderingKey,omitempty"`
+
+	// Auth references the Secrets holding the credentials used to
+	// authenticate to the subscriber and to the dead letter sink.
+	// +optional
+	Auth *DeliveryAuth `json:"auth,omitempty"`
+}
+
+// DeliveryAuth references the Secrets holding the credentials used to
+// authenticate to the destinations of the events. The Secrets are in the
+// namespace of the resource, and must be labeled with
+// eventing.knative.dev/delivery-auth: "true" to be readable by the
+// dispatchers. A Secret holds either:
+//   - a bearer token, in its "token" key
+//   - basic-auth credentials, in its "username" and "password" keys
+//   - a client certificate and key, in its "tls.crt" and "tls.key" keys
+//
+// It may also hold, in its "ca.crt" key, the CA bundle verifying the
+// certificate of the destination. The Secrets are read again a minute
+// at most after they change, so that they can be rotated.
+type DeliveryAuth struct {
+	// SubscriberSecretName is the name of the Secret used to authenticate
+	// to the subscriber.
+	// +optional
+	SubscriberSecretName string `json:"subscriberSecretName,omitempty"`
+
+	// DeadLetterSinkSecretName is the name of the Secret used to
+	// authenticate to the dead letter sink.
+	// +optional
+	DeadLetterSinkSecretName string `json:"deadLetterSinkSecretName,omitempty"`
 }
 
 func (ds *DeliverySpec) Validate(ctx context.Context) *apis.FieldError {
@@ -80,6 +177,89 @@ func (ds *DeliverySpec) Validate(ctx context.Context) *apis.FieldError {
 			errs = errs.Also(apis.ErrInvalidValue(*ds.BackoffDelay, "backoffDelay"))
 		}
 	}
+
+	if ds.BackoffMaxDelay != nil {
+		p, te := period.Parse(*ds.BackoffMaxDelay)
+		if d, _ := p.Duration(); te != nil || d <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(*ds.BackoffMaxDelay, "backoffMaxDelay"))
+		}
+	}
+
+	if ds.BackoffJitter != nil {
+		switch *ds.BackoffJitter {
+		case BackoffJitterNone, BackoffJitterFull, BackoffJitterEqual:
+			// nothing
+		default:
+			errs = errs.Also(apis.ErrInvalidValue(*ds.BackoffJitter, "backoffJitter"))
+		}
+	}
+
+	if ds.Timeout != nil {
+		p, te := period.Parse(*ds.Timeout)
+		if d, _ := p.Duration(); te != nil || d <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(*ds.Timeout, "timeout"))
+		}
+	}
+
+	errs = errs.Also(validateStatusCodes(ds.RetryableStatusCodes, ds.NonRetryableStatusCodes))
+
+	if ds.Ordering != nil {
+		switch *ds.Ordering {
+		case DeliveryOrderingUnordered, DeliveryOrderingOrdered:
+			// nothing
+		default:
+			errs = errs.Also(apis.ErrInvalidValue(*ds.Ordering, "ordering"))
+		}
+	}
+
+	if ds.OrderingKey != nil {
+		if !extensionNameRegexp.MatchString(*ds.OrderingKey) {
+			errs = errs.Also(apis.ErrInvalidValue(*ds.OrderingKey, "orderingKey"))
+		} else if ds.Ordering == nil || *ds.Ordering != DeliveryOrderingOrdered {
+			errs = errs.Also(apis.ErrGeneric("orderingKey requires ordered delivery", "orderingKey", "ordering"))
+		}
+	}
+
+	errs = errs.Also(ds.Auth.Validate(ctx).ViaField("auth"))
+	return errs
+}
+
+func (da *DeliveryAuth) Validate(ctx context.Context) *apis.FieldError {
+	if da == nil {
+		return nil
+	}
+	return validateSecretName(da.SubscriberSecretName, "subscriberSecretName").
+		Also(validateSecretName(da.DeadLetterSinkSecretName, "deadLetterSinkSecretName"))
+}
+
+func validateSecretName(name, field string) *apis.FieldError {
+	if name != "" && len(validation.IsDNS1123Subdomain(name)) != 0 {
+		return apis.ErrInvalidValue(name, field)
+	}
+	return nil
+}
+
+// extensionNameRegexp matches the valid CloudEvent extension names.
+var extensionNameRegexp = regexp.MustCompile(`^[a-z0-9]+$`)
+
+var statusCodeRegexp = regexp.MustCompile(`^[1-5]([0-9][0-9]|xx)$`)
+
+func validateStatusCodes(retryable, nonRetryable []string) *apis.FieldError {
+	var errs *apis.FieldError
+	seen := make(map[string]bool, len(retryable))
+	for i, c := range retryable {
+		if !statusCodeRegexp.MatchString(c) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(c, "retryableStatusCodes", i))
+		}
+		seen[c] = true
+	}
+	for i, c := range nonRetryable {
+		if !statusCodeRegexp.MatchString(c) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(c, "nonRetryableStatusCodes", i))
+		} else if seen[c] {
+			errs = errs.Also(apis.ErrGeneric("status code "+c+" is both retryable and non-retryable", "retryableStatusCodes", "nonRetryableStatusCodes"))
+		}
+	}
 	return errs
 }
 
@@ -94,6 +274,31 @@ const (
 	BackoffPolicyExponential BackoffPolicyType = "exponential"
 )
 
+// BackoffJitterType is the type for backoff jitters
+type BackoffJitterType string
+
+const (
+	// No jitter, the backoff delay is used as is
+	BackoffJitterNone BackoffJitterType = "none"
+
+	// Full jitter, the backoff delay is a random value in [0, delay]
+	BackoffJitterFull BackoffJitterType = "full"
+
+	// Equal jitter, the backoff delay is a random value in [delay/2, delay]
+	BackoffJitterEqual BackoffJitterType = "equal"
+)
+
+// DeliveryOrderingType is the type for delivery orderings
+type DeliveryOrderingType string
+
+const (
+	// Unordered delivery, the events are sent concurrently
+	DeliveryOrderingUnordered DeliveryOrderingType = "unordered"
+
+	// Ordered delivery, the events are sent one at a time, in order
+	DeliveryOrderingOrdered DeliveryOrderingType = "ordered"
+)
+
 // DeliveryStatus contains the Status of an object supporting delivery options.
 type DeliveryStatus struct {
 	// DeadLetterChannel is a KReference that is the reference to the native, platform specific channel
@@ -27,9 +27,14 @@ import (
 
 func TestDeliverySpecValidation(t *testing.T) {
 	invalidString := "invalid time"
+	fullJitter := BackoffJitterFull
+	invalidJitter := BackoffJitterType("garbage")
 	bop := BackoffPolicyExponential
 	validBackoffDelay := "PT2S"
 	invalidBackoffDelay := "1985-04-12T23:20:50.52Z"
+	ordered := DeliveryOrderingOrdered
+	unordered := DeliveryOrderingUnordered
+	invalidOrdering := DeliveryOrderingType("garbage")
 	tests := []struct {
 		name string
 		spec *DeliverySpec
@@ -76,6 +81,99 @@ func TestDeliverySpecValidation(t *testing.T) {
 	}, {
 		name: "valid retry 1",
 		spec: &DeliverySpec{Retry: pointer.Int32Ptr(1)},
+	}, {
+		name: "valid backoffMaxDelay",
+		spec: &DeliverySpec{BackoffMaxDelay: pointer.StringPtr("PT10M")},
+	}, {
+		name: "invalid backoffMaxDelay",
+		spec: &DeliverySpec{BackoffMaxDelay: &invalidString},
+		want: func() *apis.FieldError {
+			return apis.ErrInvalidValue(invalidString, "backoffMaxDelay")
+		}(),
+	}, {
+		name: "valid backoffJitter",
+		spec: &DeliverySpec{BackoffJitter: &fullJitter},
+	}, {
+		name: "invalid backoffJitter",
+		spec: &DeliverySpec{BackoffJitter: &invalidJitter},
+		want: func() *apis.FieldError {
+			return apis.ErrInvalidValue(invalidJitter, "backoffJitter")
+		}(),
+	}, {
+		name: "valid status codes",
+		spec: &DeliverySpec{
+			RetryableStatusCodes:    []string{"429", "5xx"},
+			NonRetryableStatusCodes: []string{"4xx", "501"},
+		},
+	}, {
+		name: "invalid status codes",
+		spec: &DeliverySpec{
+			RetryableStatusCodes:    []string{"429", "42"},
+			NonRetryableStatusCodes: []string{"6xx"},
+		},
+		want: func() *apis.FieldError {
+			return apis.ErrInvalidArrayValue("42", "retryableStatusCodes", 1).Also(
+				apis.ErrInvalidArrayValue("6xx", "nonRetryableStatusCodes", 0))
+		}(),
+	}, {
+		name: "both retryable and non-retryable",
+		spec: &DeliverySpec{
+			RetryableStatusCodes:    []string{"429"},
+			NonRetryableStatusCodes: []string{"429"},
+		},
+		want: func() *apis.FieldError {
+			return apis.ErrGeneric("status code 429 is both retryable and non-retryable", "retryableStatusCodes", "nonRetryableStatusCodes")
+		}(),
+	}, {
+		name: "valid timeout",
+		spec: &DeliverySpec{Timeout: pointer.StringPtr("PT0.5S")},
+	}, {
+		name: "invalid timeout",
+		spec: &DeliverySpec{Timeout: &invalidString},
+		want: func() *apis.FieldError {
+			return apis.ErrInvalidValue(invalidString, "timeout")
+		}(),
+	}, {
+		name: "zero timeout",
+		spec: &DeliverySpec{Timeout: pointer.StringPtr("PT0S")},
+		want: func() *apis.FieldError {
+			return apis.ErrInvalidValue("PT0S", "timeout")
+		}(),
+	}, {
+		name: "valid ordering",
+		spec: &DeliverySpec{Ordering: &unordered},
+	}, {
+		name: "valid ordering with key",
+		spec: &DeliverySpec{Ordering: &ordered, OrderingKey: pointer.StringPtr("partitionkey")},
+	}, {
+		name: "invalid ordering",
+		spec: &DeliverySpec{Ordering: &invalidOrdering},
+		want: func() *apis.FieldError {
+			return apis.ErrInvalidValue(invalidOrdering, "ordering")
+		}(),
+	}, {
+		name: "invalid orderingKey",
+		spec: &DeliverySpec{Ordering: &ordered, OrderingKey: pointer.StringPtr("Partition-Key")},
+		want: func() *apis.FieldError {
+			return apis.ErrInvalidValue("Partition-Key", "orderingKey")
+		}(),
+	}, {
+		name: "orderingKey without ordered delivery",
+		spec: &DeliverySpec{Ordering: &unordered, OrderingKey: pointer.StringPtr("partitionkey")},
+		want: func() *apis.FieldError {
+			return apis.ErrGeneric("orderingKey requires ordered delivery", "orderingKey", "ordering")
+		}(),
+	}, {
+		name: "valid auth",
+		spec: &DeliverySpec{Auth: &DeliveryAuth{SubscriberSecretName: "subscriber-auth", DeadLetterSinkSecretName: "dls-auth"}},
+		want: nil,
+	}, {
+		name: "invalid auth secret names",
+		spec: &DeliverySpec{Auth: &DeliveryAuth{SubscriberSecretName: "Not_Valid", DeadLetterSinkSecretName: "not valid"}},
+		want: func() *apis.FieldError {
+			return apis.ErrInvalidValue("Not_Valid", "auth.subscriberSecretName").
+				Also(apis.ErrInvalidValue("not valid", "auth.deadLetterSinkSecretName"))
+		}(),
 	}}
 
 	for _, test := range tests {
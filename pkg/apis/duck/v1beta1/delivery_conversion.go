@@ -31,6 +31,15 @@ func (source *DeliverySpec) ConvertTo(ctx context.Context, to apis.Convertible)
 	case *eventingduckv1.DeliverySpec:
 		sink.Retry = source.Retry
 		sink.BackoffDelay = source.BackoffDelay
+		sink.BackoffMaxDelay = source.BackoffMaxDelay
+		sink.BackoffJitter = (*eventingduckv1.BackoffJitterType)(source.BackoffJitter)
+		sink.Timeout = source.Timeout
+		sink.RetryableStatusCodes = source.RetryableStatusCodes
+		sink.NonRetryableStatusCodes = source.NonRetryableStatusCodes
+		sink.DeadLetterEnrichment = source.DeadLetterEnrichment
+		sink.Ordering = (*eventingduckv1.DeliveryOrderingType)(source.Ordering)
+		sink.OrderingKey = source.OrderingKey
+		sink.Auth = (*eventingduckv1.DeliveryAuth)(source.Auth)
 		if source.BackoffPolicy != nil {
 			if *source.BackoffPolicy == BackoffPolicyLinear {
 				linear := eventingduckv1.BackoffPolicyLinear
@@ -55,6 +64,15 @@ func (sink *DeliverySpec) ConvertFrom(ctx context.Context, from apis.Convertible
 	case *eventingduckv1.DeliverySpec:
 		sink.Retry = source.Retry
 		sink.BackoffDelay = source.BackoffDelay
+		sink.BackoffMaxDelay = source.BackoffMaxDelay
+		sink.BackoffJitter = (*BackoffJitterType)(source.BackoffJitter)
+		sink.Timeout = source.Timeout
+		sink.RetryableStatusCodes = source.RetryableStatusCodes
+		sink.NonRetryableStatusCodes = source.NonRetryableStatusCodes
+		sink.DeadLetterEnrichment = source.DeadLetterEnrichment
+		sink.Ordering = (*DeliveryOrderingType)(source.Ordering)
+		sink.OrderingKey = source.OrderingKey
+		sink.Auth = (*DeliveryAuth)(source.Auth)
 		if source.BackoffPolicy != nil {
 			if *source.BackoffPolicy == eventingduckv1.BackoffPolicyLinear {
 				linear := BackoffPolicyLinear
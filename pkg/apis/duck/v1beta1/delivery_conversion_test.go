@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/pointer"
 	v1 "knative.dev/eventing/pkg/apis/duck/v1"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
@@ -57,6 +58,7 @@ func TestDeliverySpecConversion(t *testing.T) {
 	var backoffPolicy BackoffPolicyType = BackoffPolicyLinear
 	var backoffPolicyExp BackoffPolicyType = BackoffPolicyExponential
 	var backoffPolicyBad BackoffPolicyType = "garbage"
+	ordered := DeliveryOrderingOrdered
 	badPolicyString := `unknown BackoffPolicy, got: "garbage"`
 
 	tests := []struct {
@@ -96,6 +98,32 @@ func TestDeliverySpecConversion(t *testing.T) {
 				URI: apis.HTTP("example.com"),
 			},
 		},
+	}, {
+		name: "with timeout",
+		in: &DeliverySpec{
+			Timeout:                 pointer.StringPtr("PT2S"),
+			BackoffMaxDelay:         pointer.StringPtr("PT1M"),
+			RetryableStatusCodes:    []string{"429", "5xx"},
+			NonRetryableStatusCodes: []string{"4xx"},
+			DeadLetterEnrichment:    pointer.BoolPtr(true),
+			DeadLetterSink: &pkgduck.Destination{
+				URI: apis.HTTP("example.com"),
+			},
+		},
+	}, {
+		name: "with ordering",
+		in: &DeliverySpec{
+			Ordering:    &ordered,
+			OrderingKey: pointer.StringPtr("partitionkey"),
+		},
+	}, {
+		name: "with auth",
+		in: &DeliverySpec{
+			Auth: &DeliveryAuth{
+				SubscriberSecretName:     "subscriber-auth",
+				DeadLetterSinkSecretName: "dls-auth",
+			},
+		},
 	}, {
 		name: "with bad backoff",
 		in: &DeliverySpec{
@@ -134,6 +162,7 @@ func TestDeliverySpecConversionV1(t *testing.T) {
 	var backoffPolicy v1.BackoffPolicyType = v1.BackoffPolicyLinear
 	var backoffPolicyExp v1.BackoffPolicyType = v1.BackoffPolicyExponential
 	var backoffPolicyBad v1.BackoffPolicyType = "garbage"
+	ordered := v1.DeliveryOrderingOrdered
 	badPolicyString := `unknown BackoffPolicy, got: "garbage"`
 
 	tests := []struct {
@@ -173,6 +202,32 @@ func TestDeliverySpecConversionV1(t *testing.T) {
 				URI: apis.HTTP("example.com"),
 			},
 		},
+	}, {
+		name: "with timeout",
+		in: &v1.DeliverySpec{
+			Timeout:                 pointer.StringPtr("PT2S"),
+			BackoffMaxDelay:         pointer.StringPtr("PT1M"),
+			RetryableStatusCodes:    []string{"429", "5xx"},
+			NonRetryableStatusCodes: []string{"4xx"},
+			DeadLetterEnrichment:    pointer.BoolPtr(true),
+			DeadLetterSink: &pkgduck.Destination{
+				URI: apis.HTTP("example.com"),
+			},
+		},
+	}, {
+		name: "with ordering",
+		in: &v1.DeliverySpec{
+			Ordering:    &ordered,
+			OrderingKey: pointer.StringPtr("partitionkey"),
+		},
+	}, {
+		name: "with auth",
+		in: &v1.DeliverySpec{
+			Auth: &v1.DeliveryAuth{
+				SubscriberSecretName:     "subscriber-auth",
+				DeadLetterSinkSecretName: "dls-auth",
+			},
+		},
 	}, {
 		name: "with bad backoff",
 		in: &v1.DeliverySpec{
@@ -64,6 +64,21 @@ type SubscriberStatus struct {
 	// A human readable message indicating details of Ready status.
 	// +optional
 	Message string `json:"message,omitempty"`
+	// LastDeliveryTime is the time of the last event successfully delivered to the subscriber.
+	// +optional
+	LastDeliveryTime *metav1.Time `json:"lastDeliveryTime,omitempty"`
+	// LastFailureTime is the time of the last event that couldn't be delivered to the
+	// subscriber, retries included, even if it was then sent to the dead letter sink.
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+	// LastFailureResponseCode is the response code of the subscriber to the last event that
+	// couldn't be delivered, if any.
+	// +optional
+	LastFailureResponseCode int32 `json:"lastFailureResponseCode,omitempty"`
+	// ConsecutiveFailures is the number of events that couldn't be delivered to the subscriber
+	// since the last successful delivery.
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -107,6 +107,10 @@ func (source *SubscriberStatus) ConvertTo(ctx context.Context, obj apis.Converti
 		sink.ObservedGeneration = source.ObservedGeneration
 		sink.Ready = source.Ready
 		sink.Message = source.Message
+		sink.LastDeliveryTime = source.LastDeliveryTime
+		sink.LastFailureTime = source.LastFailureTime
+		sink.LastFailureResponseCode = source.LastFailureResponseCode
+		sink.ConsecutiveFailures = source.ConsecutiveFailures
 	default:
 		return fmt.Errorf("unknown version, got: %T", sink)
 	}
@@ -193,6 +197,10 @@ func (sink *SubscriberStatus) ConvertFrom(ctx context.Context, obj apis.Converti
 		sink.ObservedGeneration = source.ObservedGeneration
 		sink.Ready = source.Ready
 		sink.Message = source.Message
+		sink.LastDeliveryTime = source.LastDeliveryTime
+		sink.LastFailureTime = source.LastFailureTime
+		sink.LastFailureResponseCode = source.LastFailureResponseCode
+		sink.ConsecutiveFailures = source.ConsecutiveFailures
 	default:
 		return fmt.Errorf("unknown version, got: %T", sink)
 	}
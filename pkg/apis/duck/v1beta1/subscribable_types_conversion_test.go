@@ -47,6 +47,8 @@ func TestSubscribableTypeConversion(t *testing.T) {
 	versions := []apis.Convertible{&eventingv1.Subscribable{}}
 
 	linear := BackoffPolicyLinear
+	lastDelivery := metav1.Unix(1600000000, 0)
+	lastFailure := metav1.Unix(1600000060, 0)
 
 	tests := []struct {
 		name string
@@ -83,10 +85,14 @@ func TestSubscribableTypeConversion(t *testing.T) {
 			Status: SubscribableStatus{
 				Subscribers: []SubscriberStatus{
 					{
-						UID:                "status-uid-1",
-						ObservedGeneration: 99,
-						Ready:              corev1.ConditionTrue,
-						Message:            "msg",
+						UID:                     "status-uid-1",
+						ObservedGeneration:      99,
+						Ready:                   corev1.ConditionTrue,
+						Message:                 "msg",
+						LastDeliveryTime:        &lastDelivery,
+						LastFailureTime:         &lastFailure,
+						LastFailureResponseCode: 503,
+						ConsecutiveFailures:     2,
 					},
 				},
 			},
@@ -126,10 +132,14 @@ func TestSubscribableTypeConversion(t *testing.T) {
 			Status: SubscribableStatus{
 				Subscribers: []SubscriberStatus{
 					{
-						UID:                "status-uid-1",
-						ObservedGeneration: 99,
-						Ready:              corev1.ConditionTrue,
-						Message:            "msg",
+						UID:                     "status-uid-1",
+						ObservedGeneration:      99,
+						Ready:                   corev1.ConditionTrue,
+						Message:                 "msg",
+						LastDeliveryTime:        &lastDelivery,
+						LastFailureTime:         &lastFailure,
+						LastFailureResponseCode: 503,
+						ConsecutiveFailures:     2,
 					},
 				},
 			},
@@ -159,6 +169,8 @@ func TestSubscribableTypeConversionWithV1(t *testing.T) {
 	versions := []apis.Convertible{&Subscribable{}}
 
 	linear := eventingv1.BackoffPolicyLinear
+	lastDelivery := metav1.Unix(1600000000, 0)
+	lastFailure := metav1.Unix(1600000060, 0)
 
 	tests := []struct {
 		name string
@@ -195,10 +207,14 @@ func TestSubscribableTypeConversionWithV1(t *testing.T) {
 			Status: eventingv1.SubscribableStatus{
 				Subscribers: []eventingv1.SubscriberStatus{
 					{
-						UID:                "status-uid-1",
-						ObservedGeneration: 99,
-						Ready:              corev1.ConditionTrue,
-						Message:            "msg",
+						UID:                     "status-uid-1",
+						ObservedGeneration:      99,
+						Ready:                   corev1.ConditionTrue,
+						Message:                 "msg",
+						LastDeliveryTime:        &lastDelivery,
+						LastFailureTime:         &lastFailure,
+						LastFailureResponseCode: 503,
+						ConsecutiveFailures:     2,
 					},
 				},
 			},
@@ -238,10 +254,14 @@ func TestSubscribableTypeConversionWithV1(t *testing.T) {
 			Status: eventingv1.SubscribableStatus{
 				Subscribers: []eventingv1.SubscriberStatus{
 					{
-						UID:                "status-uid-1",
-						ObservedGeneration: 99,
-						Ready:              corev1.ConditionTrue,
-						Message:            "msg",
+						UID:                     "status-uid-1",
+						ObservedGeneration:      99,
+						Ready:                   corev1.ConditionTrue,
+						Message:                 "msg",
+						LastDeliveryTime:        &lastDelivery,
+						LastFailureTime:         &lastFailure,
+						LastFailureResponseCode: 503,
+						ConsecutiveFailures:     2,
 					},
 				},
 			},
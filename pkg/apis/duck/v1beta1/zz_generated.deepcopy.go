@@ -133,6 +133,22 @@ func (in *ChannelableStatus) DeepCopy() *ChannelableStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeliveryAuth) DeepCopyInto(out *DeliveryAuth) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeliveryAuth.
+func (in *DeliveryAuth) DeepCopy() *DeliveryAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(DeliveryAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeliverySpec) DeepCopyInto(out *DeliverySpec) {
 	*out = *in
@@ -156,6 +172,51 @@ func (in *DeliverySpec) DeepCopyInto(out *DeliverySpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.BackoffMaxDelay != nil {
+		in, out := &in.BackoffMaxDelay, &out.BackoffMaxDelay
+		*out = new(string)
+		**out = **in
+	}
+	if in.BackoffJitter != nil {
+		in, out := &in.BackoffJitter, &out.BackoffJitter
+		*out = new(BackoffJitterType)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(string)
+		**out = **in
+	}
+	if in.RetryableStatusCodes != nil {
+		in, out := &in.RetryableStatusCodes, &out.RetryableStatusCodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NonRetryableStatusCodes != nil {
+		in, out := &in.NonRetryableStatusCodes, &out.NonRetryableStatusCodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeadLetterEnrichment != nil {
+		in, out := &in.DeadLetterEnrichment, &out.DeadLetterEnrichment
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Ordering != nil {
+		in, out := &in.Ordering, &out.Ordering
+		*out = new(DeliveryOrderingType)
+		**out = **in
+	}
+	if in.OrderingKey != nil {
+		in, out := &in.OrderingKey, &out.OrderingKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(DeliveryAuth)
+		**out = **in
+	}
 	return
 }
 
@@ -280,7 +341,9 @@ func (in *SubscribableStatus) DeepCopyInto(out *SubscribableStatus) {
 	if in.Subscribers != nil {
 		in, out := &in.Subscribers, &out.Subscribers
 		*out = make([]SubscriberStatus, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
@@ -329,6 +392,14 @@ func (in *SubscriberSpec) DeepCopy() *SubscriberSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubscriberStatus) DeepCopyInto(out *SubscriberStatus) {
 	*out = *in
+	if in.LastDeliveryTime != nil {
+		in, out := &in.LastDeliveryTime, &out.LastDeliveryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -35,6 +35,127 @@ const (
 	// pkg/reconciler/mtbroker
 	MTChannelBrokerClassValue = "MTChannelBasedBroker"
 
+	// BrokerDeliveryModeAnnotationKey is the annotation key on Brokers to
+	// indicate how events are delivered from the Broker's trigger channel
+	// to its Triggers.
+	// Valid values are: PerTrigger (default), PerBroker.
+	BrokerDeliveryModeAnnotationKey = GroupName + "/broker.deliveryMode"
+
+	// BrokerDeliveryModePerTrigger indicates that each Trigger subscribes
+	// to the trigger channel, so the filter receives each event once per Trigger.
+	BrokerDeliveryModePerTrigger = "PerTrigger"
+
+	// BrokerDeliveryModePerBroker indicates that the Broker subscribes once
+	// to the trigger channel, so the filter receives each event once and
+	// evaluates all the Triggers of the Broker in one pass. The delivery
+	// spec of the Broker applies to all its Triggers, overriding theirs:
+	// the DeliverySpecApplied condition of a Trigger with a delivery spec
+	// is False.
+	BrokerDeliveryModePerBroker = "PerBroker"
+
+	// BrokerIngressAuthAnnotationKey is the annotation key on Brokers to
+	// indicate how the Broker ingress authenticates the publishers of events.
+	// Valid values are: None (default), ServiceAccountToken, HMAC.
+	BrokerIngressAuthAnnotationKey = GroupName + "/broker.ingressAuth"
+
+	// BrokerIngressAuthNone indicates that anyone may publish events to the Broker.
+	BrokerIngressAuthNone = "None"
+
+	// BrokerIngressAuthServiceAccountToken indicates that the publishers send
+	// a Kubernetes ServiceAccount token as bearer token, which the Broker
+	// ingress validates with a TokenReview.
+	BrokerIngressAuthServiceAccountToken = "ServiceAccountToken"
+
+	// BrokerIngressAuthHMAC indicates that the publishers sign the body of the
+	// requests, along with the time they are sent at, with the key shared with
+	// the Broker ingress, see BrokerIngressHMACSecretAnnotationKey.
+	BrokerIngressAuthHMAC = "HMAC"
+
+	// BrokerIngressHMACSecretAnnotationKey is the annotation key on Brokers
+	// naming the Secret, in the namespace of the Broker, holding the HMAC key
+	// of its publishers. The Secret must be labeled with IngressAuthLabelKey.
+	// The Broker controller grants the Broker ingress get on the Secret, which
+	// is read again a minute at most after it changes.
+	BrokerIngressHMACSecretAnnotationKey = GroupName + "/broker.ingressHMACSecret"
+
+	// BrokerIngressAllowedIdentitiesAnnotationKey is the annotation key on
+	// Brokers listing, comma separated, the usernames of the ServiceAccounts
+	// allowed to publish events, e.g. system:serviceaccount:<ns>:<name>.
+	BrokerIngressAllowedIdentitiesAnnotationKey = GroupName + "/broker.ingressAllowedIdentities"
+
+	// BrokerIngressAllowedNamespacesAnnotationKey is the annotation key on
+	// Brokers listing, comma separated, the namespaces whose ServiceAccounts
+	// are allowed to publish events. Without allowed identities nor namespaces,
+	// only the ServiceAccounts of the namespace of the Broker are allowed.
+	BrokerIngressAllowedNamespacesAnnotationKey = GroupName + "/broker.ingressAllowedNamespaces"
+
+	// BrokerSchemaValidationAnnotationKey is the annotation key on Brokers to
+	// indicate how the Broker ingress handles the events whose data doesn't
+	// match the JSON Schema in the SchemaData of their EventType.
+	// Valid values are: None (default), Reject, Tag.
+	BrokerSchemaValidationAnnotationKey = GroupName + "/broker.schemaValidation"
+
+	// BrokerSchemaValidationNone indicates that the events are not validated.
+	BrokerSchemaValidationNone = "None"
+
+	// BrokerSchemaValidationReject indicates that the invalid events are
+	// rejected with 400 Bad Request.
+	BrokerSchemaValidationReject = "Reject"
+
+	// BrokerSchemaValidationTag indicates that the invalid events are
+	// accepted, with the violation of the schema in the schemaerror extension.
+	BrokerSchemaValidationTag = "Tag"
+
+	// BrokerEventTypeRegistrationAnnotationKey is the annotation key on
+	// Brokers to indicate whether EventTypes are registered for the events
+	// sent to the Broker.
+	// Valid values are: None (default), Auto.
+	BrokerEventTypeRegistrationAnnotationKey = GroupName + "/broker.eventTypeRegistration"
+
+	// BrokerEventTypeRegistrationNone indicates that no EventType is
+	// registered from the events sent to the Broker.
+	BrokerEventTypeRegistrationNone = "None"
+
+	// BrokerEventTypeRegistrationAuto indicates that the Broker ingress
+	// records the distinct types, sources and schemas of the events sent to
+	// the Broker, from which EventTypes are registered. The EventTypes of
+	// the events not seen for a while are deleted.
+	BrokerEventTypeRegistrationAuto = "Auto"
+
+	// BrokerDeduplicationWindowAnnotationKey is the annotation key on Brokers
+	// setting, as a duration such as 5m, how long the Broker ingress
+	// remembers the source and id of the events delivered to the Broker. The
+	// events with the source and id of an event delivered in that window are
+	// acknowledged without being delivered again, while those of an event
+	// still being delivered are rejected with 409 Conflict, to be retried.
+	// Unset, the events are not deduplicated.
+	BrokerDeduplicationWindowAnnotationKey = GroupName + "/broker.deduplicationWindow"
+
+	// BrokerRateLimitAnnotationKey is the annotation key on Brokers setting
+	// the number of events per second accepted by the Broker ingress for the
+	// Broker, 0 meaning no limit. It overrides the limit of each Broker set
+	// in the config-broker-ingress-rate-limits ConfigMap, the limit of the
+	// namespace of the Broker still applies. Like those, it applies to all
+	// the replicas of the Broker ingress together, each one accepting its
+	// share of it.
+	BrokerRateLimitAnnotationKey = GroupName + "/broker.rateLimit"
+
+	// BrokerRateLimitBurstAnnotationKey is the annotation key on Brokers
+	// setting the number of events accepted at once for the Broker, shared by
+	// the replicas of the Broker ingress. It defaults to the rate limit of
+	// the Broker rounded up.
+	BrokerRateLimitBurstAnnotationKey = GroupName + "/broker.rateLimitBurst"
+
+	// IngressAuthLabelKey is the label key on the Secrets holding the HMAC
+	// keys of the Brokers. Only the Secrets labeled with "true" are read by
+	// the Broker ingress.
+	IngressAuthLabelKey = GroupName + "/ingress-auth"
+
+	// DeliveryAuthLabelKey is the label key on the Secrets holding the
+	// credentials used to authenticate to the destinations of the events.
+	// Only the Secrets labeled with "true" are read by the dispatchers.
+	DeliveryAuthLabelKey = GroupName + "/delivery-auth"
+
 	// ScopeAnnotationKey is the annotation key to indicate
 	// the scope of the component handling a given resource.
 	// Valid values are: cluster, namespace, resource.
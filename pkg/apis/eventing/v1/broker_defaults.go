@@ -42,10 +42,18 @@ func (bs *BrokerSpec) SetDefaults(ctx context.Context) {
 		}
 		if bs.Delivery == nil && c.Delivery != nil {
 			bs.Delivery = &eventingduckv1.DeliverySpec{
-				DeadLetterSink: c.Delivery.DeadLetterSink,
-				Retry:          c.Delivery.Retry,
-				BackoffPolicy:  c.Delivery.BackoffPolicy,
-				BackoffDelay:   c.Delivery.BackoffDelay,
+				DeadLetterSink:          c.Delivery.DeadLetterSink,
+				Retry:                   c.Delivery.Retry,
+				BackoffPolicy:           c.Delivery.BackoffPolicy,
+				BackoffDelay:            c.Delivery.BackoffDelay,
+				BackoffMaxDelay:         c.Delivery.BackoffMaxDelay,
+				BackoffJitter:           c.Delivery.BackoffJitter,
+				Timeout:                 c.Delivery.Timeout,
+				RetryableStatusCodes:    c.Delivery.RetryableStatusCodes,
+				NonRetryableStatusCodes: c.Delivery.NonRetryableStatusCodes,
+				DeadLetterEnrichment:    c.Delivery.DeadLetterEnrichment,
+				Ordering:                c.Delivery.Ordering,
+				OrderingKey:             c.Delivery.OrderingKey,
 			}
 		}
 	}
@@ -18,13 +18,21 @@ package v1
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/kmp"
+
+	"knative.dev/eventing/pkg/apis/eventing"
 )
 
 const (
 	BrokerClassAnnotationKey = "eventing.knative.dev/broker.class"
+
+	// maxDeduplicationWindow bounds how long the Broker ingress remembers the events sent to a
+	// Broker.
+	maxDeduplicationWindow = 24 * time.Hour
 )
 
 func (b *Broker) Validate(ctx context.Context) *apis.FieldError {
@@ -35,6 +43,11 @@ func (b *Broker) Validate(ctx context.Context) *apis.FieldError {
 	if bc, ok := b.GetAnnotations()[BrokerClassAnnotationKey]; !ok || bc == "" {
 		errs = errs.Also(apis.ErrMissingField(BrokerClassAnnotationKey))
 	}
+	errs = errs.Also(validateIngressAuth(b.GetAnnotations()))
+	errs = errs.Also(validateSchemaValidation(b.GetAnnotations()))
+	errs = errs.Also(validateEventTypeRegistration(b.GetAnnotations()))
+	errs = errs.Also(validateDeduplicationWindow(b.GetAnnotations()))
+	errs = errs.Also(validateRateLimit(b.GetAnnotations()))
 
 	errs = errs.Also(b.Spec.Validate(withNS).ViaField("spec"))
 	if apis.IsInUpdate(ctx) {
@@ -44,6 +57,74 @@ func (b *Broker) Validate(ctx context.Context) *apis.FieldError {
 	return errs
 }
 
+// validateIngressAuth validates the annotations setting how the Broker ingress authenticates
+// the publishers of events.
+func validateIngressAuth(annotations map[string]string) *apis.FieldError {
+	switch mode := annotations[eventing.BrokerIngressAuthAnnotationKey]; mode {
+	case "", eventing.BrokerIngressAuthNone, eventing.BrokerIngressAuthServiceAccountToken:
+	case eventing.BrokerIngressAuthHMAC:
+		if annotations[eventing.BrokerIngressHMACSecretAnnotationKey] == "" {
+			return apis.ErrMissingField(eventing.BrokerIngressHMACSecretAnnotationKey)
+		}
+	default:
+		return apis.ErrInvalidValue(mode, eventing.BrokerIngressAuthAnnotationKey)
+	}
+	return nil
+}
+
+// validateSchemaValidation validates the annotation setting how the Broker ingress handles the
+// events not matching the schema of their EventType.
+func validateSchemaValidation(annotations map[string]string) *apis.FieldError {
+	switch mode := annotations[eventing.BrokerSchemaValidationAnnotationKey]; mode {
+	case "", eventing.BrokerSchemaValidationNone, eventing.BrokerSchemaValidationReject, eventing.BrokerSchemaValidationTag:
+		return nil
+	default:
+		return apis.ErrInvalidValue(mode, eventing.BrokerSchemaValidationAnnotationKey)
+	}
+}
+
+// validateEventTypeRegistration validates the annotation setting whether EventTypes are
+// registered from the events sent to the Broker.
+func validateEventTypeRegistration(annotations map[string]string) *apis.FieldError {
+	switch mode := annotations[eventing.BrokerEventTypeRegistrationAnnotationKey]; mode {
+	case "", eventing.BrokerEventTypeRegistrationNone, eventing.BrokerEventTypeRegistrationAuto:
+		return nil
+	default:
+		return apis.ErrInvalidValue(mode, eventing.BrokerEventTypeRegistrationAnnotationKey)
+	}
+}
+
+// validateDeduplicationWindow validates the annotation setting how long the Broker ingress
+// remembers the events sent to the Broker to deduplicate them.
+func validateDeduplicationWindow(annotations map[string]string) *apis.FieldError {
+	value, ok := annotations[eventing.BrokerDeduplicationWindowAnnotationKey]
+	if !ok {
+		return nil
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window <= 0 || window > maxDeduplicationWindow {
+		return apis.ErrInvalidValue(value, eventing.BrokerDeduplicationWindowAnnotationKey)
+	}
+	return nil
+}
+
+// validateRateLimit validates the annotations setting the rate limit of the Broker ingress for
+// the Broker.
+func validateRateLimit(annotations map[string]string) *apis.FieldError {
+	var errs *apis.FieldError
+	if value, ok := annotations[eventing.BrokerRateLimitAnnotationKey]; ok {
+		if limit, err := strconv.ParseFloat(value, 64); err != nil || limit < 0 {
+			errs = errs.Also(apis.ErrInvalidValue(value, eventing.BrokerRateLimitAnnotationKey))
+		}
+	}
+	if value, ok := annotations[eventing.BrokerRateLimitBurstAnnotationKey]; ok {
+		if burst, err := strconv.Atoi(value); err != nil || burst <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(value, eventing.BrokerRateLimitBurstAnnotationKey))
+		}
+	}
+	return errs
+}
+
 func (bs *BrokerSpec) Validate(ctx context.Context) *apis.FieldError {
 	var errs *apis.FieldError
 
@@ -187,6 +187,148 @@ func TestValidate(t *testing.T) {
 			},
 		},
 		want: apis.ErrInvalidValue(invalidString, "spec.delivery.backoffDelay"),
+	}, {
+		name: "valid ingress auth",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":                    "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.ingressAuth":              "ServiceAccountToken",
+					"eventing.knative.dev/broker.ingressAllowedNamespaces": "team-a,team-b",
+				},
+			},
+		},
+	}, {
+		name: "valid HMAC ingress auth",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":             "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.ingressAuth":       "HMAC",
+					"eventing.knative.dev/broker.ingressHMACSecret": "hmac-key",
+				},
+			},
+		},
+	}, {
+		name: "HMAC ingress auth without secret",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":       "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.ingressAuth": "HMAC",
+				},
+			},
+		},
+		want: apis.ErrMissingField("eventing.knative.dev/broker.ingressHMACSecret"),
+	}, {
+		name: "invalid ingress auth",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":       "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.ingressAuth": "Password",
+				},
+			},
+		},
+		want: apis.ErrInvalidValue("Password", "eventing.knative.dev/broker.ingressAuth"),
+	}, {
+		name: "valid schema validation",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":            "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.schemaValidation": "Reject",
+				},
+			},
+		},
+	}, {
+		name: "invalid schema validation",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":            "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.schemaValidation": "Drop",
+				},
+			},
+		},
+		want: apis.ErrInvalidValue("Drop", "eventing.knative.dev/broker.schemaValidation"),
+	}, {
+		name: "valid EventType registration",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":                 "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.eventTypeRegistration": "Auto",
+				},
+			},
+		},
+	}, {
+		name: "invalid EventType registration",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":                 "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.eventTypeRegistration": "Manual",
+				},
+			},
+		},
+		want: apis.ErrInvalidValue("Manual", "eventing.knative.dev/broker.eventTypeRegistration"),
+	}, {
+		name: "valid deduplication window",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":               "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.deduplicationWindow": "5m",
+				},
+			},
+		},
+	}, {
+		name: "invalid deduplication window",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":               "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.deduplicationWindow": "5",
+				},
+			},
+		},
+		want: apis.ErrInvalidValue("5", "eventing.knative.dev/broker.deduplicationWindow"),
+	}, {
+		name: "deduplication window too long",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":               "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.deduplicationWindow": "48h",
+				},
+			},
+		},
+		want: apis.ErrInvalidValue("48h", "eventing.knative.dev/broker.deduplicationWindow"),
+	}, {
+		name: "valid rate limit",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":          "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.rateLimit":      "0.5",
+					"eventing.knative.dev/broker.rateLimitBurst": "10",
+				},
+			},
+		},
+	}, {
+		name: "invalid rate limit",
+		b: Broker{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"eventing.knative.dev/broker.class":          "MTChannelBasedBroker",
+					"eventing.knative.dev/broker.rateLimit":      "-1",
+					"eventing.knative.dev/broker.rateLimitBurst": "0",
+				},
+			},
+		},
+		want: apis.ErrInvalidValue("-1", "eventing.knative.dev/broker.rateLimit").Also(
+			apis.ErrInvalidValue("0", "eventing.knative.dev/broker.rateLimitBurst")),
 	}}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -39,6 +39,10 @@ func (ts *TriggerSpec) SetDefaults(ctx context.Context) {
 	}
 	// Default the Subscriber namespace
 	ts.Subscriber.SetDefaults(ctx)
+	// Default the dead letter sink namespace
+	if ts.Delivery != nil && ts.Delivery.DeadLetterSink != nil {
+		ts.Delivery.DeadLetterSink.SetDefaults(ctx)
+	}
 }
 
 func setLabels(t *Trigger) {
@@ -24,6 +24,8 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -80,6 +82,38 @@ func TestTriggerDefaults(t *testing.T) {
 					},
 				}},
 		},
+		"delivery, dead letter sink ns defaulted": {
+			initial: Trigger{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: namespace,
+				},
+				Spec: TriggerSpec{
+					Broker: otherBroker,
+					Delivery: &eventingduckv1.DeliverySpec{
+						DeadLetterSink: &duckv1.Destination{
+							Ref: &duckv1.KReference{
+								Name: "dls",
+							},
+						},
+					}}},
+			expected: Trigger{
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: namespace,
+					Labels:    map[string]string{brokerLabel: otherBroker},
+				},
+				Spec: TriggerSpec{
+					Broker: otherBroker,
+					Filter: emptyTriggerFilter,
+					Delivery: &eventingduckv1.DeliverySpec{
+						DeadLetterSink: &duckv1.Destination{
+							Ref: &duckv1.KReference{
+								Name:      "dls",
+								Namespace: namespace,
+							},
+						},
+					},
+				}},
+		},
 		"nil broker and nil filter": {
 			initial:  Trigger{},
 			expected: defaultTrigger,
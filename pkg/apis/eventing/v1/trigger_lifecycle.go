@@ -37,6 +37,14 @@ const (
 
 	TriggerConditionSubscriberResolved apis.ConditionType = "SubscriberResolved"
 
+	// TriggerConditionDeliveryHealthy reflects the DeliveryHealthy condition of the Trigger's
+	// Subscription. It doesn't affect the readiness.
+	TriggerConditionDeliveryHealthy apis.ConditionType = "DeliveryHealthy"
+
+	// TriggerConditionDeliverySpecApplied has status False when the Trigger's delivery spec is
+	// ignored in favor of the Broker's one. It doesn't affect the readiness.
+	TriggerConditionDeliverySpecApplied apis.ConditionType = "DeliverySpecApplied"
+
 	// TriggerAnyFilter Constant to represent that we should allow anything.
 	TriggerAnyFilter = ""
 )
@@ -125,6 +133,33 @@ func (ts *TriggerStatus) PropagateSubscriptionCondition(sc *apis.Condition) {
 	}
 }
 
+// PropagateSubscriptionDeliveryHealth sets the DeliveryHealthy condition from the DeliveryHealthy
+// condition of the Subscription. The condition is removed if the Subscription has none.
+func (ts *TriggerStatus) PropagateSubscriptionDeliveryHealth(dc *apis.Condition) {
+	switch {
+	case dc == nil:
+		_ = triggerCondSet.Manage(ts).ClearCondition(TriggerConditionDeliveryHealthy)
+	case dc.Status == corev1.ConditionTrue:
+		triggerCondSet.Manage(ts).MarkTrue(TriggerConditionDeliveryHealthy)
+	case dc.Status == corev1.ConditionFalse:
+		triggerCondSet.Manage(ts).MarkFalse(TriggerConditionDeliveryHealthy, dc.Reason, "%s", dc.Message)
+	default:
+		triggerCondSet.Manage(ts).MarkUnknown(TriggerConditionDeliveryHealthy, dc.Reason, "%s", dc.Message)
+	}
+}
+
+// MarkDeliverySpecIgnored sets the DeliverySpecApplied condition to False, the Trigger's delivery
+// spec doesn't apply to the events it receives.
+func (ts *TriggerStatus) MarkDeliverySpecIgnored(reason, messageFormat string, messageA ...interface{}) {
+	triggerCondSet.Manage(ts).MarkFalse(TriggerConditionDeliverySpecApplied, reason, messageFormat, messageA...)
+}
+
+// ClearDeliverySpecIgnored removes the DeliverySpecApplied condition, the Trigger's delivery spec,
+// if any, applies to the events it receives.
+func (ts *TriggerStatus) ClearDeliverySpecIgnored() {
+	_ = triggerCondSet.Manage(ts).ClearCondition(TriggerConditionDeliverySpecApplied)
+}
+
 func (ts *TriggerStatus) MarkNotSubscribed(reason, messageFormat string, messageA ...interface{}) {
 	triggerCondSet.Manage(ts).MarkFalse(TriggerConditionSubscribed, reason, messageFormat, messageA...)
 }
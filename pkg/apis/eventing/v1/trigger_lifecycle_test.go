@@ -358,3 +358,73 @@ func TestTriggerConditionStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestTriggerPropagateSubscriptionDeliveryHealth(t *testing.T) {
+	ts := &TriggerStatus{}
+	ts.InitializeConditions()
+	ts.PropagateBrokerCondition(TestHelper.ReadyBrokerCondition())
+	ts.PropagateSubscriptionCondition(TestHelper.ReadySubscriptionCondition())
+	ts.MarkSubscriberResolvedSucceeded()
+	ts.MarkDependencySucceeded()
+
+	ts.PropagateSubscriptionDeliveryHealth(&apis.Condition{
+		Type:    "DeliveryHealthy",
+		Status:  corev1.ConditionFalse,
+		Reason:  "DeliveryFailing",
+		Message: "3 consecutive deliveries failed",
+	})
+	got := ts.GetCondition(TriggerConditionDeliveryHealthy)
+	want := &apis.Condition{
+		Type:     TriggerConditionDeliveryHealthy,
+		Status:   corev1.ConditionFalse,
+		Severity: apis.ConditionSeverityInfo,
+		Reason:   "DeliveryFailing",
+		Message:  "3 consecutive deliveries failed",
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(apis.Condition{}, "LastTransitionTime")); diff != "" {
+		t.Error("unexpected condition (-want, +got) =", diff)
+	}
+	if !ts.IsReady() {
+		t.Error("A failing delivery must not affect the readiness")
+	}
+
+	ts.PropagateSubscriptionDeliveryHealth(&apis.Condition{Type: "DeliveryHealthy", Status: corev1.ConditionTrue})
+	if got := ts.GetCondition(TriggerConditionDeliveryHealthy); got == nil || !got.IsTrue() {
+		t.Errorf("Expected DeliveryHealthy to be true, got %v", got)
+	}
+
+	ts.PropagateSubscriptionDeliveryHealth(nil)
+	if got := ts.GetCondition(TriggerConditionDeliveryHealthy); got != nil {
+		t.Errorf("Expected DeliveryHealthy to be removed, got %v", got)
+	}
+}
+
+func TestTriggerMarkDeliverySpecIgnored(t *testing.T) {
+	ts := &TriggerStatus{}
+	ts.InitializeConditions()
+	ts.PropagateBrokerCondition(TestHelper.ReadyBrokerCondition())
+	ts.PropagateSubscriptionCondition(TestHelper.ReadySubscriptionCondition())
+	ts.MarkSubscriberResolvedSucceeded()
+	ts.MarkDependencySucceeded()
+
+	ts.MarkDeliverySpecIgnored("BrokerDeliveryModePerBroker", "the Broker's delivery spec applies")
+	got := ts.GetCondition(TriggerConditionDeliverySpecApplied)
+	want := &apis.Condition{
+		Type:     TriggerConditionDeliverySpecApplied,
+		Status:   corev1.ConditionFalse,
+		Severity: apis.ConditionSeverityInfo,
+		Reason:   "BrokerDeliveryModePerBroker",
+		Message:  "the Broker's delivery spec applies",
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(apis.Condition{}, "LastTransitionTime")); diff != "" {
+		t.Error("unexpected condition (-want, +got) =", diff)
+	}
+	if !ts.IsReady() {
+		t.Error("An ignored delivery spec must not affect the readiness")
+	}
+
+	ts.ClearDeliverySpecIgnored()
+	if got := ts.GetCondition(TriggerConditionDeliverySpecApplied); got != nil {
+		t.Errorf("Expected DeliverySpecApplied to be removed, got %v", got)
+	}
+}
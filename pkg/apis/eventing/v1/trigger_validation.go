@@ -25,6 +25,9 @@ import (
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/kmp"
 
+	"knative.dev/eventing/pkg/eventfilter/cesql"
+	"knative.dev/eventing/pkg/eventfilter/data"
+
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -63,12 +66,120 @@ func (ts *TriggerSpec) Validate(ctx context.Context) *apis.FieldError {
 				errs = errs.Also(fe)
 			}
 		}
+		if ts.Filter.SQL != "" {
+			if _, err := cesql.Parse(ts.Filter.SQL); err != nil {
+				fe := &apis.FieldError{
+					Message: fmt.Sprintf("Invalid SQL expression: %q", ts.Filter.SQL),
+					Paths:   []string{"filter.sql"},
+					Details: err.Error(),
+				}
+				errs = errs.Also(fe)
+			}
+		}
+	}
+
+	for i, f := range ts.Filters {
+		errs = errs.Also(ValidateSubscriptionsAPIFilter(ctx, &f).ViaFieldIndex("filters", i))
 	}
 
 	if fe := ts.Subscriber.Validate(ctx); fe != nil {
 		errs = errs.Also(fe.ViaField("subscriber"))
 	}
 
+	if ts.Delivery != nil {
+		if de := ts.Delivery.Validate(ctx); de != nil {
+			errs = errs.Also(de.ViaField("delivery"))
+		}
+	}
+
+	return errs
+}
+
+// ValidateSubscriptionsAPIFilter validates the filter node and, recursively, its nested filters.
+func ValidateSubscriptionsAPIFilter(ctx context.Context, filter *SubscriptionsAPIFilter) *apis.FieldError {
+	if filter == nil {
+		return nil
+	}
+
+	var dialects []string
+	if len(filter.All) != 0 {
+		dialects = append(dialects, "all")
+	}
+	if len(filter.Any) != 0 {
+		dialects = append(dialects, "any")
+	}
+	if filter.Not != nil {
+		dialects = append(dialects, "not")
+	}
+	if len(filter.Exact) != 0 {
+		dialects = append(dialects, "exact")
+	}
+	if len(filter.Prefix) != 0 {
+		dialects = append(dialects, "prefix")
+	}
+	if len(filter.Suffix) != 0 {
+		dialects = append(dialects, "suffix")
+	}
+	if filter.SQL != "" {
+		dialects = append(dialects, "sql")
+	}
+	if filter.Data != "" {
+		dialects = append(dialects, "data")
+	}
+
+	var errs *apis.FieldError
+	switch len(dialects) {
+	case 0:
+		return apis.ErrMissingOneOf("all", "any", "not", "exact", "prefix", "suffix", "sql", "data")
+	case 1:
+	default:
+		errs = errs.Also(apis.ErrMultipleOneOf(dialects...))
+	}
+
+	errs = errs.Also(validateFilterAttributes(filter.Exact, false).ViaField("exact"))
+	errs = errs.Also(validateFilterAttributes(filter.Prefix, true).ViaField("prefix"))
+	errs = errs.Also(validateFilterAttributes(filter.Suffix, true).ViaField("suffix"))
+	if filter.SQL != "" {
+		if _, err := cesql.Parse(filter.SQL); err != nil {
+			errs = errs.Also(&apis.FieldError{
+				Message: fmt.Sprintf("Invalid SQL expression: %q", filter.SQL),
+				Paths:   []string{"sql"},
+				Details: err.Error(),
+			})
+		}
+	}
+	if filter.Data != "" {
+		if _, err := data.ParsePredicate(filter.Data); err != nil {
+			errs = errs.Also(&apis.FieldError{
+				Message: fmt.Sprintf("Invalid data predicate: %q", filter.Data),
+				Paths:   []string{"data"},
+				Details: err.Error(),
+			})
+		}
+	}
+	for i, f := range filter.All {
+		errs = errs.Also(ValidateSubscriptionsAPIFilter(ctx, &f).ViaFieldIndex("all", i))
+	}
+	for i, f := range filter.Any {
+		errs = errs.Also(ValidateSubscriptionsAPIFilter(ctx, &f).ViaFieldIndex("any", i))
+	}
+	errs = errs.Also(ValidateSubscriptionsAPIFilter(ctx, filter.Not).ViaField("not"))
+	return errs
+}
+
+func validateFilterAttributes(attrs map[string]string, requireValue bool) *apis.FieldError {
+	var errs *apis.FieldError
+	for attr, value := range attrs {
+		if !validAttributeName.MatchString(attr) {
+			errs = errs.Also(&apis.FieldError{
+				Message: fmt.Sprintf("Invalid attribute name: %q", attr),
+				Paths:   []string{""},
+			})
+		}
+		if requireValue && value == "" {
+			errs = errs.Also(apis.ErrInvalidValue(value, attr))
+		}
+	}
 	return errs
 }
 
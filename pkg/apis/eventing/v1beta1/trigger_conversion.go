@@ -20,12 +20,14 @@ import (
 	"context"
 	"fmt"
 
+	duckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	duckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
 	v1 "knative.dev/eventing/pkg/apis/eventing/v1"
 	"knative.dev/pkg/apis"
 )
 
 // ConvertTo implements apis.Convertible
-func (source *Trigger) ConvertTo(_ context.Context, to apis.Convertible) error {
+func (source *Trigger) ConvertTo(ctx context.Context, to apis.Convertible) error {
 	switch sink := to.(type) {
 	case *v1.Trigger:
 		sink.ObjectMeta = source.ObjectMeta
@@ -38,6 +40,14 @@ func (source *Trigger) ConvertTo(_ context.Context, to apis.Convertible) error {
 			for k, v := range source.Spec.Filter.Attributes {
 				sink.Spec.Filter.Attributes[k] = v
 			}
+			sink.Spec.Filter.SQL = source.Spec.Filter.SQL
+		}
+		sink.Spec.Filters = convertToV1Filters(source.Spec.Filters)
+		if source.Spec.Delivery != nil {
+			sink.Spec.Delivery = &duckv1.DeliverySpec{}
+			if err := source.Spec.Delivery.ConvertTo(ctx, sink.Spec.Delivery); err != nil {
+				return err
+			}
 		}
 		sink.Status.Status = source.Status.Status
 		sink.Status.SubscriberURI = source.Status.SubscriberURI
@@ -48,7 +58,7 @@ func (source *Trigger) ConvertTo(_ context.Context, to apis.Convertible) error {
 }
 
 // ConvertFrom implements apis.Convertible
-func (sink *Trigger) ConvertFrom(_ context.Context, from apis.Convertible) error {
+func (sink *Trigger) ConvertFrom(ctx context.Context, from apis.Convertible) error {
 	switch source := from.(type) {
 	case *v1.Trigger:
 		sink.ObjectMeta = source.ObjectMeta
@@ -61,6 +71,14 @@ func (sink *Trigger) ConvertFrom(_ context.Context, from apis.Convertible) error
 			}
 			sink.Spec.Filter = &TriggerFilter{
 				Attributes: attributes,
+				SQL:        source.Spec.Filter.SQL,
+			}
+		}
+		sink.Spec.Filters = convertFromV1Filters(source.Spec.Filters)
+		if source.Spec.Delivery != nil {
+			sink.Spec.Delivery = &duckv1beta1.DeliverySpec{}
+			if err := sink.Spec.Delivery.ConvertFrom(ctx, source.Spec.Delivery); err != nil {
+				return err
 			}
 		}
 		sink.Status.Status = source.Status.Status
@@ -70,3 +88,57 @@ func (sink *Trigger) ConvertFrom(_ context.Context, from apis.Convertible) error
 		return fmt.Errorf("unknown version, got: %T", source)
 	}
 }
+
+func convertToV1Filters(source []SubscriptionsAPIFilter) []v1.SubscriptionsAPIFilter {
+	if source == nil {
+		return nil
+	}
+	sink := make([]v1.SubscriptionsAPIFilter, 0, len(source))
+	for _, f := range source {
+		sink = append(sink, *convertToV1Filter(&f))
+	}
+	return sink
+}
+
+func convertToV1Filter(source *SubscriptionsAPIFilter) *v1.SubscriptionsAPIFilter {
+	if source == nil {
+		return nil
+	}
+	return &v1.SubscriptionsAPIFilter{
+		All:    convertToV1Filters(source.All),
+		Any:    convertToV1Filters(source.Any),
+		Not:    convertToV1Filter(source.Not),
+		Exact:  source.Exact,
+		Prefix: source.Prefix,
+		Suffix: source.Suffix,
+		SQL:    source.SQL,
+		Data:   source.Data,
+	}
+}
+
+func convertFromV1Filters(source []v1.SubscriptionsAPIFilter) []SubscriptionsAPIFilter {
+	if source == nil {
+		return nil
+	}
+	sink := make([]SubscriptionsAPIFilter, 0, len(source))
+	for _, f := range source {
+		sink = append(sink, *convertFromV1Filter(&f))
+	}
+	return sink
+}
+
+func convertFromV1Filter(source *v1.SubscriptionsAPIFilter) *SubscriptionsAPIFilter {
+	if source == nil {
+		return nil
+	}
+	return &SubscriptionsAPIFilter{
+		All:    convertFromV1Filters(source.All),
+		Any:    convertFromV1Filters(source.Any),
+		Not:    convertFromV1Filter(source.Not),
+		Exact:  source.Exact,
+		Prefix: source.Prefix,
+		Suffix: source.Suffix,
+		SQL:    source.SQL,
+		Data:   source.Data,
+	}
+}
@@ -22,7 +22,10 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
 	v1 "knative.dev/eventing/pkg/apis/eventing/v1"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
@@ -56,6 +59,7 @@ func TestTriggerConversionBadVersion(t *testing.T) {
 func TestTriggerConversionRoundTripV1beta1(t *testing.T) {
 	// Just one for now, just adding the for loop for ease of future changes.
 	versions := []apis.Convertible{&v1.Trigger{}}
+	linear := eventingduckv1beta1.BackoffPolicyLinear
 
 	tests := []struct {
 		name string
@@ -114,7 +118,21 @@ func TestTriggerConversionRoundTripV1beta1(t *testing.T) {
 				Broker: "default",
 				Filter: &TriggerFilter{
 					Attributes: TriggerFilterAttributes{"source": "mysource", "type": "mytype", "customkey": "customvalue"},
+					SQL:        "type LIKE 'my%' AND EXISTS customkey",
 				},
+				Filters: []SubscriptionsAPIFilter{{
+					Prefix: map[string]string{"source": "my"},
+				}, {
+					Not: &SubscriptionsAPIFilter{
+						Any: []SubscriptionsAPIFilter{{
+							Exact: map[string]string{"type": "othertype"},
+						}, {
+							Suffix: map[string]string{"type": "other"},
+						}, {
+							Data: `$.status == "FAILED"`,
+						}},
+					},
+				}},
 			},
 			Status: TriggerStatus{
 				Status: duckv1.Status{
@@ -147,6 +165,19 @@ func TestTriggerConversionRoundTripV1beta1(t *testing.T) {
 					},
 					URI: apis.HTTP("subscriberURI"),
 				},
+				Delivery: &eventingduckv1beta1.DeliverySpec{
+					DeadLetterSink: &duckv1.Destination{
+						Ref: &duckv1.KReference{
+							Kind:       "dl-sink-kind",
+							Namespace:  "dl-sink-ns",
+							Name:       "dl-sink-name",
+							APIVersion: "dl-sink-version",
+						},
+					},
+					Retry:         pointer.Int32Ptr(5),
+					BackoffPolicy: &linear,
+					BackoffDelay:  pointer.StringPtr("PT5S"),
+				},
 			},
 			Status: TriggerStatus{
 				Status: duckv1.Status{
@@ -185,6 +216,7 @@ func TestTriggerConversionRoundTripV1beta1(t *testing.T) {
 func TestTriggerConversionRoundTripV1(t *testing.T) {
 	// Just one for now, just adding the for loop for ease of future changes.
 	versions := []apis.Convertible{&Trigger{}}
+	linear := eventingduckv1.BackoffPolicyLinear
 
 	tests := []struct {
 		name string
@@ -243,7 +275,21 @@ func TestTriggerConversionRoundTripV1(t *testing.T) {
 				Broker: "default",
 				Filter: &v1.TriggerFilter{
 					Attributes: v1.TriggerFilterAttributes{"source": "mysource", "type": "mytype", "customkey": "customvalue"},
+					SQL:        "type LIKE 'my%' AND EXISTS customkey",
 				},
+				Filters: []v1.SubscriptionsAPIFilter{{
+					Prefix: map[string]string{"source": "my"},
+				}, {
+					Not: &v1.SubscriptionsAPIFilter{
+						Any: []v1.SubscriptionsAPIFilter{{
+							Exact: map[string]string{"type": "othertype"},
+						}, {
+							Suffix: map[string]string{"type": "other"},
+						}, {
+							Data: `$.status == "FAILED"`,
+						}},
+					},
+				}},
 			},
 			Status: v1.TriggerStatus{
 				Status: duckv1.Status{
@@ -276,6 +322,19 @@ func TestTriggerConversionRoundTripV1(t *testing.T) {
 					},
 					URI: apis.HTTP("subscriberURI"),
 				},
+				Delivery: &eventingduckv1.DeliverySpec{
+					DeadLetterSink: &duckv1.Destination{
+						Ref: &duckv1.KReference{
+							Kind:       "dl-sink-kind",
+							Namespace:  "dl-sink-ns",
+							Name:       "dl-sink-name",
+							APIVersion: "dl-sink-version",
+						},
+					},
+					Retry:         pointer.Int32Ptr(5),
+					BackoffPolicy: &linear,
+					BackoffDelay:  pointer.StringPtr("PT5S"),
+				},
 			},
 			Status: v1.TriggerStatus{
 				Status: duckv1.Status{
@@ -19,6 +19,7 @@ package v1beta1
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/kmeta"
@@ -84,9 +85,21 @@ type TriggerSpec struct {
 	// +optional
 	Filter *TriggerFilter `json:"filter,omitempty"`
 
+	// Filters is a list of filters to apply against all events from the Broker. Only events that
+	// pass all the filters, as well as Filter, will be sent to the Subscriber.
+	//
+	// +optional
+	Filters []SubscriptionsAPIFilter `json:"filters,omitempty"`
+
 	// Subscriber is the addressable that receives events from the Broker that pass the Filter. It
 	// is required.
 	Subscriber duckv1.Destination `json:"subscriber"`
+
+	// Delivery contains the delivery spec for this specific trigger. It overrides the delivery
+	// spec of the Broker.
+	//
+	// +optional
+	Delivery *eventingduckv1beta1.DeliverySpec `json:"delivery,omitempty"`
 }
 
 type TriggerFilter struct {
@@ -99,6 +112,67 @@ type TriggerFilter struct {
 	//
 	// +optional
 	Attributes TriggerFilterAttributes `json:"attributes,omitempty"`
+
+	// SQL filters events by evaluating a SQL-like expression over the event
+	// context attributes and extensions. An event passes the filter if the
+	// expression evaluates to true, e.g.
+	// "type LIKE 'com.example.%' AND region IN ('eu', 'us')".
+	//
+	// The expression supports the boolean operators AND, OR, XOR and NOT,
+	// LIKE, IN, EXISTS and integer comparisons.
+	//
+	// +optional
+	SQL string `json:"sql,omitempty"`
+}
+
+// SubscriptionsAPIFilter is a filter node composed with other nodes using the
+// all, any and not dialects. Exactly one dialect must be set in each node.
+type SubscriptionsAPIFilter struct {
+	// All evaluates to true only if all the nested filters evaluate to true.
+	//
+	// +optional
+	All []SubscriptionsAPIFilter `json:"all,omitempty"`
+
+	// Any evaluates to true if at least one of the nested filters evaluates to true.
+	//
+	// +optional
+	Any []SubscriptionsAPIFilter `json:"any,omitempty"`
+
+	// Not evaluates to true if the nested filter evaluates to false.
+	//
+	// +optional
+	Not *SubscriptionsAPIFilter `json:"not,omitempty"`
+
+	// Exact evaluates to true if the values of the matching context attributes
+	// are equal to the specified values.
+	//
+	// +optional
+	Exact map[string]string `json:"exact,omitempty"`
+
+	// Prefix evaluates to true if the values of the matching context attributes
+	// start with the specified values.
+	//
+	// +optional
+	Prefix map[string]string `json:"prefix,omitempty"`
+
+	// Suffix evaluates to true if the values of the matching context attributes
+	// end with the specified values.
+	//
+	// +optional
+	Suffix map[string]string `json:"suffix,omitempty"`
+
+	// SQL evaluates to true if the SQL-like expression evaluates to true.
+	// See TriggerFilter.SQL for the supported syntax.
+	//
+	// +optional
+	SQL string `json:"sql,omitempty"`
+
+	// Data evaluates to true if the JSONPath predicate holds for the event data,
+	// e.g. "$.order.total > 100" or "$.status == \"FAILED\"". Events whose data
+	// isn't JSON don't pass the filter.
+	//
+	// +optional
+	Data string `json:"data,omitempty"`
 }
 
 // TriggerFilterAttributes is a map of context attribute names to values for
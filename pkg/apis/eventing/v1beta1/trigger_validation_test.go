@@ -23,8 +23,11 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
 )
 
 var (
@@ -295,6 +298,86 @@ func TestTriggerSpecValidation(t *testing.T) {
 			Message: `Invalid attribute name: "invALID"`,
 			Paths:   []string{"filter.attributes"},
 		},
+	}, {
+		name: "valid SQL filter",
+		ts: &TriggerSpec{
+			Broker: "test_broker",
+			Filter: &TriggerFilter{
+				SQL: "type LIKE 'com.example.%' AND region IN ('eu', 'us')",
+			},
+			Subscriber: validSubscriber,
+		},
+		want: &apis.FieldError{},
+	}, {
+		name: "invalid SQL filter",
+		ts: &TriggerSpec{
+			Broker: "test_broker",
+			Filter: &TriggerFilter{
+				SQL: "type LIKE",
+			},
+			Subscriber: validSubscriber,
+		},
+		want: &apis.FieldError{
+			Message: `Invalid SQL expression: "type LIKE"`,
+			Paths:   []string{"filter.sql"},
+			Details: "position 9: unexpected end of expression, expected a string pattern",
+		},
+	}, {
+		name: "valid filters",
+		ts: &TriggerSpec{
+			Broker: "test_broker",
+			Filters: []SubscriptionsAPIFilter{{
+				Prefix: map[string]string{"source": "/apis/v1/namespaces/prod"},
+			}, {
+				Not: &SubscriptionsAPIFilter{
+					Any: []SubscriptionsAPIFilter{{
+						Exact: map[string]string{"type": "dev.knative.example.deleted"},
+					}, {
+						Suffix: map[string]string{"type": ".archived"},
+					}, {
+						Data: `$.order.total > 100`,
+					}},
+				},
+			}},
+			Subscriber: validSubscriber,
+		},
+		want: &apis.FieldError{},
+	}, {
+		name: "invalid filters",
+		ts: &TriggerSpec{
+			Broker: "test_broker",
+			Filters: []SubscriptionsAPIFilter{{}, {
+				Exact:  map[string]string{"type": "dev.knative.example.deleted"},
+				Suffix: map[string]string{"type": ""},
+			}, {
+				All: []SubscriptionsAPIFilter{{
+					Prefix: map[string]string{"Invalid": "value"},
+				}, {
+					SQL: "type =",
+				}, {
+					Data: "status",
+				}},
+			}},
+			Subscriber: validSubscriber,
+		},
+		want: apis.ErrMissingOneOf("all", "any", "not", "exact", "prefix", "suffix", "sql", "data").ViaFieldIndex("filters", 0).Also(
+			apis.ErrMultipleOneOf("exact", "suffix").ViaFieldIndex("filters", 1),
+			apis.ErrInvalidValue("", "type").ViaField("suffix").ViaFieldIndex("filters", 1),
+			(&apis.FieldError{
+				Message: `Invalid attribute name: "Invalid"`,
+				Paths:   []string{""},
+			}).ViaField("prefix").ViaFieldIndex("all", 0).ViaFieldIndex("filters", 2),
+			(&apis.FieldError{
+				Message: `Invalid SQL expression: "type ="`,
+				Paths:   []string{"sql"},
+				Details: "position 6: unexpected end of expression, expected a value",
+			}).ViaFieldIndex("all", 1).ViaFieldIndex("filters", 2),
+			(&apis.FieldError{
+				Message: `Invalid data predicate: "status"`,
+				Paths:   []string{"data"},
+				Details: "path must start with $",
+			}).ViaFieldIndex("all", 2).ViaFieldIndex("filters", 2),
+		),
 	}, {
 		name: "missing subscriber",
 		ts: &TriggerSpec{
@@ -342,6 +425,40 @@ func TestTriggerSpecValidation(t *testing.T) {
 			Subscriber: validSubscriber,
 		},
 		want: &apis.FieldError{},
+	}, {
+		name: "valid delivery",
+		ts: &TriggerSpec{
+			Broker:     "test_broker",
+			Filter:     validAttributesFilter,
+			Subscriber: validSubscriber,
+			Delivery: &eventingduckv1beta1.DeliverySpec{
+				DeadLetterSink: &validSubscriber,
+				Retry:          pointer.Int32Ptr(3),
+			},
+		},
+		want: &apis.FieldError{},
+	}, {
+		name: "invalid delivery, invalid delay string",
+		ts: &TriggerSpec{
+			Broker:     "test_broker",
+			Filter:     validAttributesFilter,
+			Subscriber: validSubscriber,
+			Delivery: &eventingduckv1beta1.DeliverySpec{
+				BackoffDelay: pointer.StringPtr("invalid"),
+			},
+		},
+		want: apis.ErrInvalidValue("invalid", "delivery.backoffDelay"),
+	}, {
+		name: "invalid delivery, invalid dead letter sink",
+		ts: &TriggerSpec{
+			Broker:     "test_broker",
+			Filter:     validAttributesFilter,
+			Subscriber: validSubscriber,
+			Delivery: &eventingduckv1beta1.DeliverySpec{
+				DeadLetterSink: &invalidSubscriber,
+			},
+		},
+		want: apis.ErrMissingField("delivery.deadLetterSink.ref.name"),
 	}}
 
 	for _, test := range tests {
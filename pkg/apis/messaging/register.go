@@ -41,4 +41,9 @@ var (
 		Group:    GroupName,
 		Resource: "inmemorychannels",
 	}
+	// PersistentChannelsResource represents a Knative PersistentChannel
+	PersistentChannelsResource = schema.GroupResource{
+		Group:    GroupName,
+		Resource: "persistentchannels",
+	}
 )
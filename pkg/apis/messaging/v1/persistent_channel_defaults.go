@@ -0,0 +1,39 @@
+/*
+ * Copyright 2020 The Knative Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package v1
+
+import (
+	"context"
+
+	"knative.dev/eventing/pkg/apis/messaging"
+)
+
+func (pc *PersistentChannel) SetDefaults(ctx context.Context) {
+	// Set the duck subscription to the stored version of the duck
+	// we support, see InMemoryChannel.
+	if pc.Annotations == nil {
+		pc.Annotations = make(map[string]string)
+	}
+	if _, ok := pc.Annotations[messaging.SubscribableDuckVersionAnnotation]; !ok {
+		pc.Annotations[messaging.SubscribableDuckVersionAnnotation] = "v1"
+	}
+
+	pc.Spec.SetDefaults(ctx)
+}
+
+func (pcs *PersistentChannelSpec) SetDefaults(ctx context.Context) {
+	// Nothing to default here...
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2020 The Knative Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	v1 "knative.dev/pkg/apis/duck/v1"
+)
+
+var pcCondSet = apis.NewLivingConditionSet(PersistentChannelConditionDispatcherReady, PersistentChannelConditionServiceReady, PersistentChannelConditionEndpointsReady, PersistentChannelConditionAddressable, PersistentChannelConditionChannelServiceReady)
+
+const (
+	// PersistentChannelConditionReady has status True when all subconditions below have been set to True.
+	PersistentChannelConditionReady = apis.ConditionReady
+
+	// PersistentChannelConditionDispatcherReady has status True when a Dispatcher deployment is ready
+	// Keyed off appsv1.DeploymentAvailable, which means minimum available replicas required are up
+	// and running for at least minReadySeconds.
+	PersistentChannelConditionDispatcherReady apis.ConditionType = "DispatcherReady"
+
+	// PersistentChannelConditionServiceReady has status True when a k8s Service is ready. This
+	// basically just means it exists because there's no meaningful status in Service. See Endpoints
+	// below.
+	PersistentChannelConditionServiceReady apis.ConditionType = "ServiceReady"
+
+	// PersistentChannelConditionEndpointsReady has status True when a k8s Service Endpoints are backed
+	// by at least one endpoint.
+	PersistentChannelConditionEndpointsReady apis.ConditionType = "EndpointsReady"
+
+	// PersistentChannelConditionAddressable has status true when this PersistentChannel meets
+	// the Addressable contract and has a non-empty hostname.
+	PersistentChannelConditionAddressable apis.ConditionType = "Addressable"
+
+	// PersistentChannelConditionChannelServiceReady has status True when a k8s Service representing the channel is ready.
+	// Because this uses ExternalName, there are no endpoints to check.
+	PersistentChannelConditionChannelServiceReady apis.ConditionType = "ChannelServiceReady"
+)
+
+// GetConditionSet retrieves the condition set for this resource. Implements the KRShaped interface.
+func (*PersistentChannel) GetConditionSet() apis.ConditionSet {
+	return pcCondSet
+}
+
+// GetGroupVersionKind returns GroupVersionKind for PersistentChannels
+func (*PersistentChannel) GetGroupVersionKind() schema.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("PersistentChannel")
+}
+
+// GetUntypedSpec returns the spec of the PersistentChannel.
+func (pc *PersistentChannel) GetUntypedSpec() interface{} {
+	return pc.Spec
+}
+
+// GetCondition returns the condition currently associated with the given type, or nil.
+func (pcs *PersistentChannelStatus) GetCondition(t apis.ConditionType) *apis.Condition {
+	return pcCondSet.Manage(pcs).GetCondition(t)
+}
+
+// IsReady returns true if the resource is ready overall.
+func (pcs *PersistentChannelStatus) IsReady() bool {
+	return pcCondSet.Manage(pcs).IsHappy()
+}
+
+// InitializeConditions sets relevant unset conditions to Unknown state.
+func (pcs *PersistentChannelStatus) InitializeConditions() {
+	pcCondSet.Manage(pcs).InitializeConditions()
+}
+
+func (pcs *PersistentChannelStatus) SetAddress(url *apis.URL) {
+	pcs.Address = &v1.Addressable{URL: url}
+	if url != nil {
+		pcCondSet.Manage(pcs).MarkTrue(PersistentChannelConditionAddressable)
+	} else {
+		pcCondSet.Manage(pcs).MarkFalse(PersistentChannelConditionAddressable, "emptyHostname", "hostname is the empty string")
+	}
+}
+
+func (pcs *PersistentChannelStatus) MarkDispatcherFailed(reason, messageFormat string, messageA ...interface{}) {
+	pcCondSet.Manage(pcs).MarkFalse(PersistentChannelConditionDispatcherReady, reason, messageFormat, messageA...)
+}
+
+func (pcs *PersistentChannelStatus) MarkDispatcherUnknown(reason, messageFormat string, messageA ...interface{}) {
+	pcCondSet.Manage(pcs).MarkUnknown(PersistentChannelConditionDispatcherReady, reason, messageFormat, messageA...)
+}
+
+// TODO: Unify this with the ones from Eventing. Say: Broker, Trigger.
+func (pcs *PersistentChannelStatus) PropagateDispatcherStatus(ds *appsv1.DeploymentStatus) {
+	for _, cond := range ds.Conditions {
+		if cond.Type == appsv1.DeploymentAvailable {
+			if cond.Status == corev1.ConditionTrue {
+				pcCondSet.Manage(pcs).MarkTrue(PersistentChannelConditionDispatcherReady)
+			} else if cond.Status == corev1.ConditionFalse {
+				pcs.MarkDispatcherFailed("DispatcherDeploymentFalse", "The status of Dispatcher Deployment is False: %s : %s", cond.Reason, cond.Message)
+			} else if cond.Status == corev1.ConditionUnknown {
+				pcs.MarkDispatcherUnknown("DispatcherDeploymentUnknown", "The status of Dispatcher Deployment is Unknown: %s : %s", cond.Reason, cond.Message)
+			}
+		}
+	}
+}
+
+func (pcs *PersistentChannelStatus) MarkServiceFailed(reason, messageFormat string, messageA ...interface{}) {
+	pcCondSet.Manage(pcs).MarkFalse(PersistentChannelConditionServiceReady, reason, messageFormat, messageA...)
+}
+
+func (pcs *PersistentChannelStatus) MarkServiceUnknown(reason, messageFormat string, messageA ...interface{}) {
+	pcCondSet.Manage(pcs).MarkUnknown(PersistentChannelConditionServiceReady, reason, messageFormat, messageA...)
+}
+
+func (pcs *PersistentChannelStatus) MarkServiceTrue() {
+	pcCondSet.Manage(pcs).MarkTrue(PersistentChannelConditionServiceReady)
+}
+
+func (pcs *PersistentChannelStatus) MarkChannelServiceFailed(reason, messageFormat string, messageA ...interface{}) {
+	pcCondSet.Manage(pcs).MarkFalse(PersistentChannelConditionChannelServiceReady, reason, messageFormat, messageA...)
+}
+
+func (pcs *PersistentChannelStatus) MarkChannelServiceUnknown(reason, messageFormat string, messageA ...interface{}) {
+	pcCondSet.Manage(pcs).MarkUnknown(PersistentChannelConditionChannelServiceReady, reason, messageFormat, messageA...)
+}
+
+func (pcs *PersistentChannelStatus) MarkChannelServiceTrue() {
+	pcCondSet.Manage(pcs).MarkTrue(PersistentChannelConditionChannelServiceReady)
+}
+
+func (pcs *PersistentChannelStatus) MarkEndpointsFailed(reason, messageFormat string, messageA ...interface{}) {
+	pcCondSet.Manage(pcs).MarkFalse(PersistentChannelConditionEndpointsReady, reason, messageFormat, messageA...)
+}
+
+func (pcs *PersistentChannelStatus) MarkEndpointsUnknown(reason, messageFormat string, messageA ...interface{}) {
+	pcCondSet.Manage(pcs).MarkUnknown(PersistentChannelConditionEndpointsReady, reason, messageFormat, messageA...)
+}
+
+func (pcs *PersistentChannelStatus) MarkEndpointsTrue() {
+	pcCondSet.Manage(pcs).MarkTrue(PersistentChannelConditionEndpointsReady)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	"knative.dev/pkg/apis"
+)
+
+func TestPersistentChannelGetConditionSet(t *testing.T) {
+	r := &PersistentChannel{}
+
+	if got, want := r.GetConditionSet().GetTopLevelConditionType(), apis.ConditionReady; got != want {
+		t.Errorf("GetTopLevelCondition=%v, want=%v", got, want)
+	}
+}
+
+func TestPersistentChannelIsReady(t *testing.T) {
+	tests := []struct {
+		name                    string
+		markServiceReady        bool
+		markChannelServiceReady bool
+		setAddress              bool
+		markEndpointsReady      bool
+		wantReady               bool
+		dispatcherStatus        *appsv1.DeploymentStatus
+	}{{
+		name:                    "all happy",
+		markServiceReady:        true,
+		markChannelServiceReady: true,
+		markEndpointsReady:      true,
+		dispatcherStatus:        deploymentStatusReady,
+		setAddress:              true,
+		wantReady:               true,
+	}, {
+		name:                    "service not ready",
+		markServiceReady:        false,
+		markChannelServiceReady: false,
+		markEndpointsReady:      true,
+		dispatcherStatus:        deploymentStatusReady,
+		setAddress:              true,
+		wantReady:               false,
+	}, {
+		name:                    "endpoints not ready",
+		markServiceReady:        true,
+		markChannelServiceReady: false,
+		markEndpointsReady:      false,
+		dispatcherStatus:        deploymentStatusReady,
+		setAddress:              true,
+		wantReady:               false,
+	}, {
+		name:                    "deployment not ready",
+		markServiceReady:        true,
+		markEndpointsReady:      true,
+		markChannelServiceReady: false,
+		dispatcherStatus:        deploymentStatusNotReady,
+		setAddress:              true,
+		wantReady:               false,
+	}, {
+		name:                    "address not set",
+		markServiceReady:        true,
+		markChannelServiceReady: false,
+		markEndpointsReady:      true,
+		dispatcherStatus:        deploymentStatusReady,
+		setAddress:              false,
+		wantReady:               false,
+	}, {
+		name:                    "channel service not ready",
+		markServiceReady:        true,
+		markChannelServiceReady: false,
+		markEndpointsReady:      true,
+		dispatcherStatus:        deploymentStatusReady,
+		setAddress:              true,
+		wantReady:               false,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cs := &PersistentChannelStatus{}
+			cs.InitializeConditions()
+			if test.markServiceReady {
+				cs.MarkServiceTrue()
+			} else {
+				cs.MarkServiceFailed("NotReadyService", "testing")
+			}
+			if test.markChannelServiceReady {
+				cs.MarkChannelServiceTrue()
+			} else {
+				cs.MarkChannelServiceFailed("NotReadyChannelService", "testing")
+			}
+			if test.setAddress {
+				cs.SetAddress(&apis.URL{Scheme: "http", Host: "foo.bar"})
+			}
+			if test.markEndpointsReady {
+				cs.MarkEndpointsTrue()
+			} else {
+				cs.MarkEndpointsFailed("NotReadyEndpoints", "testing")
+			}
+			if test.dispatcherStatus != nil {
+				cs.PropagateDispatcherStatus(test.dispatcherStatus)
+			} else {
+				cs.MarkDispatcherFailed("NotReadyDispatcher", "testing")
+			}
+			got := cs.IsReady()
+			if test.wantReady != got {
+				t.Errorf("unexpected readiness: want %v, got %v", test.wantReady, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2020 The Knative Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/kmeta"
+)
+
+// +genclient
+// +genreconciler
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PersistentChannel is a resource representing a channel persisting its events to disk before
+// acknowledging them, so that they survive a restart of its dispatcher.
+type PersistentChannel struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the desired state of the Channel.
+	Spec PersistentChannelSpec `json:"spec,omitempty"`
+
+	// Status represents the current state of the Channel. This data may be out of
+	// date.
+	// +optional
+	Status PersistentChannelStatus `json:"status,omitempty"`
+}
+
+var (
+	// Check that PersistentChannel can be validated and defaulted.
+	_ apis.Validatable = (*PersistentChannel)(nil)
+	_ apis.Defaultable = (*PersistentChannel)(nil)
+
+	// Check that PersistentChannel can return its spec untyped.
+	_ apis.HasSpec = (*PersistentChannel)(nil)
+
+	_ runtime.Object = (*PersistentChannel)(nil)
+
+	// Check that we can create OwnerReferences to a PersistentChannel.
+	_ kmeta.OwnerRefable = (*PersistentChannel)(nil)
+
+	// Check that the type conforms to the duck Knative Resource shape.
+	_ duckv1.KRShaped = (*PersistentChannel)(nil)
+)
+
+// PersistentChannelSpec defines which subscribers have expressed interest in
+// receiving events from this PersistentChannel.
+type PersistentChannelSpec struct {
+	// Channel conforms to Duck type Channelable.
+	eventingduckv1.ChannelableSpec `json:",inline"`
+}
+
+// PersistentChannelStatus represents the current state of a PersistentChannel.
+type PersistentChannelStatus struct {
+	// Channel conforms to Duck type Channelable.
+	eventingduckv1.ChannelableStatus `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PersistentChannelList is a collection of persistent channels.
+type PersistentChannelList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PersistentChannel `json:"items"`
+}
+
+// GetStatus retrieves the status of the PersistentChannel. Implements the KRShaped interface.
+func (t *PersistentChannel) GetStatus() *duckv1.Status {
+	return &t.Status.Status
+}
@@ -47,6 +47,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&InMemoryChannel{},
 		&InMemoryChannelList{},
+		&PersistentChannel{},
+		&PersistentChannelList{},
 		&Subscription{},
 		&SubscriptionList{},
 		&Channel{},
@@ -17,7 +17,12 @@
 package v1
 
 import (
+	"fmt"
+	"time"
+
 	"knative.dev/pkg/apis"
+
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 )
 
 // SubCondSet is a condition set with Ready as the happy condition and
@@ -37,6 +42,15 @@ const (
 
 	// SubscriptionConditionChannelReady has status True when the channel has marked the subscriber as 'ready'
 	SubscriptionConditionChannelReady apis.ConditionType = "ChannelReady"
+
+	// SubscriptionConditionDeliveryHealthy has status True when the last event was delivered to the
+	// subscriber, and False when the last events couldn't be delivered. It is only set when the
+	// channel reports the delivery health of its subscribers, and doesn't affect the readiness.
+	SubscriptionConditionDeliveryHealthy apis.ConditionType = "DeliveryHealthy"
+
+	// SubscriptionReasonDeliveryFailing is the reason of the DeliveryHealthy condition when the
+	// last events couldn't be delivered.
+	SubscriptionReasonDeliveryFailing = "DeliveryFailing"
 )
 
 // GetConditionSet retrieves the condition set for this resource. Implements the KRShaped interface.
@@ -113,3 +127,35 @@ func (ss *SubscriptionStatus) MarkChannelUnknown(reason, messageFormat string, m
 func (ss *SubscriptionStatus) MarkNotAddedToChannel(reason, messageFormat string, messageA ...interface{}) {
 	SubCondSet.Manage(ss).MarkFalse(SubscriptionConditionAddedToChannel, reason, messageFormat, messageA...)
 }
+
+// PropagateDeliveryHealth sets the DeliveryHealthy condition from the delivery health of the
+// subscriber reported by the channel. The condition is removed if the channel reports none.
+func (ss *SubscriptionStatus) PropagateDeliveryHealth(s *eventingduckv1.SubscriberStatus) {
+	switch {
+	case s != nil && s.ConsecutiveFailures > 0:
+		SubCondSet.Manage(ss).MarkFalse(SubscriptionConditionDeliveryHealthy, SubscriptionReasonDeliveryFailing, "%s", deliveryFailureMessage(s))
+	case s != nil && s.LastDeliveryTime != nil:
+		SubCondSet.Manage(ss).MarkTrue(SubscriptionConditionDeliveryHealthy)
+	default:
+		_ = SubCondSet.Manage(ss).ClearCondition(SubscriptionConditionDeliveryHealthy)
+	}
+}
+
+// deliveryFailureMessage describes the delivery failures reported in s, e.g.
+// "12 consecutive deliveries failed, the last one at 2020-10-01T10:00:00Z with response code 503;
+// last successful delivery at 2020-10-01T09:00:00Z".
+func deliveryFailureMessage(s *eventingduckv1.SubscriberStatus) string {
+	message := fmt.Sprintf("%d consecutive deliveries failed", s.ConsecutiveFailures)
+	if s.LastFailureTime != nil {
+		message += ", the last one at " + s.LastFailureTime.UTC().Format(time.RFC3339)
+	}
+	if s.LastFailureResponseCode > 0 {
+		message += fmt.Sprintf(" with response code %d", s.LastFailureResponseCode)
+	}
+	if s.LastDeliveryTime != nil {
+		message += "; last successful delivery at " + s.LastDeliveryTime.UTC().Format(time.RFC3339)
+	} else {
+		message += "; no successful delivery"
+	}
+	return message
+}
@@ -18,12 +18,17 @@ package v1
 
 import (
 	"testing"
+	"time"
 
 	"knative.dev/pkg/apis"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 )
 
 var subscriptionConditionReady = apis.Condition{
@@ -273,3 +278,74 @@ func TestSubscriptionIsReady(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriptionPropagateDeliveryHealth(t *testing.T) {
+	lastDelivery := metav1.NewTime(time.Date(2020, 10, 1, 9, 0, 0, 0, time.UTC))
+	lastFailure := metav1.NewTime(time.Date(2020, 10, 1, 10, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name   string
+		status *eventingduckv1.SubscriberStatus
+		want   *apis.Condition
+	}{{
+		name: "not reported",
+	}, {
+		name:   "no delivery yet",
+		status: &eventingduckv1.SubscriberStatus{},
+	}, {
+		name:   "healthy",
+		status: &eventingduckv1.SubscriberStatus{LastDeliveryTime: &lastDelivery, LastFailureTime: &lastFailure},
+		want: &apis.Condition{
+			Type:     SubscriptionConditionDeliveryHealthy,
+			Status:   corev1.ConditionTrue,
+			Severity: apis.ConditionSeverityInfo,
+		},
+	}, {
+		name: "failing",
+		status: &eventingduckv1.SubscriberStatus{
+			LastDeliveryTime:        &lastDelivery,
+			LastFailureTime:         &lastFailure,
+			LastFailureResponseCode: 503,
+			ConsecutiveFailures:     12,
+		},
+		want: &apis.Condition{
+			Type:     SubscriptionConditionDeliveryHealthy,
+			Status:   corev1.ConditionFalse,
+			Severity: apis.ConditionSeverityInfo,
+			Reason:   SubscriptionReasonDeliveryFailing,
+			Message:  "12 consecutive deliveries failed, the last one at 2020-10-01T10:00:00Z with response code 503; last successful delivery at 2020-10-01T09:00:00Z",
+		},
+	}, {
+		name: "failing without response",
+		status: &eventingduckv1.SubscriberStatus{
+			LastFailureTime:     &lastFailure,
+			ConsecutiveFailures: 1,
+		},
+		want: &apis.Condition{
+			Type:     SubscriptionConditionDeliveryHealthy,
+			Status:   corev1.ConditionFalse,
+			Severity: apis.ConditionSeverityInfo,
+			Reason:   SubscriptionReasonDeliveryFailing,
+			Message:  "1 consecutive deliveries failed, the last one at 2020-10-01T10:00:00Z; no successful delivery",
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ss := &SubscriptionStatus{}
+			ss.InitializeConditions()
+			ss.MarkReferencesResolved()
+			ss.MarkAddedToChannel()
+			ss.MarkChannelReady()
+			// A previous condition is replaced, or removed.
+			ss.PropagateDeliveryHealth(&eventingduckv1.SubscriberStatus{LastDeliveryTime: &lastDelivery})
+			ss.PropagateDeliveryHealth(test.status)
+
+			got := ss.GetCondition(SubscriptionConditionDeliveryHealthy)
+			if diff := cmp.Diff(test.want, got, cmpopts.IgnoreFields(apis.Condition{}, "LastTransitionTime")); diff != "" {
+				t.Error("unexpected condition (-want, +got) =", diff)
+			}
+			if !ss.IsReady() {
+				t.Error("Expected the delivery health not to affect the readiness")
+			}
+		})
+	}
+}
@@ -125,6 +125,10 @@ func TestMessagingRoundTripTypesToBetaHub(t *testing.T) {
 		&Subscription{},
 		&InMemoryChannel{},
 	)
+	// PersistentChannel only exists in v1, it's its own hub.
+	hubs.AddKnownTypes(v1.SchemeGroupVersion,
+		&v1.PersistentChannel{},
+	)
 
 	fuzzerFuncs := fuzzer.MergeFuzzerFuncs(
 		pkgfuzzer.Funcs,
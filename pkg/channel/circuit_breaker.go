@@ -0,0 +1,202 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package channel
+
+import (
+	"errors"
+	nethttp "net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a message isn't sent to a destination because its circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of the circuit breaker of a destination.
+type CircuitState string
+
+const (
+	// CircuitClosed lets the messages through to the destination.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects the messages, without sending them to the destination.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single message through to probe the destination, and rejects
+	// the others until the probe completes, or for the cool-down at most.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitBreakerConfig holds the configuration of the circuit breakers of a dispatcher.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit of a
+	// destination. Zero disables the circuit breakers, closing all the circuits.
+	FailureThreshold int
+	// CoolDown is how long the circuit stays open before a message is let through to probe
+	// the destination.
+	CoolDown time.Duration
+}
+
+// CircuitBreakers holds the circuit breaker of each destination of a dispatcher.
+type CircuitBreakers struct {
+	config        func() CircuitBreakerConfig
+	onStateChange func(destination string, state CircuitState)
+	now           func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	// since is when the circuit opened, or when the probe of the destination started once it is
+	// half-open.
+	since time.Time
+}
+
+// NewCircuitBreakers creates the circuit breakers of a dispatcher. config is called on each
+// dispatch, so that configuration changes are picked up right away, and onStateChange, if not
+// nil, is called whenever the circuit of a destination changes state.
+func NewCircuitBreakers(config func() CircuitBreakerConfig, onStateChange func(destination string, state CircuitState)) *CircuitBreakers {
+	return &CircuitBreakers{
+		config:        config,
+		onStateChange: onStateChange,
+		now:           time.Now,
+		circuits:      make(map[string]*circuit),
+	}
+}
+
+// allow returns whether a message can be sent to the destination.
+func (cb *CircuitBreakers) allow(destination string) bool {
+	if cb == nil {
+		return true
+	}
+	config := cb.config()
+	if config.FailureThreshold <= 0 {
+		cb.reset()
+		return true
+	}
+
+	cb.mu.Lock()
+	c, ok := cb.circuits[destination]
+	if !ok || c.state == CircuitClosed {
+		cb.mu.Unlock()
+		return true
+	}
+	now := cb.now()
+	if now.Sub(c.since) < config.CoolDown {
+		cb.mu.Unlock()
+		return false
+	}
+	// The cool-down is over, this message probes the destination. A half-open circuit whose
+	// probe didn't complete within the cool-down is probed again.
+	wasHalfOpen := c.state == CircuitHalfOpen
+	c.state = CircuitHalfOpen
+	c.since = now
+	cb.mu.Unlock()
+
+	if !wasHalfOpen {
+		cb.notify(destination, CircuitHalfOpen)
+	}
+	return true
+}
+
+// done records the result of sending a message to the destination.
+func (cb *CircuitBreakers) done(destination string, failed bool) {
+	if cb == nil {
+		return
+	}
+	config := cb.config()
+	if config.FailureThreshold <= 0 {
+		cb.reset()
+		return
+	}
+
+	cb.mu.Lock()
+	c, ok := cb.circuits[destination]
+	if !ok {
+		if !failed {
+			cb.mu.Unlock()
+			return
+		}
+		c = &circuit{state: CircuitClosed}
+		cb.circuits[destination] = c
+	}
+
+	var state CircuitState
+	switch {
+	case c.state == CircuitOpen:
+		// A message sent before the circuit opened, its result doesn't matter anymore.
+	case !failed:
+		if c.state == CircuitHalfOpen {
+			state = CircuitClosed
+		}
+	case c.state == CircuitHalfOpen:
+		state = CircuitOpen
+	default:
+		c.failures++
+		if c.failures >= config.FailureThreshold {
+			state = CircuitOpen
+		}
+	}
+	if state == CircuitOpen {
+		c.state = state
+		c.since = cb.now()
+	} else if !failed && c.state != CircuitOpen {
+		// Don't keep track of the healthy destinations.
+		delete(cb.circuits, destination)
+	}
+	cb.mu.Unlock()
+
+	if state != "" {
+		cb.notify(destination, state)
+	}
+}
+
+// reset closes the circuits of all the destinations, once the circuit breakers are disabled.
+func (cb *CircuitBreakers) reset() {
+	cb.mu.Lock()
+	circuits := cb.circuits
+	if len(circuits) == 0 {
+		cb.mu.Unlock()
+		return
+	}
+	cb.circuits = make(map[string]*circuit)
+	cb.mu.Unlock()
+
+	for destination, c := range circuits {
+		if c.state != CircuitClosed {
+			cb.notify(destination, CircuitClosed)
+		}
+	}
+}
+
+func (cb *CircuitBreakers) notify(destination string, state CircuitState) {
+	if cb.onStateChange != nil {
+		cb.onStateChange(destination, state)
+	}
+}
+
+// isCircuitFailure returns whether the result of a dispatch denotes an unhealthy destination.
+// The responses rejecting the message itself, such as a 400 Bad Request, don't count.
+func isCircuitFailure(info *DispatchExecutionInfo, err error) bool {
+	if err == nil {
+		return false
+	}
+	code := info.ResponseCode
+	return code == NoResponse || code == nethttp.StatusTooManyRequests || code >= nethttp.StatusInternalServerError
+}
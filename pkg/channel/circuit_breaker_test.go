@@ -0,0 +1,258 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package channel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+const testDestination = "http://subscriber.example.com/"
+
+func TestCircuitBreakers(t *testing.T) {
+	now := time.Now()
+	var states []CircuitState
+	cb := NewCircuitBreakers(func() CircuitBreakerConfig {
+		return CircuitBreakerConfig{FailureThreshold: 2, CoolDown: time.Minute}
+	}, func(destination string, state CircuitState) {
+		if destination != testDestination {
+			t.Errorf("Unexpected destination %q", destination)
+		}
+		states = append(states, state)
+	})
+	cb.now = func() time.Time { return now }
+
+	steps := []struct {
+		name      string
+		advance   time.Duration
+		wantAllow bool
+		failed    bool
+	}{
+		{name: "closed", wantAllow: true, failed: true},
+		{name: "success resets the failures", wantAllow: true},
+		{name: "first failure", wantAllow: true, failed: true},
+		{name: "second failure opens", wantAllow: true, failed: true},
+		{name: "open", wantAllow: false},
+		{name: "still cooling down", advance: 30 * time.Second, wantAllow: false},
+		{name: "probe fails", advance: 30 * time.Second, wantAllow: true, failed: true},
+		{name: "open again", wantAllow: false},
+		{name: "probe succeeds", advance: time.Minute, wantAllow: true},
+		{name: "closed again", wantAllow: true},
+	}
+	for _, s := range steps {
+		now = now.Add(s.advance)
+		if got := cb.allow(testDestination); got != s.wantAllow {
+			t.Fatalf("%s: allow() = %v, want %v", s.name, got, s.wantAllow)
+		}
+		if s.wantAllow {
+			cb.done(testDestination, s.failed)
+		}
+	}
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if diff := cmp.Diff(want, states); diff != "" {
+		t.Error("Unexpected state changes (-want, +got):", diff)
+	}
+	if len(cb.circuits) != 0 {
+		t.Errorf("Expected the healthy destinations not to be tracked, got %v", cb.circuits)
+	}
+}
+
+func TestCircuitBreakersHalfOpenRejectsOthers(t *testing.T) {
+	now := time.Now()
+	cb := NewCircuitBreakers(func() CircuitBreakerConfig {
+		return CircuitBreakerConfig{FailureThreshold: 1, CoolDown: time.Minute}
+	}, nil)
+	cb.now = func() time.Time { return now }
+
+	cb.done(testDestination, true)
+	now = now.Add(time.Minute)
+	if !cb.allow(testDestination) {
+		t.Fatal("Expected the probe to be allowed")
+	}
+	if cb.allow(testDestination) {
+		t.Error("Expected the other messages to be rejected while probing")
+	}
+	if !cb.allow("http://other.example.com/") {
+		t.Error("Expected the other destinations to be allowed")
+	}
+}
+
+func TestCircuitBreakersHalfOpenProbedAgain(t *testing.T) {
+	now := time.Now()
+	var states []CircuitState
+	cb := NewCircuitBreakers(func() CircuitBreakerConfig {
+		return CircuitBreakerConfig{FailureThreshold: 1, CoolDown: time.Minute}
+	}, func(_ string, state CircuitState) {
+		states = append(states, state)
+	})
+	cb.now = func() time.Time { return now }
+
+	cb.done(testDestination, true)
+	now = now.Add(time.Minute)
+	if !cb.allow(testDestination) {
+		t.Fatal("Expected the probe to be allowed")
+	}
+	// The probe never completes.
+	now = now.Add(time.Minute)
+	if !cb.allow(testDestination) {
+		t.Fatal("Expected the destination to be probed again after the cool-down")
+	}
+	cb.done(testDestination, false)
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if diff := cmp.Diff(want, states); diff != "" {
+		t.Error("Unexpected state changes (-want, +got):", diff)
+	}
+}
+
+func TestCircuitBreakersResetOnceDisabled(t *testing.T) {
+	now := time.Now()
+	threshold := 1
+	var states []CircuitState
+	cb := NewCircuitBreakers(func() CircuitBreakerConfig {
+		return CircuitBreakerConfig{FailureThreshold: threshold, CoolDown: time.Minute}
+	}, func(_ string, state CircuitState) {
+		states = append(states, state)
+	})
+	cb.now = func() time.Time { return now }
+
+	cb.done(testDestination, true)
+	now = now.Add(time.Minute)
+	if !cb.allow(testDestination) {
+		t.Fatal("Expected the probe to be allowed")
+	}
+
+	// The circuit breakers are disabled while probing, and enabled again later on.
+	threshold = 0
+	cb.done(testDestination, true)
+	threshold = 1
+	if !cb.allow(testDestination) {
+		t.Error("Expected the circuit to be closed once the circuit breakers were disabled")
+	}
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if diff := cmp.Diff(want, states); diff != "" {
+		t.Error("Unexpected state changes (-want, +got):", diff)
+	}
+}
+
+func TestCircuitBreakersDisabled(t *testing.T) {
+	cb := NewCircuitBreakers(func() CircuitBreakerConfig {
+		return CircuitBreakerConfig{}
+	}, func(string, CircuitState) {
+		t.Error("Unexpected state change")
+	})
+	for i := 0; i < 10; i++ {
+		cb.done(testDestination, true)
+	}
+	if !cb.allow(testDestination) {
+		t.Error("Expected disabled circuit breakers to allow the messages")
+	}
+
+	var nilBreakers *CircuitBreakers
+	if !nilBreakers.allow(testDestination) {
+		t.Error("Expected nil circuit breakers to allow the messages")
+	}
+	nilBreakers.done(testDestination, true)
+}
+
+func TestIsCircuitFailure(t *testing.T) {
+	err := errors.New("failed")
+	for code, want := range map[int]bool{
+		NoResponse:                     true,
+		http.StatusBadRequest:          false,
+		http.StatusNotFound:            false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusServiceUnavailable:  true,
+	} {
+		if got := isCircuitFailure(&DispatchExecutionInfo{ResponseCode: code}, err); got != want {
+			t.Errorf("isCircuitFailure(%d) = %v, want %v", code, got, want)
+		}
+	}
+	if isCircuitFailure(&DispatchExecutionInfo{ResponseCode: http.StatusAccepted}, nil) {
+		t.Error("isCircuitFailure() = true for a successful dispatch")
+	}
+}
+
+func TestDispatchMessageWithCircuitBreakers(t *testing.T) {
+	var attempts, deadLetters int32
+	destServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer destServer.Close()
+	deadLetterSinkServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&deadLetters, 1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer deadLetterSinkServer.Close()
+
+	cb := NewCircuitBreakers(func() CircuitBreakerConfig {
+		return CircuitBreakerConfig{FailureThreshold: 2, CoolDown: time.Hour}
+	}, nil)
+	md := NewMessageDispatcher(zaptest.NewLogger(t, zaptest.WrapOptions(zap.AddCaller())))
+	destination := getOnlyDomainURL(t, true, destServer.URL)
+	deadLetterSink := getOnlyDomainURL(t, true, deadLetterSinkServer.URL)
+
+	dispatch := func(deadLetter bool) error {
+		event := cloudevents.NewEvent(cloudevents.VersionV1)
+		event.SetID(uuid.New().String())
+		event.SetType(testCeType)
+		event.SetSource(testCeSource)
+		dls := deadLetterSink
+		if !deadLetter {
+			dls = nil
+		}
+		_, err := md.DispatchMessageWithRetries(WithCircuitBreakers(context.Background(), cb), binding.ToMessage(&event), nil, destination, nil, dls, nil)
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := dispatch(false); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected the subscriber to fail, got %v", err)
+		}
+	}
+
+	// The circuit is open, the messages fail fast or go to the dead letter sink.
+	if err := dispatch(false); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the dispatch to fail fast, got %v", err)
+	}
+	if err := dispatch(true); err != nil {
+		t.Error("Unexpected error sending to the dead letter sink:", err)
+	}
+
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("Unexpected destination attempts. Expected 2. Actual: %d", got)
+	}
+	if got := atomic.LoadInt32(&deadLetters); got != 1 {
+		t.Errorf("Unexpected dead letter sink requests. Expected 1. Actual: %d", got)
+	}
+}
@@ -17,6 +17,9 @@
 package channel
 
 import (
+	"fmt"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	"knative.dev/pkg/configmap"
 
@@ -30,6 +33,14 @@ const (
 	// based on what serving is doing. See https://github.com/knative/serving/blob/master/pkg/network/transports.go.
 	defaultMaxIdleConnections        = 1000
 	defaultMaxIdleConnectionsPerHost = 100
+
+	// The circuit breakers are disabled by default.
+	defaultCircuitBreakerFailureThreshold = 0
+	defaultCircuitBreakerCoolDown         = 30 * time.Second
+
+	// Defaults for the asynchronous dispatch of the events of each channel.
+	defaultWorkersPerChannel   = 100
+	defaultQueueSizePerChannel = 10000
 )
 
 // EventDispatcherConfigMap is the name of the configmap for event dispatcher.
@@ -40,11 +51,24 @@ var defaultEventDispatcherConfig = EventDispatcherConfig{
 		MaxIdleConns:        defaultMaxIdleConnections,
 		MaxIdleConnsPerHost: defaultMaxIdleConnectionsPerHost,
 	},
+	CircuitBreaker: CircuitBreakerConfig{
+		FailureThreshold: defaultCircuitBreakerFailureThreshold,
+		CoolDown:         defaultCircuitBreakerCoolDown,
+	},
+	WorkersPerChannel:   defaultWorkersPerChannel,
+	QueueSizePerChannel: defaultQueueSizePerChannel,
 }
 
 // EventDispatcherConfig holds the configuration parameters for the event dispatcher.
 type EventDispatcherConfig struct {
 	kncloudevents.ConnectionArgs
+	CircuitBreaker CircuitBreakerConfig
+	// WorkersPerChannel is the maximum number of events of a channel dispatched concurrently.
+	// Zero means no limit.
+	WorkersPerChannel int
+	// QueueSizePerChannel is the maximum number of events of a channel waiting for a worker.
+	// The events received when the queue is full are rejected with a 429 Too Many Requests.
+	QueueSizePerChannel int
 }
 
 // NewEventDisPatcherConfigFromConfigMap converts a k8s configmap into EventDispatcherConfig.
@@ -54,12 +78,34 @@ func NewEventDisPatcherConfigFromConfigMap(config *corev1.ConfigMap) (EventDispa
 			MaxIdleConns:        defaultMaxIdleConnections,
 			MaxIdleConnsPerHost: defaultMaxIdleConnectionsPerHost,
 		},
+		CircuitBreaker: CircuitBreakerConfig{
+			FailureThreshold: defaultCircuitBreakerFailureThreshold,
+			CoolDown:         defaultCircuitBreakerCoolDown,
+		},
+		WorkersPerChannel:   defaultWorkersPerChannel,
+		QueueSizePerChannel: defaultQueueSizePerChannel,
 	}
 	err := configmap.Parse(
 		config.Data,
 		configmap.AsInt("MaxIdleConnections", &c.MaxIdleConns),
-		configmap.AsInt("MaxIdleConnectionsPerHost", &c.MaxIdleConnsPerHost))
-	return c, err
+		configmap.AsInt("MaxIdleConnectionsPerHost", &c.MaxIdleConnsPerHost),
+		configmap.AsInt("CircuitBreakerFailureThreshold", &c.CircuitBreaker.FailureThreshold),
+		configmap.AsDuration("CircuitBreakerCoolDown", &c.CircuitBreaker.CoolDown),
+		configmap.AsInt("WorkersPerChannel", &c.WorkersPerChannel),
+		configmap.AsInt("QueueSizePerChannel", &c.QueueSizePerChannel))
+	if err != nil {
+		return c, err
+	}
+	if c.CircuitBreaker.FailureThreshold < 0 {
+		return c, fmt.Errorf("CircuitBreakerFailureThreshold must not be negative, got %d", c.CircuitBreaker.FailureThreshold)
+	}
+	if c.WorkersPerChannel < 0 {
+		return c, fmt.Errorf("WorkersPerChannel must not be negative, got %d", c.WorkersPerChannel)
+	}
+	if c.QueueSizePerChannel < 0 {
+		return c, fmt.Errorf("QueueSizePerChannel must not be negative, got %d", c.QueueSizePerChannel)
+	}
+	return c, nil
 }
 
 // EventDispatcherConfigStore loads/unloads untyped configuration from configmap.
@@ -18,8 +18,10 @@ package channel
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
 	"knative.dev/eventing/pkg/kncloudevents"
 	configmaptesting "knative.dev/pkg/configmap/testing"
 	logtesting "knative.dev/pkg/logging/testing"
@@ -40,8 +42,14 @@ func TestGetConfig(t *testing.T) {
 					MaxIdleConns:        20,
 					MaxIdleConnsPerHost: 10,
 				},
+				CircuitBreaker: CircuitBreakerConfig{
+					FailureThreshold: 5,
+					CoolDown:         time.Minute,
+				},
+				WorkersPerChannel:   10,
+				QueueSizePerChannel: 50,
 			},
-			keys: []string{"MaxIdleConnections", "MaxIdleConnectionsPerHost"},
+			keys: []string{"MaxIdleConnections", "MaxIdleConnectionsPerHost", "CircuitBreakerFailureThreshold", "CircuitBreakerCoolDown", "WorkersPerChannel", "QueueSizePerChannel"},
 		},
 		{
 			name: "Only MaxIdleConnections is configured",
@@ -51,6 +59,9 @@ func TestGetConfig(t *testing.T) {
 					MaxIdleConns:        20,
 					MaxIdleConnsPerHost: defaultMaxIdleConnectionsPerHost,
 				},
+				CircuitBreaker:      defaultEventDispatcherConfig.CircuitBreaker,
+				WorkersPerChannel:   defaultWorkersPerChannel,
+				QueueSizePerChannel: defaultQueueSizePerChannel,
 			},
 			keys: []string{"MaxIdleConnections"},
 		},
@@ -62,6 +73,9 @@ func TestGetConfig(t *testing.T) {
 					MaxIdleConns:        defaultMaxIdleConnections,
 					MaxIdleConnsPerHost: 10,
 				},
+				CircuitBreaker:      defaultEventDispatcherConfig.CircuitBreaker,
+				WorkersPerChannel:   defaultWorkersPerChannel,
+				QueueSizePerChannel: defaultQueueSizePerChannel,
 			},
 			keys: []string{"MaxIdleConnectionsPerHost"},
 		},
@@ -73,6 +87,9 @@ func TestGetConfig(t *testing.T) {
 					MaxIdleConns:        defaultMaxIdleConnections,
 					MaxIdleConnsPerHost: defaultMaxIdleConnectionsPerHost,
 				},
+				CircuitBreaker:      defaultEventDispatcherConfig.CircuitBreaker,
+				WorkersPerChannel:   defaultWorkersPerChannel,
+				QueueSizePerChannel: defaultQueueSizePerChannel,
 			},
 		},
 	} {
@@ -87,3 +104,18 @@ func TestGetConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestNewEventDisPatcherConfigFromConfigMapInvalid(t *testing.T) {
+	for name, data := range map[string]map[string]string{
+		"negative failure threshold": {"CircuitBreakerFailureThreshold": "-1"},
+		"invalid cool-down":          {"CircuitBreakerCoolDown": "soon"},
+		"negative workers":           {"WorkersPerChannel": "-1"},
+		"negative queue size":        {"QueueSizePerChannel": "-1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewEventDisPatcherConfigFromConfigMap(&corev1.ConfigMap{Data: data}); err == nil {
+				t.Error("NewEventDisPatcherConfigFromConfigMap() = nil, wanted error")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fanout
+
+import "sync"
+
+// boundedQueue bounds the number of events dispatched concurrently and the number of events
+// waiting for a worker. Each event goes through reserve, start and done, in that order.
+type boundedQueue struct {
+	mu         sync.Mutex
+	workerFree *sync.Cond
+	workers    int
+	queueSize  int
+	running    int
+	queued     int
+}
+
+func newBoundedQueue(workers, queueSize int) *boundedQueue {
+	q := &boundedQueue{}
+	q.workerFree = sync.NewCond(&q.mu)
+	q.setLimits(workers, queueSize)
+	return q
+}
+
+// setLimits changes the limits of the queue. A lower limit doesn't affect the events already
+// accepted, it applies as they complete.
+func (q *boundedQueue) setLimits(workers, queueSize int) {
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.workers = workers
+	q.queueSize = queueSize
+	q.workerFree.Broadcast()
+}
+
+// reserve returns whether there is room for a new event, and reserves it if so.
+func (q *boundedQueue) reserve() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.workers > 0 && q.running+q.queued >= q.workers+q.queueSize {
+		return false
+	}
+	q.queued++
+	return true
+}
+
+// cancel releases a reservation that won't be started.
+func (q *boundedQueue) cancel() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queued--
+}
+
+// start blocks until a worker is available for a reserved event.
+func (q *boundedQueue) start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.workers > 0 && q.running >= q.workers {
+		q.workerFree.Wait()
+	}
+	q.queued--
+	q.running++
+}
+
+// done releases the worker of a started event.
+func (q *boundedQueue) done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running--
+	q.workerFree.Signal()
+}
+
+// depth returns the number of events waiting for a worker.
+func (q *boundedQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.queued
+}
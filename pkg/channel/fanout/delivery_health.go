@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fanout
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"knative.dev/eventing/pkg/channel"
+)
+
+// DeliveryHealth is the outcome of the recent deliveries to a subscription. A delivery fails when
+// the event can't be sent to the subscriber, retries included, even if it is then sent to the
+// dead letter sink.
+type DeliveryHealth struct {
+	// LastDeliveryTime is the time of the last successful delivery, zero if none.
+	LastDeliveryTime time.Time
+	// LastFailureTime is the time of the last failed delivery, zero if none.
+	LastFailureTime time.Time
+	// LastFailureResponseCode is the response code of the last failed delivery, zero if there
+	// was no response.
+	LastFailureResponseCode int
+	// ConsecutiveFailures is the number of failed deliveries since the last successful one.
+	ConsecutiveFailures int
+}
+
+// DeliveryHealthTracker tracks the DeliveryHealth of the subscriptions of a fanout handler. Its
+// changes are notified at most once per interval, so that they can be reported without
+// overwhelming the API server.
+type DeliveryHealthTracker struct {
+	interval time.Duration
+	onChange func()
+
+	mu     sync.Mutex
+	health map[types.UID]DeliveryHealth
+	// pending is whether a notification is scheduled.
+	pending bool
+}
+
+// NewDeliveryHealthTracker creates a DeliveryHealthTracker calling onChange, at most once per
+// interval, after the health of a subscription changed.
+func NewDeliveryHealthTracker(interval time.Duration, onChange func()) *DeliveryHealthTracker {
+	return &DeliveryHealthTracker{
+		interval: interval,
+		onChange: onChange,
+		health:   make(map[types.UID]DeliveryHealth),
+	}
+}
+
+// Get returns the DeliveryHealth of the subscription uid, and whether an event was delivered to
+// it. A nil tracker doesn't know any subscription.
+func (t *DeliveryHealthTracker) Get(uid types.UID) (DeliveryHealth, bool) {
+	if t == nil {
+		return DeliveryHealth{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h, ok := t.health[uid]
+	return h, ok
+}
+
+// record records the outcome of a delivery to sub, as returned by the dispatcher. A nil tracker
+// records nothing.
+func (t *DeliveryHealthTracker) record(sub Subscription, info *channel.DispatchExecutionInfo, err error) {
+	if t == nil || sub.UID == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h := t.health[sub.UID]
+	now := time.Now()
+	if err == nil && (info == nil || !info.DeadLettered) {
+		h.LastDeliveryTime = now
+		h.ConsecutiveFailures = 0
+	} else {
+		h.LastFailureTime = now
+		h.LastFailureResponseCode = 0
+		if info != nil {
+			code := info.ResponseCode
+			if info.DeadLettered {
+				code = info.DestinationResponseCode
+			}
+			if code > 0 {
+				h.LastFailureResponseCode = code
+			}
+		}
+		h.ConsecutiveFailures++
+	}
+	t.health[sub.UID] = h
+	t.notifyLocked()
+}
+
+// retain forgets the subscriptions not in subs. A nil tracker is a no-op.
+func (t *DeliveryHealthTracker) retain(subs []Subscription) {
+	if t == nil {
+		return
+	}
+	keep := make(map[types.UID]bool, len(subs))
+	for _, sub := range subs {
+		keep[sub.UID] = true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for uid := range t.health {
+		if !keep[uid] {
+			delete(t.health, uid)
+		}
+	}
+}
+
+// notifyLocked schedules a notification of the changes, unless one is already pending.
+func (t *DeliveryHealthTracker) notifyLocked() {
+	if t.pending || t.onChange == nil {
+		return
+	}
+	t.pending = true
+	time.AfterFunc(t.interval, func() {
+		t.mu.Lock()
+		t.pending = false
+		t.mu.Unlock()
+		t.onChange()
+	})
+}
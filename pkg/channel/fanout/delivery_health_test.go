@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fanout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	bindingshttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing/pkg/channel"
+)
+
+func TestDeliveryHealthTracker(t *testing.T) {
+	var notified atomic.Int32
+	tracker := NewDeliveryHealthTracker(10*time.Millisecond, func() { notified.Inc() })
+	sub := Subscription{UID: "sub-uid"}
+
+	if _, ok := tracker.Get(sub.UID); ok {
+		t.Error("Expected no health before any delivery")
+	}
+
+	tracker.record(sub, &channel.DispatchExecutionInfo{ResponseCode: http.StatusAccepted}, nil)
+	h, _ := tracker.Get(sub.UID)
+	if h.LastDeliveryTime.IsZero() || h.ConsecutiveFailures != 0 {
+		t.Errorf("Unexpected health after a successful delivery %+v", h)
+	}
+
+	tracker.record(sub, &channel.DispatchExecutionInfo{ResponseCode: http.StatusServiceUnavailable}, errors.New("failed"))
+	tracker.record(sub, &channel.DispatchExecutionInfo{ResponseCode: http.StatusAccepted, DeadLettered: true, DestinationResponseCode: http.StatusNotFound}, nil)
+	tracker.record(sub, &channel.DispatchExecutionInfo{ResponseCode: channel.NoResponse}, errors.New("connection refused"))
+	h, _ = tracker.Get(sub.UID)
+	if h.LastFailureTime.IsZero() || h.ConsecutiveFailures != 3 || h.LastFailureResponseCode != 0 {
+		t.Errorf("Unexpected health after failed deliveries %+v", h)
+	}
+
+	tracker.record(sub, &channel.DispatchExecutionInfo{ResponseCode: http.StatusAccepted, DeadLettered: true, DestinationResponseCode: http.StatusNotFound}, nil)
+	if h, _ = tracker.Get(sub.UID); h.LastFailureResponseCode != http.StatusNotFound {
+		t.Errorf("Expected the response code of the subscriber of a dead-lettered event, got %+v", h)
+	}
+
+	tracker.record(sub, nil, nil)
+	if h, _ = tracker.Get(sub.UID); h.ConsecutiveFailures != 0 || h.LastFailureResponseCode != http.StatusNotFound {
+		t.Errorf("Unexpected health after a new successful delivery %+v", h)
+	}
+
+	// The changes are notified at most once per interval.
+	time.Sleep(100 * time.Millisecond)
+	if got := notified.Load(); got != 1 {
+		t.Errorf("Got %d notifications, want 1", got)
+	}
+
+	tracker.retain(nil)
+	if _, ok := tracker.Get(sub.UID); ok {
+		t.Error("Expected the removed subscription to be forgotten")
+	}
+}
+
+func TestFanoutMessageHandlerDeliveryHealth(t *testing.T) {
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer subscriberServer.Close()
+
+	changed := make(chan struct{}, 1)
+	health := NewDeliveryHealthTracker(time.Millisecond, func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	logger := zap.NewNop()
+	sub := Subscription{UID: "sub-uid", Subscriber: apis.HTTP(subscriberServer.URL[7:]).URL()}
+	h, err := NewFanoutMessageHandler(
+		logger,
+		channel.NewMessageDispatcher(logger),
+		Config{Subscriptions: []Subscription{sub}, Health: health},
+		channel.NewStatsReporter("testcontainer", "testpod"),
+	)
+	if err != nil {
+		t.Fatal("NewHandler failed =", err)
+	}
+
+	event := makeCloudEvent()
+	req := httptest.NewRequest(http.MethodPost, "http://channelname.channelnamespace/", nil)
+	if err := bindingshttp.WriteRequest(context.Background(), binding.ToMessage(&event), req); err != nil {
+		t.Fatal("WriteRequest =", err)
+	}
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case <-changed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the health change")
+	}
+	got, ok := health.Get(sub.UID)
+	if !ok || got.ConsecutiveFailures != 1 || got.LastFailureResponseCode != http.StatusServiceUnavailable {
+		t.Errorf("Unexpected health %+v", got)
+	}
+}
@@ -24,6 +24,7 @@ package fanout
 import (
 	"context"
 	"errors"
+	"fmt"
 	nethttp "net/http"
 	"net/url"
 	"sync"
@@ -33,9 +34,11 @@ import (
 	"github.com/cloudevents/sdk-go/v2/binding/buffering"
 	"go.opencensus.io/trace"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
 
 	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	"knative.dev/eventing/pkg/channel"
+	"knative.dev/eventing/pkg/deliveryauth"
 	"knative.dev/eventing/pkg/kncloudevents"
 )
 
@@ -44,18 +47,51 @@ const (
 )
 
 type Subscription struct {
+	// UID identifies the subscriber within its channel.
+	UID         types.UID
 	Subscriber  *url.URL
 	Reply       *url.URL
 	DeadLetter  *url.URL
 	RetryConfig *kncloudevents.RetryConfig
+	// Ordered is whether the events are delivered to the subscriber one at a time, in the
+	// order they were received.
+	Ordered bool
+	// OrderingKey is the name of the CloudEvent extension partitioning the ordered delivery,
+	// the events with different values are delivered concurrently.
+	OrderingKey string
+	// SubscriberSecret and DeadLetterSecret name the Secrets holding the credentials used to
+	// authenticate to the subscriber and to the dead letter sink, if any.
+	SubscriberSecret string
+	DeadLetterSecret string
 }
 
+// CredentialsFunc returns the credentials held by the Secret secretName, in the namespace of
+// the channel.
+type CredentialsFunc func(secretName string) (*deliveryauth.Credentials, error)
+
 // Config for a fanout.MessageHandler.
 type Config struct {
 	Subscriptions []Subscription `json:"subscriptions"`
 	// AsyncHandler controls whether the Subscriptions are called synchronous or asynchronously.
 	// It is expected to be false when used as a sidecar.
 	AsyncHandler bool `json:"asyncHandler,omitempty"`
+	// Workers is the maximum number of events the asynchronous handler dispatches concurrently.
+	// Zero means no limit.
+	Workers int `json:"workers,omitempty"`
+	// QueueSize is the maximum number of events the asynchronous handler holds while all its
+	// workers are busy, the events received when the queue is full are rejected. The events
+	// only waiting for their turn of an ordered subscription don't count.
+	QueueSize int `json:"queueSize,omitempty"`
+	// Credentials resolves the Secrets named by the Subscriptions. The deliveries of the
+	// Subscriptions naming a Secret fail if it is nil.
+	Credentials CredentialsFunc `json:"-"`
+	// InFlight tracks the events dispatched asynchronously, so that they can be drained on
+	// shutdown. It may be nil.
+	InFlight *InFlightTracker `json:"-"`
+	// Health tracks the outcome of the deliveries to each subscription. It may be nil.
+	Health *DeliveryHealthTracker `json:"-"`
+	// CircuitBreakers stop the deliveries to the subscribers which keep failing. It may be nil.
+	CircuitBreakers *channel.CircuitBreakers `json:"-"`
 }
 
 // MessageHandler is an http.Handler but has methods for managing
@@ -66,6 +102,9 @@ type MessageHandler interface {
 	nethttp.Handler
 	SetSubscriptions(ctx context.Context, subs []Subscription)
 	GetSubscriptions(ctx context.Context) []Subscription
+	// SetQueueLimits sets the number of workers and the size of the queue of the
+	// asynchronous handler, see Config.
+	SetQueueLimits(workers, queueSize int)
 }
 
 // MessageHandler is a http.Handler that takes a single request in and fans it out to N other servers.
@@ -77,6 +116,19 @@ type FanoutMessageHandler struct {
 	subscriptionsMutex sync.RWMutex
 	subscriptions      []Subscription
 
+	// queue bounds the events dispatched asynchronously.
+	queue *boundedQueue
+	// inFlight tracks the events dispatched asynchronously, it may be nil.
+	inFlight *InFlightTracker
+	// health tracks the outcome of the deliveries to each subscription, it may be nil.
+	health *DeliveryHealthTracker
+	// sequencer orders the deliveries to the ordered subscriptions.
+	sequencer *sequencer
+	// credentials resolves the Secrets named by the subscriptions, it may be nil.
+	credentials CredentialsFunc
+	// circuitBreakers protect the subscribers which keep failing, it may be nil.
+	circuitBreakers *channel.CircuitBreakers
+
 	receiver   *channel.MessageReceiver
 	dispatcher channel.MessageDispatcher
 
@@ -92,19 +144,26 @@ type FanoutMessageHandler struct {
 
 func NewFanoutMessageHandler(logger *zap.Logger, messageDispatcher channel.MessageDispatcher, config Config, reporter channel.StatsReporter) (*FanoutMessageHandler, error) {
 	handler := &FanoutMessageHandler{
-		logger:       logger,
-		dispatcher:   messageDispatcher,
-		timeout:      defaultTimeout,
-		reporter:     reporter,
-		asyncHandler: config.AsyncHandler,
+		logger:          logger,
+		dispatcher:      messageDispatcher,
+		timeout:         defaultTimeout,
+		reporter:        reporter,
+		asyncHandler:    config.AsyncHandler,
+		queue:           newBoundedQueue(config.Workers, config.QueueSize),
+		inFlight:        config.InFlight,
+		health:          config.Health,
+		sequencer:       newSequencer(),
+		credentials:     config.Credentials,
+		circuitBreakers: config.CircuitBreakers,
 	}
 	handler.subscriptions = make([]Subscription, len(config.Subscriptions))
 	for i := range config.Subscriptions {
 		handler.subscriptions[i] = config.Subscriptions[i]
 	}
 	// The receiver function needs to point back at the handler itself, so set it up after
-	// initialization.
-	receiver, err := channel.NewMessageReceiver(createMessageReceiverFunction(handler), logger, reporter)
+	// initialization. The channel may be addressed by host or by path.
+	receiver, err := channel.NewMessageReceiver(createMessageReceiverFunction(handler), logger, reporter,
+		channel.ResolveMessageChannelFromPath(channel.ParseChannelFromPath))
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +198,23 @@ func SubscriberSpecToFanoutConfig(sub eventingduckv1.SubscriberSpec) (*Subscript
 		}
 	}
 
-	return &Subscription{Subscriber: destination, Reply: reply, DeadLetter: deadLetter, RetryConfig: retryConfig}, nil
+	var ordered bool
+	var orderingKey string
+	if sub.Delivery != nil && sub.Delivery.Ordering != nil && *sub.Delivery.Ordering == eventingduckv1.DeliveryOrderingOrdered {
+		ordered = true
+		if sub.Delivery.OrderingKey != nil {
+			orderingKey = *sub.Delivery.OrderingKey
+		}
+	}
+
+	var subscriberSecret, deadLetterSecret string
+	if sub.Delivery != nil && sub.Delivery.Auth != nil {
+		subscriberSecret = sub.Delivery.Auth.SubscriberSecretName
+		deadLetterSecret = sub.Delivery.Auth.DeadLetterSinkSecretName
+	}
+
+	return &Subscription{UID: sub.UID, Subscriber: destination, Reply: reply, DeadLetter: deadLetter, RetryConfig: retryConfig, Ordered: ordered, OrderingKey: orderingKey,
+		SubscriberSecret: subscriberSecret, DeadLetterSecret: deadLetterSecret}, nil
 }
 
 func (f *FanoutMessageHandler) SetSubscriptions(ctx context.Context, subs []Subscription) {
@@ -148,6 +223,11 @@ func (f *FanoutMessageHandler) SetSubscriptions(ctx context.Context, subs []Subs
 	s := make([]Subscription, len(subs))
 	copy(s, subs)
 	f.subscriptions = s
+	f.health.retain(s)
+}
+
+func (f *FanoutMessageHandler) SetQueueLimits(workers, queueSize int) {
+	f.queue.setLimits(workers, queueSize)
 }
 
 func (f *FanoutMessageHandler) GetSubscriptions(ctx context.Context) []Subscription {
@@ -169,6 +249,14 @@ func createMessageReceiverFunction(f *FanoutMessageHandler) func(context.Context
 				return nil
 			}
 
+			// Reserve a place in the queue before buffering the message, so that the
+			// rejected events don't take any memory.
+			if !f.queue.reserve() {
+				_ = message.Finish(nil)
+				channel.ReportEventCountMetricsForDispatchError(channel.ErrQueueFull, f.reporter, &channel.ReportArgs{Ns: ref.Namespace})
+				return channel.ErrQueueFull
+			}
+
 			parentSpan := trace.FromContext(ctx)
 			te := kncloudevents.TypeExtractorTransformer("")
 			transformers = append(transformers, &te)
@@ -176,6 +264,7 @@ func createMessageReceiverFunction(f *FanoutMessageHandler) func(context.Context
 			// Because the message could be closed before the buffering happens
 			bufferedMessage, err := buffering.CopyMessage(ctx, message, transformers...)
 			if err != nil {
+				f.queue.cancel()
 				return err
 			}
 
@@ -185,40 +274,58 @@ func createMessageReceiverFunction(f *FanoutMessageHandler) func(context.Context
 
 			// We don't need the original message anymore
 			_ = message.Finish(nil)
+			// Take the turns of the ordered subscriptions in the order the events are received.
+			turns := f.sequencer.takeTurns(subs, bufferedMessage)
+			_ = f.reporter.ReportQueueDepth(ref, f.queue.depth())
+			dispatchCtx, done := f.inFlight.start()
 			go func(m binding.Message, h nethttp.Header, s *trace.Span, r *channel.StatsReporter, args *channel.ReportArgs) {
-				// Run async dispatch with background context.
-				ctx = trace.NewContext(context.Background(), s)
-				// Any returned error is already logged in f.dispatch().
-				dispatchResultForFanout := f.dispatch(ctx, subs, m, h)
+				defer done()
+				// Wait for a worker.
+				f.queue.start()
+				_ = (*r).ReportQueueDepth(ref, f.queue.depth())
+
+				// Run async dispatch with background context, unless it's tracked.
+				ctx = trace.NewContext(dispatchCtx, s)
+				// Any returned error is already logged in f.dispatch(). The worker is released
+				// before waiting for the previous events of the ordered subscriptions, so that
+				// the events waiting for their turn hold neither a worker nor room in the queue.
+				dispatchResultForFanout := f.dispatch(ctx, subs, turns, m, h, f.queue.done)
 				_ = parseFanoutResultAndReportMetrics(dispatchResultForFanout, *r, *args)
 			}(bufferedMessage, additionalHeaders, parentSpan, &f.reporter, &reportArgs)
 			return nil
 		}
 	}
 	return func(ctx context.Context, ref channel.ChannelReference, message binding.Message, transformers []binding.Transformer, additionalHeaders nethttp.Header) error {
-		subs := f.GetSubscriptions(ctx)
-		if len(subs) == 0 {
-			// Nothing to do here, finish the message and return
-			_ = message.Finish(nil)
-			return nil
-		}
+		return f.DispatchMessage(ctx, ref, message, additionalHeaders, transformers...)
+	}
+}
 
-		te := kncloudevents.TypeExtractorTransformer("")
-		transformers = append(transformers, &te)
-		// We buffer the message to send it several times
-		bufferedMessage, err := buffering.CopyMessage(ctx, message, transformers...)
-		if err != nil {
-			return err
-		}
-		// We don't need the original message anymore
+// DispatchMessage fans the message out to the subscriptions, and waits for the results, whether
+// the handler is asynchronous or not.
+func (f *FanoutMessageHandler) DispatchMessage(ctx context.Context, ref channel.ChannelReference, message binding.Message, additionalHeaders nethttp.Header, transformers ...binding.Transformer) error {
+	subs := f.GetSubscriptions(ctx)
+	if len(subs) == 0 {
+		// Nothing to do here, finish the message and return
 		_ = message.Finish(nil)
+		return nil
+	}
 
-		reportArgs := channel.ReportArgs{}
-		reportArgs.EventType = string(te)
-		reportArgs.Ns = ref.Namespace
-		dispatchResultForFanout := f.dispatch(ctx, subs, bufferedMessage, additionalHeaders)
-		return parseFanoutResultAndReportMetrics(dispatchResultForFanout, f.reporter, reportArgs)
+	te := kncloudevents.TypeExtractorTransformer("")
+	transformers = append(transformers, &te)
+	// We buffer the message to send it several times
+	bufferedMessage, err := buffering.CopyMessage(ctx, message, transformers...)
+	if err != nil {
+		return err
 	}
+	// We don't need the original message anymore
+	_ = message.Finish(nil)
+	turns := f.sequencer.takeTurns(subs, bufferedMessage)
+
+	reportArgs := channel.ReportArgs{}
+	reportArgs.EventType = string(te)
+	reportArgs.Ns = ref.Namespace
+	dispatchResultForFanout := f.dispatch(ctx, subs, turns, bufferedMessage, additionalHeaders, nil)
+	return parseFanoutResultAndReportMetrics(dispatchResultForFanout, f.reporter, reportArgs)
 }
 
 func (f *FanoutMessageHandler) ServeHTTP(response nethttp.ResponseWriter, request *nethttp.Request) {
@@ -244,16 +351,47 @@ func parseFanoutResultAndReportMetrics(result dispatchResult, reporter channel.S
 
 // dispatch takes the event, fans it out to each subscription in subs. If all the fanned out
 // events return successfully, then return nil. Else, return an error.
-func (f *FanoutMessageHandler) dispatch(ctx context.Context, subs []Subscription, bufferedMessage binding.Message, additionalHeaders nethttp.Header) dispatchResult {
+// turns holds the turn of each ordered subscription, the event is sent to them once their
+// previous events are delivered, retries included. It may be nil if none is ordered.
+// release, if not nil, is called once the event is sent to the subscriptions whose turn it is,
+// and only waits for the turn of the others.
+func (f *FanoutMessageHandler) dispatch(ctx context.Context, subs []Subscription, turns []*turn, bufferedMessage binding.Message, additionalHeaders nethttp.Header, release func()) dispatchResult {
 	// Bind the lifecycle of the buffered message to the number of subs
 	bufferedMessage = buffering.WithAcksBeforeFinish(bufferedMessage, len(subs))
 
+	// sending counts the subscriptions the event is sent to without waiting for their turn.
+	var sending sync.WaitGroup
+	sending.Add(len(subs))
+	if release != nil {
+		go func() {
+			sending.Wait()
+			release()
+		}()
+	}
+
 	errorCh := make(chan dispatchResult, len(subs))
-	for _, sub := range subs {
-		go func(s Subscription) {
+	for i, sub := range subs {
+		var t *turn
+		if turns != nil {
+			t = turns[i]
+		}
+		go func(s Subscription, t *turn) {
+			// The turn is done even if the dispatch times out, once the event is delivered
+			// or dead-lettered.
+			defer t.done()
+			if t.ready() {
+				defer sending.Done()
+			} else {
+				sending.Done()
+				if err := t.wait(ctx); err != nil {
+					errorCh <- dispatchResult{err: err}
+					return
+				}
+			}
 			dispatchedResultPerSub, err := f.makeFanoutRequest(ctx, bufferedMessage, additionalHeaders, s)
+			f.health.record(s, dispatchedResultPerSub, err)
 			errorCh <- dispatchResult{err: err, info: dispatchedResultPerSub}
-		}(sub)
+		}(sub, t)
 	}
 
 	var totalDispatchTimeForFanout time.Duration = channel.NoDuration
@@ -295,6 +433,13 @@ func (f *FanoutMessageHandler) dispatch(ctx context.Context, subs []Subscription
 // makeFanoutRequest sends the request to exactly one subscription. It handles both the `call` and
 // the `sink` portions of the subscription.
 func (f *FanoutMessageHandler) makeFanoutRequest(ctx context.Context, message binding.Message, additionalHeaders nethttp.Header, sub Subscription) (*channel.DispatchExecutionInfo, error) {
+	ctx, err := f.withCredentials(ctx, sub)
+	if err != nil {
+		return &channel.DispatchExecutionInfo{Time: channel.NoDuration, ResponseCode: channel.NoResponse}, err
+	}
+	if f.circuitBreakers != nil {
+		ctx = channel.WithCircuitBreakers(ctx, f.circuitBreakers)
+	}
 	return f.dispatcher.DispatchMessageWithRetries(
 		ctx,
 		message,
@@ -306,6 +451,30 @@ func (f *FanoutMessageHandler) makeFanoutRequest(ctx context.Context, message bi
 	)
 }
 
+// withCredentials returns a context carrying the credentials of the subscriber and of the dead
+// letter sink of sub.
+func (f *FanoutMessageHandler) withCredentials(ctx context.Context, sub Subscription) (context.Context, error) {
+	if sub.SubscriberSecret == "" && sub.DeadLetterSecret == "" {
+		return ctx, nil
+	}
+	if f.credentials == nil {
+		return ctx, errors.New("the channel does not support delivery credentials")
+	}
+	var subscriberCreds, deadLetterCreds *deliveryauth.Credentials
+	var err error
+	if sub.SubscriberSecret != "" {
+		if subscriberCreds, err = f.credentials(sub.SubscriberSecret); err != nil {
+			return ctx, fmt.Errorf("unable to get the subscriber credentials: %w", err)
+		}
+	}
+	if sub.DeadLetterSecret != "" {
+		if deadLetterCreds, err = f.credentials(sub.DeadLetterSecret); err != nil {
+			return ctx, fmt.Errorf("unable to get the dead letter sink credentials: %w", err)
+		}
+	}
+	return channel.WithCredentials(ctx, subscriberCreds, deadLetterCreds), nil
+}
+
 type dispatchResult struct {
 	err  error
 	info *channel.DispatchExecutionInfo
@@ -37,9 +37,11 @@ import (
 	"go.opencensus.io/trace"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 	"knative.dev/pkg/apis"
 
 	"knative.dev/eventing/pkg/channel"
+	"knative.dev/eventing/pkg/deliveryauth"
 )
 
 // Domains used in subscriptions, which will be replaced by the real domains of the started HTTP
@@ -69,6 +71,10 @@ func TestSubscriberSpecToFanoutConfig(t *testing.T) {
 			Retry:         &three,
 			BackoffPolicy: &linear,
 			BackoffDelay:  &delay,
+			Auth: &eventingduckv1.DeliveryAuth{
+				SubscriberSecretName:     "subscriber-creds",
+				DeadLetterSinkSecretName: "dls-creds",
+			},
 		},
 	}
 	want := Subscription{
@@ -80,6 +86,8 @@ func TestSubscriberSpecToFanoutConfig(t *testing.T) {
 			BackoffPolicy: &linear,
 			BackoffDelay:  &delay,
 		},
+		SubscriberSecret: "subscriber-creds",
+		DeadLetterSecret: "dls-creds",
 	}
 	got, err := SubscriberSpecToFanoutConfig(*spec)
 	if err != nil {
@@ -284,6 +292,187 @@ func TestFanoutMessageHandler_ServeHTTP(t *testing.T) {
 	}
 }
 
+func TestFanoutMessageHandlerQueueFull(t *testing.T) {
+	received := make(chan struct{}, 10)
+	release := make(chan struct{})
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer subscriberServer.Close()
+
+	logger := zap.NewNop()
+	h, err := NewFanoutMessageHandler(
+		logger,
+		channel.NewMessageDispatcher(logger),
+		Config{
+			Subscriptions: []Subscription{{Subscriber: apis.HTTP(subscriberServer.URL[7:]).URL()}},
+			AsyncHandler:  true,
+			Workers:       1,
+			QueueSize:     1,
+		},
+		channel.NewStatsReporter("testcontainer", "testpod"),
+	)
+	if err != nil {
+		t.Fatal("NewHandler failed =", err)
+	}
+
+	send := func(expectedStatus int) {
+		t.Helper()
+		event := makeCloudEvent()
+		req := httptest.NewRequest(http.MethodPost, "http://channelname.channelnamespace/", nil)
+		if err := bindingshttp.WriteRequest(context.Background(), binding.ToMessage(&event), req); err != nil {
+			t.Fatal("WriteRequest =", err)
+		}
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		if resp.Code != expectedStatus {
+			t.Errorf("Unexpected status code. Expected %v, Actual %v", expectedStatus, resp.Code)
+		}
+	}
+	wait := func() {
+		t.Helper()
+		select {
+		case <-received:
+		case <-time.After(10 * time.Second):
+			t.Fatal("Timed out waiting for the subscriber")
+		}
+	}
+
+	// The first event takes the only worker, the second one waits in the queue.
+	send(http.StatusAccepted)
+	wait()
+	send(http.StatusAccepted)
+	send(http.StatusTooManyRequests)
+
+	// A second worker picks up the queued event, which makes room for a new one.
+	h.SetQueueLimits(2, 1)
+	wait()
+	send(http.StatusAccepted)
+
+	close(release)
+	wait()
+}
+
+func TestFanoutMessageHandlerCredentials(t *testing.T) {
+	authorizations := make(chan string, 1)
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations <- r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer subscriberServer.Close()
+
+	testCases := map[string]struct {
+		secret         string
+		credentials    CredentialsFunc
+		expectedStatus int
+		authorization  string
+	}{
+		"no secret": {
+			expectedStatus: http.StatusAccepted,
+		},
+		"secret": {
+			secret: "creds",
+			credentials: func(secretName string) (*deliveryauth.Credentials, error) {
+				return deliveryauth.FromSecret(&corev1.Secret{Data: map[string][]byte{deliveryauth.TokenKey: []byte(secretName + "-token")}})
+			},
+			expectedStatus: http.StatusAccepted,
+			authorization:  "Bearer creds-token",
+		},
+		"unknown secret": {
+			secret: "creds",
+			credentials: func(string) (*deliveryauth.Credentials, error) {
+				return nil, errors.New("not found")
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		"no credentials resolver": {
+			secret:         "creds",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			logger := zap.NewNop()
+			h, err := NewFanoutMessageHandler(
+				logger,
+				channel.NewMessageDispatcher(logger),
+				Config{
+					Subscriptions: []Subscription{{
+						Subscriber:       apis.HTTP(subscriberServer.URL[7:]).URL(),
+						SubscriberSecret: tc.secret,
+					}},
+					Credentials: tc.credentials,
+				},
+				channel.NewStatsReporter("testcontainer", "testpod"),
+			)
+			if err != nil {
+				t.Fatal("NewHandler failed =", err)
+			}
+
+			event := makeCloudEvent()
+			req := httptest.NewRequest(http.MethodPost, "http://channelname.channelnamespace/", nil)
+			if err := bindingshttp.WriteRequest(context.Background(), binding.ToMessage(&event), req); err != nil {
+				t.Fatal("WriteRequest =", err)
+			}
+			resp := httptest.NewRecorder()
+			h.ServeHTTP(resp, req)
+			if resp.Code != tc.expectedStatus {
+				t.Errorf("Unexpected status code. Expected %v, Actual %v", tc.expectedStatus, resp.Code)
+			}
+			if tc.expectedStatus != http.StatusAccepted {
+				return
+			}
+			if got := <-authorizations; got != tc.authorization {
+				t.Errorf("Unexpected Authorization header. Expected %q, Actual %q", tc.authorization, got)
+			}
+		})
+	}
+}
+
+func TestFanoutMessageHandlerCircuitBreakers(t *testing.T) {
+	attempts := atomic.NewInt32(0)
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Inc()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer subscriberServer.Close()
+
+	logger := zap.NewNop()
+	h, err := NewFanoutMessageHandler(
+		logger,
+		channel.NewMessageDispatcher(logger),
+		Config{
+			Subscriptions: []Subscription{{Subscriber: apis.HTTP(subscriberServer.URL[7:]).URL()}},
+			CircuitBreakers: channel.NewCircuitBreakers(func() channel.CircuitBreakerConfig {
+				return channel.CircuitBreakerConfig{FailureThreshold: 1, CoolDown: time.Hour}
+			}, nil),
+		},
+		channel.NewStatsReporter("testcontainer", "testpod"),
+	)
+	if err != nil {
+		t.Fatal("NewHandler failed =", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		event := makeCloudEvent()
+		req := httptest.NewRequest(http.MethodPost, "http://channelname.channelnamespace/", nil)
+		if err := bindingshttp.WriteRequest(context.Background(), binding.ToMessage(&event), req); err != nil {
+			t.Fatal("WriteRequest =", err)
+		}
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		if resp.Code != http.StatusInternalServerError {
+			t.Errorf("Unexpected status code. Expected %v, Actual %v", http.StatusInternalServerError, resp.Code)
+		}
+	}
+	// The circuit opened after the first failure.
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("Unexpected number of attempts. Expected 1, Actual %d", got)
+	}
+}
+
 func testFanoutMessageHandler(t *testing.T, async bool, receiverFunc channel.UnbufferedMessageReceiverFunc, timeout time.Duration, inSubs []Subscription, subscriberHandler func(http.ResponseWriter, *http.Request), subscriberReqs int, replierHandler func(http.ResponseWriter, *http.Request), replierReqs int, expectedStatus int) {
 	var subscriberServerWg *sync.WaitGroup
 	reporter := channel.NewStatsReporter("testcontainer", "testpod")
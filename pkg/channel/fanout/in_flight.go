@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fanout
+
+import (
+	"context"
+	"sync"
+)
+
+// InFlightTracker tracks the asynchronous dispatches of fanout handlers, so that they can be
+// drained on shutdown instead of being dropped with the process.
+type InFlightTracker struct {
+	// ctx is the parent context of the dispatches, it's canceled when they are abandoned.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	inFlight int
+	// idle is closed once no dispatch is in flight, after Drain is called.
+	idle chan struct{}
+}
+
+// NewInFlightTracker creates an InFlightTracker.
+func NewInFlightTracker() *InFlightTracker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &InFlightTracker{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// start records the start of a dispatch, and returns its context and the function to call once
+// it's done. A nil tracker tracks nothing.
+func (t *InFlightTracker) start() (context.Context, func()) {
+	if t == nil {
+		return context.Background(), func() {}
+	}
+	t.mu.Lock()
+	t.inFlight++
+	t.mu.Unlock()
+	return t.ctx, t.done
+}
+
+func (t *InFlightTracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inFlight--
+	if t.inFlight == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// Drain waits until no dispatch is in flight, or until ctx is done. In the latter case, the
+// remaining dispatches are abandoned: their context is canceled, which stops their retries.
+// Drain returns the number of abandoned dispatches.
+func (t *InFlightTracker) Drain(ctx context.Context) int {
+	t.mu.Lock()
+	if t.inFlight == 0 {
+		t.mu.Unlock()
+		return 0
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return 0
+	case <-ctx.Done():
+	}
+
+	t.mu.Lock()
+	abandoned := t.inFlight
+	t.mu.Unlock()
+	t.cancel()
+	return abandoned
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fanout
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInFlightTrackerDrain(t *testing.T) {
+	tracker := NewInFlightTracker()
+	if got := tracker.Drain(context.Background()); got != 0 {
+		t.Errorf("Drain() = %d with nothing in flight, want 0", got)
+	}
+
+	_, done1 := tracker.start()
+	_, done2 := tracker.start()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done1()
+		done2()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if got := tracker.Drain(ctx); got != 0 {
+		t.Errorf("Drain() = %d, want 0", got)
+	}
+}
+
+func TestInFlightTrackerDrainAbandons(t *testing.T) {
+	tracker := NewInFlightTracker()
+	dispatchCtx, done := tracker.start()
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if got := tracker.Drain(ctx); got != 1 {
+		t.Errorf("Drain() = %d, want 1", got)
+	}
+	if dispatchCtx.Err() == nil {
+		t.Error("Expected the context of the abandoned dispatch to be canceled")
+	}
+}
+
+func TestInFlightTrackerNil(t *testing.T) {
+	var tracker *InFlightTracker
+	ctx, done := tracker.start()
+	done()
+	if ctx.Err() != nil {
+		t.Error("Unexpected context error:", ctx.Err())
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fanout
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/types"
+)
+
+// orderingKey identifies a sequence of events delivered in order: the events sent to a
+// subscription having the same partition key.
+type orderingKey struct {
+	subscription string
+	partition    string
+}
+
+// sequencer orders the deliveries of the ordered subscriptions. Each delivery takes a turn,
+// in the order the events were received, and waits for the previous turn of its sequence to be
+// done before being sent.
+type sequencer struct {
+	mu sync.Mutex
+	// last is the last turn taken by each sequence, it is removed once done.
+	last map[orderingKey]*turn
+}
+
+func newSequencer() *sequencer {
+	return &sequencer{last: make(map[orderingKey]*turn)}
+}
+
+// turn is the place of a delivery in its sequence.
+type turn struct {
+	s    *sequencer
+	key  orderingKey
+	prev <-chan struct{}
+	ch   chan struct{}
+}
+
+// enqueue takes the next turn of the sequence identified by key. It must be called in the
+// order the events are received, and the turn must always be done.
+func (s *sequencer) enqueue(key orderingKey) *turn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := &turn{s: s, key: key, ch: make(chan struct{})}
+	if prev, ok := s.last[key]; ok {
+		t.prev = prev.ch
+	}
+	s.last[key] = t
+	return t
+}
+
+// wait blocks until the previous turn is done, or ctx is done. A nil turn doesn't wait.
+func (t *turn) wait(ctx context.Context) error {
+	if t == nil || t.prev == nil {
+		return nil
+	}
+	select {
+	case <-t.prev:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ready returns whether wait returns right away, the previous turn being done. A nil turn is
+// always ready.
+func (t *turn) ready() bool {
+	if t == nil || t.prev == nil {
+		return true
+	}
+	select {
+	case <-t.prev:
+		return true
+	default:
+		return false
+	}
+}
+
+// done lets the next turn proceed. A nil turn is a no-op.
+func (t *turn) done() {
+	if t == nil {
+		return
+	}
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	if t.s.last[t.key] == t {
+		delete(t.s.last, t.key)
+	}
+	close(t.ch)
+}
+
+// takeTurns takes a turn for each ordered subscription in subs, the unordered ones get a nil
+// turn. The returned slice is nil when no subscription is ordered.
+func (s *sequencer) takeTurns(subs []Subscription, message binding.Message) []*turn {
+	var turns []*turn
+	for i, sub := range subs {
+		if !sub.Ordered {
+			continue
+		}
+		if turns == nil {
+			turns = make([]*turn, len(subs))
+		}
+		turns[i] = s.enqueue(orderingKey{
+			subscription: subscriptionKey(sub),
+			partition:    partitionKey(message, sub.OrderingKey),
+		})
+	}
+	return turns
+}
+
+// subscriptionKey identifies sub within its channel.
+func subscriptionKey(sub Subscription) string {
+	if sub.UID != "" {
+		return string(sub.UID)
+	}
+	if sub.Subscriber != nil {
+		return sub.Subscriber.String()
+	}
+	return ""
+}
+
+// partitionKey returns the value of the extension name of message, or the empty string if the
+// name is empty or the message doesn't have the extension, so that these events are ordered
+// together.
+func partitionKey(message binding.Message, name string) string {
+	if name == "" {
+		return ""
+	}
+	var value interface{}
+	if m, ok := message.(binding.MessageMetadataReader); ok {
+		value = m.GetExtension(name)
+	} else if e, err := binding.ToEvent(context.Background(), message); err == nil {
+		value = e.Extensions()[name]
+	}
+	if value == nil {
+		return ""
+	}
+	key, err := types.ToString(value)
+	if err != nil {
+		return ""
+	}
+	return key
+}
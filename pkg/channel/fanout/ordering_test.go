@@ -0,0 +1,308 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fanout
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	bindingshttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/ptr"
+
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/channel"
+)
+
+func TestSequencer(t *testing.T) {
+	s := newSequencer()
+	a := orderingKey{subscription: "sub", partition: "a"}
+	b := orderingKey{subscription: "sub", partition: "b"}
+
+	a1 := s.enqueue(a)
+	a2 := s.enqueue(a)
+	b1 := s.enqueue(b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := a2.wait(ctx); err == nil {
+		t.Error("Expected the second turn to wait for the first one")
+	}
+	if err := a1.wait(context.Background()); err != nil {
+		t.Error("Expected the first turn not to wait, got", err)
+	}
+	if err := b1.wait(context.Background()); err != nil {
+		t.Error("Expected the turn of another partition not to wait, got", err)
+	}
+
+	a1.done()
+	if err := a2.wait(context.Background()); err != nil {
+		t.Error("Expected the second turn to proceed once the first one is done, got", err)
+	}
+	a2.done()
+	b1.done()
+	if len(s.last) != 0 {
+		t.Errorf("Expected the done sequences to be removed, got %d", len(s.last))
+	}
+
+	// A nil turn, of an unordered subscription, never waits.
+	var none *turn
+	if err := none.wait(ctx); err != nil {
+		t.Error("Expected a nil turn not to wait, got", err)
+	}
+	none.done()
+}
+
+func TestSubscriberSpecToFanoutConfigOrdering(t *testing.T) {
+	ordered := eventingduckv1.DeliveryOrderingOrdered
+	unordered := eventingduckv1.DeliveryOrderingUnordered
+	tests := map[string]struct {
+		delivery        *eventingduckv1.DeliverySpec
+		wantOrdered     bool
+		wantOrderingKey string
+	}{
+		"no delivery": {},
+		"unordered": {
+			delivery: &eventingduckv1.DeliverySpec{Ordering: &unordered},
+		},
+		"ordered": {
+			delivery:    &eventingduckv1.DeliverySpec{Ordering: &ordered},
+			wantOrdered: true,
+		},
+		"ordered by key": {
+			delivery:        &eventingduckv1.DeliverySpec{Ordering: &ordered, OrderingKey: ptr.String("partitionkey")},
+			wantOrdered:     true,
+			wantOrderingKey: "partitionkey",
+		},
+	}
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			got, err := SubscriberSpecToFanoutConfig(eventingduckv1.SubscriberSpec{
+				SubscriberURI: apis.HTTP("subscriber.example.com"),
+				Delivery:      tc.delivery,
+			})
+			if err != nil {
+				t.Fatal("SubscriberSpecToFanoutConfig =", err)
+			}
+			if got.Ordered != tc.wantOrdered || got.OrderingKey != tc.wantOrderingKey {
+				t.Errorf("Got Ordered %v, OrderingKey %q, want %v, %q", got.Ordered, got.OrderingKey, tc.wantOrdered, tc.wantOrderingKey)
+			}
+		})
+	}
+}
+
+func TestFanoutMessageHandlerOrdered(t *testing.T) {
+	const events = 20
+	keys := []string{"a", "b"}
+
+	var (
+		mu       sync.Mutex
+		attempts = make(map[string]int)
+		inFlight = make(map[string]bool)
+		got      = make(map[string][]string)
+		received = make(chan struct{}, events)
+	)
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("ce-id")
+		key := r.Header.Get("ce-partitionkey")
+
+		mu.Lock()
+		if inFlight[key] {
+			t.Errorf("Received event %s while another event of partition %q is being delivered", id, key)
+		}
+		inFlight[key] = true
+		attempts[id]++
+		attempt := attempts[id]
+		mu.Unlock()
+
+		// Give a chance to the next events to be sent out of order.
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		inFlight[key] = false
+		// The first attempt of each event fails, the event must be retried before the next
+		// one of its partition is sent.
+		if attempt == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		got[key] = append(got[key], id)
+		w.WriteHeader(http.StatusAccepted)
+		received <- struct{}{}
+	}))
+	defer subscriberServer.Close()
+
+	ordered := eventingduckv1.DeliveryOrderingOrdered
+	sub, err := SubscriberSpecToFanoutConfig(eventingduckv1.SubscriberSpec{
+		UID:           "sub-uid",
+		SubscriberURI: apis.HTTP(subscriberServer.URL[7:]),
+		Delivery: &eventingduckv1.DeliverySpec{
+			Retry:        ptr.Int32(3),
+			BackoffDelay: ptr.String("PT0.01S"),
+			Ordering:     &ordered,
+			OrderingKey:  ptr.String("partitionkey"),
+		},
+	})
+	if err != nil {
+		t.Fatal("SubscriberSpecToFanoutConfig =", err)
+	}
+
+	logger := zap.NewNop()
+	h, err := NewFanoutMessageHandler(
+		logger,
+		channel.NewMessageDispatcher(logger),
+		Config{
+			Subscriptions: []Subscription{*sub},
+			AsyncHandler:  true,
+			Workers:       1,
+			QueueSize:     events,
+		},
+		channel.NewStatsReporter("testcontainer", "testpod"),
+	)
+	if err != nil {
+		t.Fatal("NewHandler failed =", err)
+	}
+
+	want := make(map[string][]string)
+	for i := 0; i < events; i++ {
+		id := strconv.Itoa(i)
+		key := keys[i%len(keys)]
+		want[key] = append(want[key], id)
+
+		event := makeCloudEvent()
+		event.SetID(id)
+		event.SetExtension("partitionkey", key)
+		req := httptest.NewRequest(http.MethodPost, "http://channelname.channelnamespace/", nil)
+		if err := bindingshttp.WriteRequest(context.Background(), binding.ToMessage(&event), req); err != nil {
+			t.Fatal("WriteRequest =", err)
+		}
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		if resp.Code != http.StatusAccepted {
+			t.Fatalf("Unexpected status code. Expected %v, Actual %v", http.StatusAccepted, resp.Code)
+		}
+	}
+
+	for i := 0; i < events; i++ {
+		select {
+		case <-received:
+		case <-time.After(10 * time.Second):
+			t.Fatal("Timed out waiting for the subscriber")
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("Unexpected delivery order (-want, +got):", diff)
+	}
+}
+
+func TestFanoutMessageHandlerOrderedDoesNotStallOthers(t *testing.T) {
+	const events = 5
+
+	release := make(chan struct{})
+	orderedReceived := make(chan string, events)
+	orderedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orderedReceived <- r.Header.Get("ce-id")
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer orderedServer.Close()
+	unorderedReceived := make(chan string, events)
+	unorderedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		unorderedReceived <- r.Header.Get("ce-id")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer unorderedServer.Close()
+	var releaseOnce sync.Once
+	releaseOrdered := func() { releaseOnce.Do(func() { close(release) }) }
+	// Unblock the ordered subscriber before closing the servers, should the test fail.
+	defer releaseOrdered()
+
+	ordered := eventingduckv1.DeliveryOrderingOrdered
+	orderedSub, err := SubscriberSpecToFanoutConfig(eventingduckv1.SubscriberSpec{
+		UID:           "ordered-uid",
+		SubscriberURI: apis.HTTP(orderedServer.URL[7:]),
+		Delivery:      &eventingduckv1.DeliverySpec{Ordering: &ordered},
+	})
+	if err != nil {
+		t.Fatal("SubscriberSpecToFanoutConfig =", err)
+	}
+
+	logger := zap.NewNop()
+	h, err := NewFanoutMessageHandler(
+		logger,
+		channel.NewMessageDispatcher(logger),
+		Config{
+			Subscriptions: []Subscription{
+				*orderedSub,
+				{UID: "unordered-uid", Subscriber: apis.HTTP(unorderedServer.URL[7:]).URL()},
+			},
+			AsyncHandler: true,
+			Workers:      2,
+			QueueSize:    1,
+		},
+		channel.NewStatsReporter("testcontainer", "testpod"),
+	)
+	if err != nil {
+		t.Fatal("NewHandler failed =", err)
+	}
+
+	receive := func(received <-chan string, want string) {
+		t.Helper()
+		select {
+		case id := <-received:
+			if id != want {
+				t.Errorf("Unexpected event received, want %s, got %s", want, id)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("Timed out waiting for event", want)
+		}
+	}
+
+	// The ordered subscriber holds the first event, the next ones are delivered to the
+	// unordered subscriber meanwhile, without filling the queue.
+	for i := 0; i < events; i++ {
+		id := strconv.Itoa(i)
+		event := makeCloudEvent()
+		event.SetID(id)
+		req := httptest.NewRequest(http.MethodPost, "http://channelname.channelnamespace/", nil)
+		if err := bindingshttp.WriteRequest(context.Background(), binding.ToMessage(&event), req); err != nil {
+			t.Fatal("WriteRequest =", err)
+		}
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		if resp.Code != http.StatusAccepted {
+			t.Fatalf("Unexpected status code of event %s. Expected %v, Actual %v", id, http.StatusAccepted, resp.Code)
+		}
+		receive(unorderedReceived, id)
+	}
+
+	releaseOrdered()
+	for i := 0; i < events; i++ {
+		receive(orderedReceived, strconv.Itoa(i))
+	}
+}
@@ -18,18 +18,23 @@ package channel
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"io/ioutil"
 	nethttp "net/http"
 	"net/url"
 	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/binding/transformer"
 	"github.com/cloudevents/sdk-go/v2/protocol/http"
 	"go.opencensus.io/trace"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/util/sets"
 
+	"knative.dev/eventing/pkg/deliveryauth"
 	"knative.dev/eventing/pkg/kncloudevents"
 	"knative.dev/eventing/pkg/utils"
 )
@@ -40,6 +45,22 @@ const (
 	NoResponse = -1
 )
 
+// Extensions added to the events sent to the dead letter sink, when the delivery enables it.
+const (
+	// ErrorDestinationExtension is the destination the event couldn't be delivered to.
+	ErrorDestinationExtension = "knativeerrordest"
+	// ErrorCodeExtension is the status code of the last response of the destination.
+	ErrorCodeExtension = "knativeerrorcode"
+	// ErrorAttemptsExtension is the number of attempts made to deliver the event.
+	ErrorAttemptsExtension = "knativeerrorattempts"
+	// ErrorDataExtension is the truncated body of the last response of the destination,
+	// or the error if there is no response, base64 encoded.
+	ErrorDataExtension = "knativeerrordata"
+
+	// maxErrorDataSize is the maximum size of the data in ErrorDataExtension, before encoding.
+	maxErrorDataSize = 1024
+)
+
 type MessageDispatcher interface {
 	// DispatchMessage dispatches an event to a destination over HTTP.
 	//
@@ -66,6 +87,48 @@ type MessageDispatcherImpl struct {
 type DispatchExecutionInfo struct {
 	Time         time.Duration
 	ResponseCode int
+	// Attempts is the number of attempts made to send the message.
+	Attempts int
+	// ResponseBody is the beginning of the body of a failed response, if any.
+	ResponseBody []byte
+	// DeadLettered is whether the message was sent to the dead letter sink, after failing to be
+	// sent to the destination. The other fields then describe the request to the dead letter sink.
+	DeadLettered bool
+	// DestinationResponseCode is the response code of the destination of a dead-lettered message.
+	DestinationResponseCode int
+}
+
+type credentialsKey struct{}
+
+type dispatchCredentials struct {
+	destination *deliveryauth.Credentials
+	deadLetter  *deliveryauth.Credentials
+}
+
+// WithCredentials returns a context making the MessageDispatcherImpl authenticate to the
+// destination and to the dead letter sink with the given credentials, either may be nil.
+func WithCredentials(ctx context.Context, destination, deadLetter *deliveryauth.Credentials) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, dispatchCredentials{destination: destination, deadLetter: deadLetter})
+}
+
+func credentialsFromContext(ctx context.Context) dispatchCredentials {
+	creds, _ := ctx.Value(credentialsKey{}).(dispatchCredentials)
+	return creds
+}
+
+type circuitBreakersKey struct{}
+
+// WithCircuitBreakers returns a context making the MessageDispatcherImpl stop sending messages
+// to a destination while its circuit breaker is open. Those messages are sent to the dead letter
+// sink, if any, or fail right away.
+func WithCircuitBreakers(ctx context.Context, circuitBreakers *CircuitBreakers) context.Context {
+	return context.WithValue(ctx, circuitBreakersKey{}, circuitBreakers)
+}
+
+// circuitBreakersFromContext returns the circuit breakers of ctx, nil if it has none.
+func circuitBreakersFromContext(ctx context.Context) *CircuitBreakers {
+	circuitBreakers, _ := ctx.Value(circuitBreakersKey{}).(*CircuitBreakers)
+	return circuitBreakers
 }
 
 // NewMessageDispatcherFromConfig creates a new Message dispatcher based on config.
@@ -105,6 +168,9 @@ func (d *MessageDispatcherImpl) DispatchMessageWithRetries(ctx context.Context,
 	reply = d.sanitizeURL(reply)
 	deadLetter = d.sanitizeURL(deadLetter)
 
+	creds := credentialsFromContext(ctx)
+	circuitBreakers := circuitBreakersFromContext(ctx)
+
 	// If there is a destination, variables response* are filled with the response of the destination
 	// Otherwise, they are filled with the original message
 	var responseMessage cloudevents.Message
@@ -115,11 +181,24 @@ func (d *MessageDispatcherImpl) DispatchMessageWithRetries(ctx context.Context,
 		// Try to send to destination
 		messagesToFinish = append(messagesToFinish, message)
 
-		ctx, responseMessage, responseAdditionalHeaders, dispatchExecutionInfo, err = d.executeRequest(ctx, destination, message, additionalHeaders, retriesConfig)
+		if circuitBreakers.allow(destination.String()) {
+			ctx, responseMessage, responseAdditionalHeaders, dispatchExecutionInfo, err = d.executeRequest(ctx, destination, creds.destination, message, additionalHeaders, retriesConfig)
+			circuitBreakers.done(destination.String(), isCircuitFailure(dispatchExecutionInfo, err))
+		} else {
+			err = ErrCircuitOpen
+			dispatchExecutionInfo = &DispatchExecutionInfo{
+				Time:         NoDuration,
+				ResponseCode: NoResponse,
+			}
+		}
 		if err != nil {
 			// DeadLetter is configured, send the message to it
 			if deadLetter != nil {
-				_, deadLetterResponse, _, dispatchExecutionInfo, deadLetterErr := d.executeRequest(ctx, deadLetter, message, additionalHeaders, retriesConfig)
+				transformers := deadLetterTransformers(retriesConfig, destination, dispatchExecutionInfo, err)
+				destinationResponseCode := dispatchExecutionInfo.ResponseCode
+				_, deadLetterResponse, _, dispatchExecutionInfo, deadLetterErr := d.executeRequest(ctx, deadLetter, creds.deadLetter, message, additionalHeaders, retriesConfig, transformers...)
+				dispatchExecutionInfo.DeadLettered = true
+				dispatchExecutionInfo.DestinationResponseCode = destinationResponseCode
 				if deadLetterErr != nil {
 					return dispatchExecutionInfo, fmt.Errorf("unable to complete request to either %s (%v) or %s (%v)", destination, err, deadLetter, deadLetterErr)
 				}
@@ -130,7 +209,7 @@ func (d *MessageDispatcherImpl) DispatchMessageWithRetries(ctx context.Context,
 				return dispatchExecutionInfo, nil
 			}
 			// No DeadLetter, just fail
-			return dispatchExecutionInfo, fmt.Errorf("unable to complete request to %s: %v", destination, err)
+			return dispatchExecutionInfo, fmt.Errorf("unable to complete request to %s: %w", destination, err)
 		}
 	} else {
 		// No destination url, try to send to reply if available
@@ -150,11 +229,12 @@ func (d *MessageDispatcherImpl) DispatchMessageWithRetries(ctx context.Context,
 		return dispatchExecutionInfo, nil
 	}
 
-	ctx, responseResponseMessage, _, dispatchExecutionInfo, err := d.executeRequest(ctx, reply, responseMessage, responseAdditionalHeaders, retriesConfig)
+	ctx, responseResponseMessage, _, dispatchExecutionInfo, err := d.executeRequest(ctx, reply, nil, responseMessage, responseAdditionalHeaders, retriesConfig)
 	if err != nil {
 		// DeadLetter is configured, send the message to it
 		if deadLetter != nil {
-			_, deadLetterResponse, _, dispatchExecutionInfo, deadLetterErr := d.executeRequest(ctx, deadLetter, message, responseAdditionalHeaders, retriesConfig)
+			transformers := deadLetterTransformers(retriesConfig, reply, dispatchExecutionInfo, err)
+			_, deadLetterResponse, _, dispatchExecutionInfo, deadLetterErr := d.executeRequest(ctx, deadLetter, creds.deadLetter, message, responseAdditionalHeaders, retriesConfig, transformers...)
 			if deadLetterErr != nil {
 				return dispatchExecutionInfo, fmt.Errorf("failed to forward reply to %s (%v) and failed to send it to the dead letter sink %s (%v)", reply, err, deadLetter, deadLetterErr)
 			}
@@ -174,7 +254,8 @@ func (d *MessageDispatcherImpl) DispatchMessageWithRetries(ctx context.Context,
 	return dispatchExecutionInfo, nil
 }
 
-func (d *MessageDispatcherImpl) executeRequest(ctx context.Context, url *url.URL, message cloudevents.Message, additionalHeaders nethttp.Header, configs *kncloudevents.RetryConfig) (context.Context, cloudevents.Message, nethttp.Header, *DispatchExecutionInfo, error) {
+// executeRequest sends message to url, authenticated with creds if not nil.
+func (d *MessageDispatcherImpl) executeRequest(ctx context.Context, url *url.URL, creds *deliveryauth.Credentials, message cloudevents.Message, additionalHeaders nethttp.Header, configs *kncloudevents.RetryConfig, transformers ...binding.Transformer) (context.Context, cloudevents.Message, nethttp.Header, *DispatchExecutionInfo, error) {
 	d.logger.Debug("Dispatching event", zap.String("url", url.String()))
 
 	execInfo := DispatchExecutionInfo{
@@ -184,23 +265,25 @@ func (d *MessageDispatcherImpl) executeRequest(ctx context.Context, url *url.URL
 	ctx, span := trace.StartSpan(ctx, "knative.dev", trace.WithSpanKind(trace.SpanKindClient))
 	defer span.End()
 
-	req, err := d.sender.NewCloudEventRequestWithTarget(ctx, url.String())
+	sender := creds.Sender(d.sender)
+	req, err := sender.NewCloudEventRequestWithTarget(ctx, url.String())
 	if err != nil {
 		return ctx, nil, nil, &execInfo, err
 	}
 
 	if span.IsRecordingEvents() {
-		err = kncloudevents.WriteHTTPRequestWithAdditionalHeaders(ctx, message, req, additionalHeaders, kncloudevents.PopulateSpan(span))
-	} else {
-		err = kncloudevents.WriteHTTPRequestWithAdditionalHeaders(ctx, message, req, additionalHeaders)
+		transformers = append(transformers, kncloudevents.PopulateSpan(span))
 	}
+	err = kncloudevents.WriteHTTPRequestWithAdditionalHeaders(ctx, message, req, additionalHeaders, transformers...)
 	if err != nil {
 		return ctx, nil, nil, &execInfo, err
 	}
+	creds.Authorize(req)
 
 	start := time.Now()
-	response, err := d.sender.SendWithRetries(req, configs)
+	response, attempts, err := sender.SendWithRetriesAndAttempts(req, configs)
 	dispatchTime := time.Since(start)
+	execInfo.Attempts = attempts
 	if err != nil {
 		execInfo.Time = dispatchTime
 		execInfo.ResponseCode = nethttp.StatusInternalServerError
@@ -213,6 +296,7 @@ func (d *MessageDispatcherImpl) executeRequest(ctx context.Context, url *url.URL
 	execInfo.Time = dispatchTime
 
 	if isFailure(response.StatusCode) {
+		execInfo.ResponseBody, _ = ioutil.ReadAll(io.LimitReader(response.Body, maxErrorDataSize))
 		_ = response.Body.Close()
 		// Reject non-successful responses.
 		return ctx, nil, nil, &execInfo, fmt.Errorf("unexpected HTTP response, expected 2xx, got %d", response.StatusCode)
@@ -246,3 +330,38 @@ func isFailure(statusCode int) bool {
 	return statusCode < nethttp.StatusOK /* 200 */ ||
 		statusCode >= nethttp.StatusMultipleChoices /* 300 */
 }
+
+// deadLetterTransformers returns the transformers adding the extensions describing the failure
+// to deliver the message to destination, if the delivery enables them.
+func deadLetterTransformers(config *kncloudevents.RetryConfig, destination *url.URL, info *DispatchExecutionInfo, err error) []binding.Transformer {
+	if config == nil || !config.DeadLetterEnrichment {
+		return nil
+	}
+
+	data := info.ResponseBody
+	if len(data) == 0 && err != nil {
+		data = []byte(err.Error())
+	}
+	if len(data) > maxErrorDataSize {
+		data = data[:maxErrorDataSize]
+	}
+
+	// Overwrite the extensions of a message which has already been dead-lettered.
+	transformers := []binding.Transformer{
+		setExtension(ErrorDestinationExtension, destination.String()),
+		setExtension(ErrorAttemptsExtension, int32(info.Attempts)),
+	}
+	if info.ResponseCode != NoResponse {
+		transformers = append(transformers, setExtension(ErrorCodeExtension, int32(info.ResponseCode)))
+	}
+	if len(data) != 0 {
+		transformers = append(transformers, setExtension(ErrorDataExtension, base64.StdEncoding.EncodeToString(data)))
+	}
+	return transformers
+}
+
+func setExtension(name string, value interface{}) binding.Transformer {
+	return transformer.SetExtension(name, func(interface{}) (interface{}, error) {
+		return value, nil
+	})
+}
@@ -19,23 +19,32 @@ package channel
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/pem"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/cloudevents/sdk-go/v2/binding"
 	"github.com/cloudevents/sdk-go/v2/binding/transformer"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
 
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/deliveryauth"
+	"knative.dev/eventing/pkg/kncloudevents"
 	"knative.dev/eventing/pkg/utils"
 )
 
@@ -739,6 +748,243 @@ func TestDispatchMessage(t *testing.T) {
 	}
 }
 
+func TestDispatchMessageWithRetriesStatusCodes(t *testing.T) {
+	testCases := map[string]struct {
+		statusCode         int
+		spec               eventingduckv1.DeliverySpec
+		expectedAttempts   int32
+		expectedDeadLetter int32
+	}{
+		"retryable by default": {
+			statusCode:         http.StatusNotFound,
+			spec:               eventingduckv1.DeliverySpec{Retry: pointer.Int32Ptr(2)},
+			expectedAttempts:   3,
+			expectedDeadLetter: 1,
+		},
+		"non-retryable class goes straight to the dead letter sink": {
+			statusCode: http.StatusNotFound,
+			spec: eventingduckv1.DeliverySpec{
+				Retry:                   pointer.Int32Ptr(2),
+				NonRetryableStatusCodes: []string{"4xx"},
+			},
+			expectedAttempts:   1,
+			expectedDeadLetter: 1,
+		},
+		"retryable code overrides non-retryable class": {
+			statusCode: http.StatusTooManyRequests,
+			spec: eventingduckv1.DeliverySpec{
+				Retry:                   pointer.Int32Ptr(2),
+				RetryableStatusCodes:    []string{"429"},
+				NonRetryableStatusCodes: []string{"4xx"},
+			},
+			expectedAttempts:   3,
+			expectedDeadLetter: 1,
+		},
+		"not listed as retryable": {
+			statusCode: http.StatusInternalServerError,
+			spec: eventingduckv1.DeliverySpec{
+				Retry:                pointer.Int32Ptr(2),
+				RetryableStatusCodes: []string{"503"},
+			},
+			expectedAttempts:   1,
+			expectedDeadLetter: 1,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			var attempts, deadLetters int32
+			destServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer destServer.Close()
+			deadLetterSinkServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&deadLetters, 1)
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer deadLetterSinkServer.Close()
+
+			config, err := kncloudevents.RetryConfigFromDeliverySpec(tc.spec)
+			if err != nil {
+				t.Fatal("Unable to create the retry config:", err)
+			}
+
+			event := cloudevents.NewEvent(cloudevents.VersionV1)
+			event.SetID(uuid.New().String())
+			event.SetType(testCeType)
+			event.SetSource(testCeSource)
+
+			md := NewMessageDispatcher(zaptest.NewLogger(t, zaptest.WrapOptions(zap.AddCaller())))
+			destination := getOnlyDomainURL(t, true, destServer.URL)
+			deadLetterSink := getOnlyDomainURL(t, true, deadLetterSinkServer.URL)
+
+			if _, err := md.DispatchMessageWithRetries(context.Background(), binding.ToMessage(&event), nil, destination, nil, deadLetterSink, &config); err != nil {
+				t.Error("Unexpected error from DispatchMessageWithRetries:", err)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tc.expectedAttempts {
+				t.Errorf("Unexpected destination attempts. Expected %d. Actual: %d", tc.expectedAttempts, got)
+			}
+			if got := atomic.LoadInt32(&deadLetters); got != tc.expectedDeadLetter {
+				t.Errorf("Unexpected dead letter sink requests. Expected %d. Actual: %d", tc.expectedDeadLetter, got)
+			}
+		})
+	}
+}
+
+func TestDispatchMessageWithRetriesDeadLetterEnrichment(t *testing.T) {
+	longBody := strings.Repeat("a", 2*maxErrorDataSize)
+	testCases := map[string]struct {
+		enrichment         bool
+		responseBody       string
+		expectedExtensions map[string]interface{}
+	}{
+		"disabled": {
+			responseBody: "not found",
+		},
+		"response body": {
+			enrichment:   true,
+			responseBody: "not found",
+			expectedExtensions: map[string]interface{}{
+				ErrorCodeExtension:     "404",
+				ErrorAttemptsExtension: "3",
+				ErrorDataExtension:     base64.StdEncoding.EncodeToString([]byte("not found")),
+			},
+		},
+		"truncated response body": {
+			enrichment:   true,
+			responseBody: longBody,
+			expectedExtensions: map[string]interface{}{
+				ErrorCodeExtension:     "404",
+				ErrorAttemptsExtension: "3",
+				ErrorDataExtension:     base64.StdEncoding.EncodeToString([]byte(longBody[:maxErrorDataSize])),
+			},
+		},
+		"empty response body falls back to the error": {
+			enrichment: true,
+			expectedExtensions: map[string]interface{}{
+				ErrorCodeExtension:     "404",
+				ErrorAttemptsExtension: "3",
+				ErrorDataExtension:     base64.StdEncoding.EncodeToString([]byte("unexpected HTTP response, expected 2xx, got 404")),
+			},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			destServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(tc.responseBody))
+			}))
+			defer destServer.Close()
+
+			var deadLettered *cloudevents.Event
+			deadLetterSinkServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				e, err := binding.ToEvent(context.Background(), cehttp.NewMessageFromHttpRequest(r))
+				if err != nil {
+					t.Error("Unable to read the dead-lettered event:", err)
+				}
+				deadLettered = e
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer deadLetterSinkServer.Close()
+
+			config, err := kncloudevents.RetryConfigFromDeliverySpec(eventingduckv1.DeliverySpec{
+				Retry:                pointer.Int32Ptr(2),
+				DeadLetterEnrichment: pointer.BoolPtr(tc.enrichment),
+			})
+			if err != nil {
+				t.Fatal("Unable to create the retry config:", err)
+			}
+
+			event := cloudevents.NewEvent(cloudevents.VersionV1)
+			event.SetID(uuid.New().String())
+			event.SetType(testCeType)
+			event.SetSource(testCeSource)
+
+			md := NewMessageDispatcher(zaptest.NewLogger(t, zaptest.WrapOptions(zap.AddCaller())))
+			destination := getOnlyDomainURL(t, true, destServer.URL)
+			deadLetterSink := getOnlyDomainURL(t, true, deadLetterSinkServer.URL)
+
+			info, err := md.DispatchMessageWithRetries(context.Background(), binding.ToMessage(&event), nil, destination, nil, deadLetterSink, &config)
+			if err != nil {
+				t.Fatal("Unexpected error from DispatchMessageWithRetries:", err)
+			}
+			if deadLettered == nil {
+				t.Fatal("Expected the event to be dead-lettered")
+			}
+			if !info.DeadLettered || info.DestinationResponseCode != http.StatusNotFound || info.ResponseCode != http.StatusAccepted {
+				t.Errorf("Unexpected dispatch info %+v", info)
+			}
+
+			extensions := deadLettered.Extensions()
+			if tc.enrichment {
+				if got := extensions[ErrorDestinationExtension]; got != "http://"+destination.Host+"/" {
+					t.Errorf("Unexpected %s extension %v", ErrorDestinationExtension, got)
+				}
+				delete(extensions, ErrorDestinationExtension)
+			}
+			// The values are received as strings in binary mode.
+			if diff := cmp.Diff(tc.expectedExtensions, extensions); diff != "" {
+				t.Error("Unexpected extensions (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestDispatchMessageWithCredentials(t *testing.T) {
+	var destAuthorization string
+	destServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destAuthorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer destServer.Close()
+
+	var deadLetterAuthorization string
+	deadLetterSinkServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadLetterAuthorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer deadLetterSinkServer.Close()
+
+	destCreds, err := deliveryauth.FromSecret(&corev1.Secret{Data: map[string][]byte{
+		deliveryauth.TokenKey: []byte("secret-token"),
+		deliveryauth.CAKey:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: destServer.Certificate().Raw}),
+	}})
+	if err != nil {
+		t.Fatal("Unable to create the destination credentials:", err)
+	}
+	deadLetterCreds, err := deliveryauth.FromSecret(&corev1.Secret{Data: map[string][]byte{
+		deliveryauth.UsernameKey: []byte("user"),
+		deliveryauth.PasswordKey: []byte("pass"),
+	}})
+	if err != nil {
+		t.Fatal("Unable to create the dead letter sink credentials:", err)
+	}
+
+	event := cloudevents.NewEvent(cloudevents.VersionV1)
+	event.SetID(uuid.New().String())
+	event.SetType(testCeType)
+	event.SetSource(testCeSource)
+
+	md := NewMessageDispatcher(zaptest.NewLogger(t, zaptest.WrapOptions(zap.AddCaller())))
+	destination, _ := url.Parse(destServer.URL)
+	deadLetterSink, _ := url.Parse(deadLetterSinkServer.URL)
+
+	ctx := WithCredentials(context.Background(), destCreds, deadLetterCreds)
+	info, err := md.DispatchMessage(ctx, binding.ToMessage(&event), nil, destination, nil, deadLetterSink)
+	if err != nil {
+		t.Fatal("Unexpected error from DispatchMessage:", err)
+	}
+	if info.DestinationResponseCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected the destination to be reached over TLS, got response code %d", info.DestinationResponseCode)
+	}
+	if destAuthorization != "Bearer secret-token" {
+		t.Errorf("Unexpected destination Authorization header %q", destAuthorization)
+	}
+	if deadLetterAuthorization != "Basic dXNlcjpwYXNz" {
+		t.Errorf("Unexpected dead letter sink Authorization header %q", deadLetterAuthorization)
+	}
+}
+
 func getOnlyDomainURL(t *testing.T, shouldSend bool, serverURL string) *url.URL {
 	if shouldSend {
 		server, err := url.Parse(serverURL)
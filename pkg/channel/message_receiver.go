@@ -43,6 +43,10 @@ func (e *UnknownChannelError) Error() string {
 	return fmt.Sprint("unknown channel: ", e.c)
 }
 
+// ErrQueueFull is returned when an event is rejected because the dispatcher of its channel has
+// too many events waiting to be dispatched.
+var ErrQueueFull = errors.New("dispatch queue is full")
+
 // UnknownHostError represents the error when a ResolveMessageChannelFromHostHeader func cannot resolve an host
 type UnknownHostError string
 
@@ -50,6 +54,13 @@ func (e UnknownHostError) Error() string {
 	return "cannot map host to channel: " + string(e)
 }
 
+// UnknownPathError represents the error when a ResolveMessageChannelFromPath func cannot resolve a path
+type UnknownPathError string
+
+func (e UnknownPathError) Error() string {
+	return "cannot map path to channel: " + string(e)
+}
+
 // MessageReceiver starts a server to receive new events for the channel dispatcher. The new
 // event is emitted via the receiver function.
 type MessageReceiver struct {
@@ -57,6 +68,7 @@ type MessageReceiver struct {
 	receiverFunc         UnbufferedMessageReceiverFunc
 	logger               *zap.Logger
 	hostToChannelFunc    ResolveChannelFromHostFunc
+	pathToChannelFunc    ResolveChannelFromPathFunc
 	reporter             StatsReporter
 }
 
@@ -83,6 +95,20 @@ func ResolveMessageChannelFromHostHeader(hostToChannelFunc ResolveChannelFromHos
 	}
 }
 
+// ResolveChannelFromPathFunc function enables EventReceiver to get the Channel Reference from the path of incoming
+// requests not sent to the root path, for the channels addressed by path.
+// Returns UnknownPathError if the channel is not found, otherwise returns a generic error.
+type ResolveChannelFromPathFunc func(string) (ChannelReference, error)
+
+// ResolveMessageChannelFromPath is a ReceiverOption for NewMessageReceiver which enables the channels addressed by
+// path, such as ParseChannelFromPath. Without it, only the requests to the root path are accepted.
+func ResolveMessageChannelFromPath(pathToChannelFunc ResolveChannelFromPathFunc) MessageReceiverOptions {
+	return func(r *MessageReceiver) error {
+		r.pathToChannelFunc = pathToChannelFunc
+		return nil
+	}
+}
+
 // NewMessageReceiver creates an event receiver passing new events to the
 // receiverFunc.
 func NewMessageReceiver(receiverFunc UnbufferedMessageReceiverFunc, logger *zap.Logger, reporter StatsReporter, opts ...MessageReceiverOptions) (*MessageReceiver, error) {
@@ -104,9 +130,10 @@ func NewMessageReceiver(receiverFunc UnbufferedMessageReceiverFunc, logger *zap.
 
 // Start begins to receive events for the receiver.
 //
-// Only HTTP POST requests to the root path (/) are accepted. If other paths or
-// methods are needed, use the HandleRequest method directly with another HTTP
-// server.
+// Only HTTP POST requests to the root path (/) are accepted, as well as the
+// paths of the channels addressed by path when ResolveMessageChannelFromPath is
+// set. If other paths or methods are needed, use the HandleRequest method
+// directly with another HTTP server.
 func (r *MessageReceiver) Start(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -142,7 +169,7 @@ func (r *MessageReceiver) ServeHTTP(response nethttp.ResponseWriter, request *ne
 	}
 
 	// tctx.URI is actually the path...
-	if request.URL.Path != "/" {
+	if request.URL.Path != "/" && r.pathToChannelFunc == nil {
 		response.WriteHeader(nethttp.StatusNotFound)
 		return
 	}
@@ -151,13 +178,12 @@ func (r *MessageReceiver) ServeHTTP(response nethttp.ResponseWriter, request *ne
 
 	// The response status codes:
 	//   202 - the event was sent to subscribers
+	//   207 - the events of a batch have different status codes, listed in the body
 	//   404 - the request was for an unknown channel
 	//   500 - an error occurred processing the request
-	host := request.Host
-	r.logger.Debug("Received request", zap.String("host", host))
-	channel, err := r.hostToChannelFunc(host)
+	channel, err := r.resolveChannel(request)
 	if err != nil {
-		if _, ok := err.(UnknownHostError); ok {
+		if isUnknownChannelAddress(err) {
 			response.WriteHeader(nethttp.StatusNotFound)
 			r.logger.Info(err.Error())
 		} else {
@@ -171,6 +197,11 @@ func (r *MessageReceiver) ServeHTTP(response nethttp.ResponseWriter, request *ne
 
 	args.Ns = channel.Namespace
 
+	if kncloudevents.IsBatchRequest(request) {
+		r.serveBatch(response, request, channel, &args)
+		return
+	}
+
 	message := http.NewMessageFromHttpRequest(request)
 	if message.ReadEncoding() == binding.EncodingUnknown {
 		r.logger.Info("Cannot determine the cloudevent message encoding")
@@ -179,21 +210,72 @@ func (r *MessageReceiver) ServeHTTP(response nethttp.ResponseWriter, request *ne
 		return
 	}
 	err = r.receiverFunc(request.Context(), channel, message, []binding.Transformer{}, utils.PassThroughHeaders(request.Header))
+	response.WriteHeader(r.statusCode(err))
+}
+
+// serveBatch passes each event of a batch request to the receiverFunc, and responds with the
+// status code of each event.
+func (r *MessageReceiver) serveBatch(response nethttp.ResponseWriter, request *nethttp.Request, channel ChannelReference, args *ReportArgs) {
+	events, err := kncloudevents.NewEventsFromBatchRequest(request)
 	if err != nil {
-		if _, ok := err.(*UnknownChannelError); ok {
-			response.WriteHeader(nethttp.StatusNotFound)
-		} else {
-			r.logger.Info("Error in receiver", zap.Error(err))
-			response.WriteHeader(nethttp.StatusInternalServerError)
-		}
+		r.logger.Info("Cannot read the cloudevents batch", zap.Error(err))
+		response.WriteHeader(nethttp.StatusBadRequest)
+		r.reporter.ReportEventCount(args, nethttp.StatusBadRequest)
 		return
 	}
-	response.WriteHeader(nethttp.StatusAccepted)
+
+	headers := utils.PassThroughHeaders(request.Header)
+	statuses := make([]kncloudevents.BatchEventStatus, 0, len(events))
+	for _, event := range events {
+		err := r.receiverFunc(request.Context(), channel, binding.ToMessage(event), []binding.Transformer{}, headers)
+		statuses = append(statuses, kncloudevents.BatchEventStatus{
+			ID:         event.ID(),
+			Source:     event.Source(),
+			StatusCode: r.statusCode(err),
+		})
+	}
+	kncloudevents.WriteBatchResponse(response, statuses)
+}
+
+// statusCode returns the status code of an event for the error returned by the receiverFunc.
+func (r *MessageReceiver) statusCode(err error) int {
+	if err == nil {
+		return nethttp.StatusAccepted
+	}
+	if _, ok := err.(*UnknownChannelError); ok {
+		return nethttp.StatusNotFound
+	} else if errors.Is(err, ErrQueueFull) {
+		return nethttp.StatusTooManyRequests
+	}
+	r.logger.Info("Error in receiver", zap.Error(err))
+	return nethttp.StatusInternalServerError
+}
+
+// resolveChannel resolves the channel of request from its path when it isn't sent to the root
+// path, otherwise from its host.
+func (r *MessageReceiver) resolveChannel(request *nethttp.Request) (ChannelReference, error) {
+	if request.URL.Path != "/" {
+		r.logger.Debug("Received request", zap.String("path", request.URL.Path))
+		return r.pathToChannelFunc(request.URL.Path)
+	}
+	r.logger.Debug("Received request", zap.String("host", request.Host))
+	return r.hostToChannelFunc(request.Host)
+}
+
+// isUnknownChannelAddress returns whether err is an UnknownHostError or an UnknownPathError.
+func isUnknownChannelAddress(err error) bool {
+	switch err.(type) {
+	case UnknownHostError, UnknownPathError:
+		return true
+	}
+	return false
 }
 
 func ReportEventCountMetricsForDispatchError(err error, reporter StatsReporter, args *ReportArgs) {
 	if _, ok := err.(*UnknownChannelError); ok {
 		_ = reporter.ReportEventCount(args, nethttp.StatusNotFound)
+	} else if errors.Is(err, ErrQueueFull) {
+		_ = reporter.ReportEventCount(args, nethttp.StatusTooManyRequests)
 	} else {
 		_ = reporter.ReportEventCount(args, nethttp.StatusInternalServerError)
 	}
@@ -19,6 +19,7 @@ package channel
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	nethttp "net/http"
@@ -74,6 +75,12 @@ func TestMessageReceiver_ServeHTTP(t *testing.T) {
 			},
 			expected: nethttp.StatusNotFound,
 		},
+		"queue full error": {
+			receiverFunc: func(_ context.Context, _ ChannelReference, _ binding.Message, _ []binding.Transformer, _ nethttp.Header) error {
+				return ErrQueueFull
+			},
+			expected: nethttp.StatusTooManyRequests,
+		},
 		"other receiver function error": {
 			receiverFunc: func(_ context.Context, _ ChannelReference, _ binding.Message, _ []binding.Transformer, _ nethttp.Header) error {
 				return errors.New("test induced receiver function error")
@@ -178,6 +185,58 @@ func TestMessageReceiver_ServeHTTP(t *testing.T) {
 	}
 }
 
+func TestMessageReceiver_ServeHTTPPath(t *testing.T) {
+	testCases := map[string]struct {
+		path     string
+		expected int
+	}{
+		"channel path": {
+			path:     "/test-namespace/test-name",
+			expected: nethttp.StatusAccepted,
+		},
+		"root path": {
+			path:     "/",
+			expected: nethttp.StatusAccepted,
+		},
+		"bad path": {
+			path:     "/test-namespace",
+			expected: nethttp.StatusNotFound,
+		},
+	}
+	reporter := NewStatsReporter("testcontainer", "testpod")
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			// The root path is resolved from the host, the other paths from the path.
+			host := "test-name.test-namespace.svc." + network.GetClusterDomainName()
+			if tc.path != "/" {
+				host = "dispatcher.knative-eventing.svc." + network.GetClusterDomainName()
+			}
+			f := func(_ context.Context, r ChannelReference, _ binding.Message, _ []binding.Transformer, _ nethttp.Header) error {
+				if r.Namespace != "test-namespace" || r.Name != "test-name" {
+					return fmt.Errorf("test receiver func -- bad reference: %v", r)
+				}
+				return nil
+			}
+			r, err := NewMessageReceiver(f, zaptest.NewLogger(t), reporter, ResolveMessageChannelFromPath(ParseChannelFromPath))
+			if err != nil {
+				t.Fatalf("Error creating new event receiver. Error:%s", err)
+			}
+
+			event := test.FullEvent()
+			req := httptest.NewRequest(nethttp.MethodPost, "http://"+host+tc.path, nil)
+			if err := http.WriteRequest(context.TODO(), binding.ToMessage(&event), req); err != nil {
+				t.Fatal(err)
+			}
+			res := httptest.ResponseRecorder{}
+
+			r.ServeHTTP(&res, req)
+			if res.Code != tc.expected {
+				t.Fatalf("Unexpected status code. Expected %v. Actual %v", tc.expected, res.Code)
+			}
+		})
+	}
+}
+
 func TestMessageReceiver_ServerStart_trace_propagation(t *testing.T) {
 	want := test.ConvertEventExtensionsToString(t, test.FullEvent())
 
@@ -302,3 +361,80 @@ func TestMessageReceiver_UnknownHost(t *testing.T) {
 		t.Fatal("Unexpected status code. Expected 404. Actual", res.Code)
 	}
 }
+
+func TestMessageReceiver_Batch(t *testing.T) {
+	host := "http://test-channel.test-namespace.svc." + network.GetClusterDomainName() + "/"
+	reporter := NewStatsReporter("testcontainer", "testpod")
+
+	testCases := map[string]struct {
+		body         string
+		expected     int
+		wantStatuses []kncloudevents.BatchEventStatus
+		wantReceived []string
+	}{
+		"all accepted": {
+			body:         `[{"specversion": "1.0", "id": "1", "source": "/s", "type": "t"}, {"specversion": "1.0", "id": "2", "source": "/s", "type": "t"}]`,
+			expected:     nethttp.StatusAccepted,
+			wantReceived: []string{"1", "2"},
+		},
+		"some failed": {
+			body:     `[{"specversion": "1.0", "id": "1", "source": "/s", "type": "t"}, {"specversion": "1.0", "id": "full", "source": "/s", "type": "t"}]`,
+			expected: nethttp.StatusMultiStatus,
+			wantStatuses: []kncloudevents.BatchEventStatus{
+				{ID: "1", Source: "/s", StatusCode: nethttp.StatusAccepted},
+				{ID: "full", Source: "/s", StatusCode: nethttp.StatusTooManyRequests},
+			},
+			wantReceived: []string{"1", "full"},
+		},
+		"invalid event": {
+			body:     `[{"specversion": "1.0", "id": "1", "source": "/s", "type": "t"}, {"specversion": "1.0", "id": "2"}]`,
+			expected: nethttp.StatusBadRequest,
+		},
+		"not a batch": {
+			body:     `{"specversion": "1.0", "id": "1", "source": "/s", "type": "t"}`,
+			expected: nethttp.StatusBadRequest,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			var received []string
+			f := func(ctx context.Context, _ ChannelReference, m binding.Message, _ []binding.Transformer, _ nethttp.Header) error {
+				defer m.Finish(nil)
+				event, err := binding.ToEvent(ctx, m)
+				if err != nil {
+					return err
+				}
+				received = append(received, event.ID())
+				if event.ID() == "full" {
+					return ErrQueueFull
+				}
+				return nil
+			}
+			r, err := NewMessageReceiver(f, zaptest.NewLogger(t), reporter)
+			if err != nil {
+				t.Fatalf("Error creating new event receiver. Error:%s", err)
+			}
+
+			req := httptest.NewRequest(nethttp.MethodPost, host, bytes.NewBufferString(tc.body))
+			req.Header.Set("content-type", cloudevents.ApplicationCloudEventsBatchJSON)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+			if res.Code != tc.expected {
+				t.Fatalf("Unexpected status code. Expected %v. Actual %v", tc.expected, res.Code)
+			}
+			if tc.wantStatuses != nil {
+				var got []kncloudevents.BatchEventStatus
+				if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+					t.Fatal("Unexpected error decoding the response:", err)
+				}
+				if diff := cmp.Diff(tc.wantStatuses, got); diff != "" {
+					t.Error("Unexpected statuses (-want, +got):", diff)
+				}
+			}
+			if diff := cmp.Diff(tc.wantReceived, received); diff != "" {
+				t.Error("Unexpected events received (-want, +got):", diff)
+			}
+		})
+	}
+}
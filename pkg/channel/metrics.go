@@ -24,6 +24,15 @@ const (
 
 	// LabelContainerName is the label for the immutable name of the container.
 	LabelContainerName = "container_name"
+
+	// LabelDestination is the label for the destination of a circuit breaker.
+	LabelDestination = "destination"
+
+	// LabelCircuitState is the label for the state of a circuit breaker.
+	LabelCircuitState = "circuit_state"
+
+	// LabelChannelName is the label for the name of a Channel.
+	LabelChannelName = "channel_name"
 )
 
 var (
@@ -26,8 +26,11 @@ type Config struct {
 
 // ChannelConfig is the configuration for a single Channel.
 type ChannelConfig struct {
-	Namespace    string
-	Name         string
-	HostName     string
+	Namespace string
+	Name      string
+	HostName  string
+	// Path is the path of the Channel when it is addressed by path, /namespace/name, on a host
+	// shared by several Channels. It is empty when the Channel is addressed by HostName.
+	Path         string
 	FanoutConfig fanout.Config
 }
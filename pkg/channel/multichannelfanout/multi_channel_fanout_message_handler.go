@@ -17,7 +17,8 @@ limitations under the License.
 // Package multichannelfanout provides an http.Handler that takes in one request to a Knative
 // Channel and fans it out to N other requests. Logically, it represents multiple Knative Channels.
 // It is made up of a map, map[channel]fanout.MessageHandler and each incoming request is inspected to
-// determine which Channel it is on. This Handler delegates the HTTP handling to the fanout.MessageHandler
+// determine which Channel it is on, from its path for the Channels addressed by path, otherwise
+// from its host. This Handler delegates the HTTP handling to the fanout.MessageHandler
 // corresponding to the incoming request's Channel.
 // It is often used in conjunction with a swappable.Handler. The swappable.Handler delegates all its
 // requests to the multichannelfanout.MessageHandler. When a new configuration is available, a new
@@ -39,15 +40,24 @@ import (
 
 type MultiChannelMessageHandler interface {
 	http.Handler
-	SetChannelHandler(host string, handler fanout.MessageHandler)
-	DeleteChannelHandler(host string)
-	GetChannelHandler(host string) fanout.MessageHandler
+	SetChannelHandler(channelKey string, handler fanout.MessageHandler)
+	DeleteChannelHandler(channelKey string)
+	GetChannelHandler(channelKey string) fanout.MessageHandler
+}
+
+// MakeChannelKey creates the key of the handler of a Channel, from the host and path of its
+// address. It is the path for the Channels addressed by path, otherwise the host.
+func MakeChannelKey(hostName, path string) string {
+	if path != "" && path != "/" {
+		return path
+	}
+	return hostName
 }
 
 // makeChannelKeyFromConfig creates the channel key for a given channelConfig. It is a helper around
 // MakeChannelKey.
 func makeChannelKeyFromConfig(config ChannelConfig) string {
-	return config.HostName
+	return MakeChannelKey(config.HostName, config.Path)
 }
 
 // Handler is an http.Handler that introspects the incoming request to determine what Channel it is
@@ -91,28 +101,28 @@ func NewMessageHandlerWithConfig(_ context.Context, logger *zap.Logger, messageD
 	}, nil
 }
 
-func (h *MessageHandler) SetChannelHandler(host string, handler fanout.MessageHandler) {
+func (h *MessageHandler) SetChannelHandler(channelKey string, handler fanout.MessageHandler) {
 	h.handlersLock.Lock()
 	defer h.handlersLock.Unlock()
-	h.handlers[host] = handler
+	h.handlers[channelKey] = handler
 }
 
-func (h *MessageHandler) DeleteChannelHandler(host string) {
+func (h *MessageHandler) DeleteChannelHandler(channelKey string) {
 	h.handlersLock.Lock()
 	defer h.handlersLock.Unlock()
-	delete(h.handlers, host)
+	delete(h.handlers, channelKey)
 }
 
-func (h *MessageHandler) GetChannelHandler(host string) fanout.MessageHandler {
+func (h *MessageHandler) GetChannelHandler(channelKey string) fanout.MessageHandler {
 	h.handlersLock.RLock()
 	defer h.handlersLock.RUnlock()
-	return h.handlers[host]
+	return h.handlers[channelKey]
 }
 
 // ServeHTTP delegates the actual handling of the request to a fanout.MessageHandler, based on the
 // request's channel key.
 func (h *MessageHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
-	channelKey := request.Host
+	channelKey := MakeChannelKey(request.Host, request.URL.Path)
 	fh := h.GetChannelHandler(channelKey)
 	if fh == nil {
 		h.logger.Info("Unable to find a handler for request", zap.String("channelKey", channelKey))
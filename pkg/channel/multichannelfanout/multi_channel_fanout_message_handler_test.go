@@ -20,6 +20,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
@@ -187,6 +188,46 @@ func TestServeHTTPMessageHandler(t *testing.T) {
 			key:                "second-channel.default",
 			expectedStatusCode: http.StatusAccepted,
 		},
+		"choose channel by path": {
+			config: Config{
+				ChannelConfigs: []ChannelConfig{
+					{
+						Namespace: "default",
+						Name:      "first-channel",
+						HostName:  "dispatcher.knative-eventing",
+						Path:      "/default/first-channel",
+						FanoutConfig: fanout.Config{
+							Subscriptions: []fanout.Subscription{
+								{
+									Reply: apis.HTTP("first-to-domain").URL(),
+								},
+							},
+						},
+					},
+					{
+						Namespace: "default",
+						Name:      "second-channel",
+						HostName:  "dispatcher.knative-eventing",
+						Path:      "/default/second-channel",
+						FanoutConfig: fanout.Config{
+							Subscriptions: []fanout.Subscription{
+								{
+									Subscriber: replaceDomain,
+								},
+							},
+						},
+					},
+				},
+			},
+			respStatusCode:     http.StatusOK,
+			key:                "/default/second-channel",
+			expectedStatusCode: http.StatusAccepted,
+		},
+		"non-existent channel path": {
+			config:             Config{},
+			key:                "/default/does-not-exist",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
 	}
 	for n, tc := range testCases {
 		t.Run(n, func(t *testing.T) {
@@ -210,7 +251,12 @@ func TestServeHTTPMessageHandler(t *testing.T) {
 			event.SetSource("testsource")
 			event.SetData(cloudevents.ApplicationJSON, "{}")
 
-			req := httptest.NewRequest(http.MethodPost, "http://"+tc.key+"/", nil)
+			target := "http://" + tc.key + "/"
+			if strings.HasPrefix(tc.key, "/") {
+				// The channels addressed by path share the host of the dispatcher.
+				target = "http://dispatcher.knative-eventing" + tc.key
+			}
+			req := httptest.NewRequest(http.MethodPost, target, nil)
 			err = bindingshttp.WriteRequest(ctx, binding.ToMessage(&event), req)
 			if err != nil {
 				t.Fatal(err)
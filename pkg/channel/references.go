@@ -44,3 +44,16 @@ func ParseChannel(host string) (ChannelReference, error) {
 		Namespace: chunks[1],
 	}, nil
 }
+
+// ParseChannelFromPath converts the path of a channel addressed by path, /namespace/name, into a
+// channel reference. It returns UnknownPathError if the path doesn't have this format.
+func ParseChannelFromPath(path string) (ChannelReference, error) {
+	chunks := strings.Split(path, "/")
+	if len(chunks) != 3 || chunks[0] != "" || chunks[1] == "" || chunks[2] == "" {
+		return ChannelReference{}, UnknownPathError(path)
+	}
+	return ChannelReference{
+		Namespace: chunks[1],
+		Name:      chunks[2],
+	}, nil
+}
@@ -52,3 +52,24 @@ func TestParseChannel(t *testing.T) {
 		t.Errorf("Expected Name: test-namespace. Got: %q", c.Namespace)
 	}
 }
+
+func TestParseChannelFromPath(t *testing.T) {
+	c, err := ParseChannelFromPath("/test-namespace/test-channel")
+	if err != nil {
+		t.Error("Unexpected error:", err)
+	}
+	if c.Name != "test-channel" {
+		t.Errorf("Expected Name: test-channel. Got: %q", c.Name)
+	}
+	if c.Namespace != "test-namespace" {
+		t.Errorf("Expected Namespace: test-namespace. Got: %q", c.Namespace)
+	}
+
+	for _, path := range []string{"/", "/test-namespace", "/test-namespace/", "//test-channel", "/test-namespace/test-channel/", "test-namespace/test-channel"} {
+		if _, err := ParseChannelFromPath(path); err == nil {
+			t.Errorf("Expected an error for the path %q", path)
+		} else if _, ok := err.(UnknownPathError); !ok {
+			t.Errorf("Expected an UnknownPathError for the path %q, got %v", path, err)
+		}
+	}
+}
@@ -46,6 +46,22 @@ var (
 		stats.UnitMilliseconds,
 	)
 
+	// circuitBreakerStateChangeCountM is a counter which records the number of
+	// state changes of the circuit breakers of the dispatcher.
+	circuitBreakerStateChangeCountM = stats.Int64(
+		"circuit_breaker_state_change_count",
+		"Number of state changes of the circuit breakers of the subscribers, by new state",
+		stats.UnitDimensionless,
+	)
+
+	// queueDepthM records the number of events waiting for a worker of the
+	// asynchronous dispatcher of a Channel.
+	queueDepthM = stats.Int64(
+		"dispatch_queue_depth",
+		"Number of events waiting to be dispatched by the in-memory channel",
+		stats.UnitDimensionless,
+	)
+
 	// Create the tag keys that will be used to add tags to our measurements.
 	// Tag keys must conform to the restrictions described in
 	// go.opencensus.io/tag/validate.go. Currently those restrictions are:
@@ -55,6 +71,9 @@ var (
 	eventTypeKey         = tag.MustNewKey(metricskey.LabelEventType)
 	responseCodeKey      = tag.MustNewKey(metricskey.LabelResponseCode)
 	responseCodeClassKey = tag.MustNewKey(metricskey.LabelResponseCodeClass)
+	destinationKey       = tag.MustNewKey(LabelDestination)
+	circuitStateKey      = tag.MustNewKey(LabelCircuitState)
+	channelNameKey       = tag.MustNewKey(LabelChannelName)
 )
 
 type ReportArgs struct {
@@ -69,6 +88,8 @@ func init() {
 type StatsReporter interface {
 	ReportEventCount(args *ReportArgs, responseCode int) error
 	ReportEventDispatchTime(args *ReportArgs, responseCode int, d time.Duration) error
+	ReportCircuitBreakerStateChange(args *ReportArgs, destination string, state CircuitState) error
+	ReportQueueDepth(ref ChannelReference, depth int) error
 }
 
 var _ StatsReporter = (*reporter)(nil)
@@ -112,6 +133,18 @@ func register() {
 			Aggregation: view.Distribution(metrics.Buckets125(1, 10000)...), // 1, 2, 5, 10, 20, 50, 100, 500, 1000, 5000, 10000
 			TagKeys:     tagKeys,
 		},
+		&view.View{
+			Description: circuitBreakerStateChangeCountM.Description(),
+			Measure:     circuitBreakerStateChangeCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceKey, destinationKey, circuitStateKey, UniqueTagKey, ContainerTagKey},
+		},
+		&view.View{
+			Description: queueDepthM.Description(),
+			Measure:     queueDepthM,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceKey, channelNameKey, UniqueTagKey, ContainerTagKey},
+		},
 	)
 	if err != nil {
 		log.Print("failed to register opencensus views, " + err.Error())
@@ -139,6 +172,37 @@ func (r *reporter) ReportEventDispatchTime(args *ReportArgs, responseCode int, d
 	return nil
 }
 
+// ReportCircuitBreakerStateChange captures the state changes of the circuit breakers.
+func (r *reporter) ReportCircuitBreakerStateChange(args *ReportArgs, destination string, state CircuitState) error {
+	ctx, err := tag.New(
+		emptyContext,
+		tag.Insert(namespaceKey, args.Ns),
+		tag.Insert(destinationKey, destination),
+		tag.Insert(circuitStateKey, string(state)),
+		tag.Insert(ContainerTagKey, r.container),
+		tag.Insert(UniqueTagKey, r.uniqueName))
+	if err != nil {
+		return err
+	}
+	metrics.Record(ctx, circuitBreakerStateChangeCountM.M(1))
+	return nil
+}
+
+// ReportQueueDepth captures the number of events waiting to be dispatched for a Channel.
+func (r *reporter) ReportQueueDepth(ref ChannelReference, depth int) error {
+	ctx, err := tag.New(
+		emptyContext,
+		tag.Insert(namespaceKey, ref.Namespace),
+		tag.Insert(channelNameKey, ref.Name),
+		tag.Insert(ContainerTagKey, r.container),
+		tag.Insert(UniqueTagKey, r.uniqueName))
+	if err != nil {
+		return err
+	}
+	metrics.Record(ctx, queueDepthM.M(int64(depth)))
+	return nil
+}
+
 func (r *reporter) generateTag(args *ReportArgs, responseCode int) (context.Context, error) {
 	return tag.New(
 		emptyContext,
@@ -63,6 +63,48 @@ func TestStatsReporter(t *testing.T) {
 	metricstest.CheckDistributionData(t, "event_dispatch_latencies", wantTags, 2, 1100.0, 9100.0)
 }
 
+func TestStatsReporterCircuitBreakerStateChange(t *testing.T) {
+	setup()
+	args := &ReportArgs{Ns: "testns"}
+
+	r := NewStatsReporter("testcontainer", "testpod")
+
+	wantTags := map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		LabelDestination:              "http://subscriber.example.com/",
+		LabelCircuitState:             string(CircuitOpen),
+		LabelUniqueName:               "testpod",
+		LabelContainerName:            "testcontainer",
+	}
+
+	expectSuccess(t, func() error {
+		return r.ReportCircuitBreakerStateChange(args, "http://subscriber.example.com/", CircuitOpen)
+	})
+	metricstest.CheckCountData(t, "circuit_breaker_state_change_count", wantTags, 1)
+}
+
+func TestStatsReporterQueueDepth(t *testing.T) {
+	setup()
+	ref := ChannelReference{Namespace: "testns", Name: "testchannel"}
+
+	r := NewStatsReporter("testcontainer", "testpod")
+
+	wantTags := map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		LabelChannelName:              "testchannel",
+		LabelUniqueName:               "testpod",
+		LabelContainerName:            "testcontainer",
+	}
+
+	expectSuccess(t, func() error {
+		return r.ReportQueueDepth(ref, 5)
+	})
+	expectSuccess(t, func() error {
+		return r.ReportQueueDepth(ref, 3)
+	})
+	metricstest.CheckLastValueData(t, "dispatch_queue_depth", wantTags, 3)
+}
+
 func expectSuccess(t *testing.T, f func() error) {
 	t.Helper()
 	if err := f(); err != nil {
@@ -78,6 +120,8 @@ func resetMetrics() {
 	// OpenCensus metrics carry global state that need to be reset between unit tests.
 	metricstest.Unregister(
 		"event_count",
-		"event_dispatch_latencies")
+		"event_dispatch_latencies",
+		"circuit_breaker_state_change_count",
+		"dispatch_queue_depth")
 	register()
 }
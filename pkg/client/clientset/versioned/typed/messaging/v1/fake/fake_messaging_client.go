@@ -36,6 +36,10 @@ func (c *FakeMessagingV1) InMemoryChannels(namespace string) v1.InMemoryChannelI
 	return &FakeInMemoryChannels{c, namespace}
 }
 
+func (c *FakeMessagingV1) PersistentChannels(namespace string) v1.PersistentChannelInterface {
+	return &FakePersistentChannels{c, namespace}
+}
+
 func (c *FakeMessagingV1) Subscriptions(namespace string) v1.SubscriptionInterface {
 	return &FakeSubscriptions{c, namespace}
 }
@@ -22,4 +22,6 @@ type ChannelExpansion interface{}
 
 type InMemoryChannelExpansion interface{}
 
+type PersistentChannelExpansion interface{}
+
 type SubscriptionExpansion interface{}
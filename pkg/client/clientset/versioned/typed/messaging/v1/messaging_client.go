@@ -28,6 +28,7 @@ type MessagingV1Interface interface {
 	RESTClient() rest.Interface
 	ChannelsGetter
 	InMemoryChannelsGetter
+	PersistentChannelsGetter
 	SubscriptionsGetter
 }
 
@@ -44,6 +45,10 @@ func (c *MessagingV1Client) InMemoryChannels(namespace string) InMemoryChannelIn
 	return newInMemoryChannels(c, namespace)
 }
 
+func (c *MessagingV1Client) PersistentChannels(namespace string) PersistentChannelInterface {
+	return newPersistentChannels(c, namespace)
+}
+
 func (c *MessagingV1Client) Subscriptions(namespace string) SubscriptionInterface {
 	return newSubscriptions(c, namespace)
 }
@@ -98,6 +98,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Messaging().V1().Channels().Informer()}, nil
 	case messagingv1.SchemeGroupVersion.WithResource("inmemorychannels"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Messaging().V1().InMemoryChannels().Informer()}, nil
+	case messagingv1.SchemeGroupVersion.WithResource("persistentchannels"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Messaging().V1().PersistentChannels().Informer()}, nil
 	case messagingv1.SchemeGroupVersion.WithResource("subscriptions"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Messaging().V1().Subscriptions().Informer()}, nil
 
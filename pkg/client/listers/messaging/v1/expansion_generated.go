@@ -34,6 +34,14 @@ type InMemoryChannelListerExpansion interface{}
 // InMemoryChannelNamespaceLister.
 type InMemoryChannelNamespaceListerExpansion interface{}
 
+// PersistentChannelListerExpansion allows custom methods to be added to
+// PersistentChannelLister.
+type PersistentChannelListerExpansion interface{}
+
+// PersistentChannelNamespaceListerExpansion allows custom methods to be added to
+// PersistentChannelNamespaceLister.
+type PersistentChannelNamespaceListerExpansion interface{}
+
 // SubscriptionListerExpansion allows custom methods to be added to
 // SubscriptionLister.
 type SubscriptionListerExpansion interface{}
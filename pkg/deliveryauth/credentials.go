@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package deliveryauth provides the credentials the dispatchers use to authenticate to the
+// destinations of the events, read from the Secrets referenced by the DeliverySpecs.
+package deliveryauth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+
+	"go.opencensus.io/plugin/ochttp"
+	corev1 "k8s.io/api/core/v1"
+
+	"knative.dev/eventing/pkg/kncloudevents"
+)
+
+// The keys of the Secrets holding the credentials.
+const (
+	// TokenKey holds a bearer token.
+	TokenKey = "token"
+	// UsernameKey and PasswordKey hold the basic auth credentials.
+	UsernameKey = corev1.BasicAuthUsernameKey
+	PasswordKey = corev1.BasicAuthPasswordKey
+	// CertKey and PrivateKeyKey hold the PEM encoded client certificate and its key.
+	CertKey       = corev1.TLSCertKey
+	PrivateKeyKey = corev1.TLSPrivateKeyKey
+	// CAKey holds the PEM encoded CA bundle verifying the destination certificate.
+	CAKey = "ca.crt"
+)
+
+// Credentials authenticate the requests sent to a destination. A nil Credentials leaves the
+// requests untouched.
+type Credentials struct {
+	// authorization is the value of the Authorization header, if any.
+	authorization string
+	// sender sends the requests with the client certificate and the CA bundle, it is nil
+	// when the Secret holds neither.
+	sender *kncloudevents.HTTPMessageSender
+}
+
+// FromSecret creates the Credentials held by secret: either a bearer token or basic auth
+// credentials, and optionally a client certificate and a CA bundle.
+func FromSecret(secret *corev1.Secret) (*Credentials, error) {
+	creds := &Credentials{}
+
+	token := secret.Data[TokenKey]
+	username, password := secret.Data[UsernameKey], secret.Data[PasswordKey]
+	switch {
+	case len(token) > 0 && len(username) > 0:
+		return nil, fmt.Errorf("secret %s/%s holds both a token and basic auth credentials", secret.Namespace, secret.Name)
+	case len(token) > 0:
+		creds.authorization = "Bearer " + string(token)
+	case len(username) > 0:
+		creds.authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(string(username)+":"+string(password)))
+	}
+
+	tlsConfig, err := tlsConfigFromSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+	if tlsConfig != nil {
+		creds.sender = &kncloudevents.HTTPMessageSender{Client: kncloudevents.NewClientWithTLSConfig(tlsConfig)}
+	}
+
+	if creds.authorization == "" && creds.sender == nil {
+		return nil, fmt.Errorf("secret %s/%s holds no credentials", secret.Namespace, secret.Name)
+	}
+	return creds, nil
+}
+
+func tlsConfigFromSecret(secret *corev1.Secret) (*tls.Config, error) {
+	cert, key, ca := secret.Data[CertKey], secret.Data[PrivateKeyKey], secret.Data[CAKey]
+	if len(cert) == 0 && len(key) == 0 && len(ca) == 0 {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(cert) > 0 || len(key) > 0 {
+		certificate, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	if len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("invalid CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// Authorize sets the Authorization header of req.
+func (c *Credentials) Authorize(req *nethttp.Request) {
+	if c == nil || c.authorization == "" {
+		return
+	}
+	req.Header.Set("Authorization", c.authorization)
+}
+
+// Sender returns the sender of the requests authenticated by c, that is defaultSender unless c
+// holds a client certificate or a CA bundle.
+func (c *Credentials) Sender(defaultSender *kncloudevents.HTTPMessageSender) *kncloudevents.HTTPMessageSender {
+	if c == nil || c.sender == nil {
+		return defaultSender
+	}
+	return c.sender
+}
+
+// close releases the idle connections of c, once it is replaced by the rotated credentials.
+func (c *Credentials) close() {
+	if c == nil || c.sender == nil {
+		return
+	}
+	transport := c.sender.Client.Transport
+	if t, ok := transport.(*ochttp.Transport); ok {
+		transport = t.Base
+	}
+	if t, ok := transport.(interface{ CloseIdleConnections() }); ok {
+		t.CloseIdleConnections()
+	}
+}
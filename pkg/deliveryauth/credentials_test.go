@@ -0,0 +1,192 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deliveryauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/eventing/pkg/kncloudevents"
+)
+
+func TestFromSecret(t *testing.T) {
+	cert, key := newClientCertificate(t)
+	testCases := map[string]struct {
+		data          map[string][]byte
+		authorization string
+		tls           bool
+		wantErr       bool
+	}{
+		"token": {
+			data:          map[string][]byte{TokenKey: []byte("secret-token")},
+			authorization: "Bearer secret-token",
+		},
+		"basic auth": {
+			data:          map[string][]byte{UsernameKey: []byte("user"), PasswordKey: []byte("pass")},
+			authorization: "Basic dXNlcjpwYXNz",
+		},
+		"client certificate": {
+			data: map[string][]byte{CertKey: cert, PrivateKeyKey: key},
+			tls:  true,
+		},
+		"client certificate and token": {
+			data:          map[string][]byte{CertKey: cert, PrivateKeyKey: key, TokenKey: []byte("secret-token")},
+			authorization: "Bearer secret-token",
+			tls:           true,
+		},
+		"CA bundle": {
+			data: map[string][]byte{CAKey: cert},
+			tls:  true,
+		},
+		"empty": {
+			data:    map[string][]byte{"other": []byte("value")},
+			wantErr: true,
+		},
+		"token and basic auth": {
+			data:    map[string][]byte{TokenKey: []byte("secret-token"), UsernameKey: []byte("user")},
+			wantErr: true,
+		},
+		"certificate without key": {
+			data:    map[string][]byte{CertKey: cert},
+			wantErr: true,
+		},
+		"invalid CA bundle": {
+			data:    map[string][]byte{CAKey: []byte("not a certificate")},
+			wantErr: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			creds, err := FromSecret(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "creds"},
+				Data:       tc.data,
+			})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+
+			req := httptest.NewRequest(nethttp.MethodPost, "http://example.com", nil)
+			creds.Authorize(req)
+			if got := req.Header.Get("Authorization"); got != tc.authorization {
+				t.Errorf("Unexpected Authorization header, want %q, got %q", tc.authorization, got)
+			}
+			defaultSender := &kncloudevents.HTTPMessageSender{}
+			if got := creds.Sender(defaultSender) != defaultSender; got != tc.tls {
+				t.Errorf("Unexpected TLS sender, want %t, got %t", tc.tls, got)
+			}
+		})
+	}
+}
+
+func TestNilCredentials(t *testing.T) {
+	var creds *Credentials
+	req := httptest.NewRequest(nethttp.MethodPost, "http://example.com", nil)
+	creds.Authorize(req)
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Error("Unexpected Authorization header:", got)
+	}
+	defaultSender := &kncloudevents.HTTPMessageSender{}
+	if creds.Sender(defaultSender) != defaultSender {
+		t.Error("Expected the default sender")
+	}
+	creds.close()
+}
+
+func TestCredentialsTLS(t *testing.T) {
+	server := httptest.NewTLSServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(nethttp.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(nethttp.StatusAccepted)
+	}))
+	defer server.Close()
+
+	creds, err := FromSecret(&corev1.Secret{
+		Data: map[string][]byte{
+			TokenKey: []byte("secret-token"),
+			CAKey:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+		},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	sender := creds.Sender(&kncloudevents.HTTPMessageSender{})
+	req, err := sender.NewCloudEventRequestWithTarget(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds.Authorize(req)
+	res, err := sender.Send(req)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != nethttp.StatusAccepted {
+		t.Errorf("Unexpected status code, want %d, got %d", nethttp.StatusAccepted, res.StatusCode)
+	}
+}
+
+// newClientCertificate returns a self-signed PEM encoded certificate and its key.
+func newClientCertificate(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deliveryauth
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	"knative.dev/eventing/pkg/utils"
+)
+
+// secretCacheTTL is how long a Secret, or the error getting it, is cached. A rotated Secret is
+// used by the requests at most secretCacheTTL later.
+const secretCacheTTL = time.Minute
+
+// Resolver resolves the Credentials held by the Secrets labeled with
+// eventing.DeliveryAuthLabelKey. The Credentials of a Secret are created again once it
+// changes, so that the rotated credentials are used by the next requests.
+//
+// The Secrets are read by name, the ServiceAccount needs the get permission on the Secrets
+// used only, which the controllers grant through namespaced Roles.
+type Resolver struct {
+	secrets *utils.SecretCache
+
+	mutex       sync.Mutex
+	credentials map[types.NamespacedName]*cachedCredentials
+}
+
+type cachedCredentials struct {
+	resourceVersion string
+	credentials     *Credentials
+}
+
+// NewResolver creates a Resolver reading the Secrets from secrets.
+func NewResolver(secrets *utils.SecretCache) *Resolver {
+	return &Resolver{
+		secrets:     secrets,
+		credentials: make(map[types.NamespacedName]*cachedCredentials),
+	}
+}
+
+// NewResolverFromClient creates a Resolver getting the Secrets with client.
+func NewResolverFromClient(client kubernetes.Interface) *Resolver {
+	return NewResolver(utils.NewSecretCache(client.CoreV1(), secretCacheTTL))
+}
+
+// Get returns the Credentials held by the Secret name in namespace.
+func (r *Resolver) Get(namespace, name string) (*Credentials, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	secret, err := r.secrets.Get(namespace, name)
+	if apierrs.IsNotFound(err) || apierrs.IsForbidden(err) || (err == nil && secret.Labels[eventing.DeliveryAuthLabelKey] != "true") {
+		r.forget(key)
+		return nil, fmt.Errorf("secret %s not found, not readable, or not labeled %s=true", key, eventing.DeliveryAuthLabelKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if cached, ok := r.credentials[key]; ok && cached.resourceVersion == secret.ResourceVersion {
+		return cached.credentials, nil
+	}
+	creds, err := FromSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := r.credentials[key]; ok {
+		cached.credentials.close()
+	}
+	r.credentials[key] = &cachedCredentials{resourceVersion: secret.ResourceVersion, credentials: creds}
+	return creds, nil
+}
+
+func (r *Resolver) forget(key types.NamespacedName) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if cached, ok := r.credentials[key]; ok {
+		cached.credentials.close()
+		delete(r.credentials, key)
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deliveryauth
+
+import (
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	"knative.dev/eventing/pkg/utils"
+)
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	secrets := client.CoreV1().Secrets("ns")
+	resolver := NewResolver(utils.NewSecretCache(client.CoreV1(), 0))
+
+	if _, err := resolver.Get("ns", "creds"); err == nil {
+		t.Fatal("Expected an error for a missing secret, got nil")
+	}
+
+	unlabeled := tokenSecret("1", "first-token")
+	unlabeled.Labels = nil
+	if _, err := secrets.Create(ctx, unlabeled, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolver.Get("ns", "creds"); err == nil {
+		t.Fatal("Expected an error for a secret not labeled, got nil")
+	}
+
+	if _, err := secrets.Update(ctx, tokenSecret("1", "first-token"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	first, err := resolver.Get("ns", "creds")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	assertToken(t, first, "first-token")
+	if again, _ := resolver.Get("ns", "creds"); again != first {
+		t.Error("Expected the cached credentials of an unchanged secret")
+	}
+
+	// Rotate the token.
+	if _, err := secrets.Update(ctx, tokenSecret("2", "second-token"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	second, err := resolver.Get("ns", "creds")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	assertToken(t, second, "second-token")
+
+	// An invalid update fails without dropping the secret from the cache.
+	if _, err := secrets.Update(ctx, tokenSecret("3", ""), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolver.Get("ns", "creds"); err == nil {
+		t.Error("Expected an error for a secret holding no credentials, got nil")
+	}
+
+	if err := secrets.Delete(ctx, "creds", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolver.Get("ns", "creds"); err == nil {
+		t.Error("Expected an error for a deleted secret, got nil")
+	}
+	if len(resolver.credentials) != 0 {
+		t.Error("Expected the credentials of the deleted secret to be forgotten")
+	}
+}
+
+func tokenSecret(resourceVersion, token string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "ns",
+			Name:            "creds",
+			ResourceVersion: resourceVersion,
+			Labels:          map[string]string{eventing.DeliveryAuthLabelKey: "true"},
+		},
+		Data: map[string][]byte{TokenKey: []byte(token)},
+	}
+}
+
+func assertToken(t *testing.T, creds *Credentials, token string) {
+	t.Helper()
+	req := httptest.NewRequest(nethttp.MethodPost, "http://example.com", nil)
+	creds.Authorize(req)
+	if got, want := req.Header.Get("Authorization"), "Bearer "+token; got != want {
+		t.Errorf("Unexpected Authorization header, want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmarks
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cetest "github.com/cloudevents/sdk-go/v2/test"
+
+	"knative.dev/eventing/pkg/eventfilter"
+	"knative.dev/eventing/pkg/eventfilter/data"
+)
+
+func BenchmarkDataFilter(b *testing.B) {
+	event := cetest.FullEvent()
+	_ = event.SetData(cloudevents.ApplicationJSON, map[string]interface{}{
+		"status": "FAILED",
+		"order": map[string]interface{}{
+			"id":    "qwertyuiopasdfghjklzxcvbnm",
+			"total": 150,
+			"items": []interface{}{"a", "b", "c"},
+		},
+	})
+
+	RunFilterBenchmarks(b,
+		func(i interface{}) eventfilter.Filter {
+			filter, _ := data.NewDataFilter(i.(string))
+			return filter
+		},
+		FilterBenchmark{
+			name:  "Pass with exact match of top level field",
+			arg:   `$.status == "FAILED"`,
+			event: event,
+		},
+		FilterBenchmark{
+			name:  "Pass with comparison of nested field",
+			arg:   `$.order.total > 100`,
+			event: event,
+		},
+		FilterBenchmark{
+			name:  "No pass with missing nested field",
+			arg:   `$.order.customer.id == "qwertyuiopasdfghjklzxcvbnm"`,
+			event: event,
+		},
+	)
+}
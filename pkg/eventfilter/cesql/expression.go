@@ -0,0 +1,400 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cesql
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/types"
+)
+
+// Expression is a compiled expression. Evaluating an expression returns one of
+// the supported value types: string, int32 or bool.
+type Expression interface {
+	Evaluate(event cloudevents.Event) (interface{}, error)
+}
+
+// MissingAttributeError is returned when the expression refers to an attribute
+// not present in the event.
+type MissingAttributeError struct {
+	Name string
+}
+
+func (e *MissingAttributeError) Error() string {
+	return fmt.Sprintf("missing attribute %q", e.Name)
+}
+
+type literalExpression struct {
+	value interface{}
+}
+
+func (l literalExpression) Evaluate(cloudevents.Event) (interface{}, error) {
+	return l.value, nil
+}
+
+type attributeExpression struct {
+	name string
+}
+
+func (a attributeExpression) Evaluate(event cloudevents.Event) (interface{}, error) {
+	value, ok := attribute(event, a.name)
+	if !ok {
+		return nil, &MissingAttributeError{Name: a.name}
+	}
+	return value, nil
+}
+
+type existsExpression struct {
+	name string
+}
+
+func (e existsExpression) Evaluate(event cloudevents.Event) (interface{}, error) {
+	_, ok := attribute(event, e.name)
+	return ok, nil
+}
+
+type notExpression struct {
+	operand Expression
+}
+
+func (n notExpression) Evaluate(event cloudevents.Event) (interface{}, error) {
+	b, err := evaluateBoolean(n.operand, event)
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+type negateExpression struct {
+	operand Expression
+}
+
+func (n negateExpression) Evaluate(event cloudevents.Event) (interface{}, error) {
+	i, err := evaluateInteger(n.operand, event)
+	if err != nil {
+		return nil, err
+	}
+	return -i, nil
+}
+
+type logicalExpression struct {
+	operator    string
+	left, right Expression
+}
+
+func (l logicalExpression) Evaluate(event cloudevents.Event) (interface{}, error) {
+	left, err := evaluateBoolean(l.left, event)
+	if err != nil {
+		return nil, err
+	}
+	// Short circuit when the result is already known
+	switch {
+	case l.operator == "AND" && !left:
+		return false, nil
+	case l.operator == "OR" && left:
+		return true, nil
+	}
+	right, err := evaluateBoolean(l.right, event)
+	if err != nil {
+		return nil, err
+	}
+	if l.operator == "XOR" {
+		return left != right, nil
+	}
+	return right, nil
+}
+
+type arithmeticExpression struct {
+	operator    string
+	left, right Expression
+}
+
+func (a arithmeticExpression) Evaluate(event cloudevents.Event) (interface{}, error) {
+	left, err := evaluateInteger(a.left, event)
+	if err != nil {
+		return nil, err
+	}
+	right, err := evaluateInteger(a.right, event)
+	if err != nil {
+		return nil, err
+	}
+	switch a.operator {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	}
+	if right == 0 {
+		return nil, errors.New("division by zero")
+	}
+	if a.operator == "/" {
+		return left / right, nil
+	}
+	return left % right, nil
+}
+
+type comparisonExpression struct {
+	operator    string
+	left, right Expression
+}
+
+func (c comparisonExpression) Evaluate(event cloudevents.Event) (interface{}, error) {
+	switch c.operator {
+	case "=", "!=", "<>":
+		left, err := c.left.Evaluate(event)
+		if err != nil {
+			return nil, err
+		}
+		right, err := c.right.Evaluate(event)
+		if err != nil {
+			return nil, err
+		}
+		eq, err := equal(left, right)
+		if err != nil {
+			return nil, err
+		}
+		return eq == (c.operator == "="), nil
+	}
+
+	left, err := evaluateInteger(c.left, event)
+	if err != nil {
+		return nil, err
+	}
+	right, err := evaluateInteger(c.right, event)
+	if err != nil {
+		return nil, err
+	}
+	switch c.operator {
+	case "<":
+		return left < right, nil
+	case "<=":
+		return left <= right, nil
+	case ">":
+		return left > right, nil
+	default:
+		return left >= right, nil
+	}
+}
+
+type likeExpression struct {
+	operand Expression
+	pattern *regexp.Regexp
+}
+
+// newLikeExpression translates the LIKE pattern to a regular expression:
+// '%' matches any sequence of characters, '_' matches a single character,
+// and both can be escaped with a backslash.
+func newLikeExpression(operand Expression, pattern string) likeExpression {
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\' && i+1 < len(pattern) && (pattern[i+1] == '%' || pattern[i+1] == '_'):
+			sb.WriteString(regexp.QuoteMeta(string(pattern[i+1])))
+			i++
+		case c == '%':
+			sb.WriteString(".*")
+		case c == '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return likeExpression{operand: operand, pattern: regexp.MustCompile(sb.String())}
+}
+
+func (l likeExpression) Evaluate(event cloudevents.Event) (interface{}, error) {
+	value, err := l.operand.Evaluate(event)
+	if err != nil {
+		return nil, err
+	}
+	return l.pattern.MatchString(asString(value)), nil
+}
+
+type inExpression struct {
+	operand Expression
+	set     []Expression
+}
+
+func (in inExpression) Evaluate(event cloudevents.Event) (interface{}, error) {
+	value, err := in.operand.Evaluate(event)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range in.set {
+		itemValue, err := item.Evaluate(event)
+		if err != nil {
+			return nil, err
+		}
+		eq, err := equal(value, itemValue)
+		if err != nil {
+			return nil, err
+		}
+		if eq {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// attribute returns the value of the context attribute or extension with the provided name.
+// Optional context attributes which are not set are reported as missing.
+func attribute(event cloudevents.Event, name string) (interface{}, bool) {
+	var value string
+	switch name {
+	case "specversion":
+		value = event.SpecVersion()
+	case "id":
+		value = event.ID()
+	case "source":
+		value = event.Source()
+	case "type":
+		value = event.Type()
+	case "subject":
+		value = event.Subject()
+	case "dataschema":
+		value = event.DataSchema()
+	case "datacontenttype":
+		value = event.DataContentType()
+	case "time":
+		if event.Time().IsZero() {
+			return nil, false
+		}
+		value = types.FormatTime(event.Time())
+	default:
+		ext, ok := event.Extensions()[name]
+		if !ok {
+			return nil, false
+		}
+		switch v := ext.(type) {
+		case int32, bool, string:
+			return v, true
+		}
+		s, err := types.Format(ext)
+		if err != nil {
+			return nil, false
+		}
+		return s, true
+	}
+	return value, value != ""
+}
+
+// equal compares two values. When the types differ, the values are cast
+// to boolean if one of them is a boolean, otherwise to integer.
+func equal(left, right interface{}) (bool, error) {
+	switch l := left.(type) {
+	case string:
+		if r, ok := right.(string); ok {
+			return l == r, nil
+		}
+	case int32:
+		if r, ok := right.(int32); ok {
+			return l == r, nil
+		}
+	case bool:
+		if r, ok := right.(bool); ok {
+			return l == r, nil
+		}
+	}
+
+	_, leftBool := left.(bool)
+	_, rightBool := right.(bool)
+	if leftBool || rightBool {
+		l, err := asBoolean(left)
+		if err != nil {
+			return false, err
+		}
+		r, err := asBoolean(right)
+		if err != nil {
+			return false, err
+		}
+		return l == r, nil
+	}
+
+	l, err := asInteger(left)
+	if err != nil {
+		return false, err
+	}
+	r, err := asInteger(right)
+	if err != nil {
+		return false, err
+	}
+	return l == r, nil
+}
+
+func evaluateBoolean(expr Expression, event cloudevents.Event) (bool, error) {
+	value, err := expr.Evaluate(event)
+	if err != nil {
+		return false, err
+	}
+	return asBoolean(value)
+}
+
+func evaluateInteger(expr Expression, event cloudevents.Event) (int32, error) {
+	value, err := expr.Evaluate(event)
+	if err != nil {
+		return 0, err
+	}
+	return asInteger(value)
+}
+
+func asBoolean(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("cannot cast %v to boolean", value)
+}
+
+func asInteger(value interface{}) (int32, error) {
+	switch v := value.(type) {
+	case int32:
+		return v, nil
+	case string:
+		i, err := strconv.ParseInt(v, 10, 32)
+		if err == nil {
+			return int32(i), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot cast %v to integer", value)
+}
+
+func asString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int32:
+		return strconv.Itoa(int(v))
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cesql
+
+import (
+	"context"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+
+	"knative.dev/eventing/pkg/eventfilter"
+)
+
+type cesqlFilter struct {
+	expression Expression
+}
+
+// NewCESQLFilter returns an event filter which evaluates the provided SQL-like expression
+// against the event attributes and extensions. The event passes the filter only if the expression
+// evaluates to true. An expression which fails to evaluate, e.g. because it refers to a missing
+// attribute, doesn't pass the filter.
+func NewCESQLFilter(expression string) (eventfilter.Filter, error) {
+	expr, err := Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	return &cesqlFilter{expression: expr}, nil
+}
+
+func (f *cesqlFilter) Filter(ctx context.Context, event cloudevents.Event) eventfilter.FilterResult {
+	result, err := evaluateBoolean(f.expression, event)
+	if err != nil {
+		logging.FromContext(ctx).Debug("Failed to evaluate the expression", zap.Error(err))
+		return eventfilter.FailFilter
+	}
+	if result {
+		return eventfilter.PassFilter
+	}
+	return eventfilter.FailFilter
+}
+
+var _ eventfilter.Filter = &cesqlFilter{}
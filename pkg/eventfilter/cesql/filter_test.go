@@ -0,0 +1,167 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cesql
+
+import (
+	"context"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"knative.dev/eventing/pkg/eventfilter"
+)
+
+func TestCESQLFilter_Filter(t *testing.T) {
+	tests := map[string]struct {
+		expression string
+		want       eventfilter.FilterResult
+	}{
+		"Exact type": {
+			expression: "type = 'com.acme.order.created'",
+			want:       eventfilter.PassFilter,
+		},
+		"Wrong type": {
+			expression: "type = 'com.acme.order.deleted'",
+			want:       eventfilter.FailFilter,
+		},
+		"Not equal": {
+			expression: "type <> 'com.acme.order.deleted' AND type != 'other'",
+			want:       eventfilter.PassFilter,
+		},
+		"Like prefix and in": {
+			expression: "type LIKE 'com.acme.order.%' AND region IN ('eu', 'us')",
+			want:       eventfilter.PassFilter,
+		},
+		"Like single character": {
+			expression: "region LIKE 'e_'",
+			want:       eventfilter.PassFilter,
+		},
+		"Like escaped wildcard": {
+			expression: "source LIKE '/orders\\%'",
+			want:       eventfilter.FailFilter,
+		},
+		"Not like": {
+			expression: "type NOT LIKE '%.deleted'",
+			want:       eventfilter.PassFilter,
+		},
+		"Not in": {
+			expression: "region NOT IN ('eu', 'us')",
+			want:       eventfilter.FailFilter,
+		},
+		"Exists": {
+			expression: "EXISTS region AND NOT EXISTS missing",
+			want:       eventfilter.PassFilter,
+		},
+		"Missing attribute": {
+			expression: "missing = 'value'",
+			want:       eventfilter.FailFilter,
+		},
+		"Missing attribute in short circuited or": {
+			expression: "type = 'com.acme.order.created' OR missing = 'value'",
+			want:       eventfilter.PassFilter,
+		},
+		"Integer extension comparison": {
+			expression: "priority > 3 AND priority <= 5",
+			want:       eventfilter.PassFilter,
+		},
+		"Integer comparison with string cast": {
+			expression: "amount >= 100",
+			want:       eventfilter.PassFilter,
+		},
+		"Arithmetic": {
+			expression: "priority * 2 - 1 = 9 AND -priority % 3 = -2",
+			want:       eventfilter.PassFilter,
+		},
+		"Division by zero": {
+			expression: "priority / 0 = 1",
+			want:       eventfilter.FailFilter,
+		},
+		"Boolean extension": {
+			expression: "urgent = TRUE XOR FALSE",
+			want:       eventfilter.PassFilter,
+		},
+		"Boolean cast of string": {
+			expression: "urgent = 'true'",
+			want:       eventfilter.PassFilter,
+		},
+		"Non castable comparison": {
+			expression: "type > 1",
+			want:       eventfilter.FailFilter,
+		},
+		"Non boolean result": {
+			expression: "type",
+			want:       eventfilter.FailFilter,
+		},
+		"Parenthesis": {
+			expression: "(region = 'eu' OR region = 'us') AND NOT (type = 'other')",
+			want:       eventfilter.PassFilter,
+		},
+		"Keywords are case insensitive": {
+			expression: "type like 'com.%' and exists region",
+			want:       eventfilter.PassFilter,
+		},
+		"Unset optional attribute": {
+			expression: "EXISTS subject",
+			want:       eventfilter.FailFilter,
+		},
+	}
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			f, err := NewCESQLFilter(tc.expression)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := f.Filter(context.TODO(), makeEvent()); got != tc.want {
+				t.Errorf("Filter(%q) = %s, want %s", tc.expression, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"type =",
+		"type = 'unterminated",
+		"type LIKE 1",
+		"type NOT = 'a'",
+		"region IN ('eu'",
+		"(type = 'a'",
+		"type = 'a' type",
+		"EXISTS 'type'",
+		"type # 'a'",
+		"priority = 99999999999",
+	} {
+		t.Run(expression, func(t *testing.T) {
+			if _, err := Parse(expression); err == nil {
+				t.Errorf("Parse(%q) expected an error", expression)
+			}
+		})
+	}
+}
+
+func makeEvent() cloudevents.Event {
+	e := cloudevents.NewEvent()
+	e.SetType("com.acme.order.created")
+	e.SetSource("/orders")
+	e.SetID("1234")
+	e.SetExtension("region", "eu")
+	e.SetExtension("priority", 5)
+	e.SetExtension("amount", "150")
+	e.SetExtension("urgent", true)
+	return e
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cesql
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenString
+	tokenInteger
+	tokenKeyword
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+// keywords are matched case insensitively and stored upper case in the token value.
+var keywords = map[string]struct{}{
+	"AND":    {},
+	"OR":     {},
+	"XOR":    {},
+	"NOT":    {},
+	"LIKE":   {},
+	"IN":     {},
+	"EXISTS": {},
+	"TRUE":   {},
+	"FALSE":  {},
+}
+
+type token struct {
+	kind  tokenKind
+	value string
+	// pos is the offset of the token in the expression, used for error reporting.
+	pos int
+}
+
+func (t token) is(kind tokenKind, value string) bool {
+	return t.kind == kind && t.value == value
+}
+
+// tokenize splits the expression into tokens. The last token is always tokenEOF.
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(expression) {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, value: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, value: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ",", pos: i})
+			i++
+		case c == '\'' || c == '"':
+			value, n, err := readString(expression[i:])
+			if err != nil {
+				return nil, fmt.Errorf("position %d: %w", i, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value, pos: i})
+			i += n
+		case isDigit(c):
+			start := i
+			for i < len(expression) && isDigit(expression[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenInteger, value: expression[start:i], pos: start})
+		case isLetter(c):
+			start := i
+			for i < len(expression) && (isLetter(expression[i]) || isDigit(expression[i])) {
+				i++
+			}
+			word := expression[start:i]
+			if _, ok := keywords[strings.ToUpper(word)]; ok {
+				tokens = append(tokens, token{kind: tokenKeyword, value: strings.ToUpper(word), pos: start})
+			} else {
+				tokens = append(tokens, token{kind: tokenIdentifier, value: word, pos: start})
+			}
+		default:
+			op := readOperator(expression[i:])
+			if op == "" {
+				return nil, fmt.Errorf("position %d: unexpected character %q", i, c)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, value: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expression)}), nil
+}
+
+// readString reads a quoted string literal, returning its unescaped value and the
+// number of bytes consumed. The quote character can be escaped with a backslash.
+func readString(s string) (string, int, error) {
+	quote := s[0]
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == quote || s[i+1] == '\\') {
+				sb.WriteByte(s[i+1])
+				i++
+				continue
+			}
+			sb.WriteByte(s[i])
+		case quote:
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string literal")
+}
+
+func readOperator(s string) string {
+	for _, op := range []string{"!=", "<>", "<=", ">=", "=", "<", ">", "+", "-", "*", "/", "%"} {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
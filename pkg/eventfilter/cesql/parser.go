@@ -0,0 +1,280 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cesql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Parse compiles the provided expression. The grammar, from the lowest to the highest precedence, is:
+//
+//	expression     = or
+//	or             = xor { "OR" xor }
+//	xor            = and { "XOR" and }
+//	and            = not { "AND" not }
+//	not            = "NOT" not | comparison
+//	comparison     = additive [ ( "=" | "!=" | "<>" | "<" | "<=" | ">" | ">=" ) additive
+//	                          | [ "NOT" ] "LIKE" string
+//	                          | [ "NOT" ] "IN" "(" additive { "," additive } ")" ]
+//	additive       = multiplicative { ( "+" | "-" ) multiplicative }
+//	multiplicative = unary { ( "*" | "/" | "%" ) unary }
+//	unary          = "-" unary | primary
+//	primary        = integer | string | "TRUE" | "FALSE" | "EXISTS" identifier | identifier | "(" expression ")"
+func Parse(expression string) (Expression, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("position %d: unexpected %q", t.pos, t.value)
+	}
+	return expr, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(kind tokenKind, value string) bool {
+	if p.peek().is(kind, value) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, value string) error {
+	if t := p.next(); !t.is(kind, value) {
+		return unexpected(t, value)
+	}
+	return nil
+}
+
+func unexpected(t token, want string) error {
+	if t.kind == tokenEOF {
+		return fmt.Errorf("position %d: unexpected end of expression, expected %s", t.pos, want)
+	}
+	return fmt.Errorf("position %d: unexpected %q, expected %s", t.pos, t.value, want)
+}
+
+func (p *parser) parseOr() (Expression, error) {
+	return p.parseLogical("OR", p.parseXor)
+}
+
+func (p *parser) parseXor() (Expression, error) {
+	return p.parseLogical("XOR", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (Expression, error) {
+	return p.parseLogical("AND", p.parseNot)
+}
+
+func (p *parser) parseLogical(operator string, operand func() (Expression, error)) (Expression, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenKeyword, operator) {
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpression{operator: operator, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expression, error) {
+	if p.accept(tokenKeyword, "NOT") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpression{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expression, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind == tokenOperator {
+		switch t.value {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.next()
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return comparisonExpression{operator: t.value, left: left, right: right}, nil
+		}
+	}
+
+	negate := false
+	if t.is(tokenKeyword, "NOT") {
+		// NOT here can only introduce NOT LIKE or NOT IN
+		p.next()
+		negate = true
+	}
+
+	var expr Expression
+	switch {
+	case p.accept(tokenKeyword, "LIKE"):
+		pattern := p.next()
+		if pattern.kind != tokenString {
+			return nil, unexpected(pattern, "a string pattern")
+		}
+		expr = newLikeExpression(left, pattern.value)
+	case p.accept(tokenKeyword, "IN"):
+		if err := p.expect(tokenLeftParen, "("); err != nil {
+			return nil, err
+		}
+		var set []Expression
+		for {
+			item, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, item)
+			if !p.accept(tokenComma, ",") {
+				break
+			}
+		}
+		if err := p.expect(tokenRightParen, ")"); err != nil {
+			return nil, err
+		}
+		expr = inExpression{operand: left, set: set}
+	case negate:
+		return nil, unexpected(p.peek(), "LIKE or IN")
+	default:
+		return left, nil
+	}
+
+	if negate {
+		return notExpression{operand: expr}, nil
+	}
+	return expr, nil
+}
+
+func (p *parser) parseAdditive() (Expression, error) {
+	return p.parseArithmetic(p.parseMultiplicative, "+", "-")
+}
+
+func (p *parser) parseMultiplicative() (Expression, error) {
+	return p.parseArithmetic(p.parseUnary, "*", "/", "%")
+}
+
+func (p *parser) parseArithmetic(operand func() (Expression, error), operators ...string) (Expression, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokenOperator || !contains(operators, t.value) {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmeticExpression{operator: t.value, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expression, error) {
+	if p.accept(tokenOperator, "-") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negateExpression{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expression, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenInteger:
+		i, err := strconv.ParseInt(t.value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("position %d: invalid integer %q: %w", t.pos, t.value, err)
+		}
+		return literalExpression{value: int32(i)}, nil
+	case tokenString:
+		return literalExpression{value: t.value}, nil
+	case tokenIdentifier:
+		return attributeExpression{name: t.value}, nil
+	case tokenLeftParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenRightParen, ")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case tokenKeyword:
+		switch t.value {
+		case "TRUE":
+			return literalExpression{value: true}, nil
+		case "FALSE":
+			return literalExpression{value: false}, nil
+		case "EXISTS":
+			ident := p.next()
+			if ident.kind != tokenIdentifier {
+				return nil, unexpected(ident, "an attribute name")
+			}
+			return existsExpression{name: ident.value}, nil
+		}
+	}
+	return nil, unexpected(t, "a value")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
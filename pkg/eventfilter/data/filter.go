@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"context"
+	"mime"
+	"strings"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+
+	"knative.dev/eventing/pkg/eventfilter"
+)
+
+type dataFilter struct {
+	predicate *Predicate
+}
+
+// NewDataFilter returns an event filter which evaluates the provided JSONPath predicate
+// against the event data. The data is parsed only when the filter is evaluated, and only
+// along the predicate path. Events without data, or whose data isn't JSON, don't pass the filter.
+func NewDataFilter(predicate string) (eventfilter.Filter, error) {
+	p, err := ParsePredicate(predicate)
+	if err != nil {
+		return nil, err
+	}
+	return &dataFilter{predicate: p}, nil
+}
+
+func (f *dataFilter) Filter(ctx context.Context, event cloudevents.Event) eventfilter.FilterResult {
+	if !isJSON(event.DataContentType()) {
+		logging.FromContext(ctx).Debug("Data is not JSON", zap.String("datacontenttype", event.DataContentType()))
+		return eventfilter.FailFilter
+	}
+	if len(event.Data()) == 0 {
+		return eventfilter.FailFilter
+	}
+	if !f.predicate.Evaluate(event.Data()) {
+		logging.FromContext(ctx).Debug("Data predicate not satisfied", zap.Stringer("predicate", f.predicate))
+		return eventfilter.FailFilter
+	}
+	return eventfilter.PassFilter
+}
+
+var _ eventfilter.Filter = &dataFilter{}
+
+// isJSON returns true for JSON media types, including the structured syntax suffix +json.
+// An empty content type is treated as JSON, as that's the default for CloudEvents.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
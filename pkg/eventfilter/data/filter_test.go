@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"context"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"knative.dev/eventing/pkg/eventfilter"
+)
+
+func TestDataFilter(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		data        []byte
+		want        eventfilter.FilterResult
+	}{
+		"JSON": {
+			contentType: cloudevents.ApplicationJSON,
+			data:        []byte(document),
+			want:        eventfilter.PassFilter,
+		},
+		"JSON with parameters": {
+			contentType: "application/json; charset=utf-8",
+			data:        []byte(document),
+			want:        eventfilter.PassFilter,
+		},
+		"JSON structured syntax suffix": {
+			contentType: "application/vnd.example+json",
+			data:        []byte(document),
+			want:        eventfilter.PassFilter,
+		},
+		"No content type": {
+			data: []byte(document),
+			want: eventfilter.PassFilter,
+		},
+		"Predicate not satisfied": {
+			contentType: cloudevents.ApplicationJSON,
+			data:        []byte(`{"status": "OK"}`),
+			want:        eventfilter.FailFilter,
+		},
+		"Not JSON": {
+			contentType: cloudevents.TextPlain,
+			data:        []byte(document),
+			want:        eventfilter.FailFilter,
+		},
+		"Malformed JSON": {
+			contentType: cloudevents.ApplicationJSON,
+			data:        []byte(`{"status": "FAILED"`),
+			want:        eventfilter.FailFilter,
+		},
+		"No data": {
+			contentType: cloudevents.ApplicationJSON,
+			want:        eventfilter.FailFilter,
+		},
+	}
+	f, err := NewDataFilter(`$.status == "FAILED"`)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			e := cloudevents.NewEvent()
+			e.SetType("dev.knative.example")
+			e.SetSource("/example")
+			e.SetID("1234")
+			e.SetDataContentType(tc.contentType)
+			e.DataEncoded = tc.data
+			if got := f.Filter(context.TODO(), e); got != tc.want {
+				t.Errorf("Filter() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathStep is a single step of a JSONPath. Exactly one of field and index is meaningful:
+// field is used when isIndex is false.
+type pathStep struct {
+	field   string
+	index   int
+	isIndex bool
+}
+
+// jsonPath is a parsed JSONPath, supporting the root ($), dot notation (.field),
+// bracket notation (['field']) and array indexes ([0]).
+type jsonPath []pathStep
+
+// parsePath parses the JSONPath at the beginning of s, returning the path and the number of consumed bytes.
+func parsePath(s string) (jsonPath, int, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, 0, fmt.Errorf("path must start with $")
+	}
+	var path jsonPath
+	i := 1
+	for i < len(s) {
+		switch s[i] {
+		case '.':
+			start := i + 1
+			i = start
+			for i < len(s) && isFieldChar(s[i]) {
+				i++
+			}
+			if i == start {
+				return nil, 0, fmt.Errorf("position %d: expected a field name", start)
+			}
+			path = append(path, pathStep{field: s[start:i]})
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, 0, fmt.Errorf("position %d: unterminated [", i)
+			}
+			inner := strings.TrimSpace(s[i+1 : i+end])
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				path = append(path, pathStep{field: inner[1 : len(inner)-1]})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, 0, fmt.Errorf("position %d: invalid index %q", i+1, inner)
+				}
+				path = append(path, pathStep{index: index, isIndex: true})
+			}
+			i += end + 1
+		default:
+			return path, i, nil
+		}
+	}
+	return path, i, nil
+}
+
+func isFieldChar(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (p jsonPath) String() string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, step := range p {
+		if step.isIndex {
+			sb.WriteString("[" + strconv.Itoa(step.index) + "]")
+		} else {
+			sb.WriteString("['" + step.field + "']")
+		}
+	}
+	return sb.String()
+}
+
+// lookup walks the path in the provided JSON document and returns the selected value.
+// Only the objects and arrays on the path are decoded, the rest of the document is left as raw JSON.
+func (p jsonPath) lookup(doc json.RawMessage) (interface{}, bool) {
+	current := doc
+	for _, step := range p {
+		if step.isIndex {
+			var array []json.RawMessage
+			if err := json.Unmarshal(current, &array); err != nil || step.index >= len(array) {
+				return nil, false
+			}
+			current = array[step.index]
+		} else {
+			var object map[string]json.RawMessage
+			if err := json.Unmarshal(current, &object); err != nil {
+				return nil, false
+			}
+			v, ok := object[step.field]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		}
+	}
+	var value interface{}
+	if err := json.Unmarshal(current, &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Predicate is a parsed JSONPath predicate.
+type Predicate struct {
+	path     jsonPath
+	operator string
+	// value is the decoded JSON literal the selected value is compared with.
+	value interface{}
+}
+
+var operators = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+// ParsePredicate parses a predicate of the form "<path> <operator> <literal>", e.g.
+// `$.order.total > 100` or `$.status == "FAILED"`. The operator is one of ==, !=, >, >=, < and <=,
+// and the literal is any JSON scalar: a number, a string, true, false or null. String literals
+// can also be single quoted. The operator and the literal can be omitted, in which case the
+// predicate holds if the path exists in the data.
+func ParsePredicate(predicate string) (*Predicate, error) {
+	predicate = strings.TrimSpace(predicate)
+	path, n, err := parsePath(predicate)
+	if err != nil {
+		return nil, err
+	}
+	rest := strings.TrimSpace(predicate[n:])
+	if rest == "" {
+		return &Predicate{path: path}, nil
+	}
+
+	p := &Predicate{path: path}
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			p.operator = op
+			break
+		}
+	}
+	if p.operator == "" {
+		return nil, fmt.Errorf("unexpected %q, expected one of %s", rest, strings.Join(operators, ", "))
+	}
+
+	literal := strings.TrimSpace(rest[len(p.operator):])
+	if len(literal) >= 2 && literal[0] == '\'' && literal[len(literal)-1] == '\'' {
+		p.value = literal[1 : len(literal)-1]
+	} else if err := json.Unmarshal([]byte(literal), &p.value); err != nil {
+		return nil, fmt.Errorf("invalid literal %q: %w", literal, err)
+	}
+	switch p.value.(type) {
+	case map[string]interface{}, []interface{}:
+		return nil, fmt.Errorf("invalid literal %q: only scalar values are supported", literal)
+	}
+	return p, nil
+}
+
+// Evaluate evaluates the predicate against the provided JSON document.
+func (p *Predicate) Evaluate(doc json.RawMessage) bool {
+	value, ok := p.path.lookup(doc)
+	if !ok {
+		return false
+	}
+	switch p.operator {
+	case "":
+		return true
+	case "==":
+		return value == p.value
+	case "!=":
+		return value != p.value
+	}
+
+	switch want := p.value.(type) {
+	case float64:
+		got, ok := value.(float64)
+		return ok && compare(p.operator, got < want, got == want)
+	case string:
+		got, ok := value.(string)
+		return ok && compare(p.operator, got < want, got == want)
+	}
+	return false
+}
+
+func compare(operator string, less, equal bool) bool {
+	switch operator {
+	case "<":
+		return less
+	case "<=":
+		return less || equal
+	case ">":
+		return !less && !equal
+	default:
+		return !less
+	}
+}
+
+func (p *Predicate) String() string {
+	if p.operator == "" {
+		return p.path.String()
+	}
+	literal, _ := json.Marshal(p.value)
+	return p.path.String() + " " + p.operator + " " + string(literal)
+}
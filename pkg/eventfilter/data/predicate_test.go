@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"testing"
+)
+
+const document = `{
+	"status": "FAILED",
+	"order": {"id": "o-1", "total": 150.5, "express": true, "coupon": null},
+	"items": [{"sku": "a-1", "quantity": 2}, {"sku": "b-2", "quantity": 1}],
+	"content-type": "json"
+}`
+
+func TestPredicate(t *testing.T) {
+	tests := map[string]bool{
+		`$.status == "FAILED"`:           true,
+		`$.status == 'FAILED'`:           true,
+		`$.status != "FAILED"`:           false,
+		`$.status > "A"`:                 true,
+		`$.order.total > 100`:            true,
+		`$.order.total >= 150.5`:         true,
+		`$.order.total < 100`:            false,
+		`$.order.total <= 150`:           false,
+		`$.order.total > "100"`:          false,
+		`$.order.total == "150.5"`:       false,
+		`$.order.express == true`:        true,
+		`$.order.coupon == null`:         true,
+		`$.order.id`:                     true,
+		`$.order.missing`:                false,
+		`$.order.missing != "x"`:         false,
+		`$.items[1].sku == "b-2"`:        true,
+		`$.items[2].sku`:                 false,
+		`$['items'][0]['quantity'] == 2`: true,
+		`$["content-type"] == "json"`:    true,
+		`$.content-type == "json"`:       true,
+		`$.status.nested`:                false,
+		`$.items.sku`:                    false,
+		`  $.order.total   >   150  `:    true,
+		`$ == "FAILED"`:                  false,
+		`$.items[0].quantity <= 2`:       true,
+		`$.items[0].quantity > 2`:        false,
+	}
+	for predicate, want := range tests {
+		t.Run(predicate, func(t *testing.T) {
+			p, err := ParsePredicate(predicate)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := p.Evaluate([]byte(document)); got != want {
+				t.Errorf("Evaluate(%q) = %t, want %t", predicate, got, want)
+			}
+		})
+	}
+}
+
+func TestParsePredicateErrors(t *testing.T) {
+	for _, predicate := range []string{
+		``,
+		`status == "FAILED"`,
+		`$.`,
+		`$.items[`,
+		`$.items[-1]`,
+		`$.items[a]`,
+		`$.status = "FAILED"`,
+		`$.status == FAILED`,
+		`$.status ==`,
+		`$.order == {"id": "o-1"}`,
+	} {
+		t.Run(predicate, func(t *testing.T) {
+			if _, err := ParsePredicate(predicate); err == nil {
+				t.Errorf("ParsePredicate(%q) expected an error", predicate)
+			}
+		})
+	}
+}
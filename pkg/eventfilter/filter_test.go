@@ -66,6 +66,26 @@ func TestFilters(t *testing.T) {
 	}
 }
 
+func TestFilterResultOr(t *testing.T) {
+	tests := []struct {
+		x, y FilterResult
+		want FilterResult
+	}{
+		{x: NoFilter, y: NoFilter, want: NoFilter},
+		{x: NoFilter, y: PassFilter, want: PassFilter},
+		{x: FailFilter, y: NoFilter, want: FailFilter},
+		{x: PassFilter, y: FailFilter, want: PassFilter},
+		{x: FailFilter, y: PassFilter, want: PassFilter},
+		{x: FailFilter, y: FailFilter, want: FailFilter},
+		{x: PassFilter, y: PassFilter, want: PassFilter},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("'%s' or '%s' = '%s'", tt.x, tt.y, tt.want), func(t *testing.T) {
+			require.Equal(t, tt.want, tt.x.Or(tt.y))
+		})
+	}
+}
+
 func testName(res []FilterResult, want FilterResult) string {
 	if len(res) != 0 {
 		var operands []string
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriptionsapi
+
+import (
+	"context"
+	"strings"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+
+	"knative.dev/eventing/pkg/eventfilter"
+)
+
+type attributesFilter struct {
+	attrs   map[string]string
+	dialect string
+	match   func(value, want string) bool
+}
+
+// NewExactFilter returns an event filter which passes if the values of all the provided context
+// attributes are equal to the provided values.
+func NewExactFilter(attrs map[string]string) eventfilter.Filter {
+	return &attributesFilter{
+		attrs:   attrs,
+		dialect: "exact",
+		match: func(value, want string) bool {
+			return value == want
+		},
+	}
+}
+
+// NewPrefixFilter returns an event filter which passes if the values of all the provided context
+// attributes start with the provided values.
+func NewPrefixFilter(attrs map[string]string) eventfilter.Filter {
+	return &attributesFilter{
+		attrs:   attrs,
+		dialect: "prefix",
+		match:   strings.HasPrefix,
+	}
+}
+
+// NewSuffixFilter returns an event filter which passes if the values of all the provided context
+// attributes end with the provided values.
+func NewSuffixFilter(attrs map[string]string) eventfilter.Filter {
+	return &attributesFilter{
+		attrs:   attrs,
+		dialect: "suffix",
+		match:   strings.HasSuffix,
+	}
+}
+
+func (f *attributesFilter) Filter(ctx context.Context, event cloudevents.Event) eventfilter.FilterResult {
+	if len(f.attrs) == 0 {
+		return eventfilter.NoFilter
+	}
+	for k, want := range f.attrs {
+		value, ok := attributeValue(event, k)
+		if !ok {
+			logging.FromContext(ctx).Debug("Attribute not found", zap.String("attribute", k))
+			return eventfilter.FailFilter
+		}
+		if !f.match(value, want) {
+			logging.FromContext(ctx).Debug("Attribute had non-matching value", zap.String("dialect", f.dialect),
+				zap.String("attribute", k), zap.String("filter", want), zap.String("received", value))
+			return eventfilter.FailFilter
+		}
+	}
+	return eventfilter.PassFilter
+}
+
+var _ eventfilter.Filter = &attributesFilter{}
+
+// attributeValue returns the string representation of the context attribute or extension
+// with the provided name. Optional context attributes which are not set are reported as missing.
+func attributeValue(event cloudevents.Event, name string) (string, bool) {
+	var value string
+	switch name {
+	case "specversion":
+		value = event.SpecVersion()
+	case "id":
+		value = event.ID()
+	case "source":
+		value = event.Source()
+	case "type":
+		value = event.Type()
+	case "subject":
+		value = event.Subject()
+	case "dataschema":
+		value = event.DataSchema()
+	case "datacontenttype":
+		value = event.DataContentType()
+	case "time":
+		if !event.Time().IsZero() {
+			value = types.FormatTime(event.Time())
+		}
+	default:
+		ext, ok := event.Extensions()[name]
+		if !ok {
+			return "", false
+		}
+		s, err := types.Format(ext)
+		return s, err == nil
+	}
+	return value, value != ""
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriptionsapi
+
+import (
+	"context"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"knative.dev/eventing/pkg/eventfilter"
+)
+
+const (
+	eventType      = "dev.knative.example.created"
+	eventSource    = "/apis/v1/namespaces/prod/pingsources/ping"
+	extensionName  = "myextension"
+	extensionValue = "my-extension-value"
+)
+
+func TestAttributesFilters(t *testing.T) {
+	tests := map[string]struct {
+		filter eventfilter.Filter
+		want   eventfilter.FilterResult
+	}{
+		"Exact match": {
+			filter: NewExactFilter(map[string]string{"type": eventType, extensionName: extensionValue}),
+			want:   eventfilter.PassFilter,
+		},
+		"Exact no match": {
+			filter: NewExactFilter(map[string]string{"type": eventType + ".v2"}),
+			want:   eventfilter.FailFilter,
+		},
+		"Exact missing attribute": {
+			filter: NewExactFilter(map[string]string{"subject": ""}),
+			want:   eventfilter.FailFilter,
+		},
+		"Exact empty": {
+			filter: NewExactFilter(nil),
+			want:   eventfilter.NoFilter,
+		},
+		"Prefix match": {
+			filter: NewPrefixFilter(map[string]string{"source": "/apis/v1/namespaces/prod"}),
+			want:   eventfilter.PassFilter,
+		},
+		"Prefix no match": {
+			filter: NewPrefixFilter(map[string]string{"source": "/apis/v1/namespaces/dev"}),
+			want:   eventfilter.FailFilter,
+		},
+		"Prefix missing extension": {
+			filter: NewPrefixFilter(map[string]string{"missing": "my"}),
+			want:   eventfilter.FailFilter,
+		},
+		"Suffix match": {
+			filter: NewSuffixFilter(map[string]string{"type": ".created", extensionName: "-value"}),
+			want:   eventfilter.PassFilter,
+		},
+		"Suffix no match": {
+			filter: NewSuffixFilter(map[string]string{"type": ".deleted"}),
+			want:   eventfilter.FailFilter,
+		},
+	}
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			if got := tc.filter.Filter(context.TODO(), makeEvent()); got != tc.want {
+				t.Errorf("Filter() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func makeEvent() cloudevents.Event {
+	e := cloudevents.NewEvent()
+	e.SetType(eventType)
+	e.SetSource(eventSource)
+	e.SetID("1234")
+	e.SetExtension(extensionName, extensionValue)
+	return e
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriptionsapi
+
+import (
+	"context"
+	"testing"
+
+	"knative.dev/eventing/pkg/eventfilter"
+)
+
+var (
+	passFilter = NewExactFilter(map[string]string{"type": eventType})
+	failFilter = NewExactFilter(map[string]string{"type": "other"})
+	noFilter   = NewExactFilter(nil)
+)
+
+func TestCompositeFilters(t *testing.T) {
+	tests := map[string]struct {
+		filter eventfilter.Filter
+		want   eventfilter.FilterResult
+	}{
+		"All pass": {
+			filter: NewAllFilter(passFilter, passFilter),
+			want:   eventfilter.PassFilter,
+		},
+		"All with one failing": {
+			filter: NewAllFilter(passFilter, failFilter),
+			want:   eventfilter.FailFilter,
+		},
+		"All empty": {
+			filter: NewAllFilter(),
+			want:   eventfilter.NoFilter,
+		},
+		"Any with one passing": {
+			filter: NewAnyFilter(failFilter, passFilter),
+			want:   eventfilter.PassFilter,
+		},
+		"Any all failing": {
+			filter: NewAnyFilter(failFilter, failFilter),
+			want:   eventfilter.FailFilter,
+		},
+		"Any ignores no filter": {
+			filter: NewAnyFilter(noFilter, failFilter),
+			want:   eventfilter.FailFilter,
+		},
+		"Any empty": {
+			filter: NewAnyFilter(),
+			want:   eventfilter.NoFilter,
+		},
+		"Not passing": {
+			filter: NewNotFilter(passFilter),
+			want:   eventfilter.FailFilter,
+		},
+		"Not failing": {
+			filter: NewNotFilter(failFilter),
+			want:   eventfilter.PassFilter,
+		},
+		"Not no filter": {
+			filter: NewNotFilter(noFilter),
+			want:   eventfilter.NoFilter,
+		},
+		"Nested": {
+			filter: NewAllFilter(
+				NewPrefixFilter(map[string]string{"source": "/apis/v1/namespaces/prod"}),
+				NewNotFilter(NewAnyFilter(failFilter, NewSuffixFilter(map[string]string{"type": ".deleted"}))),
+			),
+			want: eventfilter.PassFilter,
+		},
+	}
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			if got := tc.filter.Filter(context.TODO(), makeEvent()); got != tc.want {
+				t.Errorf("Filter() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,779 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package eventschema validates the JSON payloads of events against JSON Schemas.
+//
+// It implements the subset of JSON Schema draft 7 that the schemas of EventTypes
+// need, with these keywords:
+//   - $ref, to the references local to the schema only: "#" and JSON pointers
+//     such as "#/definitions/name"
+//   - type, enum, const
+//   - required, properties, patternProperties, additionalProperties,
+//     propertyNames, minProperties, maxProperties
+//   - items, additionalItems, contains, minItems, maxItems, uniqueItems
+//   - minLength, maxLength, pattern
+//   - minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf
+//   - allOf, anyOf, oneOf, not
+//
+// The format, content and conditional (if/then/else, dependencies) keywords are
+// ignored like the other unknown keywords, and the patterns are RE2 regular
+// expressions rather than ECMA 262 ones. The schemas referencing themselves without
+// descending into the validated values, such as {"$ref": "#"}, are rejected.
+//
+// The schemas are written by the users of the cluster and applied by the shared
+// Broker ingress, so unlike the general purpose JSON Schema libraries it never
+// fetches remote references, and bounds the work done per event. It has no
+// dependency outside of the standard library.
+package eventschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDepth bounds the number of schemas applied to validate a document, through the nested values
+// of the document and the references.
+const maxDepth = 1000
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	root *schema
+}
+
+// Compile parses the JSON Schema data.
+func Compile(data []byte) (*Schema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %w", err)
+	}
+	c := &compiler{doc: doc, refs: make(map[string]*schema)}
+	root, err := c.compile(doc, "#")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCycles(root); err != nil {
+		return nil, err
+	}
+	return &Schema{root: root}, nil
+}
+
+// Validate returns an error describing the first violation of the schema by the JSON
+// document data, nil if data is valid.
+func (s *Schema) Validate(data []byte) error {
+	var instance interface{}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &instance); err != nil {
+			return fmt.Errorf("data is not valid JSON: %w", err)
+		}
+	}
+	return s.root.validate(instance, "", 0)
+}
+
+// schema is a compiled schema, or subschema. Nil fields are absent keywords.
+type schema struct {
+	// always is the result of the boolean schemas, true and false.
+	always *bool
+
+	ref *schema
+
+	types    []string
+	enum     []interface{}
+	constant *interface{}
+
+	required             []string
+	properties           map[string]*schema
+	patternProperties    []patternSchema
+	additionalProperties *schema
+	propertyNames        *schema
+	minProperties        *int
+	maxProperties        *int
+
+	items           *schema
+	tupleItems      []*schema
+	additionalItems *schema
+	contains        *schema
+	minItems        *int
+	maxItems        *int
+	uniqueItems     bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	allOf []*schema
+	anyOf []*schema
+	oneOf []*schema
+	not   *schema
+}
+
+type patternSchema struct {
+	pattern *regexp.Regexp
+	schema  *schema
+}
+
+type compiler struct {
+	doc interface{}
+	// refs holds the schemas referenced with $ref, by reference, so the recursive schemas
+	// are compiled once.
+	refs map[string]*schema
+}
+
+func (c *compiler) compile(node interface{}, path string) (*schema, error) {
+	if b, ok := node.(bool); ok {
+		return &schema{always: &b}, nil
+	}
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or a boolean", path)
+	}
+
+	s := &schema{}
+	var err error
+	if ref, ok := m["$ref"]; ok {
+		r, ok := ref.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s/$ref: must be a string", path)
+		}
+		if s.ref, err = c.resolve(r); err != nil {
+			return nil, fmt.Errorf("%s/$ref: %w", path, err)
+		}
+	}
+
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/type: must be a string or an array of strings", path)
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return nil, fmt.Errorf("%s/type: must be a string or an array of strings", path)
+	}
+	for _, t := range s.types {
+		switch t {
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+		default:
+			return nil, fmt.Errorf("%s/type: unknown type %q", path, t)
+		}
+	}
+
+	if e, ok := m["enum"]; ok {
+		if s.enum, ok = e.([]interface{}); !ok {
+			return nil, fmt.Errorf("%s/enum: must be an array", path)
+		}
+	}
+	if v, ok := m["const"]; ok {
+		s.constant = &v
+	}
+
+	if r, ok := m["required"]; ok {
+		list, ok := r.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s/required: must be an array of strings", path)
+		}
+		for _, v := range list {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/required: must be an array of strings", path)
+			}
+			s.required = append(s.required, name)
+		}
+	}
+	if s.properties, err = c.compileMap(m, "properties", path); err != nil {
+		return nil, err
+	}
+	patterns, err := c.compileMap(m, "patternProperties", path)
+	if err != nil {
+		return nil, err
+	}
+	for _, expr := range sortedKeys(patterns) {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s/patternProperties: invalid pattern %q: %w", path, expr, err)
+		}
+		s.patternProperties = append(s.patternProperties, patternSchema{pattern: re, schema: patterns[expr]})
+	}
+	if s.additionalProperties, err = c.compileKeyword(m, "additionalProperties", path); err != nil {
+		return nil, err
+	}
+	if s.propertyNames, err = c.compileKeyword(m, "propertyNames", path); err != nil {
+		return nil, err
+	}
+	if s.minProperties, err = intKeyword(m, "minProperties", path); err != nil {
+		return nil, err
+	}
+	if s.maxProperties, err = intKeyword(m, "maxProperties", path); err != nil {
+		return nil, err
+	}
+
+	if items, ok := m["items"].([]interface{}); ok {
+		if s.tupleItems, err = c.compileList(items, path+"/items"); err != nil {
+			return nil, err
+		}
+		if s.additionalItems, err = c.compileKeyword(m, "additionalItems", path); err != nil {
+			return nil, err
+		}
+	} else if s.items, err = c.compileKeyword(m, "items", path); err != nil {
+		return nil, err
+	}
+	if s.contains, err = c.compileKeyword(m, "contains", path); err != nil {
+		return nil, err
+	}
+	if s.minItems, err = intKeyword(m, "minItems", path); err != nil {
+		return nil, err
+	}
+	if s.maxItems, err = intKeyword(m, "maxItems", path); err != nil {
+		return nil, err
+	}
+	if u, ok := m["uniqueItems"]; ok {
+		if s.uniqueItems, ok = u.(bool); !ok {
+			return nil, fmt.Errorf("%s/uniqueItems: must be a boolean", path)
+		}
+	}
+
+	if s.minLength, err = intKeyword(m, "minLength", path); err != nil {
+		return nil, err
+	}
+	if s.maxLength, err = intKeyword(m, "maxLength", path); err != nil {
+		return nil, err
+	}
+	if p, ok := m["pattern"]; ok {
+		expr, ok := p.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s/pattern: must be a string", path)
+		}
+		if s.pattern, err = regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("%s/pattern: invalid pattern %q: %w", path, expr, err)
+		}
+	}
+
+	for keyword, field := range map[string]**float64{
+		"minimum":          &s.minimum,
+		"maximum":          &s.maximum,
+		"exclusiveMinimum": &s.exclusiveMinimum,
+		"exclusiveMaximum": &s.exclusiveMaximum,
+		"multipleOf":       &s.multipleOf,
+	} {
+		if *field, err = numberKeyword(m, keyword, path); err != nil {
+			return nil, err
+		}
+	}
+	if s.multipleOf != nil && *s.multipleOf <= 0 {
+		return nil, fmt.Errorf("%s/multipleOf: must be greater than 0", path)
+	}
+
+	for keyword, field := range map[string]*[]*schema{
+		"allOf": &s.allOf,
+		"anyOf": &s.anyOf,
+		"oneOf": &s.oneOf,
+	} {
+		v, ok := m[keyword]
+		if !ok {
+			continue
+		}
+		list, ok := v.([]interface{})
+		if !ok || len(list) == 0 {
+			return nil, fmt.Errorf("%s/%s: must be a non-empty array", path, keyword)
+		}
+		if *field, err = c.compileList(list, path+"/"+keyword); err != nil {
+			return nil, err
+		}
+	}
+	if s.not, err = c.compileKeyword(m, "not", path); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (c *compiler) compileKeyword(m map[string]interface{}, keyword, path string) (*schema, error) {
+	v, ok := m[keyword]
+	if !ok {
+		return nil, nil
+	}
+	return c.compile(v, path+"/"+keyword)
+}
+
+func (c *compiler) compileMap(m map[string]interface{}, keyword, path string) (map[string]*schema, error) {
+	v, ok := m[keyword]
+	if !ok {
+		return nil, nil
+	}
+	nodes, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s/%s: must be an object", path, keyword)
+	}
+	schemas := make(map[string]*schema, len(nodes))
+	for name, node := range nodes {
+		s, err := c.compile(node, path+"/"+keyword+"/"+name)
+		if err != nil {
+			return nil, err
+		}
+		schemas[name] = s
+	}
+	return schemas, nil
+}
+
+func (c *compiler) compileList(nodes []interface{}, path string) ([]*schema, error) {
+	schemas := make([]*schema, 0, len(nodes))
+	for i, node := range nodes {
+		s, err := c.compile(node, path+"/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, nil
+}
+
+// resolve returns the schema referenced by ref, a JSON pointer within the document.
+func (c *compiler) resolve(ref string) (*schema, error) {
+	if s, ok := c.refs[ref]; ok {
+		return s, nil
+	}
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %q, only the references local to the schema are supported", ref)
+	}
+
+	node := c.doc
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if node, ok = n[token]; !ok {
+				return nil, fmt.Errorf("unresolvable reference %q", ref)
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("unresolvable reference %q", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("unresolvable reference %q", ref)
+		}
+	}
+
+	// Register the schema before compiling it, for the references to itself.
+	s := &schema{}
+	c.refs[ref] = s
+	compiled, err := c.compile(node, ref)
+	if err != nil {
+		return nil, err
+	}
+	*s = *compiled
+	return s, nil
+}
+
+// checkCycles returns an error if a schema applies to the values it validates through a chain of
+// $ref, allOf, anyOf, oneOf and not keywords leading back to it, such as {"$ref": "#"}. Unlike
+// the keywords applying to the properties or items of the values, these never end.
+func checkCycles(root *schema) error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[*schema]int)
+	var visit func(s *schema) bool
+	visit = func(s *schema) bool {
+		switch state[s] {
+		case visiting:
+			return true
+		case visited:
+			return false
+		}
+		state[s] = visiting
+		for _, next := range s.inPlace() {
+			if visit(next) {
+				return true
+			}
+		}
+		state[s] = visited
+		return false
+	}
+	for _, s := range root.all() {
+		if visit(s) {
+			return errors.New("schema references itself without descending into the values it validates")
+		}
+	}
+	return nil
+}
+
+// inPlace returns the subschemas applying to the same values as s.
+func (s *schema) inPlace() []*schema {
+	var schemas []*schema
+	if s.ref != nil {
+		schemas = append(schemas, s.ref)
+	}
+	schemas = append(schemas, s.allOf...)
+	schemas = append(schemas, s.anyOf...)
+	schemas = append(schemas, s.oneOf...)
+	if s.not != nil {
+		schemas = append(schemas, s.not)
+	}
+	return schemas
+}
+
+// all returns s and the schemas reachable from it.
+func (s *schema) all() []*schema {
+	seen := map[*schema]bool{s: true}
+	schemas := []*schema{s}
+	for i := 0; i < len(schemas); i++ {
+		current := schemas[i]
+		next := current.inPlace()
+		for _, p := range current.properties {
+			next = append(next, p)
+		}
+		for _, p := range current.patternProperties {
+			next = append(next, p.schema)
+		}
+		next = append(next, current.tupleItems...)
+		next = append(next, current.additionalProperties, current.propertyNames, current.items, current.additionalItems, current.contains)
+		for _, n := range next {
+			if n != nil && !seen[n] {
+				seen[n] = true
+				schemas = append(schemas, n)
+			}
+		}
+	}
+	return schemas
+}
+
+func intKeyword(m map[string]interface{}, keyword, path string) (*int, error) {
+	v, ok := m[keyword]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, fmt.Errorf("%s/%s: must be a non-negative integer", path, keyword)
+	}
+	i := int(f)
+	return &i, nil
+}
+
+func numberKeyword(m map[string]interface{}, keyword, path string) (*float64, error) {
+	v, ok := m[keyword]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%s/%s: must be a number", path, keyword)
+	}
+	return &f, nil
+}
+
+// validate returns the first violation of the schema by instance, at path. depth is the number of
+// schemas applied so far, bounded by maxDepth.
+func (s *schema) validate(instance interface{}, path string, depth int) error {
+	if depth > maxDepth {
+		return violation(path, "value is nested more than %d levels deep", maxDepth)
+	}
+	if s.always != nil {
+		if !*s.always {
+			return violation(path, "no value is allowed")
+		}
+		return nil
+	}
+	if s.ref != nil {
+		if err := s.ref.validate(instance, path, depth+1); err != nil {
+			return err
+		}
+	}
+
+	if len(s.types) > 0 {
+		matched := false
+		for _, t := range s.types {
+			if isType(instance, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return violation(path, "expected %s, got %s", strings.Join(s.types, " or "), typeOf(instance))
+		}
+	}
+	if s.enum != nil {
+		matched := false
+		for _, v := range s.enum {
+			if reflect.DeepEqual(v, instance) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return violation(path, "value is not one of the enumerated values")
+		}
+	}
+	if s.constant != nil && !reflect.DeepEqual(*s.constant, instance) {
+		return violation(path, "value is not the constant value")
+	}
+
+	var err error
+	switch v := instance.(type) {
+	case map[string]interface{}:
+		err = s.validateObject(v, path, depth)
+	case []interface{}:
+		err = s.validateArray(v, path, depth)
+	case string:
+		err = s.validateString(v, path)
+	case float64:
+		err = s.validateNumber(v, path)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range s.allOf {
+		if err := sub.validate(instance, path, depth+1); err != nil {
+			return err
+		}
+	}
+	if s.anyOf != nil {
+		matched := false
+		for _, sub := range s.anyOf {
+			if sub.validate(instance, path, depth+1) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return violation(path, "value matches none of the schemas of anyOf")
+		}
+	}
+	if s.oneOf != nil {
+		matches := 0
+		for _, sub := range s.oneOf {
+			if sub.validate(instance, path, depth+1) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return violation(path, "value matches %d of the schemas of oneOf, instead of exactly one", matches)
+		}
+	}
+	if s.not != nil && s.not.validate(instance, path, depth+1) == nil {
+		return violation(path, "value matches the schema of not")
+	}
+	return nil
+}
+
+func (s *schema) validateObject(object map[string]interface{}, path string, depth int) error {
+	for _, name := range s.required {
+		if _, ok := object[name]; !ok {
+			return violation(path, "missing required property %q", name)
+		}
+	}
+	if s.minProperties != nil && len(object) < *s.minProperties {
+		return violation(path, "expected at least %d properties, got %d", *s.minProperties, len(object))
+	}
+	if s.maxProperties != nil && len(object) > *s.maxProperties {
+		return violation(path, "expected at most %d properties, got %d", *s.maxProperties, len(object))
+	}
+
+	for _, name := range sortedKeys(object) {
+		value := object[name]
+		propertyPath := path + "/" + escapePointer(name)
+		if s.propertyNames != nil {
+			if err := s.propertyNames.validate(name, propertyPath, depth+1); err != nil {
+				return err
+			}
+		}
+		additional := true
+		if property, ok := s.properties[name]; ok {
+			additional = false
+			if err := property.validate(value, propertyPath, depth+1); err != nil {
+				return err
+			}
+		}
+		for _, p := range s.patternProperties {
+			if p.pattern.MatchString(name) {
+				additional = false
+				if err := p.schema.validate(value, propertyPath, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		if additional && s.additionalProperties != nil {
+			if s.additionalProperties.always != nil && !*s.additionalProperties.always {
+				return violation(path, "additional property %q is not allowed", name)
+			}
+			if err := s.additionalProperties.validate(value, propertyPath, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *schema) validateArray(array []interface{}, path string, depth int) error {
+	if s.minItems != nil && len(array) < *s.minItems {
+		return violation(path, "expected at least %d items, got %d", *s.minItems, len(array))
+	}
+	if s.maxItems != nil && len(array) > *s.maxItems {
+		return violation(path, "expected at most %d items, got %d", *s.maxItems, len(array))
+	}
+	if s.uniqueItems {
+		for i := range array {
+			for j := i + 1; j < len(array); j++ {
+				if reflect.DeepEqual(array[i], array[j]) {
+					return violation(path, "items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+
+	for i, item := range array {
+		itemPath := path + "/" + strconv.Itoa(i)
+		var itemSchema *schema
+		switch {
+		case s.items != nil:
+			itemSchema = s.items
+		case i < len(s.tupleItems):
+			itemSchema = s.tupleItems[i]
+		default:
+			itemSchema = s.additionalItems
+		}
+		if itemSchema == nil {
+			continue
+		}
+		if err := itemSchema.validate(item, itemPath, depth+1); err != nil {
+			return err
+		}
+	}
+
+	if s.contains != nil {
+		for _, item := range array {
+			if s.contains.validate(item, path, depth+1) == nil {
+				return nil
+			}
+		}
+		return violation(path, "no item matches the schema of contains")
+	}
+	return nil
+}
+
+func (s *schema) validateString(str, path string) error {
+	length := utf8.RuneCountInString(str)
+	if s.minLength != nil && length < *s.minLength {
+		return violation(path, "expected at least %d characters, got %d", *s.minLength, length)
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		return violation(path, "expected at most %d characters, got %d", *s.maxLength, length)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		return violation(path, "value does not match the pattern %q", s.pattern.String())
+	}
+	return nil
+}
+
+func (s *schema) validateNumber(n float64, path string) error {
+	if s.minimum != nil && n < *s.minimum {
+		return violation(path, "expected a value of at least %v, got %v", *s.minimum, n)
+	}
+	if s.maximum != nil && n > *s.maximum {
+		return violation(path, "expected a value of at most %v, got %v", *s.maximum, n)
+	}
+	if s.exclusiveMinimum != nil && n <= *s.exclusiveMinimum {
+		return violation(path, "expected a value greater than %v, got %v", *s.exclusiveMinimum, n)
+	}
+	if s.exclusiveMaximum != nil && n >= *s.exclusiveMaximum {
+		return violation(path, "expected a value less than %v, got %v", *s.exclusiveMaximum, n)
+	}
+	if s.multipleOf != nil {
+		if q := n / *s.multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			return violation(path, "expected a multiple of %v, got %v", *s.multipleOf, n)
+		}
+	}
+	return nil
+}
+
+func isType(instance interface{}, t string) bool {
+	switch v := instance.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case map[string]interface{}:
+		return t == "object"
+	case []interface{}:
+		return t == "array"
+	case string:
+		return t == "string"
+	case float64:
+		return t == "number" || (t == "integer" && v == math.Trunc(v))
+	}
+	return false
+}
+
+func typeOf(instance interface{}) string {
+	switch instance.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	default:
+		return "number"
+	}
+}
+
+func violation(path, format string, args ...interface{}) error {
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...))
+}
+
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		names = append(names, k.String())
+	}
+	sort.Strings(names)
+	return names
+}
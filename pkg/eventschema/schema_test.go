@@ -0,0 +1,247 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventschema
+
+import (
+	"strings"
+	"testing"
+)
+
+const orderSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"required": ["id", "items"],
+	"properties": {
+		"id": {"type": "string", "pattern": "^order-[0-9]+$"},
+		"status": {"enum": ["created", "shipped"]},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"items": {"$ref": "#/definitions/item"}
+		},
+		"parent": {"$ref": "#"}
+	},
+	"additionalProperties": false,
+	"definitions": {
+		"item": {
+			"type": "object",
+			"required": ["sku", "quantity"],
+			"properties": {
+				"sku": {"type": "string", "minLength": 3},
+				"quantity": {"type": "integer", "exclusiveMinimum": 0}
+			}
+		}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	testCases := map[string]struct {
+		schema  string
+		data    string
+		wantErr string
+	}{
+		"valid": {
+			schema: orderSchema,
+			data:   `{"id": "order-1", "status": "created", "items": [{"sku": "abc", "quantity": 2}]}`,
+		},
+		"valid recursive": {
+			schema: orderSchema,
+			data:   `{"id": "order-2", "items": [{"sku": "abc", "quantity": 1}], "parent": {"id": "order-1", "items": [{"sku": "def", "quantity": 1}]}}`,
+		},
+		"missing property": {
+			schema:  orderSchema,
+			data:    `{"id": "order-1"}`,
+			wantErr: `/: missing required property "items"`,
+		},
+		"wrong type": {
+			schema:  orderSchema,
+			data:    `{"id": 1, "items": []}`,
+			wantErr: "/id: expected string, got number",
+		},
+		"pattern": {
+			schema:  orderSchema,
+			data:    `{"id": "1", "items": [{"sku": "abc", "quantity": 1}]}`,
+			wantErr: `/id: value does not match the pattern "^order-[0-9]+$"`,
+		},
+		"enum": {
+			schema:  orderSchema,
+			data:    `{"id": "order-1", "status": "lost", "items": [{"sku": "abc", "quantity": 1}]}`,
+			wantErr: "/status: value is not one of the enumerated values",
+		},
+		"min items": {
+			schema:  orderSchema,
+			data:    `{"id": "order-1", "items": []}`,
+			wantErr: "/items: expected at least 1 items, got 0",
+		},
+		"referenced schema": {
+			schema:  orderSchema,
+			data:    `{"id": "order-1", "items": [{"sku": "abc", "quantity": 1.5}]}`,
+			wantErr: "/items/0/quantity: expected integer, got number",
+		},
+		"exclusive minimum": {
+			schema:  orderSchema,
+			data:    `{"id": "order-1", "items": [{"sku": "abc", "quantity": 0}]}`,
+			wantErr: "/items/0/quantity: expected a value greater than 0, got 0",
+		},
+		"additional property": {
+			schema:  orderSchema,
+			data:    `{"id": "order-1", "items": [{"sku": "abc", "quantity": 1}], "note": "fragile"}`,
+			wantErr: `/: additional property "note" is not allowed`,
+		},
+		"recursive violation": {
+			schema:  orderSchema,
+			data:    `{"id": "order-2", "items": [{"sku": "abc", "quantity": 1}], "parent": {"id": "order-1", "items": [{"sku": "d", "quantity": 1}]}}`,
+			wantErr: "/parent/items/0/sku: expected at least 3 characters, got 1",
+		},
+		"no data": {
+			schema:  orderSchema,
+			data:    "",
+			wantErr: "/: expected object, got null",
+		},
+		"invalid JSON": {
+			schema:  orderSchema,
+			data:    `{"id": `,
+			wantErr: "data is not valid JSON: unexpected end of JSON input",
+		},
+		"true schema": {
+			schema: "true",
+			data:   `[1, "a"]`,
+		},
+		"false schema": {
+			schema:  "false",
+			data:    `1`,
+			wantErr: "/: no value is allowed",
+		},
+		"anyOf": {
+			schema: `{"anyOf": [{"type": "string"}, {"type": "number"}]}`,
+			data:   `1`,
+		},
+		"anyOf violation": {
+			schema:  `{"anyOf": [{"type": "string"}, {"type": "number"}]}`,
+			data:    `true`,
+			wantErr: "/: value matches none of the schemas of anyOf",
+		},
+		"oneOf violation": {
+			schema:  `{"oneOf": [{"type": "integer"}, {"type": "number"}]}`,
+			data:    `1`,
+			wantErr: "/: value matches 2 of the schemas of oneOf, instead of exactly one",
+		},
+		"not violation": {
+			schema:  `{"not": {"const": "forbidden"}}`,
+			data:    `"forbidden"`,
+			wantErr: "/: value matches the schema of not",
+		},
+		"tuple": {
+			schema: `{"items": [{"type": "string"}, {"type": "integer"}], "additionalItems": false}`,
+			data:   `["a", 1]`,
+		},
+		"tuple violation": {
+			schema:  `{"items": [{"type": "string"}, {"type": "integer"}], "additionalItems": false}`,
+			data:    `["a", 1, 2]`,
+			wantErr: "/2: no value is allowed",
+		},
+		"unique items": {
+			schema:  `{"uniqueItems": true}`,
+			data:    `[{"a": 1}, {"a": 1}]`,
+			wantErr: "/: items 0 and 1 are equal",
+		},
+		"contains": {
+			schema:  `{"contains": {"const": 3}}`,
+			data:    `[1, 2]`,
+			wantErr: "/: no item matches the schema of contains",
+		},
+		"multiple of": {
+			schema:  `{"multipleOf": 0.5}`,
+			data:    `1.25`,
+			wantErr: "/: expected a multiple of 0.5, got 1.25",
+		},
+		"pattern properties": {
+			schema:  `{"patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": false}`,
+			data:    `{"x-a": 1}`,
+			wantErr: "/x-a: expected string, got number",
+		},
+		"property names escaped": {
+			schema:  `{"additionalProperties": {"type": "string"}}`,
+			data:    `{"a/b": 1}`,
+			wantErr: "/a~1b: expected string, got number",
+		},
+		"unknown keywords": {
+			schema: `{"format": "email", "title": "Email"}`,
+			data:   `"not an email"`,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			s, err := Compile([]byte(tc.schema))
+			if err != nil {
+				t.Fatal("Unexpected error compiling the schema:", err)
+			}
+			err = s.Validate([]byte(tc.data))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Error("Unexpected error:", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected error %q, got nil", tc.wantErr)
+			}
+			if err.Error() != tc.wantErr {
+				t.Errorf("Unexpected error, want %q, got %q", tc.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	testCases := map[string]string{
+		"not JSON":          `{"type": `,
+		"not a schema":      `"string"`,
+		"unknown type":      `{"type": "text"}`,
+		"invalid pattern":   `{"pattern": "("}`,
+		"negative length":   `{"minLength": -1}`,
+		"remote reference":  `{"$ref": "http://example.com/schema.json"}`,
+		"missing reference": `{"$ref": "#/definitions/missing"}`,
+		"empty anyOf":       `{"anyOf": []}`,
+		"invalid subschema": `{"properties": {"a": 1}}`,
+		"zero multipleOf":   `{"multipleOf": 0}`,
+		"self reference":    `{"$ref": "#"}`,
+		"reference cycle":   `{"$ref": "#/definitions/a", "definitions": {"a": {"allOf": [{"$ref": "#/definitions/b"}]}, "b": {"not": {"$ref": "#/definitions/a"}}}}`,
+		"nested cycle":      `{"properties": {"a": {"anyOf": [{"$ref": "#/properties/a"}]}}}`,
+	}
+	for n, schema := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if _, err := Compile([]byte(schema)); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestValidateMaxDepth(t *testing.T) {
+	s, err := Compile([]byte(`{"type": "array", "items": {"$ref": "#"}}`))
+	if err != nil {
+		t.Fatal("Unexpected error compiling the schema:", err)
+	}
+	if err := s.Validate([]byte(strings.Repeat("[", 10) + strings.Repeat("]", 10))); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+	nested := strings.Repeat("[", maxDepth+1) + strings.Repeat("]", maxDepth+1)
+	if err := s.Validate([]byte(nested)); err == nil || !strings.Contains(err.Error(), "nested more than") {
+		t.Errorf("Expected a nesting error, got %v", err)
+	}
+}
@@ -21,6 +21,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"knative.dev/eventing/pkg/channel/fanout"
 	"knative.dev/eventing/pkg/channel/multichannelfanout"
 	"knative.dev/eventing/pkg/kncloudevents"
 )
@@ -33,6 +34,8 @@ type InMemoryMessageDispatcher struct {
 	handler              multichannelfanout.MultiChannelMessageHandler
 	httpBindingsReceiver *kncloudevents.HTTPMessageReceiver
 	writeTimeout         time.Duration
+	inFlight             *fanout.InFlightTracker
+	drainTimeout         time.Duration
 	logger               *zap.Logger
 }
 
@@ -41,6 +44,11 @@ type InMemoryMessageDispatcherArgs struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	Handler      multichannelfanout.MultiChannelMessageHandler
+	// InFlight tracks the asynchronous dispatches of Handler, which are drained on shutdown.
+	InFlight *fanout.InFlightTracker
+	// DrainTimeout is how long the shutdown waits for the asynchronous dispatches to complete,
+	// before abandoning them.
+	DrainTimeout time.Duration
 	Logger       *zap.Logger
 }
 
@@ -51,9 +59,23 @@ func (d *InMemoryMessageDispatcher) GetHandler(ctx context.Context) multichannel
 }
 
 // Start starts the inmemory dispatcher's message processing.
-// This is a blocking call.
+// This is a blocking call, it returns once the in-flight dispatches are drained.
 func (d *InMemoryMessageDispatcher) Start(ctx context.Context) error {
-	return d.httpBindingsReceiver.StartListen(kncloudevents.WithShutdownTimeout(ctx, d.writeTimeout), d.handler)
+	err := d.httpBindingsReceiver.StartListen(kncloudevents.WithShutdownTimeout(ctx, d.writeTimeout), d.handler)
+	d.drain()
+	return err
+}
+
+// drain waits for the in-flight dispatches to complete, up to the drain timeout.
+func (d *InMemoryMessageDispatcher) drain() {
+	if d.inFlight == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d.drainTimeout)
+	defer cancel()
+	if abandoned := d.inFlight.Drain(ctx); abandoned > 0 {
+		d.logger.Warn("Abandoned in-flight dispatches on shutdown", zap.Int("count", abandoned), zap.Duration("drainTimeout", d.drainTimeout))
+	}
 }
 
 func NewMessageDispatcher(args *InMemoryMessageDispatcherArgs) *InMemoryMessageDispatcher {
@@ -65,6 +87,8 @@ func NewMessageDispatcher(args *InMemoryMessageDispatcherArgs) *InMemoryMessageD
 		httpBindingsReceiver: bindingsReceiver,
 		logger:               args.Logger,
 		writeTimeout:         args.WriteTimeout,
+		inFlight:             args.InFlight,
+		drainTimeout:         args.DrainTimeout,
 	}
 
 	return dispatcher
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2020 The Knative Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kncloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	nethttp "net/http"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+)
+
+// BatchEventStatus is the status code of an event of a batch.
+type BatchEventStatus struct {
+	ID         string `json:"id"`
+	Source     string `json:"source"`
+	StatusCode int    `json:"status"`
+}
+
+// IsBatchRequest returns whether request holds a batch of events, in the JSON batch format.
+func IsBatchRequest(request *nethttp.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	return err == nil && mediaType == event.ApplicationCloudEventsBatchJSON
+}
+
+// NewEventsFromBatchRequest reads the events of a batch request. The batch is rejected as a
+// whole if one of its events is invalid.
+func NewEventsFromBatchRequest(request *nethttp.Request) ([]*cloudevents.Event, error) {
+	var events []*cloudevents.Event
+	if err := json.NewDecoder(request.Body).Decode(&events); err != nil {
+		return nil, fmt.Errorf("failed to decode the batch: %w", err)
+	}
+	for i, e := range events {
+		if e == nil {
+			return nil, fmt.Errorf("event %d of the batch is null", i)
+		}
+		if err := e.Validate(); err != nil {
+			return nil, fmt.Errorf("event %d of the batch is invalid: %w", i, err)
+		}
+	}
+	return events, nil
+}
+
+// WriteBatchResponse writes the status code of a batch: the status code shared by all its events
+// or, when they differ, 207 Multi-Status with the status code of each event in the body. An
+// empty batch is accepted.
+func WriteBatchResponse(writer nethttp.ResponseWriter, statuses []BatchEventStatus) {
+	if len(statuses) == 0 {
+		writer.WriteHeader(nethttp.StatusAccepted)
+		return
+	}
+	statusCode := statuses[0].StatusCode
+	for _, s := range statuses[1:] {
+		if s.StatusCode != statusCode {
+			statusCode = nethttp.StatusMultiStatus
+			break
+		}
+	}
+	if statusCode != nethttp.StatusMultiStatus {
+		writer.WriteHeader(statusCode)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(nethttp.StatusMultiStatus)
+	_ = json.NewEncoder(writer).Encode(statuses)
+}
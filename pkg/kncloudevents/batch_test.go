@@ -0,0 +1,119 @@
+/*
+ * Copyright 2020 The Knative Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kncloudevents
+
+import (
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsBatchRequest(t *testing.T) {
+	testCases := map[string]bool{
+		"application/cloudevents-batch+json":                true,
+		"application/cloudevents-batch+json; charset=UTF-8": true,
+		"application/cloudevents+json":                      false,
+		"application/json":                                  false,
+		"":                                                  false,
+	}
+	for contentType, want := range testCases {
+		request := httptest.NewRequest(nethttp.MethodPost, "/", nil)
+		request.Header.Set("Content-Type", contentType)
+		if got := IsBatchRequest(request); got != want {
+			t.Errorf("IsBatchRequest(%q) = %t, want %t", contentType, got, want)
+		}
+	}
+}
+
+func TestNewEventsFromBatchRequest(t *testing.T) {
+	testCases := map[string]struct {
+		body    string
+		wantIDs []string
+		wantErr bool
+	}{
+		"events": {
+			body:    `[{"specversion": "1.0", "id": "1", "source": "/s", "type": "t"}, {"specversion": "1.0", "id": "2", "source": "/s", "type": "t", "data": {"a": 1}}]`,
+			wantIDs: []string{"1", "2"},
+		},
+		"empty": {
+			body: `[]`,
+		},
+		"invalid event": {
+			body:    `[{"specversion": "1.0", "id": "1"}]`,
+			wantErr: true,
+		},
+		"null event": {
+			body:    `[null]`,
+			wantErr: true,
+		},
+		"not an array": {
+			body:    `{"specversion": "1.0", "id": "1", "source": "/s", "type": "t"}`,
+			wantErr: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			request := httptest.NewRequest(nethttp.MethodPost, "/", strings.NewReader(tc.body))
+			events, err := NewEventsFromBatchRequest(request)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error, want %t, got %v", tc.wantErr, err)
+			}
+			if len(events) != len(tc.wantIDs) {
+				t.Fatalf("Unexpected events, want %d, got %d", len(tc.wantIDs), len(events))
+			}
+			for i, e := range events {
+				if e.ID() != tc.wantIDs[i] {
+					t.Errorf("Unexpected event %d ID, want %q, got %q", i, tc.wantIDs[i], e.ID())
+				}
+			}
+		})
+	}
+}
+
+func TestWriteBatchResponse(t *testing.T) {
+	testCases := map[string]struct {
+		statuses   []BatchEventStatus
+		statusCode int
+		wantBody   string
+	}{
+		"empty": {
+			statusCode: nethttp.StatusAccepted,
+		},
+		"same status codes": {
+			statuses:   []BatchEventStatus{{ID: "1", StatusCode: 202}, {ID: "2", StatusCode: 202}},
+			statusCode: nethttp.StatusAccepted,
+		},
+		"different status codes": {
+			statuses:   []BatchEventStatus{{ID: "1", Source: "/s", StatusCode: 202}, {ID: "2", Source: "/s", StatusCode: 400}},
+			statusCode: nethttp.StatusMultiStatus,
+			wantBody:   `[{"id":"1","source":"/s","status":202},{"id":"2","source":"/s","status":400}]` + "\n",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			WriteBatchResponse(recorder, tc.statuses)
+			if recorder.Code != tc.statusCode {
+				t.Errorf("Unexpected status code, want %d, got %d", tc.statusCode, recorder.Code)
+			}
+			if got := recorder.Body.String(); got != tc.wantBody {
+				t.Errorf("Unexpected body, want %q, got %q", tc.wantBody, got)
+			}
+		})
+	}
+}
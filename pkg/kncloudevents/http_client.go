@@ -17,6 +17,7 @@ limitations under the License.
 package kncloudevents
 
 import (
+	"crypto/tls"
 	nethttp "net/http"
 	"sync"
 	"time"
@@ -45,22 +46,39 @@ func getClient() *nethttp.Client {
 	defer clientHolder.clientMutex.Unlock()
 
 	if clientHolder.client == nil {
-		// Add connection options to the default transport.
-		var base = nethttp.DefaultTransport.(*nethttp.Transport).Clone()
-		clientHolder.connectionArgs.configureTransport(base)
-		c := &nethttp.Client{
-			// Add output tracing.
-			Transport: &ochttp.Transport{
-				Base:        base,
-				Propagation: tracecontextb3.TraceContextEgress,
-			},
-		}
+		c := newClient(clientHolder.connectionArgs, nil)
 		clientHolder.client = &c
 	}
 
 	return *clientHolder.client
 }
 
+// NewClientWithTLSConfig creates an HTTP client using tlsConfig, configured with the current
+// connection args. Unlike the shared client, it owns its connection pool, so it is meant to be
+// reused for all the requests needing the same TLS configuration.
+func NewClientWithTLSConfig(tlsConfig *tls.Config) *nethttp.Client {
+	clientHolder.clientMutex.Lock()
+	defer clientHolder.clientMutex.Unlock()
+
+	return newClient(clientHolder.connectionArgs, tlsConfig)
+}
+
+func newClient(ca *ConnectionArgs, tlsConfig *tls.Config) *nethttp.Client {
+	// Add connection options to the default transport.
+	var base = nethttp.DefaultTransport.(*nethttp.Transport).Clone()
+	ca.configureTransport(base)
+	if tlsConfig != nil {
+		base.TLSClientConfig = tlsConfig
+	}
+	return &nethttp.Client{
+		// Add output tracing.
+		Transport: &ochttp.Transport{
+			Base:        base,
+			Propagation: tracecontextb3.TraceContextEgress,
+		},
+	}
+}
+
 // ConfigureConnectionArgs configures the new connection args.
 // The existing client won't be affected, but a new one will be created.
 // Use sparingly, because it might lead to creating a lot of clients, none of them sharing their connection pool!
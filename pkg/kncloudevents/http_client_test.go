@@ -17,6 +17,7 @@ limitations under the License.
 package kncloudevents
 
 import (
+	"crypto/tls"
 	nethttp "net/http"
 	"testing"
 
@@ -67,6 +68,23 @@ func TestConfigureConnectionArgs(t *testing.T) {
 	require.NotSame(t, client2, client3)
 }
 
+func TestNewClientWithTLSConfig(t *testing.T) {
+	ConfigureConnectionArgs(&ConnectionArgs{
+		MaxIdleConnsPerHost: 1000,
+		MaxIdleConns:        1000,
+	})
+	defer ConfigureConnectionArgs(nil)
+
+	tlsConfig := &tls.Config{ServerName: "example.com"}
+	client := NewClientWithTLSConfig(tlsConfig)
+
+	require.NotSame(t, getClient(), client)
+	require.Same(t, tlsConfig, castToTransport(client).TLSClientConfig)
+	require.Equal(t, 1000, castToTransport(client).MaxIdleConns)
+	require.Equal(t, 1000, castToTransport(client).MaxIdleConnsPerHost)
+	require.NotSame(t, NewClientWithTLSConfig(tlsConfig), client)
+}
+
 func castToTransport(client *nethttp.Client) *nethttp.Transport {
 	return client.Transport.(*ochttp.Transport).Base.(*nethttp.Transport)
 }
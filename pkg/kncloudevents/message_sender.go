@@ -20,7 +20,10 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/rand"
 	nethttp "net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
@@ -29,6 +32,10 @@ import (
 	duckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 )
 
+// maxRetryAfter caps the delay requested with a Retry-After header when the DeliverySpec has no
+// BackoffMaxDelay, so that a destination can't stall the delivery of its events indefinitely.
+const maxRetryAfter = 5 * time.Minute
+
 var noRetries = RetryConfig{
 	RetryMax: 0,
 	CheckRetry: func(ctx context.Context, resp *nethttp.Response, err error) (bool, error) {
@@ -87,20 +94,50 @@ type RetryConfig struct {
 	// These next two variables are just copied from the original DeliverySpec so
 	// we can detect if anything has changed. We can not do that with the CheckRetry
 	// Backoff (at least not easily).
-	BackoffDelay  *string
-	BackoffPolicy *duckv1.BackoffPolicyType
+	BackoffDelay    *string
+	BackoffPolicy   *duckv1.BackoffPolicyType
+	BackoffMaxDelay *string
+	BackoffJitter   *duckv1.BackoffJitterType
+
+	RetryableStatusCodes    []string
+	NonRetryableStatusCodes []string
+
+	// DeadLetterEnrichment is whether the events sent to the dead letter sink
+	// carry the extensions describing the failure.
+	DeadLetterEnrichment bool
+
+	// Timeout is the timeout of each single attempt. Zero means no timeout.
+	Timeout time.Duration
 
 	CheckRetry CheckRetry
 	Backoff    Backoff
 }
 
 func (s *HTTPMessageSender) SendWithRetries(req *nethttp.Request, config *RetryConfig) (*nethttp.Response, error) {
+	resp, _, err := s.SendWithRetriesAndAttempts(req, config)
+	return resp, err
+}
+
+// SendWithRetriesAndAttempts is like SendWithRetries, and also returns the number of
+// attempts made to send the request.
+func (s *HTTPMessageSender) SendWithRetriesAndAttempts(req *nethttp.Request, config *RetryConfig) (*nethttp.Response, int, error) {
 	if config == nil {
-		return s.Send(req)
+		resp, err := s.Send(req)
+		return resp, 1, err
+	}
+
+	client := s.Client
+	if config.Timeout > 0 {
+		// The client timeout applies to each attempt, while the request context
+		// bounds all of them.
+		c := *s.Client
+		c.Timeout = config.Timeout
+		client = &c
 	}
 
+	attempts := 0
 	retryableClient := retryablehttp.Client{
-		HTTPClient:   s.Client,
+		HTTPClient:   client,
 		RetryWaitMin: defaultRetryWaitMin,
 		RetryWaitMax: defaultRetryWaitMax,
 		RetryMax:     config.RetryMax,
@@ -111,14 +148,18 @@ func (s *HTTPMessageSender) SendWithRetries(req *nethttp.Request, config *RetryC
 		ErrorHandler: func(resp *nethttp.Response, err error, numTries int) (*nethttp.Response, error) {
 			return resp, err
 		},
+		RequestLogHook: func(_ retryablehttp.Logger, _ *nethttp.Request, _ int) {
+			attempts++
+		},
 	}
 
 	retryableReq, err := retryablehttp.FromRequest(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return retryableClient.Do(retryableReq)
+	resp, err := retryableClient.Do(retryableReq)
+	return resp, attempts, err
 }
 
 func NoRetries() RetryConfig {
@@ -130,12 +171,32 @@ func RetryConfigFromDeliverySpec(spec duckv1.DeliverySpec) (RetryConfig, error)
 	retryConfig := NoRetries()
 
 	retryConfig.CheckRetry = checkRetry
+	if len(spec.RetryableStatusCodes) != 0 || len(spec.NonRetryableStatusCodes) != 0 {
+		codes, err := newStatusCodes(spec.RetryableStatusCodes, spec.NonRetryableStatusCodes)
+		if err != nil {
+			return retryConfig, err
+		}
+		retryConfig.RetryableStatusCodes = spec.RetryableStatusCodes
+		retryConfig.NonRetryableStatusCodes = spec.NonRetryableStatusCodes
+		retryConfig.CheckRetry = codes.checkRetry
+	}
 
 	if spec.Retry != nil {
 		retryConfig.RetryMax = int(*spec.Retry)
 	}
 	retryConfig.BackoffPolicy = spec.BackoffPolicy
 	retryConfig.BackoffDelay = spec.BackoffDelay
+	retryConfig.BackoffMaxDelay = spec.BackoffMaxDelay
+	retryConfig.BackoffJitter = spec.BackoffJitter
+	retryConfig.DeadLetterEnrichment = spec.DeadLetterEnrichment != nil && *spec.DeadLetterEnrichment
+
+	if spec.Timeout != nil {
+		timeout, err := period.Parse(*spec.Timeout)
+		if err != nil {
+			return retryConfig, fmt.Errorf("failed to parse Spec.Timeout: %w", err)
+		}
+		retryConfig.Timeout, _ = timeout.Duration()
+	}
 
 	if spec.BackoffPolicy != nil && spec.BackoffDelay != nil {
 
@@ -148,7 +209,12 @@ func RetryConfigFromDeliverySpec(spec duckv1.DeliverySpec) (RetryConfig, error)
 		switch *spec.BackoffPolicy {
 		case duckv1.BackoffPolicyExponential:
 			retryConfig.Backoff = func(attemptNum int, resp *nethttp.Response) time.Duration {
-				return delayDuration * time.Duration(math.Exp2(float64(attemptNum)))
+				// Saturate rather than overflow, so that the delay can be capped.
+				delay := float64(delayDuration) * math.Exp2(float64(attemptNum))
+				if delay >= math.MaxInt64 {
+					return math.MaxInt64
+				}
+				return time.Duration(delay)
 			}
 		case duckv1.BackoffPolicyLinear:
 			retryConfig.Backoff = func(attemptNum int, resp *nethttp.Response) time.Duration {
@@ -157,9 +223,143 @@ func RetryConfigFromDeliverySpec(spec duckv1.DeliverySpec) (RetryConfig, error)
 		}
 	}
 
+	var maxDelay time.Duration
+	if spec.BackoffMaxDelay != nil {
+		p, err := period.Parse(*spec.BackoffMaxDelay)
+		if err != nil {
+			return retryConfig, fmt.Errorf("failed to parse Spec.BackoffMaxDelay: %w", err)
+		}
+		maxDelay, _ = p.Duration()
+	}
+
+	retryConfig.Backoff = boundedBackoff(retryConfig.Backoff, maxDelay, spec.BackoffJitter)
+
 	return retryConfig, nil
 }
 
+// boundedBackoff wraps the backoff of the policy so that the delay is capped by maxDelay, if not
+// zero, and randomized according to the jitter. The delay requested by a 429 or 503 response with
+// a Retry-After header takes precedence: it is capped by maxDelay as well, or by maxRetryAfter if
+// zero, but not randomized since it's the minimum the destination asks to wait.
+func boundedBackoff(backoff Backoff, maxDelay time.Duration, jitter *duckv1.BackoffJitterType) Backoff {
+	retryAfterCap := maxDelay
+	if retryAfterCap <= 0 {
+		retryAfterCap = maxRetryAfter
+	}
+	return func(attemptNum int, resp *nethttp.Response) time.Duration {
+		if retryAfter, ok := parseRetryAfter(resp, time.Now()); ok {
+			if retryAfter > retryAfterCap {
+				return retryAfterCap
+			}
+			return retryAfter
+		}
+
+		delay := backoff(attemptNum, resp)
+		if maxDelay > 0 && delay > maxDelay {
+			delay = maxDelay
+		}
+		if delay <= 0 || jitter == nil {
+			return delay
+		}
+		switch *jitter {
+		case duckv1.BackoffJitterFull:
+			return time.Duration(rand.Int63n(int64(delay)))
+		case duckv1.BackoffJitterEqual:
+			return delay - time.Duration(rand.Int63n(int64(delay/2)+1))
+		}
+		return delay
+	}
+}
+
+// parseRetryAfter returns the delay requested by the Retry-After header of a 429 (Too Many
+// Requests) or 503 (Service Unavailable) response, expressed either in seconds or as an HTTP date.
+// The delay is clamped to maxRetryAfter.
+func parseRetryAfter(resp *nethttp.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil || (resp.StatusCode != nethttp.StatusTooManyRequests && resp.StatusCode != nethttp.StatusServiceUnavailable) {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		if seconds > int64(maxRetryAfter/time.Second) {
+			return maxRetryAfter, true
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := nethttp.ParseTime(v); err == nil {
+		d := date.Sub(now)
+		switch {
+		case d > maxRetryAfter:
+			return maxRetryAfter, true
+		case d > 0:
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
 func checkRetry(_ context.Context, resp *nethttp.Response, err error) (bool, error) {
 	return !(resp != nil && resp.StatusCode < 300), err
 }
+
+// statusCodes tells whether a failed response is retryable, according to the status codes
+// and classes listed in the DeliverySpec.
+type statusCodes struct {
+	// codes and classes map a status code, or class (e.g. 4 for "4xx"), to whether it's retryable.
+	codes   map[int]bool
+	classes map[int]bool
+	// retryByDefault is whether the status codes which aren't listed are retryable.
+	retryByDefault bool
+}
+
+func newStatusCodes(retryable, nonRetryable []string) (*statusCodes, error) {
+	sc := &statusCodes{
+		codes:          make(map[int]bool),
+		classes:        make(map[int]bool),
+		retryByDefault: len(retryable) == 0,
+	}
+	for _, l := range []struct {
+		codes     []string
+		retryable bool
+		field     string
+	}{
+		{codes: retryable, retryable: true, field: "RetryableStatusCodes"},
+		{codes: nonRetryable, retryable: false, field: "NonRetryableStatusCodes"},
+	} {
+		for _, c := range l.codes {
+			if len(c) == 3 && strings.HasSuffix(c, "xx") {
+				class, err := strconv.Atoi(c[:1])
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse Spec.%s: %q", l.field, c)
+				}
+				sc.classes[class] = l.retryable
+				continue
+			}
+			code, err := strconv.Atoi(c)
+			if err != nil || len(c) != 3 {
+				return nil, fmt.Errorf("failed to parse Spec.%s: %q", l.field, c)
+			}
+			sc.codes[code] = l.retryable
+		}
+	}
+	return sc, nil
+}
+
+func (sc *statusCodes) checkRetry(ctx context.Context, resp *nethttp.Response, err error) (bool, error) {
+	if err != nil || resp == nil || resp.StatusCode < 300 {
+		return checkRetry(ctx, resp, err)
+	}
+	if retryable, ok := sc.codes[resp.StatusCode]; ok {
+		return retryable, nil
+	}
+	if retryable, ok := sc.classes[resp.StatusCode/100]; ok {
+		return retryable, nil
+	}
+	return sc.retryByDefault, nil
+}
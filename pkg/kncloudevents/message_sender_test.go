@@ -114,6 +114,266 @@ func TestRetryConfigFromDeliverySpec(t *testing.T) {
 	}
 }
 
+func TestRetryConfigFromDeliverySpecTimeout(t *testing.T) {
+	retryConfig, err := RetryConfigFromDeliverySpec(duckv1.DeliverySpec{
+		Timeout: pointer.StringPtr("PT2.5S"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2500*time.Millisecond, retryConfig.Timeout)
+
+	_, err = RetryConfigFromDeliverySpec(duckv1.DeliverySpec{
+		Timeout: pointer.StringPtr("FOO"),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestRetryConfigFromDeliverySpecBackoffMaxDelay(t *testing.T) {
+	exponential := duckv1.BackoffPolicyExponential
+	retryConfig, err := RetryConfigFromDeliverySpec(duckv1.DeliverySpec{
+		Retry:           pointer.Int32Ptr(10),
+		BackoffPolicy:   &exponential,
+		BackoffDelay:    pointer.StringPtr("PT1S"),
+		BackoffMaxDelay: pointer.StringPtr("PT10S"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 4*time.Second, retryConfig.Backoff(2, nil))
+	assert.Equal(t, 10*time.Second, retryConfig.Backoff(4, nil))
+	// The exponential backoff overflows.
+	assert.Equal(t, 10*time.Second, retryConfig.Backoff(100, nil))
+
+	_, err = RetryConfigFromDeliverySpec(duckv1.DeliverySpec{
+		BackoffMaxDelay: pointer.StringPtr("FOO"),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestRetryConfigFromDeliverySpecBackoffJitter(t *testing.T) {
+	linear := duckv1.BackoffPolicyLinear
+	for jitter, min := range map[duckv1.BackoffJitterType]time.Duration{
+		duckv1.BackoffJitterNone:  10 * time.Second,
+		duckv1.BackoffJitterFull:  0,
+		duckv1.BackoffJitterEqual: 5 * time.Second,
+	} {
+		jitter, min := jitter, min
+		t.Run(string(jitter), func(t *testing.T) {
+			retryConfig, err := RetryConfigFromDeliverySpec(duckv1.DeliverySpec{
+				Retry:         pointer.Int32Ptr(10),
+				BackoffPolicy: &linear,
+				BackoffDelay:  pointer.StringPtr("PT1S"),
+				BackoffJitter: &jitter,
+			})
+			assert.Nil(t, err)
+			for i := 0; i < 100; i++ {
+				got := retryConfig.Backoff(10, nil)
+				if got < min || got > 10*time.Second {
+					t.Fatalf("Backoff(10) = %v, want in [%v, %v]", got, min, 10*time.Second)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryConfigFromDeliverySpecRetryAfter(t *testing.T) {
+	linear := duckv1.BackoffPolicyLinear
+	fullJitter := duckv1.BackoffJitterFull
+	retryConfig, err := RetryConfigFromDeliverySpec(duckv1.DeliverySpec{
+		Retry:           pointer.Int32Ptr(10),
+		BackoffPolicy:   &linear,
+		BackoffDelay:    pointer.StringPtr("PT1S"),
+		BackoffMaxDelay: pointer.StringPtr("PT1M"),
+		BackoffJitter:   &fullJitter,
+	})
+	assert.Nil(t, err)
+
+	response := func(statusCode int, retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: statusCode, Header: http.Header{}}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+
+	assert.Equal(t, 30*time.Second, retryConfig.Backoff(1, response(http.StatusTooManyRequests, "30")))
+	assert.Equal(t, 30*time.Second, retryConfig.Backoff(1, response(http.StatusServiceUnavailable, "30")))
+	// Capped by the max delay.
+	assert.Equal(t, time.Minute, retryConfig.Backoff(1, response(http.StatusServiceUnavailable, "3600")))
+
+	date := time.Now().Add(20 * time.Second).UTC().Format(http.TimeFormat)
+	if got := retryConfig.Backoff(1, response(http.StatusTooManyRequests, date)); got <= 10*time.Second || got > 20*time.Second {
+		t.Errorf("Backoff() with Retry-After %q = %v, want in (10s, 20s]", date, got)
+	}
+
+	// Ignored for other status codes, or when not parseable.
+	for _, resp := range []*http.Response{
+		response(http.StatusInternalServerError, "30"),
+		response(http.StatusTooManyRequests, "soon"),
+		response(http.StatusTooManyRequests, ""),
+	} {
+		if got := retryConfig.Backoff(1, resp); got > time.Second {
+			t.Errorf("Backoff() = %v, want at most 1s for status %d and Retry-After %q", got, resp.StatusCode, resp.Header.Get("Retry-After"))
+		}
+	}
+}
+
+func TestRetryConfigFromDeliverySpecRetryAfterNoMaxDelay(t *testing.T) {
+	retryConfig, err := RetryConfigFromDeliverySpec(duckv1.DeliverySpec{
+		Retry: pointer.Int32Ptr(10),
+	})
+	assert.Nil(t, err)
+
+	response := func(retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		resp.Header.Set("Retry-After", retryAfter)
+		return resp
+	}
+
+	assert.Equal(t, 30*time.Second, retryConfig.Backoff(1, response("30")))
+	// Capped by default, including values which would overflow a time.Duration.
+	assert.Equal(t, maxRetryAfter, retryConfig.Backoff(1, response("3600")))
+	assert.Equal(t, maxRetryAfter, retryConfig.Backoff(1, response("9223372036854775807")))
+	date := time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat)
+	assert.Equal(t, maxRetryAfter, retryConfig.Backoff(1, response(date)))
+}
+
+func TestRetryConfigFromDeliverySpecStatusCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryable    []string
+		nonRetryable []string
+		wantRetry    map[int]bool
+		wantErr      bool
+	}{{
+		name:      "default",
+		wantRetry: map[int]bool{200: false, 202: false, 400: true, 404: true, 429: true, 500: true, 503: true},
+	}, {
+		name:         "non-retryable class",
+		nonRetryable: []string{"4xx"},
+		wantRetry:    map[int]bool{202: false, 400: false, 404: false, 429: false, 500: true, 503: true},
+	}, {
+		name:         "retryable code overrides non-retryable class",
+		retryable:    []string{"429"},
+		nonRetryable: []string{"4xx"},
+		wantRetry:    map[int]bool{202: false, 400: false, 429: true, 500: false, 503: false},
+	}, {
+		name:         "non-retryable code overrides retryable class",
+		retryable:    []string{"5xx"},
+		nonRetryable: []string{"501"},
+		wantRetry:    map[int]bool{202: false, 404: false, 500: true, 501: false, 503: true},
+	}, {
+		name:      "invalid code",
+		retryable: []string{"abc"},
+		wantErr:   true,
+	}, {
+		name:         "invalid class",
+		nonRetryable: []string{"Axx"},
+		wantErr:      true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryConfig, err := RetryConfigFromDeliverySpec(duckv1.DeliverySpec{
+				Retry:                   pointer.Int32Ptr(3),
+				RetryableStatusCodes:    tt.retryable,
+				NonRetryableStatusCodes: tt.nonRetryable,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RetryConfigFromDeliverySpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			for code, want := range tt.wantRetry {
+				got, err := retryConfig.CheckRetry(context.Background(), &http.Response{StatusCode: code}, nil)
+				if err != nil {
+					t.Errorf("CheckRetry(%d) error = %v", code, err)
+				}
+				if got != want {
+					t.Errorf("CheckRetry(%d) = %v, want %v", code, got, want)
+				}
+			}
+			// Network errors are always retried.
+			if got, _ := retryConfig.CheckRetry(context.Background(), nil, net.ErrClosed); !got {
+				t.Error("CheckRetry() = false for a network error, want true")
+			}
+		})
+	}
+}
+
+func TestHTTPMessageSenderSendWithRetriesAndAttempts(t *testing.T) {
+	var n int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.AddInt32(&n, 1) < 3 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := &HTTPMessageSender{Client: http.DefaultClient}
+	config, err := RetryConfigFromDeliverySpec(duckv1.DeliverySpec{Retry: pointer.Int32Ptr(5)})
+	assert.Nil(t, err)
+
+	request, err := http.NewRequest("POST", server.URL, nil)
+	assert.Nil(t, err)
+	got, attempts, err := sender.SendWithRetriesAndAttempts(request, &config)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, got.StatusCode)
+	assert.Equal(t, 3, attempts)
+
+	request, err = http.NewRequest("POST", server.URL, nil)
+	assert.Nil(t, err)
+	_, attempts, err = sender.SendWithRetriesAndAttempts(request, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestHTTPMessageSenderSendWithRetriesTimeout(t *testing.T) {
+	var n int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// The first attempt hangs until the test ends.
+		if atomic.AddInt32(&n, 1) == 1 {
+			<-release
+		}
+		writer.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	linear := duckv1.BackoffPolicyLinear
+	config, err := RetryConfigFromDeliverySpec(duckv1.DeliverySpec{
+		Retry:         pointer.Int32Ptr(2),
+		BackoffPolicy: &linear,
+		BackoffDelay:  pointer.StringPtr("PT0.01S"),
+		Timeout:       pointer.StringPtr("PT0.1S"),
+	})
+	assert.Nil(t, err)
+
+	sender := &HTTPMessageSender{
+		Client: http.DefaultClient,
+	}
+	request, err := http.NewRequest("POST", server.URL, nil)
+	assert.Nil(t, err)
+
+	start := time.Now()
+	got, err := sender.SendWithRetries(request, &config)
+	if err != nil {
+		t.Fatalf("SendWithRetries() error = %v, wantErr nil", err)
+	}
+	if got.StatusCode != http.StatusAccepted {
+		t.Fatalf("SendWithRetries() got = %v, want %v", got.StatusCode, http.StatusAccepted)
+	}
+	if count := atomic.LoadInt32(&n); count != 2 {
+		t.Fatalf("expected 2 attempts got %d", count)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the first attempt to time out, took %v", elapsed)
+	}
+	if http.DefaultClient.Timeout != 0 {
+		t.Fatal("expected the shared client to be left untouched")
+	}
+}
+
 func TestHTTPMessageSenderSendWithRetries(t *testing.T) {
 	t.Parallel()
 
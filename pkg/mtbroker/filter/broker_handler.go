@@ -0,0 +1,227 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/client"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/network"
+	"knative.dev/pkg/system"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/eventing/pkg/eventfilter"
+	"knative.dev/eventing/pkg/kncloudevents"
+	broker "knative.dev/eventing/pkg/mtbroker"
+	"knative.dev/eventing/pkg/reconciler/names"
+	"knative.dev/eventing/pkg/reconciler/sugar/trigger/path"
+	"knative.dev/eventing/pkg/tracing"
+)
+
+// serveBroker handles the events sent once per Broker, rather than once per Trigger:
+// 1. extract event from request
+// 2. get the Triggers of the Broker which may match the event from the index
+// 3. filter event with each of them
+// 4. send event to the subscribers of the matching Triggers, concurrently
+// 5. send the replies back to the Broker ingress
+// 6. write the response
+//
+// Delivery is at-least-once: if any of the dispatches fails the whole request fails, so that the
+// channel retries it, and the subscribers which already received the event receive it again.
+func (h *Handler) serveBroker(writer http.ResponseWriter, request *http.Request) {
+	brokerRef, err := path.ParseBroker(request.RequestURI)
+	if err != nil {
+		h.logger.Info("Unable to parse path as broker", zap.Error(err), zap.String("path", request.RequestURI))
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx := request.Context()
+
+	message := cehttp.NewMessageFromHttpRequest(request)
+	defer message.Finish(nil)
+
+	event, err := binding.ToEvent(ctx, message)
+	if err != nil {
+		h.logger.Warn("failed to extract event from request", zap.Error(err))
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, span := trace.StartSpan(ctx, tracing.BrokerMessagingDestination(brokerRef))
+	defer span.End()
+
+	if span.IsRecordingEvents() {
+		span.AddAttributes(
+			tracing.MessagingSystemAttribute,
+			tracing.MessagingProtocolHTTP,
+			tracing.BrokerMessagingDestinationAttribute(brokerRef),
+			tracing.MessagingMessageIDAttribute(event.ID()),
+		)
+		span.AddAttributes(client.EventTraceAttributes(event)...)
+	}
+
+	// Remove the TTL attribute that is used by the Broker.
+	ttl, err := broker.GetTTL(event.Context)
+	if err != nil {
+		// Only messages sent by the Broker should be here. If the attribute isn't here, then the
+		// event wasn't sent by the Broker, so we can drop it.
+		h.logger.Warn("No TTL seen, dropping", zap.Any("brokerRef", brokerRef), zap.Any("event", event))
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := broker.DeleteTTL(event.Context); err != nil {
+		h.logger.Warn("Failed to delete TTL.", zap.Error(err))
+	}
+
+	h.logger.Debug("Received message", zap.Any("brokerRef", brokerRef))
+
+	triggers, err := h.triggers.candidates(brokerRef, *event)
+	if err != nil {
+		h.logger.Info("Unable to list the Triggers", zap.Error(err), zap.Any("brokerRef", brokerRef))
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ctx = logging.WithLogger(ctx, h.logger.Sugar())
+	// A failed dispatch doesn't cancel the others, so that the subscribers which are available
+	// receive the event without waiting for the retry.
+	var g errgroup.Group
+	for _, t := range triggers {
+		if t.Status.SubscriberURI == nil {
+			// The Trigger isn't ready yet, it will be reconciled again once it is.
+			continue
+		}
+		if h.filters.get(ctx, t).Filter(ctx, *event) == eventfilter.FailFilter {
+			continue
+		}
+		t := t
+		g.Go(func() error {
+			return h.dispatch(ctx, request.Header, brokerRef, t, event, ttl)
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		h.logger.Error("failed to dispatch event", zap.Error(err), zap.Any("brokerRef", brokerRef))
+		writer.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	writer.WriteHeader(http.StatusAccepted)
+}
+
+// dispatch sends the event to the subscriber of the Trigger 't' and the reply, if any, to the
+// ingress of the Broker.
+func (h *Handler) dispatch(ctx context.Context, headers http.Header, brokerRef types.NamespacedName, t *eventingv1beta1.Trigger, event *cloudevents.Event, ttl int32) error {
+	reportArgs := &ReportArgs{
+		ns:         t.Namespace,
+		trigger:    t.Name,
+		broker:     t.Spec.Broker,
+		filterType: triggerFilterAttribute(t.Spec.Filter, "type"),
+	}
+	h.reportArrivalTime(event, reportArgs)
+
+	creds, err := h.subscriberCredentials(t)
+	if err != nil {
+		_ = h.reporter.ReportEventCount(reportArgs, http.StatusInternalServerError)
+		return err
+	}
+
+	target := t.Status.SubscriberURI.String()
+	response, err := h.sendEvent(ctx, headers, target, creds, event, reportArgs)
+	if err != nil {
+		_ = h.reporter.ReportEventCount(reportArgs, http.StatusInternalServerError)
+		return err
+	}
+
+	statusCode, err := h.forwardReply(ctx, response, brokerRef, ttl, target)
+	_ = h.reporter.ReportEventCount(reportArgs, statusCode)
+	return err
+}
+
+// forwardReply sends the event in the response of the subscriber, if any, to the ingress of the
+// Broker. The returned status code is the one reported for the Trigger.
+func (h *Handler) forwardReply(ctx context.Context, resp *http.Response, brokerRef types.NamespacedName, ttl int32, target string) (int, error) {
+	response := cehttp.NewMessageFromHttpResponse(resp)
+	defer response.Finish(nil)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("subscriber %q responded with status code %d", target, resp.StatusCode)
+	}
+
+	if response.ReadEncoding() == binding.EncodingUnknown {
+		// See writeResponse, a non-empty response which isn't a CloudEvent is a delivery failure.
+		body := make([]byte, 1)
+		n, _ := response.BodyReader.Read(body)
+		response.BodyReader.Close()
+		if n != 0 {
+			return http.StatusBadGateway, errors.New("received a non-empty response not recognized as CloudEvent. The response MUST be or empty or a valid CloudEvent")
+		}
+		return resp.StatusCode, nil
+	}
+
+	event, err := binding.ToEvent(ctx, response)
+	if err != nil {
+		return http.StatusBadGateway, err
+	}
+
+	// Reattach the TTL (with the same value) to the response event before sending it to the Broker.
+	if err := broker.SetTTL(event.Context, ttl); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to reset TTL: %w", err)
+	}
+
+	req, err := h.sender.NewCloudEventRequestWithTarget(ctx, h.brokerIngressURL(brokerRef))
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to create the request: %w", err)
+	}
+	reply := binding.ToMessage(event)
+	defer reply.Finish(nil)
+	if err := kncloudevents.WriteHTTPRequestWithAdditionalHeaders(ctx, reply, req, nil); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to write request: %w", err)
+	}
+
+	ingressResp, err := h.sender.Send(req)
+	if err != nil {
+		return http.StatusBadGateway, fmt.Errorf("failed to send the reply to the broker: %w", err)
+	}
+	defer ingressResp.Body.Close()
+	if ingressResp.StatusCode < http.StatusOK || ingressResp.StatusCode >= http.StatusMultipleChoices {
+		return http.StatusBadGateway, fmt.Errorf("broker ingress responded with status code %d", ingressResp.StatusCode)
+	}
+
+	h.logger.Debug("Sent the reply to the broker", zap.Any("target", target), zap.Any("brokerRef", brokerRef))
+
+	return resp.StatusCode, nil
+}
+
+func (h *Handler) brokerIngressURL(brokerRef types.NamespacedName) string {
+	host := h.ingressHost
+	if host == "" {
+		host = network.GetServiceHostname(names.BrokerIngressName, system.Namespace())
+	}
+	return fmt.Sprintf("http://%s/%s/%s", host, brokerRef.Namespace, brokerRef.Name)
+}
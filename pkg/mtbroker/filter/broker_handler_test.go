@@ -0,0 +1,230 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	broker "knative.dev/eventing/pkg/mtbroker"
+	reconcilertesting "knative.dev/eventing/pkg/reconciler/testing"
+)
+
+var brokerPath = fmt.Sprintf("/brokers/%s/%s", testNS, brokerName)
+
+func TestBrokerReceiver(t *testing.T) {
+	testCases := map[string]struct {
+		triggers           []*eventingv1beta1.Trigger
+		path               string
+		event              *cloudevents.Event
+		failingSubscribers map[string]bool
+		returnedEvent      *cloudevents.Event
+		expectedStatus     int
+		expectedDispatched []string
+		expectedReplies    int
+	}{
+		"Path without namespace": {
+			path:           "/brokers/" + brokerName,
+			expectedStatus: http.StatusBadRequest,
+		},
+		"No TTL": {
+			triggers: []*eventingv1beta1.Trigger{
+				makeBrokerTrigger("no-filter", nil),
+			},
+			event:          makeEventWithoutTTL(),
+			expectedStatus: http.StatusBadRequest,
+		},
+		"No Triggers": {
+			expectedStatus: http.StatusAccepted,
+		},
+		"Dispatch to the matching Triggers only": {
+			triggers: []*eventingv1beta1.Trigger{
+				makeBrokerTrigger("by-type", makeTriggerFilterWithAttributes(eventType, "")),
+				makeBrokerTrigger("by-other-type", makeTriggerFilterWithAttributes("some-other-type", "")),
+				makeBrokerTrigger("by-source", makeTriggerFilterWithAttributes("", eventSource)),
+				makeBrokerTrigger("by-wrong-extension", makeTriggerFilterWithAttributesAndExtension(eventType, eventSource, "wrong")),
+				makeBrokerTrigger("no-filter", nil),
+			},
+			expectedStatus:     http.StatusAccepted,
+			expectedDispatched: []string{"by-source", "by-type", "no-filter"},
+		},
+		"Trigger without SubscriberURI is skipped": {
+			triggers: []*eventingv1beta1.Trigger{
+				func() *eventingv1beta1.Trigger {
+					t := makeBrokerTrigger("not-ready", nil)
+					t.Status = eventingv1beta1.TriggerStatus{}
+					return t
+				}(),
+				makeBrokerTrigger("no-filter", nil),
+			},
+			expectedStatus:     http.StatusAccepted,
+			expectedDispatched: []string{"no-filter"},
+		},
+		"Subscriber failure fails the request": {
+			triggers: []*eventingv1beta1.Trigger{
+				makeBrokerTrigger("by-type", makeTriggerFilterWithAttributes(eventType, "")),
+				makeBrokerTrigger("no-filter", nil),
+			},
+			failingSubscribers: map[string]bool{"no-filter": true},
+			expectedStatus:     http.StatusBadGateway,
+			expectedDispatched: []string{"by-type", "no-filter"},
+		},
+		"Replies are sent to the Broker ingress": {
+			triggers: []*eventingv1beta1.Trigger{
+				makeBrokerTrigger("by-type", makeTriggerFilterWithAttributes(eventType, "")),
+				makeBrokerTrigger("no-filter", nil),
+			},
+			returnedEvent:      makeDifferentEvent(),
+			expectedStatus:     http.StatusAccepted,
+			expectedDispatched: []string{"by-type", "no-filter"},
+			expectedReplies:    2,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			var mu sync.Mutex
+			var dispatched []string
+			var replies []*cloudevents.Event
+
+			ingress := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != fmt.Sprintf("/%s/%s", testNS, brokerName) {
+					t.Errorf("Unexpected ingress path %q", req.URL.Path)
+				}
+				e, err := binding.ToEvent(context.Background(), cehttp.NewMessageFromHttpRequest(req))
+				if err != nil {
+					t.Errorf("Unable to read the reply: %v", err)
+				}
+				mu.Lock()
+				replies = append(replies, e)
+				mu.Unlock()
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer ingress.Close()
+
+			subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				name := req.URL.Query().Get("trigger")
+				mu.Lock()
+				dispatched = append(dispatched, name)
+				mu.Unlock()
+				if tc.failingSubscribers[name] {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if tc.returnedEvent != nil {
+					message := binding.ToMessage(tc.returnedEvent)
+					defer message.Finish(nil)
+					if err := cehttp.WriteResponseWriter(context.Background(), message, http.StatusAccepted, w); err != nil {
+						t.Errorf("Unable to write the reply: %v", err)
+					}
+					return
+				}
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer subscriber.Close()
+
+			objs := make([]runtime.Object, 0, len(tc.triggers))
+			for _, trig := range tc.triggers {
+				if trig.Status.SubscriberURI != nil {
+					u, err := apis.ParseURL(subscriber.URL + "?" + url.Values{"trigger": {trig.Name}}.Encode())
+					if err != nil {
+						t.Fatalf("Failed to parse URL %q : %s", subscriber.URL, err)
+					}
+					trig.Status.SubscriberURI = u
+				}
+				objs = append(objs, trig)
+			}
+			listers := reconcilertesting.NewListers(objs)
+			reporter := &mockReporter{}
+			r, err := NewHandler(
+				zaptest.NewLogger(t, zaptest.WrapOptions(zap.AddCaller())),
+				listers.GetV1Beta1TriggerLister(),
+				reporter,
+				8080,
+				nil)
+			if err != nil {
+				t.Fatal("Unable to create receiver:", err)
+			}
+			ingressURL, _ := url.Parse(ingress.URL)
+			r.ingressHost = ingressURL.Host
+
+			e := tc.event
+			if e == nil {
+				e = makeEventWithExtension(extensionName, extensionValue)
+			}
+			b, err := e.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := tc.path
+			if p == "" {
+				p = brokerPath
+			}
+			request := httptest.NewRequest(http.MethodPost, p, bytes.NewBuffer(b))
+			request.Header.Set(cehttp.ContentType, event.ApplicationCloudEventsJSON)
+
+			responseWriter := httptest.NewRecorder()
+			r.ServeHTTP(responseWriter, request)
+
+			if got := responseWriter.Result().StatusCode; got != tc.expectedStatus {
+				t.Errorf("Unexpected status. Expected %v. Actual %v.", tc.expectedStatus, got)
+			}
+			if diff := cmp.Diff(tc.expectedDispatched, sortedNames(dispatched)); diff != "" {
+				t.Error("Unexpected dispatched triggers (-want +got):", diff)
+			}
+			if len(replies) != tc.expectedReplies {
+				t.Fatalf("Expected %d replies, got %d", tc.expectedReplies, len(replies))
+			}
+			for _, reply := range replies {
+				if reply.ID() != tc.returnedEvent.ID() {
+					t.Errorf("Unexpected reply ID %q, want %q", reply.ID(), tc.returnedEvent.ID())
+				}
+				if _, err := broker.GetTTL(reply.Context); err != nil {
+					t.Error("Expected the TTL to be reattached to the reply:", err)
+				}
+			}
+			if len(tc.expectedDispatched) != 0 && !reporter.eventCountReported {
+				t.Error("Expected the event count to be reported")
+			}
+		})
+	}
+}
+
+func sortedNames(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright 2020 The Knative Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"context"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/logging"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/eventing/pkg/eventfilter"
+	"knative.dev/eventing/pkg/eventfilter/attributes"
+	"knative.dev/eventing/pkg/eventfilter/cesql"
+	"knative.dev/eventing/pkg/eventfilter/data"
+	"knative.dev/eventing/pkg/eventfilter/subscriptionsapi"
+)
+
+// filterCache holds the compiled filters of the Triggers, so that they are built once per
+// Trigger generation rather than on every event.
+type filterCache struct {
+	mu      sync.RWMutex
+	entries map[types.UID]filterCacheEntry
+}
+
+type filterCacheEntry struct {
+	generation int64
+	filter     eventfilter.Filter
+}
+
+func newFilterCache() *filterCache {
+	return &filterCache{
+		entries: make(map[types.UID]filterCacheEntry),
+	}
+}
+
+// get returns the compiled filter for the given Trigger, compiling and storing it
+// if the cache has no entry for the Trigger's current generation.
+func (c *filterCache) get(ctx context.Context, t *eventingv1beta1.Trigger) eventfilter.Filter {
+	c.mu.RLock()
+	entry, ok := c.entries[t.UID]
+	c.mu.RUnlock()
+	if ok && entry.generation == t.Generation {
+		return entry.filter
+	}
+
+	f := compileFilter(ctx, t.Spec)
+	c.mu.Lock()
+	c.entries[t.UID] = filterCacheEntry{generation: t.Generation, filter: f}
+	c.mu.Unlock()
+	return f
+}
+
+func (c *filterCache) delete(uid types.UID) {
+	c.mu.Lock()
+	delete(c.entries, uid)
+	c.mu.Unlock()
+}
+
+// eventHandler returns the informer event handler which invalidates the entries of the updated
+// or deleted Triggers.
+func (c *filterCache) eventHandler() cache.ResourceEventHandler {
+	invalidate := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if t, ok := obj.(*eventingv1beta1.Trigger); ok {
+			c.delete(t.UID)
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldTrigger, ok := oldObj.(*eventingv1beta1.Trigger)
+			if !ok {
+				return
+			}
+			if newTrigger, ok := newObj.(*eventingv1beta1.Trigger); ok && newTrigger.Generation != oldTrigger.Generation {
+				c.delete(oldTrigger.UID)
+			}
+		},
+		DeleteFunc: invalidate,
+	}
+}
+
+// failFilter is used in place of filters which can't be compiled, so that no event passes them.
+type failFilter struct{}
+
+func (failFilter) Filter(context.Context, cloudevents.Event) eventfilter.FilterResult {
+	return eventfilter.FailFilter
+}
+
+// compileFilter builds the filter for the given Trigger spec. Filter and each of the Filters are ANDed together.
+func compileFilter(ctx context.Context, spec eventingv1beta1.TriggerSpec) eventfilter.Filter {
+	var filters eventfilter.Filters
+	if spec.Filter != nil {
+		if spec.Filter.Attributes != nil && len(spec.Filter.Attributes) != 0 {
+			filters = append(filters, attributes.NewAttributesFilter(spec.Filter.Attributes))
+		}
+		if spec.Filter.SQL != "" {
+			f, err := cesql.NewCESQLFilter(spec.Filter.SQL)
+			if err != nil {
+				// The expression is validated by the webhook, so this should never happen.
+				logging.FromContext(ctx).Warnw("Failed to parse the SQL filter", zap.Error(err))
+				return failFilter{}
+			}
+			filters = append(filters, f)
+		}
+	}
+	for _, apiFilter := range spec.Filters {
+		f, err := materializeSubscriptionsAPIFilter(apiFilter)
+		if err != nil {
+			// The filters are validated by the webhook, so this should never happen.
+			logging.FromContext(ctx).Warnw("Failed to materialize the filters", zap.Error(err))
+			return failFilter{}
+		}
+		filters = append(filters, f)
+	}
+	return filters
+}
+
+func materializeSubscriptionsAPIFilter(filter eventingv1beta1.SubscriptionsAPIFilter) (eventfilter.Filter, error) {
+	switch {
+	case len(filter.Exact) != 0:
+		return subscriptionsapi.NewExactFilter(filter.Exact), nil
+	case len(filter.Prefix) != 0:
+		return subscriptionsapi.NewPrefixFilter(filter.Prefix), nil
+	case len(filter.Suffix) != 0:
+		return subscriptionsapi.NewSuffixFilter(filter.Suffix), nil
+	case filter.SQL != "":
+		return cesql.NewCESQLFilter(filter.SQL)
+	case filter.Data != "":
+		return data.NewDataFilter(filter.Data)
+	case len(filter.All) != 0:
+		filters, err := materializeSubscriptionsAPIFilters(filter.All)
+		if err != nil {
+			return nil, err
+		}
+		return subscriptionsapi.NewAllFilter(filters...), nil
+	case len(filter.Any) != 0:
+		filters, err := materializeSubscriptionsAPIFilters(filter.Any)
+		if err != nil {
+			return nil, err
+		}
+		return subscriptionsapi.NewAnyFilter(filters...), nil
+	case filter.Not != nil:
+		f, err := materializeSubscriptionsAPIFilter(*filter.Not)
+		if err != nil {
+			return nil, err
+		}
+		return subscriptionsapi.NewNotFilter(f), nil
+	}
+	return eventfilter.Filters{}, nil
+}
+
+func materializeSubscriptionsAPIFilters(filters []eventingv1beta1.SubscriptionsAPIFilter) ([]eventfilter.Filter, error) {
+	materialized := make([]eventfilter.Filter, 0, len(filters))
+	for _, f := range filters {
+		mf, err := materializeSubscriptionsAPIFilter(f)
+		if err != nil {
+			return nil, err
+		}
+		materialized = append(materialized, mf)
+	}
+	return materialized, nil
+}
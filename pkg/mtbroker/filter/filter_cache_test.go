@@ -0,0 +1,98 @@
+/*
+ * Copyright 2020 The Knative Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/client-go/tools/cache"
+
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/eventing/pkg/eventfilter"
+)
+
+func TestFilterCache(t *testing.T) {
+	ctx := context.Background()
+	c := newFilterCache()
+	event := makeEvent()
+
+	trigger := makeTrigger(makeTriggerFilterWithAttributes(eventType, eventSource))
+	trigger.Generation = 1
+	f := c.get(ctx, trigger)
+	if got := f.Filter(ctx, *event); got != eventfilter.PassFilter {
+		t.Errorf("Filter() = %s, want %s", got, eventfilter.PassFilter)
+	}
+	if len(c.entries) != 1 {
+		t.Errorf("Expected 1 entry, got %d", len(c.entries))
+	}
+
+	// Same generation, the cached filter is returned even if the spec changed.
+	stale := trigger.DeepCopy()
+	stale.Spec.Filter = makeTriggerFilterWithAttributes("some-other-type", "")
+	if got := c.get(ctx, stale).Filter(ctx, *event); got != eventfilter.PassFilter {
+		t.Errorf("Filter() = %s, want cached result %s", got, eventfilter.PassFilter)
+	}
+
+	// A new generation recompiles the filter.
+	updated := stale.DeepCopy()
+	updated.Generation = 2
+	if got := c.get(ctx, updated).Filter(ctx, *event); got != eventfilter.FailFilter {
+		t.Errorf("Filter() = %s, want %s", got, eventfilter.FailFilter)
+	}
+	if entry := c.entries[trigger.UID]; entry.generation != 2 {
+		t.Errorf("Expected generation 2, got %d", entry.generation)
+	}
+
+	// Invalid filters never pass.
+	invalid := makeTrigger(&eventingv1beta1.TriggerFilter{SQL: "type ="})
+	invalid.UID = "invalid"
+	if got := c.get(ctx, invalid).Filter(ctx, *event); got != eventfilter.FailFilter {
+		t.Errorf("Filter() = %s, want %s", got, eventfilter.FailFilter)
+	}
+}
+
+func TestFilterCacheEventHandler(t *testing.T) {
+	ctx := context.Background()
+	c := newFilterCache()
+	h := c.eventHandler()
+
+	trigger := makeTrigger(makeTriggerFilterWithAttributes(eventType, eventSource))
+	trigger.Generation = 1
+	c.get(ctx, trigger)
+
+	// Status updates don't invalidate the entry.
+	statusUpdate := trigger.DeepCopy()
+	statusUpdate.Status.SubscriberURI = nil
+	h.OnUpdate(trigger, statusUpdate)
+	if _, ok := c.entries[trigger.UID]; !ok {
+		t.Error("Expected the entry to be kept after a status update")
+	}
+
+	specUpdate := trigger.DeepCopy()
+	specUpdate.Generation = 2
+	h.OnUpdate(trigger, specUpdate)
+	if _, ok := c.entries[trigger.UID]; ok {
+		t.Error("Expected the entry to be invalidated after a spec update")
+	}
+
+	c.get(ctx, specUpdate)
+	h.OnDelete(cache.DeletedFinalStateUnknown{Key: "ns/name", Obj: specUpdate})
+	if _, ok := c.entries[trigger.UID]; ok {
+		t.Error("Expected the entry to be invalidated after a delete")
+	}
+}
@@ -30,12 +30,13 @@ import (
 	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
 	"go.opencensus.io/trace"
 	"go.uber.org/zap"
+	"k8s.io/client-go/tools/cache"
 	"knative.dev/pkg/logging"
 
 	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
 	eventinglisters "knative.dev/eventing/pkg/client/listers/eventing/v1beta1"
+	"knative.dev/eventing/pkg/deliveryauth"
 	"knative.dev/eventing/pkg/eventfilter"
-	"knative.dev/eventing/pkg/eventfilter/attributes"
 	"knative.dev/eventing/pkg/kncloudevents"
 	broker "knative.dev/eventing/pkg/mtbroker"
 	"knative.dev/eventing/pkg/reconciler/sugar/trigger/path"
@@ -65,11 +66,24 @@ type Handler struct {
 
 	triggerLister eventinglisters.TriggerLister
 	logger        *zap.Logger
+
+	// filters caches the compiled filters of the Triggers
+	filters *filterCache
+	// triggers indexes the Triggers of each Broker, for the Brokers evaluating all their Triggers in one pass
+	triggers *triggerIndex
+	// ingressHost is the host of the Broker ingress, where the replies to the events sent once
+	// per Broker are sent. Defaults to the ingress service of the system namespace.
+	ingressHost string
+	// credentials resolves the Secrets holding the credentials of the Triggers' subscribers, it
+	// may be nil.
+	credentials *deliveryauth.Resolver
 }
 
 // NewHandler creates a new Handler and its associated MessageReceiver. The caller is responsible for
-// Start()ing the returned Handler.
-func NewHandler(logger *zap.Logger, triggerLister eventinglisters.TriggerLister, reporter StatsReporter, port int) (*Handler, error) {
+// Start()ing the returned Handler. The Handler authenticates to the subscribers of the Triggers
+// with the credentials resolved by credentials, the events sent to the subscribers of the
+// Triggers naming a Secret fail if it is nil.
+func NewHandler(logger *zap.Logger, triggerLister eventinglisters.TriggerLister, reporter StatsReporter, port int, credentials *deliveryauth.Resolver) (*Handler, error) {
 	kncloudevents.ConfigureConnectionArgs(&kncloudevents.ConnectionArgs{
 		MaxIdleConns:        defaultMaxIdleConnections,
 		MaxIdleConnsPerHost: defaultMaxIdleConnectionsPerHost,
@@ -85,13 +99,41 @@ func NewHandler(logger *zap.Logger, triggerLister eventinglisters.TriggerLister,
 		sender:        sender,
 		reporter:      reporter,
 		triggerLister: triggerLister,
+		filters:       newFilterCache(),
+		triggers:      newTriggerIndex(triggerLister),
 		logger:        logger,
+		credentials:   credentials,
 	}, nil
 }
 
+// TriggerEventHandler returns the event handler to register on the Trigger informer, which
+// invalidates the compiled filters of the updated and deleted Triggers, and the index of the
+// Brokers they belong to.
+func (h *Handler) TriggerEventHandler() cache.ResourceEventHandler {
+	handlers := []cache.ResourceEventHandler{h.filters.eventHandler(), h.triggers.eventHandler()}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			for _, handler := range handlers {
+				handler.OnAdd(obj)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			for _, handler := range handlers {
+				handler.OnUpdate(oldObj, newObj)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			for _, handler := range handlers {
+				handler.OnDelete(obj)
+			}
+		},
+	}
+}
+
 // Start begins to receive messages for the handler.
 //
-// HTTP POST requests to the root path (/) are accepted.
+// HTTP POST requests to the Trigger paths (/triggers/<ns>/<name>/<uid>) and to the Broker
+// paths (/brokers/<ns>/<name>) are accepted.
 //
 // This method will block until ctx is done.
 func (h *Handler) Start(ctx context.Context) error {
@@ -111,6 +153,11 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	if path.IsBroker(request.RequestURI) {
+		h.serveBroker(writer, request)
+		return
+	}
+
 	triggerRef, err := path.Parse(request.RequestURI)
 	if err != nil {
 		h.logger.Info("Unable to parse path as trigger", zap.Error(err), zap.String("path", request.RequestURI))
@@ -184,7 +231,7 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 
 	// Check if the event should be sent.
 	ctx = logging.WithLogger(ctx, h.logger.Sugar())
-	filterResult := filterEvent(ctx, t.Spec.Filter, *event)
+	filterResult := h.filters.get(ctx, t).Filter(ctx, *event)
 
 	if filterResult == eventfilter.FailFilter {
 		// We do not count the event. The event will be counted in the broker ingress.
@@ -194,12 +241,20 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 
 	h.reportArrivalTime(event, reportArgs)
 
-	h.send(ctx, writer, request.Header, subscriberURI.String(), reportArgs, event, ttl)
+	creds, err := h.subscriberCredentials(t)
+	if err != nil {
+		h.logger.Error("Unable to get the subscriber credentials", zap.Error(err), zap.Any("triggerRef", triggerRef))
+		writer.WriteHeader(http.StatusInternalServerError)
+		_ = h.reporter.ReportEventCount(reportArgs, http.StatusInternalServerError)
+		return
+	}
+
+	h.send(ctx, writer, request.Header, subscriberURI.String(), creds, reportArgs, event, ttl)
 }
 
-func (h *Handler) send(ctx context.Context, writer http.ResponseWriter, headers http.Header, target string, reportArgs *ReportArgs, event *cloudevents.Event, ttl int32) {
+func (h *Handler) send(ctx context.Context, writer http.ResponseWriter, headers http.Header, target string, creds *deliveryauth.Credentials, reportArgs *ReportArgs, event *cloudevents.Event, ttl int32) {
 	// send the event to trigger's subscriber
-	response, err := h.sendEvent(ctx, headers, target, event, reportArgs)
+	response, err := h.sendEvent(ctx, headers, target, creds, event, reportArgs)
 	if err != nil {
 		h.logger.Error("failed to send event", zap.Error(err))
 		writer.WriteHeader(http.StatusInternalServerError)
@@ -217,9 +272,10 @@ func (h *Handler) send(ctx context.Context, writer http.ResponseWriter, headers
 	_ = h.reporter.ReportEventCount(reportArgs, statusCode)
 }
 
-func (h *Handler) sendEvent(ctx context.Context, headers http.Header, target string, event *cloudevents.Event, reporterArgs *ReportArgs) (*http.Response, error) {
+func (h *Handler) sendEvent(ctx context.Context, headers http.Header, target string, creds *deliveryauth.Credentials, event *cloudevents.Event, reporterArgs *ReportArgs) (*http.Response, error) {
 	// Send the event to the subscriber
-	req, err := h.sender.NewCloudEventRequestWithTarget(ctx, target)
+	sender := creds.Sender(h.sender)
+	req, err := sender.NewCloudEventRequestWithTarget(ctx, target)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create the request: %w", err)
 	}
@@ -232,9 +288,10 @@ func (h *Handler) sendEvent(ctx context.Context, headers http.Header, target str
 	if err != nil {
 		return nil, fmt.Errorf("failed to write request: %w", err)
 	}
+	creds.Authorize(req)
 
 	start := time.Now()
-	resp, err := h.sender.Send(req)
+	resp, err := sender.Send(req)
 	dispatchTime := time.Since(start)
 	if err != nil {
 		err = fmt.Errorf("failed to dispatch message: %w", err)
@@ -313,6 +370,17 @@ func (h *Handler) reportArrivalTime(event *event.Event, reportArgs *ReportArgs)
 	}
 }
 
+// subscriberCredentials returns the credentials of the subscriber of the Trigger 't', if any.
+func (h *Handler) subscriberCredentials(t *eventingv1beta1.Trigger) (*deliveryauth.Credentials, error) {
+	if t.Spec.Delivery == nil || t.Spec.Delivery.Auth == nil || t.Spec.Delivery.Auth.SubscriberSecretName == "" {
+		return nil, nil
+	}
+	if h.credentials == nil {
+		return nil, errors.New("the broker filter does not support delivery credentials")
+	}
+	return h.credentials.Get(t.Namespace, t.Spec.Delivery.Auth.SubscriberSecretName)
+}
+
 func (h *Handler) getTrigger(ref path.NamespacedNameUID) (*eventingv1beta1.Trigger, error) {
 	t, err := h.triggerLister.Triggers(ref.Namespace).Get(ref.Name)
 	if err != nil {
@@ -324,17 +392,6 @@ func (h *Handler) getTrigger(ref path.NamespacedNameUID) (*eventingv1beta1.Trigg
 	return t, nil
 }
 
-func filterEvent(ctx context.Context, filter *eventingv1beta1.TriggerFilter, event cloudevents.Event) eventfilter.FilterResult {
-	if filter == nil {
-		return eventfilter.NoFilter
-	}
-	var filters eventfilter.Filters
-	if filter.Attributes != nil && len(filter.Attributes) != 0 {
-		filters = append(filters, attributes.NewAttributesFilter(filter.Attributes))
-	}
-	return filters.Filter(ctx, event)
-}
-
 // triggerFilterAttribute returns the filter attribute value for a given `attributeName`. If it doesn't not exist,
 // returns the any value filter.
 func triggerFilterAttribute(filter *eventingv1beta1.TriggerFilter, attributeName string) string {
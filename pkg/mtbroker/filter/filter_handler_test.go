@@ -24,6 +24,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -35,12 +36,17 @@ import (
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
 	"knative.dev/pkg/apis"
 
+	eventingduckv1beta1 "knative.dev/eventing/pkg/apis/duck/v1beta1"
+	"knative.dev/eventing/pkg/apis/eventing"
 	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/eventing/pkg/deliveryauth"
 	broker "knative.dev/eventing/pkg/mtbroker"
 	reconcilertesting "knative.dev/eventing/pkg/reconciler/testing"
 )
@@ -225,6 +231,68 @@ func TestReceiver(t *testing.T) {
 			event:              makeEventWithExtension(extensionName, extensionValue),
 			expectedEventCount: false,
 		},
+		"Dispatch succeeded - SQL": {
+			triggers: []*eventingv1beta1.Trigger{
+				makeTrigger(makeTriggerFilterWithSQL("type = '" + eventType + "' AND " + extensionName + " LIKE 'my-%'")),
+			},
+			event:                     makeEventWithExtension(extensionName, extensionValue),
+			expectedDispatch:          true,
+			expectedEventCount:        true,
+			expectedEventDispatchTime: true,
+		},
+		"Wrong SQL": {
+			triggers: []*eventingv1beta1.Trigger{
+				makeTrigger(makeTriggerFilterWithSQL("type = '" + eventType + "' AND NOT EXISTS " + extensionName)),
+			},
+			event:              makeEventWithExtension(extensionName, extensionValue),
+			expectedEventCount: false,
+		},
+		"Dispatch succeeded - Filters": {
+			triggers: []*eventingv1beta1.Trigger{
+				makeTriggerWithFilters(eventingv1beta1.SubscriptionsAPIFilter{
+					Prefix: map[string]string{"type": "com.example."},
+				}, eventingv1beta1.SubscriptionsAPIFilter{
+					Not: &eventingv1beta1.SubscriptionsAPIFilter{
+						Exact: map[string]string{"source": "some-other-source"},
+					},
+				}),
+			},
+			expectedDispatch:          true,
+			expectedEventCount:        true,
+			expectedEventDispatchTime: true,
+		},
+		"Wrong Filters": {
+			triggers: []*eventingv1beta1.Trigger{
+				makeTriggerWithFilters(eventingv1beta1.SubscriptionsAPIFilter{
+					Any: []eventingv1beta1.SubscriptionsAPIFilter{{
+						Suffix: map[string]string{"type": ".otherevent"},
+					}, {
+						Exact: map[string]string{"source": "some-other-source"},
+					}},
+				}),
+			},
+			expectedEventCount: false,
+		},
+		"Dispatch succeeded - Data": {
+			triggers: []*eventingv1beta1.Trigger{
+				makeTriggerWithFilters(eventingv1beta1.SubscriptionsAPIFilter{
+					Data: `$.order.total > 100`,
+				}),
+			},
+			event:                     makeEventWithJSONData(`{"order": {"total": 150}}`),
+			expectedDispatch:          true,
+			expectedEventCount:        true,
+			expectedEventDispatchTime: true,
+		},
+		"Wrong Data": {
+			triggers: []*eventingv1beta1.Trigger{
+				makeTriggerWithFilters(eventingv1beta1.SubscriptionsAPIFilter{
+					Data: `$.order.total > 100`,
+				}),
+			},
+			event:              makeEventWithJSONData(`{"order": {"total": 50}}`),
+			expectedEventCount: false,
+		},
 		"Returned Cloud Event": {
 			triggers: []*eventingv1beta1.Trigger{
 				makeTrigger(makeTriggerFilterWithAttributes("", "")),
@@ -363,7 +431,8 @@ func TestReceiver(t *testing.T) {
 				zaptest.NewLogger(t, zaptest.WrapOptions(zap.AddCaller())),
 				listers.GetV1Beta1TriggerLister(),
 				reporter,
-				8080)
+				8080,
+				nil)
 			if tc.expectNewToFail {
 				if err == nil {
 					t.Fatal("Expected New to fail, it didn't")
@@ -451,6 +520,83 @@ func TestReceiver(t *testing.T) {
 	}
 }
 
+func TestReceiverDeliveryAuth(t *testing.T) {
+	testCases := map[string]struct {
+		secretName     string
+		resolver       bool
+		expectedStatus int
+	}{
+		"Secret found": {
+			secretName:     "creds",
+			resolver:       true,
+			expectedStatus: http.StatusAccepted,
+		},
+		"Secret not found": {
+			secretName:     "other-creds",
+			resolver:       true,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		"No credentials resolver": {
+			secretName:     "creds",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			var authorization string
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer s.Close()
+
+			trig := makeTriggerWithoutFilter()
+			trig.Spec.Delivery = &eventingduckv1beta1.DeliverySpec{
+				Auth: &eventingduckv1beta1.DeliveryAuth{SubscriberSecretName: tc.secretName},
+			}
+			trig.Status.SubscriberURI, _ = apis.ParseURL(s.URL)
+			listers := reconcilertesting.NewListers([]runtime.Object{trig})
+
+			var resolver *deliveryauth.Resolver
+			if tc.resolver {
+				resolver = deliveryauth.NewResolverFromClient(fakekubeclientset.NewSimpleClientset(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: testNS,
+						Name:      "creds",
+						Labels:    map[string]string{eventing.DeliveryAuthLabelKey: "true"},
+					},
+					Data: map[string][]byte{deliveryauth.TokenKey: []byte("secret-token")},
+				}))
+			}
+			r, err := NewHandler(
+				zaptest.NewLogger(t, zaptest.WrapOptions(zap.AddCaller())),
+				listers.GetV1Beta1TriggerLister(),
+				&mockReporter{},
+				8080,
+				resolver)
+			if err != nil {
+				t.Fatal("Unable to create receiver:", err)
+			}
+
+			b, err := makeEvent().MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			request := httptest.NewRequest(http.MethodPost, validPath, bytes.NewBuffer(b))
+			request.Header.Set(cehttp.ContentType, event.ApplicationCloudEventsJSON)
+			responseWriter := httptest.NewRecorder()
+			r.ServeHTTP(responseWriter, request)
+
+			if responseWriter.Code != tc.expectedStatus {
+				t.Errorf("Unexpected status. Expected %v. Actual %v.", tc.expectedStatus, responseWriter.Code)
+			}
+			if tc.expectedStatus == http.StatusAccepted && authorization != "Bearer secret-token" {
+				t.Errorf("Unexpected Authorization header %q", authorization)
+			}
+		})
+	}
+}
+
 type responseWriterWithInvocationsCheck struct {
 	http.ResponseWriter
 	headersWritten *atomic.Bool
@@ -465,22 +611,30 @@ func (r *responseWriterWithInvocationsCheck) WriteHeader(statusCode int) {
 }
 
 type mockReporter struct {
+	// mu guards the fields below, as the events sent once per Broker are dispatched concurrently.
+	mu                          sync.Mutex
 	eventCountReported          bool
 	eventDispatchTimeReported   bool
 	eventProcessingTimeReported bool
 }
 
 func (r *mockReporter) ReportEventCount(args *ReportArgs, responseCode int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.eventCountReported = true
 	return nil
 }
 
 func (r *mockReporter) ReportEventDispatchTime(args *ReportArgs, responseCode int, d time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.eventDispatchTimeReported = true
 	return nil
 }
 
 func (r *mockReporter) ReportEventProcessingTime(args *ReportArgs, d time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.eventProcessingTimeReported = true
 	return nil
 }
@@ -566,6 +720,12 @@ func makeTriggerFilterWithAttributesAndExtension(t, s, e string) *eventingv1beta
 	}
 }
 
+func makeTriggerFilterWithSQL(sql string) *eventingv1beta1.TriggerFilter {
+	return &eventingv1beta1.TriggerFilter{
+		SQL: sql,
+	}
+}
+
 func makeTrigger(filter *eventingv1beta1.TriggerFilter) *eventingv1beta1.Trigger {
 	return &eventingv1beta1.Trigger{
 		TypeMeta: metav1.TypeMeta{
@@ -586,6 +746,12 @@ func makeTrigger(filter *eventingv1beta1.TriggerFilter) *eventingv1beta1.Trigger
 	}
 }
 
+func makeTriggerWithFilters(filters ...eventingv1beta1.SubscriptionsAPIFilter) *eventingv1beta1.Trigger {
+	t := makeTrigger(nil)
+	t.Spec.Filters = filters
+	return t
+}
+
 func makeTriggerWithoutFilter() *eventingv1beta1.Trigger {
 	t := makeTrigger(makeTriggerFilterWithAttributes("", ""))
 	t.Spec.Filter = nil
@@ -631,6 +797,12 @@ func makeEventWithExtension(extName, extValue string) *cloudevents.Event {
 	return &e
 }
 
+func makeEventWithJSONData(data string) *cloudevents.Event {
+	e := makeEvent()
+	_ = e.SetData(cloudevents.ApplicationJSON, []byte(data))
+	return e
+}
+
 func makeNonEmptyResponse() *http.Response {
 	r := &http.Response{
 		Status:     "200 OK",
@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	eventinglisters "knative.dev/eventing/pkg/client/listers/eventing/v1beta1"
+)
+
+// triggerIndex indexes the Triggers of each Broker by the exact event type or source they
+// filter on, so that a Broker only evaluates the filters of the Triggers an event may match.
+// The index of a Broker is built lazily and dropped whenever one of its Triggers changes.
+type triggerIndex struct {
+	triggerLister eventinglisters.TriggerLister
+
+	mu      sync.RWMutex
+	brokers map[types.NamespacedName]*brokerTriggers
+	// generations is bumped whenever the index of a Broker is dropped, so that an index built
+	// from a listing that raced with the invalidation is not stored.
+	generations map[types.NamespacedName]uint64
+}
+
+// brokerTriggers holds each Trigger of a Broker in exactly one bucket.
+type brokerTriggers struct {
+	byType   map[string][]*eventingv1beta1.Trigger
+	bySource map[string][]*eventingv1beta1.Trigger
+	// others holds the Triggers which don't require an exact type nor source.
+	others []*eventingv1beta1.Trigger
+}
+
+func newTriggerIndex(triggerLister eventinglisters.TriggerLister) *triggerIndex {
+	return &triggerIndex{
+		triggerLister: triggerLister,
+		brokers:       make(map[types.NamespacedName]*brokerTriggers),
+		generations:   make(map[types.NamespacedName]uint64),
+	}
+}
+
+// candidates returns the Triggers of the given Broker whose filters may pass the event.
+func (i *triggerIndex) candidates(broker types.NamespacedName, event cloudevents.Event) ([]*eventingv1beta1.Trigger, error) {
+	bt, err := i.get(broker)
+	if err != nil {
+		return nil, err
+	}
+	byType := bt.byType[event.Type()]
+	bySource := bt.bySource[event.Source()]
+	candidates := make([]*eventingv1beta1.Trigger, 0, len(byType)+len(bySource)+len(bt.others))
+	candidates = append(candidates, byType...)
+	candidates = append(candidates, bySource...)
+	return append(candidates, bt.others...), nil
+}
+
+func (i *triggerIndex) get(broker types.NamespacedName) (*brokerTriggers, error) {
+	i.mu.RLock()
+	bt, ok := i.brokers[broker]
+	generation := i.generations[broker]
+	i.mu.RUnlock()
+	if ok {
+		return bt, nil
+	}
+
+	selector := labels.SelectorFromSet(map[string]string{eventing.BrokerLabelKey: broker.Name})
+	triggers, err := i.triggerLister.Triggers(broker.Namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	bt = &brokerTriggers{
+		byType:   make(map[string][]*eventingv1beta1.Trigger),
+		bySource: make(map[string][]*eventingv1beta1.Trigger),
+	}
+	for _, t := range triggers {
+		if t.Spec.Broker != broker.Name {
+			continue
+		}
+		if v, ok := exactAttribute(t.Spec, "type"); ok {
+			bt.byType[v] = append(bt.byType[v], t)
+		} else if v, ok := exactAttribute(t.Spec, "source"); ok {
+			bt.bySource[v] = append(bt.bySource[v], t)
+		} else {
+			bt.others = append(bt.others, t)
+		}
+	}
+
+	i.mu.Lock()
+	if i.generations[broker] == generation {
+		i.brokers[broker] = bt
+	}
+	i.mu.Unlock()
+	return bt, nil
+}
+
+func (i *triggerIndex) delete(broker types.NamespacedName) {
+	i.mu.Lock()
+	delete(i.brokers, broker)
+	i.generations[broker]++
+	i.mu.Unlock()
+}
+
+// eventHandler returns the informer event handler which drops the index of the Brokers whose
+// Triggers are added, updated or deleted.
+func (i *triggerIndex) eventHandler() cache.ResourceEventHandler {
+	invalidate := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if t, ok := obj.(*eventingv1beta1.Trigger); ok {
+			i.delete(types.NamespacedName{Namespace: t.Namespace, Name: t.Spec.Broker})
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: invalidate,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			invalidate(oldObj)
+			invalidate(newObj)
+		},
+		DeleteFunc: invalidate,
+	}
+}
+
+// exactAttribute returns the value the given attribute must be equal to for an event to pass
+// the Trigger's filters, if any. The legacy filter and the top level exact filters are ANDed
+// together, so any of them is a sufficient condition.
+func exactAttribute(spec eventingv1beta1.TriggerSpec, name string) (string, bool) {
+	if spec.Filter != nil {
+		if v, ok := spec.Filter.Attributes[name]; ok && v != eventingv1beta1.TriggerAnyFilter {
+			return v, true
+		}
+	}
+	for _, f := range spec.Filters {
+		if v, ok := f.Exact[name]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	eventinglisters "knative.dev/eventing/pkg/client/listers/eventing/v1beta1"
+	reconcilertesting "knative.dev/eventing/pkg/reconciler/testing"
+)
+
+const brokerName = "test-broker"
+
+func TestTriggerIndexCandidates(t *testing.T) {
+	triggers := []*eventingv1beta1.Trigger{
+		makeBrokerTrigger("by-type", makeTriggerFilterWithAttributes(eventType, "")),
+		makeBrokerTrigger("by-other-type", makeTriggerFilterWithAttributes("some-other-type", eventSource)),
+		makeBrokerTrigger("by-source", makeTriggerFilterWithAttributes("", eventSource)),
+		makeBrokerTrigger("by-other-source", makeTriggerFilterWithAttributes("", "another-source")),
+		makeBrokerTrigger("by-exact-type", nil, eventingv1beta1.SubscriptionsAPIFilter{
+			Exact: map[string]string{"type": eventType},
+		}),
+		makeBrokerTrigger("no-filter", nil),
+		makeBrokerTrigger("by-prefix", nil, eventingv1beta1.SubscriptionsAPIFilter{
+			Prefix: map[string]string{"type": "com.example"},
+		}),
+	}
+	otherBroker := makeBrokerTrigger("other-broker", nil)
+	otherBroker.Spec.Broker = "other"
+	otherBroker.Labels[eventing.BrokerLabelKey] = "other"
+
+	objs := []runtime.Object{otherBroker}
+	for _, trig := range triggers {
+		objs = append(objs, trig)
+	}
+	listers := reconcilertesting.NewListers(objs)
+	index := newTriggerIndex(listers.GetV1Beta1TriggerLister())
+
+	got, err := index.candidates(types.NamespacedName{Namespace: testNS, Name: brokerName}, *makeEvent())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	want := []string{"by-exact-type", "by-prefix", "by-source", "by-type", "no-filter"}
+	if diff := cmp.Diff(want, triggerNames(got)); diff != "" {
+		t.Error("Unexpected candidates (-want +got):", diff)
+	}
+}
+
+func TestTriggerIndexEventHandler(t *testing.T) {
+	ref := types.NamespacedName{Namespace: testNS, Name: brokerName}
+	trigger := makeBrokerTrigger("by-type", makeTriggerFilterWithAttributes(eventType, ""))
+	listers := reconcilertesting.NewListers([]runtime.Object{trigger})
+	index := newTriggerIndex(listers.GetV1Beta1TriggerLister())
+	h := index.eventHandler()
+
+	for name, invalidate := range map[string]func(){
+		"add":    func() { h.OnAdd(trigger) },
+		"update": func() { h.OnUpdate(trigger, trigger.DeepCopy()) },
+		"delete": func() { h.OnDelete(trigger) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := index.candidates(ref, *makeEvent()); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if _, ok := index.brokers[ref]; !ok {
+				t.Fatal("Expected the broker to be indexed")
+			}
+			invalidate()
+			if _, ok := index.brokers[ref]; ok {
+				t.Error("Expected the broker index to be invalidated")
+			}
+		})
+	}
+}
+
+func TestTriggerIndexInvalidatedWhileListing(t *testing.T) {
+	ref := types.NamespacedName{Namespace: testNS, Name: brokerName}
+	trigger := makeBrokerTrigger("by-type", makeTriggerFilterWithAttributes(eventType, ""))
+	listers := reconcilertesting.NewListers([]runtime.Object{trigger})
+	lister := &hookedTriggerLister{TriggerLister: listers.GetV1Beta1TriggerLister()}
+	index := newTriggerIndex(lister)
+	lister.onList = func() { index.eventHandler().OnAdd(trigger) }
+
+	if _, err := index.candidates(ref, *makeEvent()); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, ok := index.brokers[ref]; ok {
+		t.Error("Expected the index built from a stale listing not to be stored")
+	}
+
+	lister.onList = nil
+	if _, err := index.candidates(ref, *makeEvent()); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, ok := index.brokers[ref]; !ok {
+		t.Error("Expected the broker to be indexed")
+	}
+}
+
+// hookedTriggerLister calls onList before listing Triggers.
+type hookedTriggerLister struct {
+	eventinglisters.TriggerLister
+	onList func()
+}
+
+func (l *hookedTriggerLister) Triggers(namespace string) eventinglisters.TriggerNamespaceLister {
+	return &hookedTriggerNamespaceLister{TriggerNamespaceLister: l.TriggerLister.Triggers(namespace), lister: l}
+}
+
+type hookedTriggerNamespaceLister struct {
+	eventinglisters.TriggerNamespaceLister
+	lister *hookedTriggerLister
+}
+
+func (l *hookedTriggerNamespaceLister) List(selector labels.Selector) ([]*eventingv1beta1.Trigger, error) {
+	if l.lister.onList != nil {
+		l.lister.onList()
+	}
+	return l.TriggerNamespaceLister.List(selector)
+}
+
+func makeBrokerTrigger(name string, filter *eventingv1beta1.TriggerFilter, filters ...eventingv1beta1.SubscriptionsAPIFilter) *eventingv1beta1.Trigger {
+	t := makeTrigger(filter)
+	t.Name = name
+	t.UID = types.UID(name + "-uid")
+	t.Labels = map[string]string{eventing.BrokerLabelKey: brokerName}
+	t.Spec.Broker = brokerName
+	t.Spec.Filters = filters
+	return t
+}
+
+func triggerNames(triggers []*eventingv1beta1.Trigger) []string {
+	names := make([]string, 0, len(triggers))
+	for _, t := range triggers {
+		names = append(names, t.Name)
+	}
+	sort.Strings(names)
+	return names
+}
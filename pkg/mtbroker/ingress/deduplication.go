@@ -0,0 +1,174 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+)
+
+// maxDeduplicationPending bounds how long an event stays pending in a DeduplicationStore, for it to
+// be accepted again if its delivery never completes, for instance when the replica of the ingress
+// delivering it stops.
+const maxDeduplicationPending = time.Minute
+
+// DeduplicationState is the state of an event recorded by a DeduplicationStore.
+type DeduplicationState int
+
+const (
+	// DeduplicationNew is the state of the events not recorded yet.
+	DeduplicationNew DeduplicationState = iota
+	// DeduplicationPending is the state of the events being delivered.
+	DeduplicationPending
+	// DeduplicationDelivered is the state of the events delivered within their window.
+	DeduplicationDelivered
+)
+
+// DeduplicationStore records the events sent to the Brokers deduplicating them. A store shared
+// by the replicas of the ingress deduplicates the events they receive together.
+type DeduplicationStore interface {
+	// Add records key as pending for ttl if it is not recorded yet, and returns the state of key
+	// before the call.
+	Add(ctx context.Context, key string, ttl time.Duration) (DeduplicationState, error)
+	// Confirm records key as delivered for window.
+	Confirm(ctx context.Context, key string, window time.Duration) error
+	// Remove forgets key, so that the event is accepted again when it is retried.
+	Remove(ctx context.Context, key string) error
+}
+
+// MemoryDeduplicationStore is a DeduplicationStore holding a bounded number of keys in memory.
+// When it is full, the least recently added or seen key is forgotten first.
+type MemoryDeduplicationStore struct {
+	mutex   sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	// lru holds the entries, the most recently added or seen first.
+	lru *list.List
+	now func() time.Time
+}
+
+type deduplicationEntry struct {
+	key     string
+	pending bool
+	expires time.Time
+}
+
+var _ DeduplicationStore = (*MemoryDeduplicationStore)(nil)
+
+// NewMemoryDeduplicationStore creates a MemoryDeduplicationStore holding up to size keys.
+func NewMemoryDeduplicationStore(size int) *MemoryDeduplicationStore {
+	return &MemoryDeduplicationStore{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+// Add implements DeduplicationStore.
+func (s *MemoryDeduplicationStore) Add(_ context.Context, key string, ttl time.Duration) (DeduplicationState, error) {
+	now := s.now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if element, ok := s.entries[key]; ok {
+		s.lru.MoveToFront(element)
+		entry := element.Value.(*deduplicationEntry)
+		if now.Before(entry.expires) {
+			if entry.pending {
+				return DeduplicationPending, nil
+			}
+			return DeduplicationDelivered, nil
+		}
+		entry.pending = true
+		entry.expires = now.Add(ttl)
+		return DeduplicationNew, nil
+	}
+
+	s.entries[key] = s.lru.PushFront(&deduplicationEntry{key: key, pending: true, expires: now.Add(ttl)})
+	for s.lru.Len() > s.size {
+		s.remove(s.lru.Back())
+	}
+	// Forget the expired keys no longer seen.
+	for back := s.lru.Back(); back != nil && !now.Before(back.Value.(*deduplicationEntry).expires); back = s.lru.Back() {
+		s.remove(back)
+	}
+	return DeduplicationNew, nil
+}
+
+// Confirm implements DeduplicationStore.
+func (s *MemoryDeduplicationStore) Confirm(_ context.Context, key string, window time.Duration) error {
+	now := s.now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	element, ok := s.entries[key]
+	if !ok {
+		// Evicted while pending.
+		element = s.lru.PushFront(&deduplicationEntry{key: key})
+		s.entries[key] = element
+		for s.lru.Len() > s.size {
+			s.remove(s.lru.Back())
+		}
+	}
+	entry := element.Value.(*deduplicationEntry)
+	entry.pending = false
+	entry.expires = now.Add(window)
+	return nil
+}
+
+// Remove implements DeduplicationStore.
+func (s *MemoryDeduplicationStore) Remove(_ context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+	return nil
+}
+
+func (s *MemoryDeduplicationStore) remove(element *list.Element) {
+	s.lru.Remove(element)
+	delete(s.entries, element.Value.(*deduplicationEntry).key)
+}
+
+// deduplicationWindow returns how long the events sent to b are deduplicated, 0 if they aren't.
+func deduplicationWindow(b *eventingv1.Broker) time.Duration {
+	value, ok := b.GetAnnotations()[eventing.BrokerDeduplicationWindowAnnotationKey]
+	if !ok {
+		return 0
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window < 0 {
+		return 0
+	}
+	return window
+}
+
+// deduplicationKey identifies event, sent to b, by its source and id.
+func deduplicationKey(b *eventingv1.Broker, event *cloudevents.Event) string {
+	// The source and id may contain any character but the null one.
+	return strings.Join([]string{b.Namespace, b.Name, event.Source(), event.ID()}, "\x00")
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"bytes"
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	"knative.dev/eventing/pkg/kncloudevents"
+	broker "knative.dev/eventing/pkg/mtbroker"
+	reconcilertestingv1 "knative.dev/eventing/pkg/reconciler/testing/v1"
+)
+
+func TestMemoryDeduplicationStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryDeduplicationStore(2)
+	s.now = func() time.Time { return now }
+
+	add := func(key string, ttl time.Duration, want DeduplicationState) {
+		t.Helper()
+		if got, err := s.Add(ctx, key, ttl); err != nil || got != want {
+			t.Errorf("Add(%q) = %d, %v, want %d", key, got, err, want)
+		}
+	}
+	confirm := func(key string, window time.Duration) {
+		t.Helper()
+		if err := s.Confirm(ctx, key, window); err != nil {
+			t.Errorf("Confirm(%q) = %v", key, err)
+		}
+	}
+
+	add("a", time.Minute, DeduplicationNew)
+	add("a", time.Minute, DeduplicationPending)
+	confirm("a", time.Minute)
+	add("a", time.Minute, DeduplicationDelivered)
+
+	// Seen again after its window.
+	now = now.Add(time.Minute)
+	add("a", time.Minute, DeduplicationNew)
+
+	// Pending for its ttl at most.
+	now = now.Add(time.Minute)
+	add("a", time.Minute, DeduplicationNew)
+	confirm("a", time.Minute)
+
+	// The least recently seen key is evicted.
+	add("b", time.Hour, DeduplicationNew)
+	confirm("b", time.Hour)
+	add("a", time.Minute, DeduplicationDelivered)
+	add("c", time.Hour, DeduplicationNew)
+	add("b", time.Hour, DeduplicationNew)
+
+	// Forgotten keys are accepted again.
+	if err := s.Remove(ctx, "c"); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+	add("c", time.Hour, DeduplicationNew)
+
+	// Keys evicted while pending are recorded once delivered.
+	add("d", time.Hour, DeduplicationNew)
+	add("e", time.Hour, DeduplicationNew)
+	add("f", time.Hour, DeduplicationNew)
+	confirm("d", time.Hour)
+	add("d", time.Hour, DeduplicationDelivered)
+
+	if s.lru.Len() != 2 || len(s.entries) != 2 {
+		t.Errorf("Unexpected size, want 2, got %d and %d", s.lru.Len(), len(s.entries))
+	}
+}
+
+func TestHandler_Deduplication(t *testing.T) {
+	testCases := map[string]struct {
+		window       string
+		channelCodes []int
+		wantCodes    []int
+		wantSent     int
+		wantReporter *mockReporter
+	}{
+		"duplicate acknowledged": {
+			window:       "5m",
+			channelCodes: []int{nethttp.StatusAccepted},
+			wantCodes:    []int{nethttp.StatusAccepted, nethttp.StatusAccepted},
+			wantSent:     1,
+			wantReporter: &mockReporter{StatusCode: nethttp.StatusAccepted, EventDispatchTimeReported: true, DuplicateReported: true},
+		},
+		"undelivered event retried": {
+			window:       "5m",
+			channelCodes: []int{nethttp.StatusInternalServerError, nethttp.StatusAccepted},
+			wantCodes:    []int{nethttp.StatusInternalServerError, nethttp.StatusAccepted},
+			wantSent:     2,
+			wantReporter: &mockReporter{StatusCode: nethttp.StatusAccepted, EventDispatchTimeReported: true},
+		},
+		"not deduplicated": {
+			channelCodes: []int{nethttp.StatusAccepted, nethttp.StatusAccepted},
+			wantCodes:    []int{nethttp.StatusAccepted, nethttp.StatusAccepted},
+			wantSent:     2,
+			wantReporter: &mockReporter{StatusCode: nethttp.StatusAccepted, EventDispatchTimeReported: true},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			sent := 0
+			s := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+				w.WriteHeader(tc.channelCodes[sent])
+				sent++
+			}))
+			defer s.Close()
+
+			b := makeBroker("name", "ns")
+			if tc.window != "" {
+				b.Annotations = map[string]string{eventing.BrokerDeduplicationWindowAnnotationKey: tc.window}
+			}
+			b.Status.Annotations = map[string]string{eventing.BrokerChannelAddressStatusAnnotationKey: s.URL}
+			listers := reconcilertestingv1.NewListers([]runtime.Object{b})
+			sender, _ := kncloudevents.NewHTTPMessageSenderWithTarget("")
+			h := &Handler{
+				Sender:             sender,
+				Defaulter:          broker.TTLDefaulter(zap.NewNop(), 100),
+				Reporter:           &mockReporter{},
+				Logger:             zap.NewNop(),
+				BrokerLister:       listers.GetBrokerLister(),
+				DeduplicationStore: NewMemoryDeduplicationStore(10),
+			}
+
+			body, _ := makeEvent("", "{}").MarshalJSON()
+			for i, want := range tc.wantCodes {
+				request := httptest.NewRequest(nethttp.MethodPost, "/ns/name", bytes.NewBuffer(body))
+				request.Header.Set(cehttp.ContentType, event.ApplicationCloudEventsJSON)
+				recorder := httptest.NewRecorder()
+				h.ServeHTTP(recorder, request)
+				if got := recorder.Result().StatusCode; got != want {
+					t.Errorf("Unexpected status code of request %d, want %d, got %d", i, want, got)
+				}
+			}
+			if sent != tc.wantSent {
+				t.Errorf("Unexpected events sent, want %d, got %d", tc.wantSent, sent)
+			}
+			if diff := cmp.Diff(tc.wantReporter, h.Reporter); diff != "" {
+				t.Error("Unexpected reporter state (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestHandler_DeduplicationWhileDelivering(t *testing.T) {
+	received := make(chan struct{})
+	respond := make(chan int)
+	sent := 0
+	s := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+		sent++
+		received <- struct{}{}
+		w.WriteHeader(<-respond)
+	}))
+	defer s.Close()
+
+	b := makeBroker("name", "ns")
+	b.Annotations = map[string]string{eventing.BrokerDeduplicationWindowAnnotationKey: "5m"}
+	b.Status.Annotations = map[string]string{eventing.BrokerChannelAddressStatusAnnotationKey: s.URL}
+	listers := reconcilertestingv1.NewListers([]runtime.Object{b})
+	sender, _ := kncloudevents.NewHTTPMessageSenderWithTarget("")
+	h := &Handler{
+		Sender:             sender,
+		Defaulter:          broker.TTLDefaulter(zap.NewNop(), 100),
+		Reporter:           &mockReporter{},
+		Logger:             zap.NewNop(),
+		BrokerLister:       listers.GetBrokerLister(),
+		DeduplicationStore: NewMemoryDeduplicationStore(10),
+	}
+
+	body, _ := makeEvent("", "{}").MarshalJSON()
+	serve := func() int {
+		request := httptest.NewRequest(nethttp.MethodPost, "/ns/name", bytes.NewBuffer(body))
+		request.Header.Set(cehttp.ContentType, event.ApplicationCloudEventsJSON)
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, request)
+		return recorder.Result().StatusCode
+	}
+
+	first := make(chan int)
+	go func() {
+		first <- serve()
+	}()
+	<-received
+
+	// The producer retries while the event is being delivered.
+	if got := serve(); got != nethttp.StatusConflict {
+		t.Errorf("Unexpected status code of the retry while delivering, want %d, got %d", nethttp.StatusConflict, got)
+	}
+
+	// The delivery fails, the next retry is delivered.
+	respond <- nethttp.StatusInternalServerError
+	if got := <-first; got != nethttp.StatusInternalServerError {
+		t.Errorf("Unexpected status code of the first request, want %d, got %d", nethttp.StatusInternalServerError, got)
+	}
+	go func() {
+		<-received
+		respond <- nethttp.StatusAccepted
+	}()
+	if got := serve(); got != nethttp.StatusAccepted {
+		t.Errorf("Unexpected status code of the retry, want %d, got %d", nethttp.StatusAccepted, got)
+	}
+	if sent != 2 {
+		t.Errorf("Unexpected events sent, want 2, got %d", sent)
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/retry"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	broker "knative.dev/eventing/pkg/mtbroker"
+)
+
+const (
+	// eventTypesFlushPeriod is how often the observed event types are written.
+	eventTypesFlushPeriod = 10 * time.Second
+	// observedEventTypeRefresh is how often the last time an event type is seen is written.
+	observedEventTypeRefresh = 10 * time.Minute
+)
+
+// EventTypeRecorder records the distinct types, sources and schemas of the events sent to the
+// Brokers registering their EventTypes, in the ConfigMaps from which the controller registers
+// them. A nil EventTypeRecorder records nothing.
+type EventTypeRecorder struct {
+	configMaps corev1client.ConfigMapsGetter
+	logger     *zap.Logger
+
+	mutex   sync.Mutex
+	brokers map[types.NamespacedName]*recordedBroker
+	now     func() time.Time
+}
+
+type recordedBroker struct {
+	// broker is the last version of the Broker seen, owning its ConfigMap.
+	broker *eventingv1.Broker
+	// written holds the last time the event types were written, by key.
+	written map[string]time.Time
+	// pending holds the event types to write, by key.
+	pending map[string]broker.ObservedEventType
+}
+
+// NewEventTypeRecorder creates an EventTypeRecorder writing the observed event types with
+// configMaps.
+func NewEventTypeRecorder(configMaps corev1client.ConfigMapsGetter, logger *zap.Logger) *EventTypeRecorder {
+	return &EventTypeRecorder{
+		configMaps: configMaps,
+		logger:     logger,
+		brokers:    make(map[types.NamespacedName]*recordedBroker),
+		now:        time.Now,
+	}
+}
+
+// StartEventTypeRecorder creates an EventTypeRecorder writing the observed event types
+// periodically, until ctx is done.
+func StartEventTypeRecorder(ctx context.Context, client kubernetes.Interface, logger *zap.Logger) *EventTypeRecorder {
+	r := NewEventTypeRecorder(client.CoreV1(), logger)
+	go func() {
+		ticker := time.NewTicker(eventTypesFlushPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Flush(ctx)
+			}
+		}
+	}()
+	return r
+}
+
+// Record records the type, source and schema of event, sent to b, if b registers its
+// EventTypes.
+func (r *EventTypeRecorder) Record(b *eventingv1.Broker, event *cloudevents.Event) {
+	if r == nil || b.GetAnnotations()[eventing.BrokerEventTypeRegistrationAnnotationKey] != eventing.BrokerEventTypeRegistrationAuto {
+		return
+	}
+	now := r.now()
+	o := broker.ObservedEventType{
+		Type:     event.Type(),
+		Source:   event.Source(),
+		Schema:   event.DataSchema(),
+		LastSeen: now,
+	}
+	key := o.Key()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	brokerKey := types.NamespacedName{Namespace: b.Namespace, Name: b.Name}
+	rb, ok := r.brokers[brokerKey]
+	if !ok {
+		rb = &recordedBroker{
+			written: make(map[string]time.Time),
+			pending: make(map[string]broker.ObservedEventType),
+		}
+		r.brokers[brokerKey] = rb
+	}
+	rb.broker = b
+
+	if written, ok := rb.written[key]; ok && now.Sub(written) < observedEventTypeRefresh {
+		return
+	}
+	if _, ok := rb.pending[key]; !ok && len(rb.written)+len(rb.pending) >= broker.MaxObservedEventTypes {
+		return
+	}
+	rb.pending[key] = o
+}
+
+// Flush writes the event types recorded since the last flush. The event types failing to be
+// written are written by the next flush.
+func (r *EventTypeRecorder) Flush(ctx context.Context) {
+	now := r.now()
+
+	r.mutex.Lock()
+	flushed := make(map[types.NamespacedName]*recordedBroker, len(r.brokers))
+	for key, rb := range r.brokers {
+		// Forget the event types not seen since they were refreshed, and the Brokers without
+		// event types.
+		for k, written := range rb.written {
+			if now.Sub(written) >= observedEventTypeRefresh {
+				delete(rb.written, k)
+			}
+		}
+		if len(rb.pending) > 0 {
+			flushed[key] = &recordedBroker{broker: rb.broker, pending: rb.pending}
+			rb.pending = make(map[string]broker.ObservedEventType)
+		} else if len(rb.written) == 0 {
+			delete(r.brokers, key)
+		}
+	}
+	r.mutex.Unlock()
+
+	for key, f := range flushed {
+		err := r.write(ctx, f.broker, f.pending)
+
+		r.mutex.Lock()
+		rb, ok := r.brokers[key]
+		if !ok {
+			r.mutex.Unlock()
+			continue
+		}
+		for k, o := range f.pending {
+			if err != nil {
+				if _, ok := rb.pending[k]; !ok {
+					rb.pending[k] = o
+				}
+			} else {
+				rb.written[k] = o.LastSeen
+			}
+		}
+		r.mutex.Unlock()
+
+		if err != nil {
+			r.logger.Warn("Failed to write the observed event types", zap.String("namespace", key.Namespace),
+				zap.String("broker", key.Name), zap.Error(err))
+		}
+	}
+}
+
+// write adds the observed event types to the ConfigMap of b. The ConfigMap is created by the
+// controller, the event types are written by a later flush until it exists.
+func (r *EventTypeRecorder) write(ctx context.Context, b *eventingv1.Broker, observed map[string]broker.ObservedEventType) error {
+	configMaps := r.configMaps.ConfigMaps(b.Namespace)
+	name := broker.ObservedEventTypesConfigMapName(b.Name)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		cm = cm.DeepCopy()
+		for _, o := range observed {
+			broker.SetObservedEventType(cm, &o)
+		}
+		_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+}
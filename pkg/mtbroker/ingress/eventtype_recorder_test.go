@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	broker "knative.dev/eventing/pkg/mtbroker"
+)
+
+func TestEventTypeRecorder(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	r := NewEventTypeRecorder(client.CoreV1(), zap.NewNop())
+	r.now = func() time.Time { return now }
+
+	b := registeringBroker()
+	createConfigMap(t, client, b)
+	r.Record(b, makeTypedEvent("order.created"))
+	r.Record(b, makeTypedEvent("order.created"))
+	r.Record(b, makeTypedEvent("order.shipped"))
+	// The Brokers not registering their EventTypes are ignored.
+	r.Record(makeBroker("other", "ns"), makeTypedEvent("order.created"))
+	r.Flush(ctx)
+
+	assertObserved(t, client, map[string]time.Time{"order.created": now, "order.shipped": now})
+	if _, err := client.CoreV1().ConfigMaps("ns").Get(ctx, broker.ObservedEventTypesConfigMapName("other"), metav1.GetOptions{}); err == nil {
+		t.Error("Unexpected ConfigMap for a Broker not registering its EventTypes")
+	}
+
+	// The event types already written are not written again until they are refreshed.
+	writes := 0
+	client.PrependReactor("update", "configmaps", func(clientgotesting.Action) (bool, runtime.Object, error) {
+		writes++
+		return false, nil, nil
+	})
+	now = now.Add(time.Minute)
+	r.Record(b, makeTypedEvent("order.created"))
+	r.Flush(ctx)
+	if writes != 0 {
+		t.Errorf("Expected no write, got %d", writes)
+	}
+
+	now = start.Add(observedEventTypeRefresh)
+	r.Record(b, makeTypedEvent("order.created"))
+	r.Flush(ctx)
+	if writes != 1 {
+		t.Errorf("Expected 1 write, got %d", writes)
+	}
+	assertObserved(t, client, map[string]time.Time{"order.created": now, "order.shipped": start})
+}
+
+func TestEventTypeRecorderRetries(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	r := NewEventTypeRecorder(client.CoreV1(), zap.NewNop())
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	fail := true
+	client.PrependReactor("update", "configmaps", func(clientgotesting.Action) (bool, runtime.Object, error) {
+		if fail {
+			return true, nil, errors.New("unavailable")
+		}
+		return false, nil, nil
+	})
+
+	// The event types are kept until the controller creates the ConfigMap, and the update
+	// succeeds.
+	b := registeringBroker()
+	r.Record(b, makeTypedEvent("order.created"))
+	r.Flush(ctx)
+	createConfigMap(t, client, b)
+	r.Flush(ctx)
+	fail = false
+	r.Flush(ctx)
+
+	assertObserved(t, client, map[string]time.Time{"order.created": now})
+}
+
+func TestNilEventTypeRecorder(t *testing.T) {
+	var r *EventTypeRecorder
+	r.Record(registeringBroker(), makeTypedEvent("order.created"))
+}
+
+func createConfigMap(t *testing.T, client *fake.Clientset, b *eventingv1.Broker) {
+	t.Helper()
+	if _, err := client.CoreV1().ConfigMaps(b.Namespace).Create(context.Background(), broker.MakeObservedEventTypesConfigMap(b), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertObserved(t *testing.T, client *fake.Clientset, want map[string]time.Time) {
+	t.Helper()
+	cm, err := client.CoreV1().ConfigMaps("ns").Get(context.Background(), broker.ObservedEventTypesConfigMapName("name"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal("Unexpected error getting the ConfigMap:", err)
+	}
+	if cm.Labels[eventing.BrokerLabelKey] != "name" || cm.Labels[broker.ObservedEventTypesLabelKey] != "true" {
+		t.Error("Unexpected labels:", cm.Labels)
+	}
+	if len(cm.OwnerReferences) != 1 || cm.OwnerReferences[0].Kind != "Broker" || cm.OwnerReferences[0].Name != "name" {
+		t.Error("Unexpected owner references:", cm.OwnerReferences)
+	}
+	got := make(map[string]time.Time)
+	for _, o := range broker.ObservedEventTypes(cm) {
+		if o.Source != "source" {
+			t.Errorf("Unexpected source %q", o.Source)
+		}
+		got[o.Type] = o.LastSeen
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("Unexpected observed event types (-want, +got):", diff)
+	}
+}
+
+func registeringBroker() *eventingv1.Broker {
+	b := makeBroker("name", "ns")
+	b.Annotations = map[string]string{eventing.BrokerEventTypeRegistrationAnnotationKey: eventing.BrokerEventTypeRegistrationAuto}
+	return b
+}
+
+func makeTypedEvent(eventType string) *cloudevents.Event {
+	e := makeEvent("", "{}")
+	e.SetType(eventType)
+	return e
+}
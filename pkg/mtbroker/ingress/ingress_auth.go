@@ -0,0 +1,329 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/utils"
+)
+
+const (
+	// HMACSignatureHeader is the header holding the signature of the requests sent to the Brokers
+	// authenticating their publishers with HMAC: "sha256=" followed by the hex encoded HMAC-SHA256
+	// of the value of the HMACTimestampHeader, a dot, and the body.
+	HMACSignatureHeader = "Knative-Signature"
+	// HMACTimestampHeader is the header holding the time the request was signed at, in seconds
+	// since the Unix epoch. Requests signed more than maxSignatureSkew away from now are rejected,
+	// so that a signed request can't be replayed later on.
+	HMACTimestampHeader = "Knative-Signature-Timestamp"
+	// HMACKey is the key of the Secrets holding the HMAC key of a Broker.
+	HMACKey = "key"
+
+	hmacSignaturePrefix = "sha256="
+	// maxSignatureSkew bounds the difference between the time a request was signed at and now.
+	maxSignatureSkew = 5 * time.Minute
+
+	// serviceAccountUsernamePrefix prefixes the usernames of the ServiceAccounts,
+	// system:serviceaccount:<namespace>:<name>.
+	serviceAccountUsernamePrefix = "system:serviceaccount:"
+
+	// maxSignedBodySize bounds the body read to verify its signature.
+	maxSignedBodySize = 10 << 20
+
+	// tokenReviewTTL is how long the result of a TokenReview is reused.
+	tokenReviewTTL = time.Minute
+	// maxCachedTokenReviews bounds the number of TokenReview results kept.
+	maxCachedTokenReviews = 1024
+	// secretCacheTTL is how long an HMAC key is cached. A rotated key is used at most
+	// secretCacheTTL later.
+	secretCacheTTL = time.Minute
+)
+
+// The reasons the requests are rejected for, reported in the rejection metrics.
+const (
+	rejectionMissingCredentials  = "missing_credentials"
+	rejectionInvalidCredentials  = "invalid_credentials"
+	rejectionExpiredCredentials  = "expired_credentials"
+	rejectionIdentityNotAllowed  = "identity_not_allowed"
+	rejectionPolicyMisconfigured = "policy_misconfigured"
+	rejectionTokenReviewFailed   = "token_review_failed"
+	rejectionSecretReadFailed    = "secret_read_failed"
+)
+
+// Rejection is the reason a request is not allowed to publish to a Broker.
+type Rejection struct {
+	// StatusCode is the status code of the response, 401, 403, or 503 when the publisher couldn't
+	// be authenticated, the TokenReview or the read of the HMAC key failing.
+	StatusCode int
+	// Reason is the reason reported in the rejection metrics.
+	Reason string
+}
+
+func unauthorized(reason string) *Rejection {
+	return &Rejection{StatusCode: http.StatusUnauthorized, Reason: reason}
+}
+
+func forbidden(reason string) *Rejection {
+	return &Rejection{StatusCode: http.StatusForbidden, Reason: reason}
+}
+
+func unavailable(reason string) *Rejection {
+	return &Rejection{StatusCode: http.StatusServiceUnavailable, Reason: reason}
+}
+
+// Authenticator authenticates the publishers of the events sent to the Brokers, according to the
+// ingress policy set by their annotations. A nil Authenticator rejects the requests sent to the
+// Brokers requiring authentication.
+type Authenticator struct {
+	tokenReviews authenticationv1client.TokenReviewInterface
+	secrets      *utils.SecretCache
+
+	mutex sync.Mutex
+	// reviews caches the results of the TokenReviews, keyed by the hash of the token.
+	reviews map[string]tokenReview
+	now     func() time.Time
+}
+
+type tokenReview struct {
+	authenticated bool
+	username      string
+	expires       time.Time
+}
+
+// NewAuthenticator creates an Authenticator validating the ServiceAccount tokens with
+// tokenReviews, and reading the HMAC keys from secrets.
+func NewAuthenticator(tokenReviews authenticationv1client.TokenReviewInterface, secrets *utils.SecretCache) *Authenticator {
+	return &Authenticator{
+		tokenReviews: tokenReviews,
+		secrets:      secrets,
+		reviews:      make(map[string]tokenReview),
+		now:          time.Now,
+	}
+}
+
+// NewAuthenticatorFromClient creates an Authenticator validating the ServiceAccount tokens, and
+// getting the HMAC keys, with client. The HMAC keys are cached for secretCacheTTL, the Broker
+// controller grants the ingress get on the Secret of each Broker through a Role.
+func NewAuthenticatorFromClient(client kubernetes.Interface) *Authenticator {
+	return NewAuthenticator(client.AuthenticationV1().TokenReviews(), utils.NewSecretCache(client.CoreV1(), secretCacheTTL))
+}
+
+// Authenticate returns nil if the sender of request may publish to b, the reason it may not
+// otherwise. The body of request is read and replaced when it is signed. The requests sent to an
+// unknown Broker, b being nil, are rejected, as its policy is unknown.
+func (a *Authenticator) Authenticate(ctx context.Context, b *eventingv1.Broker, request *http.Request) *Rejection {
+	if b == nil {
+		return forbidden(rejectionPolicyMisconfigured)
+	}
+	annotations := b.GetAnnotations()
+	switch annotations[eventing.BrokerIngressAuthAnnotationKey] {
+	case "", eventing.BrokerIngressAuthNone:
+		return nil
+	case eventing.BrokerIngressAuthServiceAccountToken:
+		if a == nil {
+			return forbidden(rejectionPolicyMisconfigured)
+		}
+		return a.authenticateToken(ctx, b.Namespace, annotations, request)
+	case eventing.BrokerIngressAuthHMAC:
+		if a == nil {
+			return forbidden(rejectionPolicyMisconfigured)
+		}
+		return a.authenticateHMAC(b.Namespace, annotations[eventing.BrokerIngressHMACSecretAnnotationKey], request)
+	default:
+		// Fail closed on the policies this ingress doesn't know about.
+		return forbidden(rejectionPolicyMisconfigured)
+	}
+}
+
+func (a *Authenticator) authenticateToken(ctx context.Context, namespace string, annotations map[string]string, request *http.Request) *Rejection {
+	authorization := request.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return unauthorized(rejectionMissingCredentials)
+	}
+	review, err := a.review(ctx, strings.TrimPrefix(authorization, "Bearer "))
+	if err != nil {
+		// Fail closed when the token can't be reviewed, the publisher may retry later.
+		return unavailable(rejectionTokenReviewFailed)
+	}
+	if !review.authenticated {
+		return unauthorized(rejectionInvalidCredentials)
+	}
+	if !isAllowed(review.username, namespace, annotations) {
+		return forbidden(rejectionIdentityNotAllowed)
+	}
+	return nil
+}
+
+// review returns the result of the TokenReview of token, cached for tokenReviewTTL.
+func (a *Authenticator) review(ctx context.Context, token string) (tokenReview, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+
+	a.mutex.Lock()
+	review, ok := a.reviews[key]
+	a.mutex.Unlock()
+	if ok && a.now().Before(review.expires) {
+		return review, nil
+	}
+
+	result, err := a.tokenReviews.Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return tokenReview{}, err
+	}
+	review = tokenReview{
+		authenticated: result.Status.Authenticated,
+		username:      result.Status.User.Username,
+		expires:       a.now().Add(tokenReviewTTL),
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if len(a.reviews) >= maxCachedTokenReviews {
+		for k, r := range a.reviews {
+			if !a.now().Before(r.expires) {
+				delete(a.reviews, k)
+			}
+		}
+		if len(a.reviews) >= maxCachedTokenReviews {
+			a.reviews = make(map[string]tokenReview)
+		}
+	}
+	a.reviews[key] = review
+	return review, nil
+}
+
+// isAllowed returns whether username may publish to a Broker of namespace, according to the
+// allow-lists of the Broker.
+func isAllowed(username, namespace string, annotations map[string]string) bool {
+	identities := splitList(annotations[eventing.BrokerIngressAllowedIdentitiesAnnotationKey])
+	namespaces := splitList(annotations[eventing.BrokerIngressAllowedNamespacesAnnotationKey])
+	if len(identities) == 0 && len(namespaces) == 0 {
+		// Only the ServiceAccounts of the namespace of the Broker are allowed.
+		namespaces = []string{namespace}
+	}
+	for _, identity := range identities {
+		if identity == username {
+			return true
+		}
+	}
+	if saNamespace, ok := serviceAccountNamespace(username); ok {
+		for _, ns := range namespaces {
+			if ns == saNamespace {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// serviceAccountNamespace returns the namespace of the ServiceAccount named username, if it is
+// one.
+func serviceAccountNamespace(username string) (string, bool) {
+	if !strings.HasPrefix(username, serviceAccountUsernamePrefix) {
+		return "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(username, serviceAccountUsernamePrefix), ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (a *Authenticator) authenticateHMAC(namespace, secretName string, request *http.Request) *Rejection {
+	signature := request.Header.Get(HMACSignatureHeader)
+	if !strings.HasPrefix(signature, hmacSignaturePrefix) {
+		return unauthorized(rejectionMissingCredentials)
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, hmacSignaturePrefix))
+	if err != nil {
+		return unauthorized(rejectionInvalidCredentials)
+	}
+	timestamp := request.Header.Get(HMACTimestampHeader)
+	if timestamp == "" {
+		return unauthorized(rejectionMissingCredentials)
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return unauthorized(rejectionInvalidCredentials)
+	}
+	if skew := a.now().Sub(time.Unix(seconds, 0)); skew > maxSignatureSkew || skew < -maxSignatureSkew {
+		return unauthorized(rejectionExpiredCredentials)
+	}
+
+	secret, err := a.secrets.Get(namespace, secretName)
+	if err != nil && !apierrs.IsNotFound(err) && !apierrs.IsForbidden(err) {
+		return unavailable(rejectionSecretReadFailed)
+	}
+	if err != nil || secret.Labels[eventing.IngressAuthLabelKey] != "true" || len(secret.Data[HMACKey]) == 0 {
+		return forbidden(rejectionPolicyMisconfigured)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(request.Body, maxSignedBodySize+1))
+	if err != nil || len(body) > maxSignedBodySize {
+		return unauthorized(rejectionInvalidCredentials)
+	}
+	_ = request.Body.Close()
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	mac := hmac.New(sha256.New, secret.Data[HMACKey])
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return unauthorized(rejectionInvalidCredentials)
+	}
+	return nil
+}
+
+// removeCredentials removes the credentials of the publisher from headers, so that they are not
+// forwarded to the channel of the Broker, and through it to the subscribers of its Triggers.
+func removeCredentials(headers http.Header) {
+	headers.Del("Authorization")
+	headers.Del(HMACSignatureHeader)
+	headers.Del(HMACTimestampHeader)
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	clientgotesting "k8s.io/client-go/testing"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	"knative.dev/eventing/pkg/utils"
+)
+
+const (
+	testHMACKey = "hmac-key"
+	testBody    = `{"id":"1234"}`
+)
+
+func TestAuthenticator(t *testing.T) {
+	now := time.Now()
+	tokenAuth := map[string]string{eventing.BrokerIngressAuthAnnotationKey: eventing.BrokerIngressAuthServiceAccountToken}
+	hmacAuth := map[string]string{
+		eventing.BrokerIngressAuthAnnotationKey:       eventing.BrokerIngressAuthHMAC,
+		eventing.BrokerIngressHMACSecretAnnotationKey: "hmac",
+	}
+	testCases := map[string]struct {
+		annotations   map[string]string
+		headers       map[string]string
+		nilAuth       bool
+		nilBroker     bool
+		wantRejection *Rejection
+	}{
+		"no policy": {},
+		"no policy, nil authenticator": {
+			nilAuth: true,
+		},
+		"token, nil authenticator": {
+			annotations:   tokenAuth,
+			headers:       map[string]string{"Authorization": "Bearer team-a"},
+			nilAuth:       true,
+			wantRejection: forbidden(rejectionPolicyMisconfigured),
+		},
+		"unknown broker": {
+			nilBroker:     true,
+			wantRejection: forbidden(rejectionPolicyMisconfigured),
+		},
+		"unknown policy": {
+			annotations:   map[string]string{eventing.BrokerIngressAuthAnnotationKey: "Password"},
+			wantRejection: forbidden(rejectionPolicyMisconfigured),
+		},
+		"token, ServiceAccount of the namespace": {
+			annotations: tokenAuth,
+			headers:     map[string]string{"Authorization": "Bearer ns"},
+		},
+		"token, ServiceAccount of another namespace": {
+			annotations:   tokenAuth,
+			headers:       map[string]string{"Authorization": "Bearer team-a"},
+			wantRejection: forbidden(rejectionIdentityNotAllowed),
+		},
+		"token, not a ServiceAccount": {
+			annotations:   tokenAuth,
+			headers:       map[string]string{"Authorization": "Bearer user"},
+			wantRejection: forbidden(rejectionIdentityNotAllowed),
+		},
+		"token missing": {
+			annotations:   tokenAuth,
+			wantRejection: unauthorized(rejectionMissingCredentials),
+		},
+		"token invalid": {
+			annotations:   tokenAuth,
+			headers:       map[string]string{"Authorization": "Bearer invalid"},
+			wantRejection: unauthorized(rejectionInvalidCredentials),
+		},
+		"token review fails": {
+			annotations:   tokenAuth,
+			headers:       map[string]string{"Authorization": "Bearer fail"},
+			wantRejection: unavailable(rejectionTokenReviewFailed),
+		},
+		"token, allowed namespace": {
+			annotations: withAnnotations(tokenAuth, eventing.BrokerIngressAllowedNamespacesAnnotationKey, "team-b, team-a"),
+			headers:     map[string]string{"Authorization": "Bearer team-a"},
+		},
+		"token, allowed identity": {
+			annotations: withAnnotations(tokenAuth, eventing.BrokerIngressAllowedIdentitiesAnnotationKey, "system:serviceaccount:team-a:publisher"),
+			headers:     map[string]string{"Authorization": "Bearer team-a"},
+		},
+		"token, not allowed": {
+			annotations: withAnnotations(withAnnotations(tokenAuth,
+				eventing.BrokerIngressAllowedNamespacesAnnotationKey, "team-b"),
+				eventing.BrokerIngressAllowedIdentitiesAnnotationKey, "system:serviceaccount:team-c:publisher"),
+			headers:       map[string]string{"Authorization": "Bearer team-a"},
+			wantRejection: forbidden(rejectionIdentityNotAllowed),
+		},
+		"hmac": {
+			annotations: hmacAuth,
+			headers:     signedHeaders(testHMACKey, now, testBody),
+		},
+		"hmac missing": {
+			annotations:   hmacAuth,
+			wantRejection: unauthorized(rejectionMissingCredentials),
+		},
+		"hmac invalid": {
+			annotations:   hmacAuth,
+			headers:       signedHeaders("other-key", now, testBody),
+			wantRejection: unauthorized(rejectionInvalidCredentials),
+		},
+		"hmac malformed": {
+			annotations:   hmacAuth,
+			headers:       map[string]string{HMACSignatureHeader: "sha256=not-hex", HMACTimestampHeader: strconv.FormatInt(now.Unix(), 10)},
+			wantRejection: unauthorized(rejectionInvalidCredentials),
+		},
+		"hmac timestamp missing": {
+			annotations:   hmacAuth,
+			headers:       map[string]string{HMACSignatureHeader: signedHeaders(testHMACKey, now, testBody)[HMACSignatureHeader]},
+			wantRejection: unauthorized(rejectionMissingCredentials),
+		},
+		"hmac timestamp malformed": {
+			annotations:   hmacAuth,
+			headers:       withAnnotations(signedHeaders(testHMACKey, now, testBody), HMACTimestampHeader, "yesterday"),
+			wantRejection: unauthorized(rejectionInvalidCredentials),
+		},
+		"hmac timestamp not signed": {
+			annotations:   hmacAuth,
+			headers:       withAnnotations(signedHeaders(testHMACKey, now, testBody), HMACTimestampHeader, strconv.FormatInt(now.Unix()+1, 10)),
+			wantRejection: unauthorized(rejectionInvalidCredentials),
+		},
+		"hmac replayed": {
+			annotations:   hmacAuth,
+			headers:       signedHeaders(testHMACKey, now.Add(-maxSignatureSkew-time.Minute), testBody),
+			wantRejection: unauthorized(rejectionExpiredCredentials),
+		},
+		"hmac signed in the future": {
+			annotations:   hmacAuth,
+			headers:       signedHeaders(testHMACKey, now.Add(maxSignatureSkew+time.Minute), testBody),
+			wantRejection: unauthorized(rejectionExpiredCredentials),
+		},
+		"hmac secret missing": {
+			annotations: map[string]string{
+				eventing.BrokerIngressAuthAnnotationKey:       eventing.BrokerIngressAuthHMAC,
+				eventing.BrokerIngressHMACSecretAnnotationKey: "missing",
+			},
+			headers:       signedHeaders(testHMACKey, now, testBody),
+			wantRejection: forbidden(rejectionPolicyMisconfigured),
+		},
+		"hmac secret not labeled": {
+			annotations: map[string]string{
+				eventing.BrokerIngressAuthAnnotationKey:       eventing.BrokerIngressAuthHMAC,
+				eventing.BrokerIngressHMACSecretAnnotationKey: "unlabeled",
+			},
+			headers:       signedHeaders(testHMACKey, now, testBody),
+			wantRejection: forbidden(rejectionPolicyMisconfigured),
+		},
+		"hmac secret read fails": {
+			annotations: map[string]string{
+				eventing.BrokerIngressAuthAnnotationKey:       eventing.BrokerIngressAuthHMAC,
+				eventing.BrokerIngressHMACSecretAnnotationKey: "unreadable",
+			},
+			headers:       signedHeaders(testHMACKey, now, testBody),
+			wantRejection: unavailable(rejectionSecretReadFailed),
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			var a *Authenticator
+			if !tc.nilAuth {
+				a = newTestAuthenticator(t)
+			}
+			b := makeBroker("name", "ns")
+			b.Annotations = tc.annotations
+			if tc.nilBroker {
+				b = nil
+			}
+
+			request := httptest.NewRequest(nethttp.MethodPost, "/ns/name", bytes.NewBufferString(testBody))
+			for k, v := range tc.headers {
+				request.Header.Set(k, v)
+			}
+			rejection := a.Authenticate(context.Background(), b, request)
+			if diff := cmp.Diff(tc.wantRejection, rejection); diff != "" {
+				t.Error("Unexpected rejection (-want, +got):", diff)
+			}
+
+			// The body is still readable after its signature is verified.
+			if body, _ := ioutil.ReadAll(request.Body); string(body) != testBody && rejection == nil {
+				t.Errorf("Unexpected body %q", body)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorCachesTokenReviews(t *testing.T) {
+	a := newTestAuthenticator(t)
+	reviews := 0
+	a.tokenReviews.(*fakeTokenReviews).onCreate = func() { reviews++ }
+
+	b := makeBroker("name", "ns")
+	b.Annotations = map[string]string{eventing.BrokerIngressAuthAnnotationKey: eventing.BrokerIngressAuthServiceAccountToken}
+	for i := 0; i < 3; i++ {
+		request := httptest.NewRequest(nethttp.MethodPost, "/ns/name", nil)
+		request.Header.Set("Authorization", "Bearer ns")
+		if rejection := a.Authenticate(context.Background(), b, request); rejection != nil {
+			t.Fatal("Unexpected rejection:", rejection)
+		}
+	}
+	if reviews != 1 {
+		t.Errorf("Expected 1 TokenReview, got %d", reviews)
+	}
+}
+
+// fakeTokenReviews wraps the TokenReviews of a fake clientset, to count them.
+type fakeTokenReviews struct {
+	authenticationv1client.TokenReviewInterface
+	onCreate func()
+}
+
+func (f *fakeTokenReviews) Create(ctx context.Context, review *authenticationv1.TokenReview, opts metav1.CreateOptions) (*authenticationv1.TokenReview, error) {
+	if f.onCreate != nil {
+		f.onCreate()
+	}
+	return f.TokenReviewInterface.Create(ctx, review, opts)
+}
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		review := action.(clientgotesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch review.Spec.Token {
+		case "team-a":
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "system:serviceaccount:team-a:publisher"}}
+		case "ns":
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "system:serviceaccount:ns:publisher"}}
+		case "user":
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "jane"}}
+		case "fail":
+			return true, nil, errors.New("unavailable")
+		}
+		return true, review, nil
+	})
+
+	client.PrependReactor("get", "secrets", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		if action.(clientgotesting.GetAction).GetName() == "unreadable" {
+			return true, nil, errors.New("unavailable")
+		}
+		return false, nil, nil
+	})
+	for _, secret := range []*corev1.Secret{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "hmac", Labels: map[string]string{eventing.IngressAuthLabelKey: "true"}},
+		Data:       map[string][]byte{HMACKey: []byte(testHMACKey)},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "unlabeled"},
+		Data:       map[string][]byte{HMACKey: []byte(testHMACKey)},
+	}} {
+		if _, err := client.CoreV1().Secrets("ns").Create(context.Background(), secret, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewAuthenticator(&fakeTokenReviews{TokenReviewInterface: client.AuthenticationV1().TokenReviews()}, utils.NewSecretCache(client.CoreV1(), 0))
+}
+
+func withAnnotations(annotations map[string]string, key, value string) map[string]string {
+	a := make(map[string]string, len(annotations)+1)
+	for k, v := range annotations {
+		a[k] = v
+	}
+	a[key] = value
+	return a
+}
+
+// signedHeaders returns the headers of a request signed with key at the given time.
+func signedHeaders(key string, at time.Time, body string) map[string]string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + "." + body))
+	return map[string]string{
+		HMACSignatureHeader: "sha256=" + hex.EncodeToString(mac.Sum(nil)),
+		HMACTimestampHeader: timestamp,
+	}
+}
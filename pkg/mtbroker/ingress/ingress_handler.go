@@ -19,8 +19,9 @@ package ingress
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
-	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -39,7 +40,6 @@ import (
 	broker "knative.dev/eventing/pkg/mtbroker"
 	"knative.dev/eventing/pkg/tracing"
 	"knative.dev/eventing/pkg/utils"
-	"knative.dev/pkg/network"
 )
 
 const (
@@ -58,39 +58,40 @@ type Handler struct {
 	Reporter StatsReporter
 	// BrokerLister gets broker objects
 	BrokerLister eventinglisters.BrokerLister
+	// Authenticator authenticates the publishers to the Brokers requiring it. If nil, the
+	// requests sent to those Brokers are rejected.
+	Authenticator *Authenticator
+	// SchemaValidator validates the events sent to the Brokers requiring it. If nil, the events
+	// are not validated.
+	SchemaValidator *SchemaValidator
+	// EventTypeRecorder records the event types sent to the Brokers registering them. If nil,
+	// no event type is recorded.
+	EventTypeRecorder *EventTypeRecorder
+	// DeduplicationStore records the events sent to the Brokers deduplicating them. If nil, no
+	// event is deduplicated.
+	DeduplicationStore DeduplicationStore
+	// RateLimiter limits the events accepted for each namespace and each Broker. If nil, the
+	// events are not limited.
+	RateLimiter *RateLimiter
 
 	Logger *zap.Logger
 }
 
-func (h *Handler) getBroker(name, namespace string) (*eventingv1.Broker, error) {
-	broker, err := h.BrokerLister.Brokers(namespace).Get(name)
-	if err != nil {
-		h.Logger.Warn("Broker getter failed")
-		return nil, err
+// brokerChannelAddress returns the address of the trigger channel of b, or why b isn't ready to
+// receive events.
+func brokerChannelAddress(b *eventingv1.Broker) (string, error) {
+	if !b.DeletionTimestamp.IsZero() {
+		return "", fmt.Errorf("Broker %s/%s is being deleted", b.Namespace, b.Name)
 	}
-	return broker, nil
-}
-
-func guessChannelAddress(name, namespace, domain string) string {
-	url := url.URL{
-		Scheme: "http",
-		Host:   fmt.Sprintf("%s-kne-trigger-kn-channel.%s.svc.%s", name, namespace, domain),
-		Path:   "/",
+	if c := b.Status.GetCondition(eventingv1.BrokerConditionTriggerChannel); c == nil || !c.IsTrue() {
+		if c == nil || c.Message == "" {
+			return "", fmt.Errorf("Broker %s/%s is not ready: its channel is not ready", b.Namespace, b.Name)
+		}
+		return "", fmt.Errorf("Broker %s/%s is not ready: its channel is not ready: %s", b.Namespace, b.Name, c.Message)
 	}
-	return url.String()
-}
-
-func (h *Handler) getChannelAddress(name, namespace string) (string, error) {
-	broker, err := h.getBroker(name, namespace)
-	if err != nil {
-		return "", err
-	}
-	if broker.Status.Annotations == nil {
-		return "", fmt.Errorf("Broker status annotations uninitialized")
-	}
-	address, present := broker.Status.Annotations[eventing.BrokerChannelAddressStatusAnnotationKey]
-	if !present {
-		return "", fmt.Errorf("Channel address not found in broker status annotations")
+	address := b.Status.Annotations[eventing.BrokerChannelAddressStatusAnnotationKey]
+	if address == "" {
+		return "", fmt.Errorf("Broker %s/%s is not ready: its channel has no address", b.Namespace, b.Name)
 	}
 	return address, nil
 }
@@ -101,7 +102,7 @@ func (h *Handler) Start(ctx context.Context) error {
 
 func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	// validate request method
-	if request.Method != http.MethodPost {
+	if request.Method != http.MethodPost && request.Method != http.MethodGet {
 		h.Logger.Warn("unexpected request method", zap.String("method", request.Method))
 		writer.WriteHeader(http.StatusMethodNotAllowed)
 		return
@@ -121,6 +122,65 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 
 	ctx := request.Context()
 
+	brokerNamespace := nsBrokerName[1]
+	brokerName := nsBrokerName[2]
+
+	b, err := h.BrokerLister.Brokers(brokerNamespace).Get(brokerName)
+	if err != nil {
+		h.Logger.Info("Broker not found", zap.String("namespace", brokerNamespace), zap.String("broker", brokerName), zap.Error(err))
+		http.Error(writer, fmt.Sprintf("Broker %s/%s not found", brokerNamespace, brokerName), http.StatusNotFound)
+		return
+	}
+
+	if request.Method == http.MethodGet {
+		h.serveReadiness(writer, b)
+		return
+	}
+
+	if rejection := h.Authenticator.Authenticate(ctx, b, request); rejection != nil {
+		h.Logger.Info("Request rejected by the ingress policy", zap.String("reason", rejection.Reason),
+			zap.String("namespace", brokerNamespace), zap.String("broker", brokerName))
+		_ = h.Reporter.ReportEventRejected(&ReportArgs{ns: brokerNamespace, broker: brokerName}, rejection.StatusCode, rejection.Reason)
+		writer.WriteHeader(rejection.StatusCode)
+		return
+	}
+	removeCredentials(request.Header)
+
+	channelAddress, err := brokerChannelAddress(b)
+	if err != nil {
+		h.Logger.Info("Broker not ready", zap.Error(err))
+		_ = h.Reporter.ReportEventCount(&ReportArgs{ns: brokerNamespace, broker: brokerName}, http.StatusServiceUnavailable)
+		http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	brokerNamespacedName := types.NamespacedName{
+		Name:      brokerName,
+		Namespace: brokerNamespace,
+	}
+
+	if kncloudevents.IsBatchRequest(request) {
+		events, err := kncloudevents.NewEventsFromBatchRequest(request)
+		if err != nil {
+			h.Logger.Warn("failed to extract events from batch request", zap.Error(err))
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !h.reserve(writer, b, brokerNamespacedName, events...) {
+			return
+		}
+		statuses := make([]kncloudevents.BatchEventStatus, 0, len(events))
+		for _, event := range events {
+			statuses = append(statuses, kncloudevents.BatchEventStatus{
+				ID:         event.ID(),
+				Source:     event.Source(),
+				StatusCode: h.handleEvent(ctx, request.Header, b, brokerNamespacedName, channelAddress, event),
+			})
+		}
+		kncloudevents.WriteBatchResponse(writer, statuses)
+		return
+	}
+
 	message := cehttp.NewMessageFromHttpRequest(request)
 	defer message.Finish(nil)
 
@@ -130,14 +190,53 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		writer.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	if !h.reserve(writer, b, brokerNamespacedName, event) {
+		return
+	}
 
-	brokerNamespace := nsBrokerName[1]
-	brokerName := nsBrokerName[2]
-	brokerNamespacedName := types.NamespacedName{
-		Name:      brokerName,
-		Namespace: brokerNamespace,
+	writer.WriteHeader(h.handleEvent(ctx, request.Header, b, brokerNamespacedName, channelAddress, event))
+}
+
+// serveReadiness responds whether b is ready to receive events, with 200 OK or 503 Service
+// Unavailable and the reason why it isn't.
+func (h *Handler) serveReadiness(writer http.ResponseWriter, b *eventingv1.Broker) {
+	if _, err := brokerChannelAddress(b); err != nil {
+		http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	writer.WriteHeader(http.StatusOK)
+	fmt.Fprintf(writer, "Broker %s/%s is ready\n", b.Namespace, b.Name)
+}
+
+// reserve takes events from the rate limits of their Broker and its namespace, and returns false
+// after responding if they are throttled. The events are either all accepted or all throttled.
+func (h *Handler) reserve(writer http.ResponseWriter, b *eventingv1.Broker, brokerNamespacedName types.NamespacedName, events ...*cloudevents.Event) bool {
+	throttled := h.RateLimiter.Reserve(b, len(events))
+	if throttled == nil {
+		return true
 	}
 
+	statusCode := http.StatusTooManyRequests
+	if throttled.RetryAfter > 0 {
+		writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	} else {
+		// More events than accepted at once, they are never accepted.
+		statusCode = http.StatusRequestEntityTooLarge
+	}
+	h.Logger.Debug("Events throttled", zap.String("scope", throttled.Scope), zap.Int("events", len(events)),
+		zap.String("namespace", brokerNamespacedName.Namespace), zap.String("broker", brokerNamespacedName.Name))
+	for _, event := range events {
+		args := &ReportArgs{ns: brokerNamespacedName.Namespace, broker: brokerNamespacedName.Name, eventType: event.Type()}
+		_ = h.Reporter.ReportEventThrottled(args, statusCode, throttled.Scope)
+	}
+	writer.WriteHeader(statusCode)
+	return false
+}
+
+// handleEvent validates, sends to channelAddress and reports event, sent to the Broker b named
+// brokerNamespacedName, and returns the status code of the event.
+func (h *Handler) handleEvent(ctx context.Context, headers http.Header, b *eventingv1.Broker, brokerNamespacedName types.NamespacedName, channelAddress string, event *cloudevents.Event) int {
 	ctx, span := trace.StartSpan(ctx, tracing.BrokerMessagingDestination(brokerNamespacedName))
 	defer span.End()
 
@@ -152,21 +251,49 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	}
 
 	reporterArgs := &ReportArgs{
-		ns:        brokerNamespace,
-		broker:    brokerName,
+		ns:        brokerNamespacedName.Namespace,
+		broker:    brokerNamespacedName.Name,
 		eventType: event.Type(),
 	}
 
-	statusCode, dispatchTime := h.receive(ctx, request.Header, event, brokerNamespace, brokerName)
+	if !h.validateSchema(b, event) {
+		_ = h.Reporter.ReportEventRejected(reporterArgs, http.StatusBadRequest, rejectionInvalidSchema)
+		return http.StatusBadRequest
+	}
+
+	statusCode, dispatchTime := h.receive(ctx, headers, b, event, channelAddress)
 	if dispatchTime > noDuration {
 		_ = h.Reporter.ReportEventDispatchTime(reporterArgs, statusCode, dispatchTime)
 	}
 	_ = h.Reporter.ReportEventCount(reporterArgs, statusCode)
+	if statusCode >= 200 && statusCode < 300 {
+		h.EventTypeRecorder.Record(b, event)
+	}
 
-	writer.WriteHeader(statusCode)
+	return statusCode
 }
 
-func (h *Handler) receive(ctx context.Context, headers http.Header, event *cloudevents.Event, brokerNamespace, brokerName string) (int, time.Duration) {
+// validateSchema applies the schema validation policy of b to event, it returns false if the
+// event is rejected.
+func (h *Handler) validateSchema(b *eventingv1.Broker, event *cloudevents.Event) bool {
+	policy := b.GetAnnotations()[eventing.BrokerSchemaValidationAnnotationKey]
+	if policy != eventing.BrokerSchemaValidationReject && policy != eventing.BrokerSchemaValidationTag {
+		return true
+	}
+	err := h.SchemaValidator.Validate(b, event)
+	if err == nil {
+		return true
+	}
+	h.Logger.Debug("Event does not match the schema of its EventType", zap.String("namespace", b.Namespace),
+		zap.String("broker", b.Name), zap.String("event.id", event.ID()), zap.Error(err))
+	if policy == eventing.BrokerSchemaValidationReject {
+		return false
+	}
+	event.SetExtension(SchemaErrorExtension, err.Error())
+	return true
+}
+
+func (h *Handler) receive(ctx context.Context, headers http.Header, b *eventingv1.Broker, event *cloudevents.Event, channelAddress string) (int, time.Duration) {
 
 	// Setting the extension as a string as the CloudEvents sdk does not support non-string extensions.
 	event.SetExtension(broker.EventArrivalTime, cloudevents.Timestamp{Time: time.Now()})
@@ -180,13 +307,56 @@ func (h *Handler) receive(ctx context.Context, headers http.Header, event *cloud
 		return http.StatusBadRequest, noDuration
 	}
 
-	channelAddress, err := h.getChannelAddress(brokerName, brokerNamespace)
-	if err != nil {
-		h.Logger.Warn("Failed to get channel address, falling back on guess", zap.Error(err))
-		channelAddress = guessChannelAddress(brokerName, brokerNamespace, network.GetClusterDomainName())
+	key, window, state := h.deduplicate(ctx, b, event)
+	switch state {
+	case DeduplicationDelivered:
+		h.Logger.Debug("Acknowledging duplicate event", zap.String("event.id", event.ID()), zap.String("event.source", event.Source()))
+		_ = h.Reporter.ReportEventDuplicate(&ReportArgs{ns: b.Namespace, broker: b.Name, eventType: event.Type()}, http.StatusAccepted)
+		return http.StatusAccepted, noDuration
+	case DeduplicationPending:
+		// The event may still fail to be delivered, the retries are accepted once it is.
+		h.Logger.Debug("Rejecting duplicate event being delivered", zap.String("event.id", event.ID()), zap.String("event.source", event.Source()))
+		_ = h.Reporter.ReportEventDuplicate(&ReportArgs{ns: b.Namespace, broker: b.Name, eventType: event.Type()}, http.StatusConflict)
+		return http.StatusConflict, noDuration
 	}
 
-	return h.send(ctx, headers, event, channelAddress)
+	statusCode, dispatchTime := h.send(ctx, headers, event, channelAddress)
+	if key == "" {
+		return statusCode, dispatchTime
+	}
+	if statusCode >= 200 && statusCode < 300 {
+		if err := h.DeduplicationStore.Confirm(ctx, key, window); err != nil {
+			h.Logger.Warn("Failed to record the delivered event", zap.String("event.id", event.ID()), zap.Error(err))
+		}
+	} else if err := h.DeduplicationStore.Remove(ctx, key); err != nil {
+		// The event wasn't delivered, accept it again when it is retried.
+		h.Logger.Warn("Failed to forget the undelivered event", zap.String("event.id", event.ID()), zap.Error(err))
+	}
+	return statusCode, dispatchTime
+}
+
+// deduplicate records event as pending if b deduplicates its events, and returns the key of the
+// recorded event, the window it is deduplicated for and its state before it was recorded. The
+// events failing to be recorded are not deduplicated.
+func (h *Handler) deduplicate(ctx context.Context, b *eventingv1.Broker, event *cloudevents.Event) (string, time.Duration, DeduplicationState) {
+	if h.DeduplicationStore == nil {
+		return "", 0, DeduplicationNew
+	}
+	window := deduplicationWindow(b)
+	if window <= 0 {
+		return "", 0, DeduplicationNew
+	}
+	ttl := window
+	if ttl > maxDeduplicationPending {
+		ttl = maxDeduplicationPending
+	}
+	key := deduplicationKey(b, event)
+	state, err := h.DeduplicationStore.Add(ctx, key, ttl)
+	if err != nil {
+		h.Logger.Warn("Failed to record the event for deduplication", zap.String("event.id", event.ID()), zap.Error(err))
+		return "", 0, DeduplicationNew
+	}
+	return key, window, state
 }
 
 func (h *Handler) send(ctx context.Context, headers http.Header, event *cloudevents.Event, target string) (int, time.Duration) {
@@ -18,12 +18,14 @@ package ingress
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	nethttp "net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/cloudevents/sdk-go/v2/client"
 	"github.com/cloudevents/sdk-go/v2/event"
 	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
@@ -31,7 +33,10 @@ import (
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	pkgduckv1 "knative.dev/pkg/apis/duck/v1"
 
+	duckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	"knative.dev/eventing/pkg/apis/eventing"
 	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
 	"knative.dev/eventing/pkg/kncloudevents"
@@ -60,6 +65,9 @@ func TestHandler_ServeHTTP(t *testing.T) {
 		reporter        StatsReporter
 		defaulter       client.EventDefaulter
 		brokers         []*eventingv1.Broker
+
+		// unexpectedHeaders are the headers which must not be passed to the handler.
+		unexpectedHeaders []string
 	}{
 		{
 			name:       "invalid method PATCH",
@@ -92,11 +100,22 @@ func TestHandler_ServeHTTP(t *testing.T) {
 			defaulter:  broker.TTLDefaulter(logger, 100),
 		},
 		{
-			name:       "invalid method GET",
+			name:       "readiness GET",
 			method:     nethttp.MethodGet,
 			uri:        "/ns/name",
+			statusCode: nethttp.StatusOK,
+			handler:    handler(),
+			reporter:   &mockReporter{},
+			brokers: []*eventingv1.Broker{
+				makeBroker("name", "ns"),
+			},
+		},
+		{
+			name:       "unknown broker",
+			method:     nethttp.MethodPost,
+			uri:        "/ns/name",
 			body:       getValidEvent(),
-			statusCode: nethttp.StatusMethodNotAllowed,
+			statusCode: nethttp.StatusNotFound,
 			handler:    handler(),
 			reporter:   &mockReporter{},
 			defaulter:  broker.TTLDefaulter(logger, 100),
@@ -124,6 +143,19 @@ func TestHandler_ServeHTTP(t *testing.T) {
 				makeBroker("name", "ns"),
 			},
 		},
+		{
+			name:       "publisher not authenticated",
+			method:     nethttp.MethodPost,
+			uri:        "/ns/name",
+			body:       getValidEvent(),
+			statusCode: nethttp.StatusForbidden,
+			handler:    handler(),
+			reporter:   &mockReporter{StatusCode: nethttp.StatusForbidden},
+			defaulter:  broker.TTLDefaulter(logger, 100),
+			brokers: []*eventingv1.Broker{
+				withIngressAuth(makeBroker("name", "ns"), eventing.BrokerIngressAuthServiceAccountToken),
+			},
+		},
 		{
 			name:       "no TTL drop event",
 			method:     nethttp.MethodPost,
@@ -184,6 +216,30 @@ func TestHandler_ServeHTTP(t *testing.T) {
 				makeBroker("name", "ns"),
 			},
 		},
+		{
+			name:       "credentials not passed to handler",
+			method:     nethttp.MethodPost,
+			uri:        "/ns/name",
+			body:       getValidEvent(),
+			statusCode: senderResponseStatusCode,
+			headers: nethttp.Header{
+				"Authorization":     []string{"Bearer token"},
+				HMACSignatureHeader: []string{"sha256=abcd"},
+				HMACTimestampHeader: []string{"1600000000"},
+				"Knative-Foo":       []string{"123"},
+				cehttp.ContentType:  []string{event.ApplicationCloudEventsJSON},
+			},
+			handler: &svc{},
+			expectedHeaders: nethttp.Header{
+				"Knative-Foo": []string{"123"},
+			},
+			unexpectedHeaders: []string{"Authorization", HMACSignatureHeader, HMACTimestampHeader},
+			reporter:          &mockReporter{StatusCode: senderResponseStatusCode, EventDispatchTimeReported: true},
+			defaulter:         broker.TTLDefaulter(logger, 100),
+			brokers: []*eventingv1.Broker{
+				makeBroker("name", "ns"),
+			},
+		},
 	}
 
 	for _, tc := range tt {
@@ -237,6 +293,11 @@ func TestHandler_ServeHTTP(t *testing.T) {
 						t.Error("(-want +got)", diff)
 					}
 				}
+				for _, k := range tc.unexpectedHeaders {
+					if v := svc.receivedHeaders.Get(k); v != "" {
+						t.Errorf("unexpected header %s: %s", k, v)
+					}
+				}
 			}
 
 			if diff := cmp.Diff(tc.reporter, h.Reporter); diff != "" {
@@ -264,6 +325,8 @@ func handler() nethttp.Handler {
 type mockReporter struct {
 	StatusCode                int
 	EventDispatchTimeReported bool
+	DuplicateReported         bool
+	ThrottledScope            string
 }
 
 func (r *mockReporter) ReportEventCount(_ *ReportArgs, responseCode int) error {
@@ -276,6 +339,22 @@ func (r *mockReporter) ReportEventDispatchTime(_ *ReportArgs, _ int, _ time.Dura
 	return nil
 }
 
+func (r *mockReporter) ReportEventRejected(_ *ReportArgs, responseCode int, _ string) error {
+	r.StatusCode = responseCode
+	return nil
+}
+
+func (r *mockReporter) ReportEventDuplicate(_ *ReportArgs, _ int) error {
+	r.DuplicateReported = true
+	return nil
+}
+
+func (r *mockReporter) ReportEventThrottled(_ *ReportArgs, responseCode int, scope string) error {
+	r.StatusCode = responseCode
+	r.ThrottledScope = scope
+	return nil
+}
+
 func getValidEvent() io.Reader {
 	e := event.New()
 	e.SetType("type")
@@ -285,8 +364,13 @@ func getValidEvent() io.Reader {
 	return bytes.NewBuffer(b)
 }
 
+func withIngressAuth(b *eventingv1.Broker, auth string) *eventingv1.Broker {
+	b.Annotations = map[string]string{eventing.BrokerIngressAuthAnnotationKey: auth}
+	return b
+}
+
 func makeBroker(name, namespace string) *eventingv1.Broker {
-	return &eventingv1.Broker{
+	b := &eventingv1.Broker{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "eventing.knative.dev/v1",
 			Kind:       "Broker",
@@ -297,4 +381,195 @@ func makeBroker(name, namespace string) *eventingv1.Broker {
 		},
 		Spec: eventingv1.BrokerSpec{},
 	}
+	b.Status.InitializeConditions()
+	b.Status.PropagateTriggerChannelReadiness(&duckv1.ChannelableStatus{
+		AddressStatus: pkgduckv1.AddressStatus{Address: &pkgduckv1.Addressable{URL: apis.HTTP("channel")}},
+	})
+	return b
+}
+
+func TestHandler_Batch(t *testing.T) {
+	expired := makeEvent("", "{}")
+	expired.SetID("expired")
+	_ = broker.SetTTL(expired.Context, 0)
+
+	testCases := map[string]struct {
+		body         string
+		statusCode   int
+		wantStatuses []kncloudevents.BatchEventStatus
+		wantSent     int
+	}{
+		"all sent": {
+			body:       batchOf(makeEvent("", "{}"), makeEvent("", "{}")),
+			statusCode: senderResponseStatusCode,
+			wantSent:   2,
+		},
+		"some dropped": {
+			body:       batchOf(makeEvent("", "{}"), expired),
+			statusCode: nethttp.StatusMultiStatus,
+			wantStatuses: []kncloudevents.BatchEventStatus{
+				{ID: "1234", Source: "source", StatusCode: senderResponseStatusCode},
+				{ID: "expired", Source: "source", StatusCode: nethttp.StatusBadRequest},
+			},
+			wantSent: 1,
+		},
+		"empty": {
+			body:       "[]",
+			statusCode: nethttp.StatusAccepted,
+		},
+		"malformed": {
+			body:       `[{"specversion": "1.0"}]`,
+			statusCode: nethttp.StatusBadRequest,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			var sent []string
+			s := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, req *nethttp.Request) {
+				sent = append(sent, req.Header.Get("Ce-"+broker.TTLAttribute))
+				w.WriteHeader(senderResponseStatusCode)
+			}))
+			defer s.Close()
+
+			b := makeBroker("name", "ns")
+			b.Status.Annotations = map[string]string{eventing.BrokerChannelAddressStatusAnnotationKey: s.URL}
+			listers := reconcilertestingv1.NewListers([]runtime.Object{b})
+			sender, _ := kncloudevents.NewHTTPMessageSenderWithTarget("")
+			h := &Handler{
+				Sender:       sender,
+				Defaulter:    broker.TTLDefaulter(zap.NewNop(), 100),
+				Reporter:     &mockReporter{},
+				Logger:       zap.NewNop(),
+				BrokerLister: listers.GetBrokerLister(),
+			}
+
+			request := httptest.NewRequest(nethttp.MethodPost, "/ns/name", bytes.NewBufferString(tc.body))
+			request.Header.Set(cehttp.ContentType, event.ApplicationCloudEventsBatchJSON)
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, request)
+
+			if got := recorder.Result().StatusCode; got != tc.statusCode {
+				t.Errorf("Unexpected status code, want %d, got %d", tc.statusCode, got)
+			}
+			if tc.wantStatuses != nil {
+				var got []kncloudevents.BatchEventStatus
+				if err := json.NewDecoder(recorder.Body).Decode(&got); err != nil {
+					t.Fatal("Unexpected error decoding the response:", err)
+				}
+				if diff := cmp.Diff(tc.wantStatuses, got); diff != "" {
+					t.Error("Unexpected statuses (-want, +got):", diff)
+				}
+			}
+			if len(sent) != tc.wantSent {
+				t.Errorf("Unexpected events sent, want %d, got %d", tc.wantSent, len(sent))
+			}
+			// The TTL is defaulted for each event.
+			for _, ttl := range sent {
+				if ttl != "100" {
+					t.Errorf("Unexpected TTL %q", ttl)
+				}
+			}
+		})
+	}
+}
+
+func batchOf(events ...*cloudevents.Event) string {
+	b, _ := json.Marshal(events)
+	return string(b)
+}
+
+func TestHandler_Readiness(t *testing.T) {
+	ready := makeBroker("name", "ns")
+	ready.Status.Annotations = map[string]string{eventing.BrokerChannelAddressStatusAnnotationKey: "http://channel"}
+
+	channelNotReady := ready.DeepCopy()
+	channelNotReady.Status.MarkTriggerChannelFailed("NoAddress", "Channel does not have an address.")
+
+	noAddress := makeBroker("name", "ns")
+
+	deleted := ready.DeepCopy()
+	deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+	testCases := map[string]struct {
+		broker     *eventingv1.Broker
+		method     string
+		statusCode int
+		wantBody   string
+		reporter   *mockReporter
+	}{
+		"ready": {
+			broker:     ready,
+			method:     nethttp.MethodGet,
+			statusCode: nethttp.StatusOK,
+			wantBody:   "Broker ns/name is ready\n",
+			reporter:   &mockReporter{},
+		},
+		"channel not ready": {
+			broker:     channelNotReady,
+			method:     nethttp.MethodGet,
+			statusCode: nethttp.StatusServiceUnavailable,
+			wantBody:   "Broker ns/name is not ready: its channel is not ready: Channel does not have an address.\n",
+			reporter:   &mockReporter{},
+		},
+		"no channel address": {
+			broker:     noAddress,
+			method:     nethttp.MethodGet,
+			statusCode: nethttp.StatusServiceUnavailable,
+			wantBody:   "Broker ns/name is not ready: its channel has no address\n",
+			reporter:   &mockReporter{},
+		},
+		"being deleted": {
+			broker:     deleted,
+			method:     nethttp.MethodGet,
+			statusCode: nethttp.StatusServiceUnavailable,
+			wantBody:   "Broker ns/name is being deleted\n",
+			reporter:   &mockReporter{},
+		},
+		"not found": {
+			method:     nethttp.MethodGet,
+			statusCode: nethttp.StatusNotFound,
+			wantBody:   "Broker ns/name not found\n",
+			reporter:   &mockReporter{},
+		},
+		"event sent to a Broker not ready": {
+			broker:     channelNotReady,
+			method:     nethttp.MethodPost,
+			statusCode: nethttp.StatusServiceUnavailable,
+			wantBody:   "Broker ns/name is not ready: its channel is not ready: Channel does not have an address.\n",
+			reporter:   &mockReporter{StatusCode: nethttp.StatusServiceUnavailable},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			var brokers []runtime.Object
+			if tc.broker != nil {
+				brokers = append(brokers, tc.broker)
+			}
+			listers := reconcilertestingv1.NewListers(brokers)
+			h := &Handler{
+				Reporter:     &mockReporter{},
+				Logger:       zap.NewNop(),
+				BrokerLister: listers.GetBrokerLister(),
+			}
+
+			var body io.Reader
+			if tc.method == nethttp.MethodPost {
+				body = getValidEvent()
+			}
+			request := httptest.NewRequest(tc.method, "/ns/name", body)
+			request.Header.Set(cehttp.ContentType, event.ApplicationCloudEventsJSON)
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, request)
+
+			if got := recorder.Result().StatusCode; got != tc.statusCode {
+				t.Errorf("Unexpected status code, want %d, got %d", tc.statusCode, got)
+			}
+			if got := recorder.Body.String(); got != tc.wantBody {
+				t.Errorf("Unexpected body, want %q, got %q", tc.wantBody, got)
+			}
+			if diff := cmp.Diff(tc.reporter, h.Reporter); diff != "" {
+				t.Error("Unexpected reporter state (-want, +got):", diff)
+			}
+		})
+	}
 }
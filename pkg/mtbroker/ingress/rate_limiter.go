@@ -0,0 +1,310 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/configmap"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+)
+
+const (
+	// RateLimitsConfigMap is the name of the ConfigMap holding the rate limits of the Broker
+	// ingress.
+	RateLimitsConfigMap = "config-broker-ingress-rate-limits"
+
+	// The scopes of the rate limits, reported with the throttled events.
+	throttleScopeNamespace = "namespace"
+	throttleScopeBroker    = "broker"
+
+	// rateLimiterPruneInterval is how often the token buckets of the idle namespaces and
+	// Brokers are dropped.
+	rateLimiterPruneInterval = time.Minute
+)
+
+// RateLimitConfig holds the rate limits of the Broker ingress. The events per second set to 0
+// mean no limit, the bursts set to 0 default to the events per second rounded up.
+type RateLimitConfig struct {
+	NamespaceEventsPerSecond float64
+	NamespaceBurst           int
+	BrokerEventsPerSecond    float64
+	BrokerBurst              int
+}
+
+// NewRateLimitConfigFromConfigMap converts a k8s configmap into RateLimitConfig.
+func NewRateLimitConfigFromConfigMap(config *corev1.ConfigMap) (RateLimitConfig, error) {
+	c := RateLimitConfig{}
+	err := configmap.Parse(
+		config.Data,
+		configmap.AsFloat64("NamespaceEventsPerSecond", &c.NamespaceEventsPerSecond),
+		configmap.AsInt("NamespaceBurst", &c.NamespaceBurst),
+		configmap.AsFloat64("BrokerEventsPerSecond", &c.BrokerEventsPerSecond),
+		configmap.AsInt("BrokerBurst", &c.BrokerBurst))
+	if err != nil {
+		return c, err
+	}
+	if c.NamespaceEventsPerSecond < 0 {
+		return c, fmt.Errorf("NamespaceEventsPerSecond must be positive, got %v", c.NamespaceEventsPerSecond)
+	}
+	if c.NamespaceBurst < 0 {
+		return c, fmt.Errorf("NamespaceBurst must be positive, got %d", c.NamespaceBurst)
+	}
+	if c.BrokerEventsPerSecond < 0 {
+		return c, fmt.Errorf("BrokerEventsPerSecond must be positive, got %v", c.BrokerEventsPerSecond)
+	}
+	if c.BrokerBurst < 0 {
+		return c, fmt.Errorf("BrokerBurst must be positive, got %d", c.BrokerBurst)
+	}
+	return c, nil
+}
+
+// Throttled describes the events rejected by a RateLimiter.
+type Throttled struct {
+	// Scope is the scope of the rate limit rejecting the events, namespace or broker.
+	Scope string
+	// RetryAfter is how long to wait before the events are accepted, 0 if they are more events
+	// than accepted at once.
+	RetryAfter time.Duration
+}
+
+// RateLimiter limits the events accepted by the Broker ingress for each namespace and each
+// Broker, with token buckets. The token buckets are not shared by the replicas of the Broker
+// ingress, each replica accepts its share of the rate limits instead, the events being evenly
+// spread across them. A nil RateLimiter accepts all the events.
+type RateLimiter struct {
+	logger *zap.Logger
+
+	mutex  sync.Mutex
+	config RateLimitConfig
+	// replicas is the number of replicas of the Broker ingress sharing the rate limits.
+	replicas int
+	// namespaces and brokers hold the token buckets by namespace and by namespace/name.
+	namespaces map[string]*tokenBucket
+	brokers    map[string]*tokenBucket
+	lastPrune  time.Time
+	now        func() time.Time
+}
+
+type tokenBucket struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// NewRateLimiter creates a RateLimiter without limits, until it is configured with
+// UpdateFromConfigMap.
+func NewRateLimiter(logger *zap.Logger) *RateLimiter {
+	return &RateLimiter{
+		logger:     logger,
+		namespaces: make(map[string]*tokenBucket),
+		brokers:    make(map[string]*tokenBucket),
+		replicas:   1,
+		now:        time.Now,
+	}
+}
+
+// WatchReplicas keeps the number of replicas sharing the rate limits up to date with the ready
+// endpoints of the Service named service in namespace, until ctx is done.
+func (l *RateLimiter) WatchReplicas(ctx context.Context, client kubernetes.Interface, namespace, service string) {
+	informer := corev1informers.NewFilteredEndpointsInformer(client, namespace, 0, cache.Indexers{},
+		func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", service).String()
+		})
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			l.updateReplicas(obj)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			l.updateReplicas(obj)
+		},
+		DeleteFunc: func(interface{}) {
+			l.SetReplicas(1)
+		},
+	})
+	go informer.Run(ctx.Done())
+}
+
+func (l *RateLimiter) updateReplicas(obj interface{}) {
+	endpoints, ok := obj.(*corev1.Endpoints)
+	if !ok {
+		return
+	}
+	replicas := 0
+	for _, subset := range endpoints.Subsets {
+		replicas += len(subset.Addresses)
+	}
+	l.SetReplicas(replicas)
+}
+
+// SetReplicas sets the number of replicas of the Broker ingress sharing the rate limits, at least
+// one.
+func (l *RateLimiter) SetReplicas(replicas int) {
+	if replicas < 1 {
+		replicas = 1
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if replicas != l.replicas {
+		l.logger.Info("The rate limits are shared by a new number of replicas", zap.Int("replicas", replicas))
+		l.replicas = replicas
+	}
+}
+
+// UpdateFromConfigMap updates the rate limits from the config-broker-ingress-rate-limits
+// ConfigMap. Invalid rate limits are ignored.
+func (l *RateLimiter) UpdateFromConfigMap(cm *corev1.ConfigMap) {
+	config, err := NewRateLimitConfigFromConfigMap(cm)
+	if err != nil {
+		l.logger.Error("Failed to parse the rate limits, keeping the current ones", zap.Error(err))
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.config = config
+}
+
+// Reserve takes n events from the token buckets of the Broker b and of its namespace. If the
+// events are rejected, no event is taken from any token bucket and Reserve returns the rate limit
+// rejecting them.
+func (l *RateLimiter) Reserve(b *eventingv1.Broker, n int) *Throttled {
+	if l == nil {
+		return nil
+	}
+	now := l.now()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.prune(now)
+
+	var reservations []*rate.Reservation
+	cancel := func() {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+	}
+	reserve := func(scope string, bucket *tokenBucket) *Throttled {
+		if bucket == nil {
+			return nil
+		}
+		bucket.lastUsed = now
+		r := bucket.limiter.ReserveN(now, n)
+		if !r.OK() {
+			cancel()
+			return &Throttled{Scope: scope}
+		}
+		if delay := r.DelayFrom(now); delay > 0 {
+			r.CancelAt(now)
+			cancel()
+			return &Throttled{Scope: scope, RetryAfter: delay}
+		}
+		reservations = append(reservations, r)
+		return nil
+	}
+
+	limit, burst := l.config.NamespaceEventsPerSecond, l.config.NamespaceBurst
+	if throttled := reserve(throttleScopeNamespace, l.bucket(l.namespaces, b.Namespace, limit, burst, now)); throttled != nil {
+		return throttled
+	}
+	limit, burst = l.brokerLimit(b)
+	key := types.NamespacedName{Namespace: b.Namespace, Name: b.Name}.String()
+	return reserve(throttleScopeBroker, l.bucket(l.brokers, key, limit, burst, now))
+}
+
+// brokerLimit returns the rate limit of b, set by its annotations or the ConfigMap.
+func (l *RateLimiter) brokerLimit(b *eventingv1.Broker) (float64, int) {
+	limit, burst := l.config.BrokerEventsPerSecond, l.config.BrokerBurst
+	annotations := b.Annotations
+	if value, ok := annotations[eventing.BrokerRateLimitAnnotationKey]; ok {
+		if v, err := strconv.ParseFloat(value, 64); err == nil && v >= 0 {
+			limit = v
+		}
+	}
+	if value, ok := annotations[eventing.BrokerRateLimitBurstAnnotationKey]; ok {
+		if v, err := strconv.Atoi(value); err == nil && v > 0 {
+			burst = v
+		}
+	}
+	return limit, burst
+}
+
+// bucket returns the token bucket at key in buckets, updated to the share of the rate limit of
+// this replica, nil if there is no limit.
+func (l *RateLimiter) bucket(buckets map[string]*tokenBucket, key string, limit float64, burst int, now time.Time) *tokenBucket {
+	if limit <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = int(math.Ceil(limit))
+	}
+	limit /= float64(l.replicas)
+	burst = int(math.Ceil(float64(burst) / float64(l.replicas)))
+
+	bucket, ok := buckets[key]
+	if !ok {
+		bucket = &tokenBucket{limiter: rate.NewLimiter(rate.Limit(limit), burst)}
+		buckets[key] = bucket
+		return bucket
+	}
+	if bucket.limiter.Limit() != rate.Limit(limit) {
+		bucket.limiter.SetLimitAt(now, rate.Limit(limit))
+	}
+	if bucket.limiter.Burst() != burst {
+		bucket.limiter.SetBurstAt(now, burst)
+	}
+	return bucket
+}
+
+// prune drops the token buckets refilled since they were last used, as they are the same as new
+// ones.
+func (l *RateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < rateLimiterPruneInterval {
+		return
+	}
+	l.lastPrune = now
+	for key, bucket := range l.namespaces {
+		if bucket.refilled(now) {
+			delete(l.namespaces, key)
+		}
+	}
+	for key, bucket := range l.brokers {
+		if bucket.refilled(now) {
+			delete(l.brokers, key)
+		}
+	}
+}
+
+func (b *tokenBucket) refilled(now time.Time) bool {
+	limit := float64(b.limiter.Limit())
+	refill := time.Duration(float64(b.limiter.Burst()) / limit * float64(time.Second))
+	return now.Sub(b.lastUsed) >= refill
+}
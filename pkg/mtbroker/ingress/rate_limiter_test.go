@@ -0,0 +1,255 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"bytes"
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/kncloudevents"
+	broker "knative.dev/eventing/pkg/mtbroker"
+	reconcilertestingv1 "knative.dev/eventing/pkg/reconciler/testing/v1"
+)
+
+func TestNewRateLimitConfigFromConfigMap(t *testing.T) {
+	testCases := map[string]struct {
+		data    map[string]string
+		want    RateLimitConfig
+		wantErr bool
+	}{
+		"empty": {},
+		"limits": {
+			data: map[string]string{
+				"NamespaceEventsPerSecond": "100",
+				"NamespaceBurst":           "200",
+				"BrokerEventsPerSecond":    "0.5",
+				"BrokerBurst":              "5",
+			},
+			want: RateLimitConfig{
+				NamespaceEventsPerSecond: 100,
+				NamespaceBurst:           200,
+				BrokerEventsPerSecond:    0.5,
+				BrokerBurst:              5,
+			},
+		},
+		"negative": {
+			data:    map[string]string{"BrokerEventsPerSecond": "-1"},
+			wantErr: true,
+		},
+		"malformed": {
+			data:    map[string]string{"NamespaceBurst": "lots"},
+			wantErr: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			got, err := NewRateLimitConfigFromConfigMap(&corev1.ConfigMap{Data: tc.data})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error, want %t, got %v", tc.wantErr, err)
+			}
+			if diff := cmp.Diff(tc.want, got); !tc.wantErr && diff != "" {
+				t.Error("Unexpected config (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(zap.NewNop())
+	l.now = func() time.Time { return now }
+	l.UpdateFromConfigMap(&corev1.ConfigMap{Data: map[string]string{
+		"NamespaceEventsPerSecond": "3",
+		"BrokerEventsPerSecond":    "2",
+	}})
+
+	limited := makeBroker("limited", "ns")
+	limited.Annotations = map[string]string{
+		eventing.BrokerRateLimitAnnotationKey:      "1",
+		eventing.BrokerRateLimitBurstAnnotationKey: "1",
+	}
+	unlimited := makeBroker("unlimited", "ns")
+	unlimited.Annotations = map[string]string{eventing.BrokerRateLimitAnnotationKey: "0"}
+
+	reserve := func(b *eventingv1.Broker, n int, want *Throttled) {
+		t.Helper()
+		if diff := cmp.Diff(want, l.Reserve(b, n)); diff != "" {
+			t.Error("Unexpected throttling (-want, +got):", diff)
+		}
+	}
+
+	reserve(limited, 1, nil)
+	// Throttled by the Broker, without taking events from the namespace.
+	reserve(limited, 1, &Throttled{Scope: throttleScopeBroker, RetryAfter: time.Second})
+	reserve(unlimited, 2, nil)
+	// Throttled by the namespace.
+	reserve(unlimited, 1, &Throttled{Scope: throttleScopeNamespace, RetryAfter: time.Second / 3})
+	// The Brokers of other namespaces are not throttled.
+	reserve(makeBroker("unlimited", "other"), 2, nil)
+	// More events than accepted at once.
+	reserve(makeBroker("name", "other-ns"), 3, &Throttled{Scope: throttleScopeBroker})
+
+	now = now.Add(time.Second)
+	reserve(limited, 1, nil)
+
+	// The refilled token buckets are dropped.
+	now = now.Add(rateLimiterPruneInterval)
+	reserve(unlimited, 1, nil)
+	if len(l.namespaces) != 1 || len(l.brokers) != 0 {
+		t.Errorf("Unexpected token buckets, got %d namespaces and %d brokers", len(l.namespaces), len(l.brokers))
+	}
+}
+
+func TestRateLimiterReplicas(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(zap.NewNop())
+	l.now = func() time.Time { return now }
+	l.UpdateFromConfigMap(&corev1.ConfigMap{Data: map[string]string{
+		"BrokerEventsPerSecond": "4",
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := fake.NewSimpleClientset(&corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "knative-eventing", Name: "broker-ingress"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+		}},
+	})
+	l.WatchReplicas(ctx, client, "knative-eventing", "broker-ingress")
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		return l.replicas == 2, nil
+	}); err != nil {
+		t.Fatal("Timed out waiting for the replicas to be counted")
+	}
+
+	// Each of the 2 replicas accepts 2 events per second.
+	b := makeBroker("name", "ns")
+	if throttled := l.Reserve(b, 2); throttled != nil {
+		t.Error("Unexpected throttling:", throttled)
+	}
+	want := &Throttled{Scope: throttleScopeBroker, RetryAfter: time.Second / 2}
+	if diff := cmp.Diff(want, l.Reserve(b, 1)); diff != "" {
+		t.Error("Unexpected throttling (-want, +got):", diff)
+	}
+
+	l.SetReplicas(0)
+	if l.replicas != 1 {
+		t.Errorf("Expected at least 1 replica, got %d", l.replicas)
+	}
+}
+
+func TestNilRateLimiter(t *testing.T) {
+	var l *RateLimiter
+	if throttled := l.Reserve(makeBroker("name", "ns"), 1000); throttled != nil {
+		t.Error("Unexpected throttling:", throttled)
+	}
+}
+
+func TestHandler_RateLimit(t *testing.T) {
+	testCases := map[string]struct {
+		contentType    string
+		body           string
+		statusCode     int
+		wantRetryAfter string
+		wantReporter   *mockReporter
+	}{
+		"accepted": {
+			body:         `{}`,
+			statusCode:   senderResponseStatusCode,
+			wantReporter: &mockReporter{StatusCode: senderResponseStatusCode, EventDispatchTimeReported: true},
+		},
+		"throttled": {
+			body:           batchOf(makeEvent("", "{}"), makeEvent("", "{}")),
+			contentType:    event.ApplicationCloudEventsBatchJSON,
+			statusCode:     nethttp.StatusTooManyRequests,
+			wantRetryAfter: "10",
+			wantReporter:   &mockReporter{StatusCode: nethttp.StatusTooManyRequests, ThrottledScope: throttleScopeBroker},
+		},
+		"batch too large": {
+			body:         batchOf(makeEvent("", "{}"), makeEvent("", "{}"), makeEvent("", "{}")),
+			contentType:  event.ApplicationCloudEventsBatchJSON,
+			statusCode:   nethttp.StatusRequestEntityTooLarge,
+			wantReporter: &mockReporter{StatusCode: nethttp.StatusRequestEntityTooLarge, ThrottledScope: throttleScopeBroker},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			channel := &svc{}
+			s := httptest.NewServer(channel)
+			defer s.Close()
+
+			b := makeBroker("name", "ns")
+			b.Annotations = map[string]string{
+				eventing.BrokerRateLimitAnnotationKey:      "0.1",
+				eventing.BrokerRateLimitBurstAnnotationKey: "2",
+			}
+			b.Status.Annotations = map[string]string{eventing.BrokerChannelAddressStatusAnnotationKey: s.URL}
+			listers := reconcilertestingv1.NewListers([]runtime.Object{b})
+			sender, _ := kncloudevents.NewHTTPMessageSenderWithTarget("")
+			h := &Handler{
+				Sender:       sender,
+				Defaulter:    broker.TTLDefaulter(zap.NewNop(), 100),
+				Reporter:     &mockReporter{},
+				Logger:       zap.NewNop(),
+				BrokerLister: listers.GetBrokerLister(),
+				RateLimiter:  NewRateLimiter(zap.NewNop()),
+			}
+			// Take an event from the Broker.
+			h.RateLimiter.Reserve(b, 1)
+
+			body := []byte(tc.body)
+			contentType := tc.contentType
+			if contentType == "" {
+				body, _ = makeEvent("", tc.body).MarshalJSON()
+				contentType = event.ApplicationCloudEventsJSON
+			}
+			request := httptest.NewRequest(nethttp.MethodPost, "/ns/name", bytes.NewBuffer(body))
+			request.Header.Set(cehttp.ContentType, contentType)
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, request)
+
+			if got := recorder.Result().StatusCode; got != tc.statusCode {
+				t.Errorf("Unexpected status code, want %d, got %d", tc.statusCode, got)
+			}
+			if got := recorder.Header().Get("Retry-After"); got != tc.wantRetryAfter {
+				t.Errorf("Unexpected Retry-After, want %q, got %q", tc.wantRetryAfter, got)
+			}
+			if diff := cmp.Diff(tc.wantReporter, h.Reporter); diff != "" {
+				t.Error("Unexpected reporter state (-want, +got):", diff)
+			}
+		})
+	}
+}
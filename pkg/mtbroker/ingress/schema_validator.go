@@ -0,0 +1,190 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/eventing/pkg/eventschema"
+)
+
+const (
+	// SchemaErrorExtension is the extension set on the events not matching the schema of their
+	// EventType, to the violation of the schema, by the Brokers tagging them.
+	SchemaErrorExtension = "schemaerror"
+
+	rejectionInvalidSchema = "schema_validation_failed"
+
+	// eventTypeSchemaIndex indexes the EventTypes holding a schema by namespace, Broker and type.
+	eventTypeSchemaIndex = "schema"
+)
+
+// EventTypeIndexers are the indexers the informer of the EventTypes looked up by a
+// SchemaValidator must have.
+var EventTypeIndexers = cache.Indexers{eventTypeSchemaIndex: indexEventTypeSchema}
+
+// SchemaValidator validates the data of the events against the JSON Schema in the SchemaData
+// of their EventType. A nil SchemaValidator accepts all the events.
+type SchemaValidator struct {
+	eventTypes cache.Indexer
+	logger     *zap.Logger
+
+	mutex sync.Mutex
+	// schemas caches the compiled schemas, keyed by EventType, until the EventType is deleted.
+	schemas map[types.NamespacedName]compiledSchema
+}
+
+type compiledSchema struct {
+	resourceVersion string
+	schema          *eventschema.Schema
+}
+
+// NewSchemaValidator creates a SchemaValidator looking up the EventTypes in eventTypes, which
+// must have the EventTypeIndexers.
+func NewSchemaValidator(eventTypes cache.Indexer, logger *zap.Logger) *SchemaValidator {
+	return &SchemaValidator{
+		eventTypes: eventTypes,
+		logger:     logger,
+		schemas:    make(map[types.NamespacedName]compiledSchema),
+	}
+}
+
+// EventTypeDeleted drops the compiled schema of the deleted EventType obj, it handles the deletions
+// of the informer of the EventTypes.
+func (v *SchemaValidator) EventTypeDeleted(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	et, ok := obj.(*v1beta1.EventType)
+	if !ok {
+		return
+	}
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	delete(v.schemas, types.NamespacedName{Namespace: et.Namespace, Name: et.Name})
+}
+
+// Validate returns the violation of the schema of its EventType by event, sent to b. The events
+// without an EventType holding a schema are valid, as are the events whose EventType holds an
+// invalid schema, which is logged.
+func (v *SchemaValidator) Validate(b *eventingv1.Broker, event *cloudevents.Event) error {
+	if v == nil {
+		return nil
+	}
+	et, err := v.eventType(b, event)
+	if err != nil || et == nil {
+		return err
+	}
+	schema := v.schema(et)
+	if schema == nil {
+		return nil
+	}
+
+	if !isJSON(event.DataContentType()) {
+		return fmt.Errorf("data content type %q is not JSON", event.DataContentType())
+	}
+	return schema.Validate(event.Data())
+}
+
+// eventType returns the EventType of event holding a schema, nil if there is none. The
+// EventTypes of the source of event take precedence over the EventTypes of any source.
+func (v *SchemaValidator) eventType(b *eventingv1.Broker, event *cloudevents.Event) (*v1beta1.EventType, error) {
+	objs, err := v.eventTypes.ByIndex(eventTypeSchemaIndex, eventTypeSchemaKey(b.Namespace, b.Name, event.Type()))
+	if err != nil {
+		return nil, err
+	}
+	eventTypes := make([]*v1beta1.EventType, 0, len(objs))
+	for _, obj := range objs {
+		if et, ok := obj.(*v1beta1.EventType); ok {
+			eventTypes = append(eventTypes, et)
+		}
+	}
+	// Sort the EventTypes, for the same one to be picked when several match.
+	sort.Slice(eventTypes, func(i, j int) bool {
+		return eventTypes[i].Name < eventTypes[j].Name
+	})
+
+	var anySource *v1beta1.EventType
+	for _, et := range eventTypes {
+		if et.Spec.Source == nil {
+			if anySource == nil {
+				anySource = et
+			}
+		} else if et.Spec.Source.String() == event.Source() {
+			return et, nil
+		}
+	}
+	return anySource, nil
+}
+
+// indexEventTypeSchema indexes the EventTypes holding a schema by namespace, Broker and type.
+func indexEventTypeSchema(obj interface{}) ([]string, error) {
+	et, ok := obj.(*v1beta1.EventType)
+	if !ok || et.Spec.SchemaData == "" {
+		return nil, nil
+	}
+	return []string{eventTypeSchemaKey(et.Namespace, et.Spec.Broker, et.Spec.Type)}, nil
+}
+
+// eventTypeSchemaKey returns the key of eventTypeSchemaIndex. The namespace and the name of the
+// Broker can't hold a slash, unlike the type.
+func eventTypeSchemaKey(namespace, broker, eventType string) string {
+	return namespace + "/" + broker + "/" + eventType
+}
+
+// schema returns the compiled schema of et, nil if it is invalid.
+func (v *SchemaValidator) schema(et *v1beta1.EventType) *eventschema.Schema {
+	key := types.NamespacedName{Namespace: et.Namespace, Name: et.Name}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if cached, ok := v.schemas[key]; ok && cached.resourceVersion == et.ResourceVersion {
+		return cached.schema
+	}
+	schema, err := eventschema.Compile([]byte(et.Spec.SchemaData))
+	if err != nil {
+		v.logger.Warn("Invalid schema, the events are not validated", zap.String("namespace", et.Namespace),
+			zap.String("eventType", et.Name), zap.Error(err))
+	}
+	// The invalid schemas are cached too, to log them once per version.
+	v.schemas[key] = compiledSchema{resourceVersion: et.ResourceVersion, schema: schema}
+	return schema
+}
+
+// isJSON returns whether the data of the events of contentType is JSON, the events without a
+// content type carrying JSON.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"bytes"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/eventing/pkg/kncloudevents"
+	broker "knative.dev/eventing/pkg/mtbroker"
+	reconcilertestingv1 "knative.dev/eventing/pkg/reconciler/testing/v1"
+)
+
+const (
+	idSchema     = `{"type": "object", "required": ["id"]}`
+	amountSchema = `{"type": "object", "required": ["amount"]}`
+)
+
+func TestSchemaValidator(t *testing.T) {
+	testCases := map[string]struct {
+		eventTypes  []*v1beta1.EventType
+		contentType string
+		data        string
+		wantErr     string
+	}{
+		"no EventType": {
+			data: `{}`,
+		},
+		"valid": {
+			eventTypes: []*v1beta1.EventType{makeEventType("et", "name", "", idSchema)},
+			data:       `{"id": "1"}`,
+		},
+		"invalid": {
+			eventTypes: []*v1beta1.EventType{makeEventType("et", "name", "", idSchema)},
+			data:       `{}`,
+			wantErr:    `/: missing required property "id"`,
+		},
+		"EventType of the source first": {
+			eventTypes: []*v1beta1.EventType{
+				makeEventType("a-any", "name", "", idSchema),
+				makeEventType("b-source", "name", "source", amountSchema),
+			},
+			data:    `{"id": "1"}`,
+			wantErr: `/: missing required property "amount"`,
+		},
+		"EventType of another source": {
+			eventTypes: []*v1beta1.EventType{makeEventType("et", "name", "other-source", idSchema)},
+			data:       `{}`,
+		},
+		"EventType of another broker": {
+			eventTypes: []*v1beta1.EventType{makeEventType("et", "other", "", idSchema)},
+			data:       `{}`,
+		},
+		"EventType of another type": {
+			eventTypes: []*v1beta1.EventType{func() *v1beta1.EventType {
+				et := makeEventType("et", "name", "", idSchema)
+				et.Spec.Type = "other-type"
+				return et
+			}()},
+			data: `{}`,
+		},
+		"EventType without schema": {
+			eventTypes: []*v1beta1.EventType{makeEventType("et", "name", "", "")},
+			data:       `{}`,
+		},
+		"invalid schema": {
+			eventTypes: []*v1beta1.EventType{makeEventType("et", "name", "", `{"type": 1}`)},
+			data:       `{}`,
+		},
+		"JSON content type": {
+			eventTypes:  []*v1beta1.EventType{makeEventType("et", "name", "", idSchema)},
+			contentType: "application/vnd.order+json; charset=utf-8",
+			data:        `{"id": "1"}`,
+		},
+		"not JSON": {
+			eventTypes:  []*v1beta1.EventType{makeEventType("et", "name", "", idSchema)},
+			contentType: "text/plain",
+			data:        `id=1`,
+			wantErr:     `data content type "text/plain" is not JSON`,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			v := NewSchemaValidator(newEventTypeIndexer(t, tc.eventTypes...), zap.NewNop())
+			e := makeEvent(tc.contentType, tc.data)
+			err := v.Validate(makeBroker("name", "ns"), e)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Error("Unexpected error:", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("Unexpected error, want %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSchemaValidatorUpdatedEventType(t *testing.T) {
+	indexer := newEventTypeIndexer(t)
+	v := NewSchemaValidator(indexer, zap.NewNop())
+	b := makeBroker("name", "ns")
+	e := makeEvent("", `{"id": "1"}`)
+
+	et := makeEventType("et", "name", "", idSchema)
+	et.ResourceVersion = "1"
+	if err := indexer.Add(et); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate(b, e); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	et = makeEventType("et", "name", "", amountSchema)
+	et.ResourceVersion = "2"
+	if err := indexer.Update(et); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate(b, e); err == nil {
+		t.Error("Expected the event to be validated against the updated schema")
+	}
+}
+
+func TestSchemaValidatorDeletedEventType(t *testing.T) {
+	indexer := newEventTypeIndexer(t)
+	v := NewSchemaValidator(indexer, zap.NewNop())
+	b := makeBroker("name", "ns")
+
+	et := makeEventType("et", "name", "", idSchema)
+	if err := indexer.Add(et); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate(b, makeEvent("", `{"id": "1"}`)); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(v.schemas) != 1 {
+		t.Fatalf("Expected the schema to be cached, got %d schemas", len(v.schemas))
+	}
+
+	if err := indexer.Delete(et); err != nil {
+		t.Fatal(err)
+	}
+	v.EventTypeDeleted(cache.DeletedFinalStateUnknown{Key: "ns/et", Obj: et})
+	if len(v.schemas) != 0 {
+		t.Errorf("Expected the schema of the deleted EventType to be dropped, got %d schemas", len(v.schemas))
+	}
+}
+
+func TestHandler_SchemaValidation(t *testing.T) {
+	testCases := map[string]struct {
+		policy          string
+		data            string
+		statusCode      int
+		reporter        StatsReporter
+		wantSchemaError bool
+	}{
+		"valid": {
+			policy:     eventing.BrokerSchemaValidationReject,
+			data:       `{"id": "1"}`,
+			statusCode: senderResponseStatusCode,
+			reporter:   &mockReporter{StatusCode: senderResponseStatusCode, EventDispatchTimeReported: true},
+		},
+		"invalid, rejected": {
+			policy:     eventing.BrokerSchemaValidationReject,
+			data:       `{}`,
+			statusCode: nethttp.StatusBadRequest,
+			reporter:   &mockReporter{StatusCode: nethttp.StatusBadRequest},
+		},
+		"invalid, tagged": {
+			policy:          eventing.BrokerSchemaValidationTag,
+			data:            `{}`,
+			statusCode:      senderResponseStatusCode,
+			reporter:        &mockReporter{StatusCode: senderResponseStatusCode, EventDispatchTimeReported: true},
+			wantSchemaError: true,
+		},
+		"invalid, not validated": {
+			data:       `{}`,
+			statusCode: senderResponseStatusCode,
+			reporter:   &mockReporter{StatusCode: senderResponseStatusCode, EventDispatchTimeReported: true},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			channel := &svc{}
+			s := httptest.NewServer(channel)
+			defer s.Close()
+
+			b := makeBroker("name", "ns")
+			b.Annotations = map[string]string{eventing.BrokerSchemaValidationAnnotationKey: tc.policy}
+			b.Status.Annotations = map[string]string{eventing.BrokerChannelAddressStatusAnnotationKey: s.URL}
+			listers := reconcilertestingv1.NewListers([]runtime.Object{b})
+			sender, _ := kncloudevents.NewHTTPMessageSenderWithTarget("")
+			h := &Handler{
+				Sender:          sender,
+				Defaulter:       broker.TTLDefaulter(zap.NewNop(), 100),
+				Reporter:        &mockReporter{},
+				Logger:          zap.NewNop(),
+				BrokerLister:    listers.GetBrokerLister(),
+				SchemaValidator: NewSchemaValidator(newEventTypeIndexer(t, makeEventType("et", "name", "", idSchema)), zap.NewNop()),
+			}
+
+			body, _ := makeEvent("", tc.data).MarshalJSON()
+			request := httptest.NewRequest(nethttp.MethodPost, "/ns/name", bytes.NewBuffer(body))
+			request.Header.Set(cehttp.ContentType, event.ApplicationCloudEventsJSON)
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, request)
+
+			if got := recorder.Result().StatusCode; got != tc.statusCode {
+				t.Errorf("Unexpected status code, want %d, got %d", tc.statusCode, got)
+			}
+			if diff := cmp.Diff(tc.reporter, h.Reporter); diff != "" {
+				t.Error("Unexpected reporter state (-want, +got):", diff)
+			}
+			if got := channel.receivedHeaders.Get("Ce-"+SchemaErrorExtension) != ""; got != tc.wantSchemaError {
+				t.Errorf("Unexpected %s extension, want %t, got %t", SchemaErrorExtension, tc.wantSchemaError, got)
+			}
+		})
+	}
+}
+
+func newEventTypeIndexer(t *testing.T, eventTypes ...*v1beta1.EventType) cache.Indexer {
+	t.Helper()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := indexer.AddIndexers(EventTypeIndexers); err != nil {
+		t.Fatal(err)
+	}
+	for _, et := range eventTypes {
+		if err := indexer.Add(et); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return indexer
+}
+
+func makeEventType(name, brokerName, source, schemaData string) *v1beta1.EventType {
+	et := &v1beta1.EventType{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Spec: v1beta1.EventTypeSpec{
+			Type:       "type",
+			Broker:     brokerName,
+			SchemaData: schemaData,
+		},
+	}
+	if source != "" {
+		et.Spec.Source = &apis.URL{Path: source}
+	}
+	return et
+}
+
+func makeEvent(contentType, data string) *cloudevents.Event {
+	e := event.New()
+	e.SetType("type")
+	e.SetSource("source")
+	e.SetID("1234")
+	if contentType == "" {
+		contentType = cloudevents.ApplicationJSON
+	}
+	_ = e.SetData(contentType, []byte(data))
+	return &e
+}
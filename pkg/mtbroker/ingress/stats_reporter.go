@@ -48,6 +48,30 @@ var (
 		stats.UnitMilliseconds,
 	)
 
+	// rejectedCountM is a counter which records the number of requests
+	// rejected by the ingress policy of a Broker.
+	rejectedCountM = stats.Int64(
+		"event_rejected_count",
+		"Number of requests rejected by the ingress policy of a Broker",
+		stats.UnitDimensionless,
+	)
+
+	// duplicateCountM is a counter which records the number of duplicate
+	// events acknowledged without being sent to the Channel of a Broker.
+	duplicateCountM = stats.Int64(
+		"event_duplicate_count",
+		"Number of duplicate events received by a Broker",
+		stats.UnitDimensionless,
+	)
+
+	// throttledCountM is a counter which records the number of events
+	// rejected by the rate limits of a Broker or of its namespace.
+	throttledCountM = stats.Int64(
+		"event_throttled_count",
+		"Number of events rejected by the rate limits of a Broker or of its namespace",
+		stats.UnitDimensionless,
+	)
+
 	// Create the tag keys that will be used to add tags to our measurements.
 	// Tag keys must conform to the restrictions described in
 	// go.opencensus.io/tag/validate.go. Currently those restrictions are:
@@ -56,6 +80,8 @@ var (
 	eventTypeKey         = tag.MustNewKey(metricskey.LabelEventType)
 	responseCodeKey      = tag.MustNewKey(metricskey.LabelResponseCode)
 	responseCodeClassKey = tag.MustNewKey(metricskey.LabelResponseCodeClass)
+	rejectionReasonKey   = tag.MustNewKey("rejection_reason")
+	throttleScopeKey     = tag.MustNewKey("throttle_scope")
 )
 
 type ReportArgs struct {
@@ -72,6 +98,9 @@ func init() {
 type StatsReporter interface {
 	ReportEventCount(args *ReportArgs, responseCode int) error
 	ReportEventDispatchTime(args *ReportArgs, responseCode int, d time.Duration) error
+	ReportEventRejected(args *ReportArgs, responseCode int, reason string) error
+	ReportEventDuplicate(args *ReportArgs, responseCode int) error
+	ReportEventThrottled(args *ReportArgs, responseCode int, scope string) error
 }
 
 var _ StatsReporter = (*reporter)(nil)
@@ -113,6 +142,24 @@ func register() {
 			Aggregation: view.Distribution(metrics.Buckets125(1, 10000)...), // 1, 2, 5, 10, 20, 50, 100, 500, 1000, 5000, 10000
 			TagKeys:     tagKeys,
 		},
+		&view.View{
+			Description: rejectedCountM.Description(),
+			Measure:     rejectedCountM,
+			Aggregation: view.Count(),
+			TagKeys:     append(tagKeys, rejectionReasonKey),
+		},
+		&view.View{
+			Description: duplicateCountM.Description(),
+			Measure:     duplicateCountM,
+			Aggregation: view.Count(),
+			TagKeys:     tagKeys,
+		},
+		&view.View{
+			Description: throttledCountM.Description(),
+			Measure:     throttledCountM,
+			Aggregation: view.Count(),
+			TagKeys:     append(tagKeys, throttleScopeKey),
+		},
 	)
 	if err != nil {
 		log.Printf("failed to register opencensus views, %s", err)
@@ -140,6 +187,44 @@ func (r *reporter) ReportEventDispatchTime(args *ReportArgs, responseCode int, d
 	return nil
 }
 
+// ReportEventRejected captures the requests rejected by the ingress policy.
+func (r *reporter) ReportEventRejected(args *ReportArgs, responseCode int, reason string) error {
+	ctx, err := r.generateTag(args, responseCode)
+	if err != nil {
+		return err
+	}
+	ctx, err = tag.New(ctx, tag.Insert(rejectionReasonKey, reason))
+	if err != nil {
+		return err
+	}
+	metrics.Record(ctx, rejectedCountM.M(1))
+	return nil
+}
+
+// ReportEventDuplicate captures the duplicate events acknowledged without being sent.
+func (r *reporter) ReportEventDuplicate(args *ReportArgs, responseCode int) error {
+	ctx, err := r.generateTag(args, responseCode)
+	if err != nil {
+		return err
+	}
+	metrics.Record(ctx, duplicateCountM.M(1))
+	return nil
+}
+
+// ReportEventThrottled captures the events rejected by the rate limits, of the given scope.
+func (r *reporter) ReportEventThrottled(args *ReportArgs, responseCode int, scope string) error {
+	ctx, err := r.generateTag(args, responseCode)
+	if err != nil {
+		return err
+	}
+	ctx, err = tag.New(ctx, tag.Insert(throttleScopeKey, scope))
+	if err != nil {
+		return err
+	}
+	metrics.Record(ctx, throttledCountM.M(1))
+	return nil
+}
+
 func (r *reporter) generateTag(args *ReportArgs, responseCode int) (context.Context, error) {
 	ctx := metricskey.WithResource(emptyContext, resource.Resource{
 		Type: metricskey.ResourceTypeKnativeBroker,
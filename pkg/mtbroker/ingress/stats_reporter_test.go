@@ -73,6 +73,47 @@ func TestStatsReporter(t *testing.T) {
 	})
 	metricstest.AssertMetric(t, metricstest.DistributionCountOnlyMetric("event_dispatch_latencies", 2, wantTags))
 	metricstest.CheckDistributionData(t, "event_dispatch_latencies", wantTags, 2, 1100.0, 9100.0)
+
+	// test ReportEventRejected
+	expectSuccess(t, func() error {
+		return r.ReportEventRejected(args, http.StatusForbidden, "identity_not_allowed")
+	})
+	metricstest.AssertMetric(t, metricstest.IntMetric("event_rejected_count", 1, map[string]string{
+		metricskey.LabelEventType:         "testeventtype",
+		metricskey.LabelResponseCode:      "403",
+		metricskey.LabelResponseCodeClass: "4xx",
+		broker.LabelUniqueName:            "testpod",
+		broker.LabelContainerName:         "testcontainer",
+		"rejection_reason":                "identity_not_allowed",
+	}).WithResource(&resource))
+
+	// test ReportEventDuplicate
+	expectSuccess(t, func() error {
+		return r.ReportEventDuplicate(args, http.StatusAccepted)
+	})
+	expectSuccess(t, func() error {
+		return r.ReportEventDuplicate(args, http.StatusAccepted)
+	})
+	metricstest.AssertMetric(t, metricstest.IntMetric("event_duplicate_count", 2, map[string]string{
+		metricskey.LabelEventType:         "testeventtype",
+		metricskey.LabelResponseCode:      "202",
+		metricskey.LabelResponseCodeClass: "2xx",
+		broker.LabelUniqueName:            "testpod",
+		broker.LabelContainerName:         "testcontainer",
+	}).WithResource(&resource))
+
+	// test ReportEventThrottled
+	expectSuccess(t, func() error {
+		return r.ReportEventThrottled(args, http.StatusTooManyRequests, "namespace")
+	})
+	metricstest.AssertMetric(t, metricstest.IntMetric("event_throttled_count", 1, map[string]string{
+		metricskey.LabelEventType:         "testeventtype",
+		metricskey.LabelResponseCode:      "429",
+		metricskey.LabelResponseCodeClass: "4xx",
+		broker.LabelUniqueName:            "testpod",
+		broker.LabelContainerName:         "testcontainer",
+		"throttle_scope":                  "namespace",
+	}).WithResource(&resource))
 }
 
 func expectSuccess(t *testing.T, f func() error) {
@@ -90,6 +131,9 @@ func resetMetrics() {
 	// OpenCensus metrics carry global state that need to be reset between unit tests.
 	metricstest.Unregister(
 		"event_count",
-		"event_dispatch_latencies")
+		"event_dispatch_latencies",
+		"event_rejected_count",
+		"event_duplicate_count",
+		"event_throttled_count")
 	register()
 }
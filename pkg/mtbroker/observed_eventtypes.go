@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/kmeta"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+)
+
+const (
+	// ObservedEventTypesLabelKey is the label key on the ConfigMaps holding the event types
+	// observed by the ingress of the Brokers registering their EventTypes. The ConfigMaps are
+	// also labeled with eventing.BrokerLabelKey, set to the name of their Broker.
+	ObservedEventTypesLabelKey = eventing.GroupName + "/observed-eventtypes"
+
+	// MaxObservedEventTypes bounds the number of event types observed per Broker.
+	MaxObservedEventTypes = 256
+)
+
+// ObservedEventType is a distinct type, source and schema of the events sent to a Broker.
+type ObservedEventType struct {
+	Type   string `json:"type"`
+	Source string `json:"source,omitempty"`
+	Schema string `json:"schema,omitempty"`
+	// LastSeen is about the last time an event of this type, source and schema was sent. It
+	// is refreshed periodically, not on every event.
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// Key returns the key of the event type in the ConfigMap of its Broker.
+func (o *ObservedEventType) Key() string {
+	sum := sha256.Sum256([]byte(o.Type + "\n" + o.Source + "\n" + o.Schema))
+	return hex.EncodeToString(sum[:16])
+}
+
+// ObservedEventTypesConfigMapName returns the name of the ConfigMap holding the event types
+// observed by the ingress of the Broker named brokerName, in the namespace of the Broker.
+func ObservedEventTypesConfigMapName(brokerName string) string {
+	return kmeta.ChildName(brokerName, "-observed-eventtypes")
+}
+
+// MakeObservedEventTypesConfigMap creates the empty ConfigMap holding the event types observed by
+// the ingress of b. The controller creates it, the ingress only updates it.
+func MakeObservedEventTypesConfigMap(b *eventingv1.Broker) *corev1.ConfigMap {
+	ownerRef := kmeta.NewControllerRef(b)
+	// Deleting the Broker doesn't wait for its ConfigMap.
+	ownerRef.BlockOwnerDeletion = nil
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: b.Namespace,
+			Name:      ObservedEventTypesConfigMapName(b.Name),
+			Labels: map[string]string{
+				eventing.BrokerLabelKey:    b.Name,
+				ObservedEventTypesLabelKey: "true",
+			},
+			OwnerReferences: []metav1.OwnerReference{*ownerRef},
+		},
+	}
+}
+
+// ObservedEventTypes returns the event types held by cm. The invalid entries are skipped.
+func ObservedEventTypes(cm *corev1.ConfigMap) []ObservedEventType {
+	observed := make([]ObservedEventType, 0, len(cm.Data))
+	for _, v := range cm.Data {
+		var o ObservedEventType
+		if err := json.Unmarshal([]byte(v), &o); err != nil || o.Type == "" {
+			continue
+		}
+		observed = append(observed, o)
+	}
+	return observed
+}
+
+// SetObservedEventType adds or updates o in cm, unless cm already holds MaxObservedEventTypes
+// other event types. It returns whether o was set.
+func SetObservedEventType(cm *corev1.ConfigMap, o *ObservedEventType) bool {
+	key := o.Key()
+	if _, ok := cm.Data[key]; !ok && len(cm.Data) >= MaxObservedEventTypes {
+		return false
+	}
+	v, err := json.Marshal(o)
+	if err != nil {
+		return false
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string, 1)
+	}
+	cm.Data[key] = string(v)
+	return true
+}
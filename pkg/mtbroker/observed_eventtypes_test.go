@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package broker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestObservedEventTypes(t *testing.T) {
+	cm := &corev1.ConfigMap{}
+	created := ObservedEventType{Type: "order.created", Source: "/orders", LastSeen: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)}
+	if !SetObservedEventType(cm, &created) {
+		t.Fatal("Expected the event type to be set")
+	}
+	// Refreshing an event type doesn't add another one.
+	created.LastSeen = created.LastSeen.Add(time.Hour)
+	if !SetObservedEventType(cm, &created) {
+		t.Fatal("Expected the event type to be set")
+	}
+	cm.Data["invalid"] = "{"
+
+	if diff := cmp.Diff([]ObservedEventType{created}, ObservedEventTypes(cm)); diff != "" {
+		t.Error("Unexpected event types (-want, +got):", diff)
+	}
+}
+
+func TestSetObservedEventTypeBounded(t *testing.T) {
+	cm := &corev1.ConfigMap{}
+	for i := 0; i < MaxObservedEventTypes; i++ {
+		if !SetObservedEventType(cm, &ObservedEventType{Type: fmt.Sprint("type-", i)}) {
+			t.Fatal("Expected the event type to be set")
+		}
+	}
+	if SetObservedEventType(cm, &ObservedEventType{Type: "one-too-many"}) {
+		t.Error("Expected the event type not to be set")
+	}
+	if !SetObservedEventType(cm, &ObservedEventType{Type: "type-0", LastSeen: time.Now()}) {
+		t.Error("Expected an existing event type to be refreshed")
+	}
+}
+
+func TestObservedEventTypesConfigMapName(t *testing.T) {
+	// Any changes to this name are breaking changes, as the ingress and the controller of
+	// different versions must agree on it.
+	if got, want := ObservedEventTypesConfigMapName("default"), "default-observed-eventtypes"; got != want {
+		t.Errorf("Unexpected name, want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,410 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package persistentchannel provides the fanout.MessageHandler of the PersistentChannels, which
+// persists the events to disk before acknowledging them, and delivers them to each subscriber
+// from its own offset, so that the deliveries resume after a restart.
+package persistentchannel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"go.uber.org/zap"
+
+	"knative.dev/eventing/pkg/channel"
+	"knative.dev/eventing/pkg/channel/fanout"
+)
+
+const (
+	logDir     = "log"
+	offsetsDir = "offsets"
+
+	// readRetryDelay is how long a subscriber waits before reading the log again after an error.
+	readRetryDelay = time.Second
+	// startRetryDelay is how long the subscribers that failed to start wait before starting again.
+	startRetryDelay = 10 * time.Second
+)
+
+// record is how an event is stored in the log.
+type record struct {
+	Headers nethttp.Header     `json:"headers,omitempty"`
+	Event   *cloudevents.Event `json:"event"`
+}
+
+// ChannelHandler is the fanout.MessageHandler of a PersistentChannel. The events are appended to
+// a Log, synced to disk, before being acknowledged. Each subscriber has its own offset in the Log,
+// and receives the events in order, through a synchronous fanout.FanoutMessageHandler. The
+// offset of a subscriber moves past an event once the delivery succeeded, or failed according to
+// the delivery spec of the subscriber, so that an event is delivered at least once. The events
+// are rejected with channel.ErrQueueFull while the Log is full.
+type ChannelHandler struct {
+	logger     *zap.Logger
+	ref        channel.ChannelReference
+	dir        string
+	log        *Log
+	offsets    *offsetStore
+	receiver   *channel.MessageReceiver
+	dispatcher channel.MessageDispatcher
+	reporter   channel.StatsReporter
+
+	mu            sync.Mutex
+	subscriptions []fanout.Subscription
+	subscribers   map[string]*subscriber
+	// failed holds the subscribers which failed to start, started again every startRetryDelay.
+	// Their offset holds back the deletion of the events until they start.
+	failed     map[string]*failedSubscriber
+	retryTimer *time.Timer
+	closed     bool
+}
+
+// subscriber delivers the events of the log to a subscription.
+type subscriber struct {
+	key     string
+	handler *fanout.FanoutMessageHandler
+	// offset is the offset of the next event delivered.
+	offset int64
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// failedSubscriber is a subscription which failed to start.
+type failedSubscriber struct {
+	sub fanout.Subscription
+	// offset is the offset of the first event kept for the subscriber.
+	offset int64
+}
+
+var _ fanout.MessageHandler = (*ChannelHandler)(nil)
+
+// NewChannelHandler creates the handler of the channel ref, storing its events in dir. The events
+// are rejected once they use maxLogSize bytes, unless it is 0.
+func NewChannelHandler(logger *zap.Logger, dir string, maxLogSize int64, ref channel.ChannelReference, dispatcher channel.MessageDispatcher, reporter channel.StatsReporter) (*ChannelHandler, error) {
+	l, err := OpenLog(filepath.Join(dir, logDir), maxLogSize)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := newOffsetStore(filepath.Join(dir, offsetsDir))
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	h := &ChannelHandler{
+		logger:      logger.With(zap.String("channel", ref.String())),
+		ref:         ref,
+		dir:         dir,
+		log:         l,
+		offsets:     offsets,
+		dispatcher:  dispatcher,
+		reporter:    reporter,
+		subscribers: make(map[string]*subscriber),
+		failed:      make(map[string]*failedSubscriber),
+	}
+	receiver, err := channel.NewMessageReceiver(h.receive, logger, reporter)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	h.receiver = receiver
+	return h, nil
+}
+
+func (h *ChannelHandler) ServeHTTP(response nethttp.ResponseWriter, request *nethttp.Request) {
+	h.receiver.ServeHTTP(response, request)
+}
+
+// receive appends the message to the log. It returns once the message is synced to disk, so that
+// the message is acknowledged only once persisted.
+func (h *ChannelHandler) receive(ctx context.Context, _ channel.ChannelReference, message binding.Message, transformers []binding.Transformer, additionalHeaders nethttp.Header) error {
+	defer message.Finish(nil)
+	if len(h.GetSubscriptions(ctx)) == 0 {
+		// Nothing to do here, like the other channels, the events aren't kept for the
+		// future subscribers.
+		return nil
+	}
+
+	event, err := binding.ToEvent(ctx, message, transformers...)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(record{Headers: additionalHeaders, Event: event})
+	if err != nil {
+		return err
+	}
+	if _, err := h.log.Append(b); err != nil {
+		if errors.Is(err, ErrLogFull) {
+			// Until the subscribers catch up.
+			return fmt.Errorf("%w: %v", channel.ErrQueueFull, err)
+		}
+		h.logger.Error("Failed to persist the event", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// SetSubscriptions starts delivering the events to the new subscriptions, and stops for the
+// subscriptions removed. A new subscription receives the events received from now on.
+func (h *ChannelHandler) SetSubscriptions(ctx context.Context, subs []fanout.Subscription) {
+	h.mu.Lock()
+	keep := make(map[string]bool, len(subs))
+	for _, sub := range subs {
+		key := subscriberKey(sub)
+		keep[key] = true
+		if s, ok := h.subscribers[key]; ok {
+			s.handler.SetSubscriptions(ctx, []fanout.Subscription{sub})
+			continue
+		}
+		if err := h.startSubscriber(key, sub); err != nil {
+			h.logger.Error("Failed to start delivering to the subscriber", zap.String("subscriber", key), zap.Error(err))
+		}
+	}
+	var removed []*subscriber
+	for key, s := range h.subscribers {
+		if !keep[key] {
+			removed = append(removed, s)
+			delete(h.subscribers, key)
+		}
+	}
+	var removedFailed []string
+	for key := range h.failed {
+		if !keep[key] {
+			removedFailed = append(removedFailed, key)
+			delete(h.failed, key)
+		}
+	}
+
+	h.subscriptions = make([]fanout.Subscription, len(subs))
+	copy(h.subscriptions, subs)
+	h.deleteDelivered()
+	h.mu.Unlock()
+
+	// Wait for the removed subscribers without the lock, which they take to commit their offset.
+	for _, s := range stop(removed) {
+		removedFailed = append(removedFailed, s.key)
+	}
+	for _, key := range removedFailed {
+		if err := h.offsets.delete(key); err != nil {
+			h.logger.Warn("Failed to delete the offset of the subscriber", zap.String("subscriber", key), zap.Error(err))
+		}
+	}
+}
+
+// stop stops the deliveries to the subscribers, and waits for them to return.
+func stop(subscribers []*subscriber) []*subscriber {
+	for _, s := range subscribers {
+		s.cancel()
+	}
+	for _, s := range subscribers {
+		<-s.done
+	}
+	return subscribers
+}
+
+// startSubscriber starts delivering to a subscription. It must be called with the lock held. If
+// it fails, the subscription is started again later.
+func (h *ChannelHandler) startSubscriber(key string, sub fanout.Subscription) error {
+	offset, ok, err := h.offsets.load(key)
+	if err != nil {
+		// The offset is unknown, keep all the events.
+		h.retryLater(key, sub, h.log.First())
+		return err
+	}
+	if !ok {
+		offset = h.log.Next()
+		if err := h.offsets.store(key, offset); err != nil {
+			h.retryLater(key, sub, offset)
+			return err
+		}
+	}
+	handler, err := fanout.NewFanoutMessageHandler(h.logger, h.dispatcher, fanout.Config{Subscriptions: []fanout.Subscription{sub}}, h.reporter)
+	if err != nil {
+		h.retryLater(key, sub, offset)
+		return err
+	}
+	delete(h.failed, key)
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &subscriber{
+		key:     key,
+		handler: handler,
+		offset:  offset,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	h.subscribers[key] = s
+	go h.deliver(ctx, s)
+	return nil
+}
+
+// retryLater keeps the events from offset for a subscription which failed to start, and starts it
+// again after startRetryDelay. It must be called with the lock held.
+func (h *ChannelHandler) retryLater(key string, sub fanout.Subscription, offset int64) {
+	if f, ok := h.failed[key]; ok && f.offset < offset {
+		offset = f.offset
+	}
+	h.failed[key] = &failedSubscriber{sub: sub, offset: offset}
+	if h.retryTimer == nil && !h.closed {
+		h.retryTimer = time.AfterFunc(startRetryDelay, h.retryFailed)
+	}
+}
+
+// retryFailed starts again the subscriptions which failed to start.
+func (h *ChannelHandler) retryFailed() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.retryTimer = nil
+	if h.closed {
+		return
+	}
+	for key, f := range h.failed {
+		if err := h.startSubscriber(key, f.sub); err != nil {
+			h.logger.Error("Failed to start delivering to the subscriber", zap.String("subscriber", key), zap.Error(err))
+		}
+	}
+}
+
+// deliver delivers the events of the log to the subscriber, in order, until ctx is done.
+func (h *ChannelHandler) deliver(ctx context.Context, s *subscriber) {
+	defer close(s.done)
+	logger := h.logger.With(zap.String("subscriber", s.key))
+	reader := h.log.NewReader(s.offset)
+	defer reader.Close()
+
+	for {
+		b, err := reader.Read(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Error("Failed to read the log", zap.Error(err))
+			select {
+			case <-time.After(readRetryDelay):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		var rec record
+		if err := json.Unmarshal(b, &rec); err != nil || rec.Event == nil {
+			logger.Error("Skipping an invalid record", zap.Int64("offset", reader.Offset()-1), zap.Error(err))
+		} else if err := s.handler.DispatchMessage(ctx, h.ref, binding.ToMessage(rec.Event), rec.Headers); err != nil {
+			if ctx.Err() != nil {
+				// Stopped while delivering, the event is delivered again on restart.
+				return
+			}
+			// The event was retried and dead-lettered according to the delivery spec.
+			logger.Warn("Failed to deliver the event", zap.String("id", rec.Event.ID()), zap.Error(err))
+		}
+
+		h.commit(s, reader.Offset())
+	}
+}
+
+// commit records that the events before offset were delivered to the subscriber.
+func (h *ChannelHandler) commit(s *subscriber, offset int64) {
+	if err := h.offsets.store(s.key, offset); err != nil {
+		h.logger.Error("Failed to store the offset of the subscriber", zap.String("subscriber", s.key), zap.Error(err))
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s.offset = offset
+	h.deleteDelivered()
+}
+
+// deleteDelivered deletes the events delivered to all the subscribers, including those which
+// failed to start. It must be called with the lock held.
+func (h *ChannelHandler) deleteDelivered() {
+	min := h.log.Next()
+	for _, s := range h.subscribers {
+		if s.offset < min {
+			min = s.offset
+		}
+	}
+	for _, f := range h.failed {
+		if f.offset < min {
+			min = f.offset
+		}
+	}
+	if err := h.log.DeleteBefore(min); err != nil {
+		h.logger.Warn("Failed to delete the delivered events", zap.Error(err))
+	}
+}
+
+func (h *ChannelHandler) GetSubscriptions(ctx context.Context) []fanout.Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ret := make([]fanout.Subscription, len(h.subscriptions))
+	copy(ret, h.subscriptions)
+	return ret
+}
+
+// SetQueueLimits does nothing, the events are queued on disk and delivered one at a time to
+// each subscriber.
+func (h *ChannelHandler) SetQueueLimits(workers, queueSize int) {}
+
+// Close stops the deliveries and closes the log. The events not delivered yet are delivered by
+// the next handler of the channel.
+func (h *ChannelHandler) Close() error {
+	h.mu.Lock()
+	subscribers := make([]*subscriber, 0, len(h.subscribers))
+	for _, s := range h.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	h.subscribers = make(map[string]*subscriber)
+	h.closed = true
+	if h.retryTimer != nil {
+		h.retryTimer.Stop()
+		h.retryTimer = nil
+	}
+	h.mu.Unlock()
+
+	stop(subscribers)
+	return h.log.Close()
+}
+
+// Delete stops the deliveries and deletes the events of the channel.
+func (h *ChannelHandler) Delete() error {
+	if err := h.Close(); err != nil {
+		return err
+	}
+	return os.RemoveAll(h.dir)
+}
+
+// subscriberKey returns the key of the offset of a subscription.
+func subscriberKey(sub fanout.Subscription) string {
+	if sub.UID != "" {
+		return string(sub.UID)
+	}
+	var key string
+	if sub.Subscriber != nil {
+		key = sub.Subscriber.String()
+	}
+	if sub.Reply != nil {
+		key += " " + sub.Reply.String()
+	}
+	return url.PathEscape(key)
+}
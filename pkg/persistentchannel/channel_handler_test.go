@@ -0,0 +1,264 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistentchannel
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	bindingshttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing/pkg/channel"
+	"knative.dev/eventing/pkg/channel/fanout"
+)
+
+func newTestChannelHandler(t *testing.T, dir string) *ChannelHandler {
+	t.Helper()
+	return newTestChannelHandlerWithMaxLogSize(t, dir, 0)
+}
+
+func newTestChannelHandlerWithMaxLogSize(t *testing.T, dir string, maxLogSize int64) *ChannelHandler {
+	t.Helper()
+	logger := zaptest.NewLogger(t, zaptest.WrapOptions(zap.AddCaller()))
+	h, err := NewChannelHandler(logger, dir, maxLogSize, channel.ChannelReference{Namespace: "ns", Name: "channel"}, channel.NewMessageDispatcher(logger), channel.NewStatsReporter("testcontainer", "testpod"))
+	if err != nil {
+		t.Fatal("NewChannelHandler() =", err)
+	}
+	return h
+}
+
+func sendEvent(t *testing.T, h http.Handler, id string) {
+	t.Helper()
+	if code := trySendEvent(t, h, id); code != http.StatusAccepted {
+		t.Fatalf("Unexpected status code. Expected %d, Actual %d", http.StatusAccepted, code)
+	}
+}
+
+func trySendEvent(t *testing.T, h http.Handler, id string) int {
+	t.Helper()
+	event := cloudevents.NewEvent(cloudevents.VersionV1)
+	event.SetID(id)
+	event.SetType("com.example.someevent")
+	event.SetSource("/mycontext")
+	req := httptest.NewRequest(http.MethodPost, "http://channel-kn-channel.ns.svc.cluster.local/", nil)
+	if err := bindingshttp.WriteRequest(context.Background(), binding.ToMessage(&event), req); err != nil {
+		t.Fatal("WriteRequest() =", err)
+	}
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	return resp.Code
+}
+
+func TestChannelHandlerResumesAfterRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "channel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	received := make(chan string, 10)
+	// The subscriber doesn't answer until the first handler is closed.
+	blocked := make(chan struct{})
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-blocked:
+			received <- r.Header.Get("ce-id")
+			w.WriteHeader(http.StatusAccepted)
+		case <-r.Context().Done():
+		}
+	}))
+	defer subscriberServer.Close()
+	subs := []fanout.Subscription{{
+		UID:        "subscriber-uid",
+		Subscriber: apis.HTTP(subscriberServer.URL[7:]).URL(),
+	}}
+
+	h := newTestChannelHandler(t, dir)
+	h.SetSubscriptions(context.Background(), subs)
+	sendEvent(t, h, "1")
+	sendEvent(t, h, "2")
+	if err := h.Close(); err != nil {
+		t.Fatal("Close() =", err)
+	}
+	close(blocked)
+
+	h = newTestChannelHandler(t, dir)
+	defer h.Delete()
+	h.SetSubscriptions(context.Background(), subs)
+	sendEvent(t, h, "3")
+
+	for _, want := range []string{"1", "2", "3"} {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Errorf("Unexpected event delivered. Expected %q, Actual %q", want, got)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timed out waiting for event %q", want)
+		}
+	}
+}
+
+func TestChannelHandlerNewSubscriber(t *testing.T) {
+	dir, err := ioutil.TempDir("", "channel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	received := make(chan string, 10)
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("ce-id")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer subscriberServer.Close()
+
+	h := newTestChannelHandler(t, dir)
+	defer h.Delete()
+
+	// Without subscribers, the events aren't kept.
+	sendEvent(t, h, "1")
+	h.SetSubscriptions(context.Background(), []fanout.Subscription{{
+		UID:        "subscriber-uid",
+		Subscriber: apis.HTTP(subscriberServer.URL[7:]).URL(),
+	}})
+	sendEvent(t, h, "2")
+
+	select {
+	case got := <-received:
+		if got != "2" {
+			t.Errorf("Unexpected event delivered. Expected %q, Actual %q", "2", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the event")
+	}
+
+	h.SetSubscriptions(context.Background(), nil)
+	if _, ok, _ := h.offsets.load("subscriber-uid"); ok {
+		t.Error("Expected the offset of the removed subscriber to be deleted")
+	}
+}
+
+func TestChannelHandlerFailedSubscriberKeepsEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "channel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	received := make(chan string, 10)
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("ce-id")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer subscriberServer.Close()
+
+	h := newTestChannelHandler(t, dir)
+	defer h.Delete()
+	h.log.maxSegmentSize = 64
+
+	// The offset of the subscriber can't be loaded.
+	offsetPath := filepath.Join(dir, offsetsDir, "subscriber-uid")
+	if err := os.Mkdir(offsetPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	subs := []fanout.Subscription{{
+		UID:        "subscriber-uid",
+		Subscriber: apis.HTTP(subscriberServer.URL[7:]).URL(),
+	}}
+	h.SetSubscriptions(context.Background(), subs)
+	for i := 0; i < 10; i++ {
+		sendEvent(t, h, fmt.Sprint(i))
+	}
+
+	h.mu.Lock()
+	h.deleteDelivered()
+	h.mu.Unlock()
+	if got := h.log.First(); got != 0 {
+		t.Fatalf("Expected the events of the failed subscriber to be kept, got first offset %d", got)
+	}
+
+	// Once the offset is fixed, the subscriber starts and receives the events kept.
+	if err := os.Remove(offsetPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.offsets.store("subscriber-uid", 0); err != nil {
+		t.Fatal(err)
+	}
+	h.retryFailed()
+	for i := 0; i < 10; i++ {
+		select {
+		case got := <-received:
+			if want := fmt.Sprint(i); got != want {
+				t.Errorf("Unexpected event delivered. Expected %q, Actual %q", want, got)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timed out waiting for event %d", i)
+		}
+	}
+	h.mu.Lock()
+	failed := len(h.failed)
+	h.mu.Unlock()
+	if failed != 0 {
+		t.Errorf("Expected no failed subscriber, got %d", failed)
+	}
+}
+
+func TestChannelHandlerLogFull(t *testing.T) {
+	dir, err := ioutil.TempDir("", "channel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The subscriber never answers, so that the events are kept.
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer subscriberServer.Close()
+
+	h := newTestChannelHandlerWithMaxLogSize(t, dir, 1024)
+	defer h.Delete()
+	h.SetSubscriptions(context.Background(), []fanout.Subscription{{
+		UID:        "subscriber-uid",
+		Subscriber: apis.HTTP(subscriberServer.URL[7:]).URL(),
+	}})
+
+	for i := 0; ; i++ {
+		if i == 100 {
+			t.Fatal("Expected the events to be rejected once the log is full")
+		}
+		if code := trySendEvent(t, h, fmt.Sprint(i)); code != http.StatusAccepted {
+			if code != http.StatusTooManyRequests {
+				t.Fatalf("Unexpected status code. Expected %d, Actual %d", http.StatusTooManyRequests, code)
+			}
+			break
+		}
+	}
+}
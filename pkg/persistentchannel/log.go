@@ -0,0 +1,440 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistentchannel
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	segmentSuffix = ".log"
+	// recordHeaderSize is the size of the length and the CRC-32 checksum preceding each record.
+	recordHeaderSize = 8
+	// maxRecordSize bounds the records read back, to detect corrupted lengths.
+	maxRecordSize = 64 << 20
+
+	defaultMaxSegmentSize = 64 << 20
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrLogFull is returned when appending records would grow the log beyond its maximum size.
+var ErrLogFull = errors.New("log is full")
+
+// Log is an append-only log of records stored in a directory. Each record is identified by its
+// offset, starting at 0. The records are stored in segment files, named after the offset of
+// their first record, so that the records already consumed can be deleted a segment at a time.
+type Log struct {
+	dir            string
+	maxSegmentSize int64
+	// maxSize bounds the size of all the segments, 0 meaning unbounded.
+	maxSize int64
+
+	mu sync.Mutex
+	// bases holds the offset of the first record of each segment, in order.
+	bases []int64
+	// active is the last segment, records are appended to it.
+	active     *os.File
+	activeSize int64
+	// size is the size of all the segments.
+	size int64
+	// next is the offset of the next record appended.
+	next int64
+	// appended is closed, and replaced, each time records are appended.
+	appended chan struct{}
+	closed   bool
+}
+
+// OpenLog opens the log stored in dir, creating it if needed. A record partially written when
+// the process stopped is discarded. The records appended beyond maxSize bytes are rejected with
+// ErrLogFull, unless maxSize is 0.
+func OpenLog(dir string, maxSize int64) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	l := &Log{
+		dir:            dir,
+		maxSegmentSize: defaultMaxSegmentSize,
+		maxSize:        maxSize,
+		appended:       make(chan struct{}),
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		base, err := strconv.ParseInt(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		l.bases = append(l.bases, base)
+		l.size += info.Size()
+	}
+	sort.Slice(l.bases, func(i, j int) bool { return l.bases[i] < l.bases[j] })
+
+	if len(l.bases) == 0 {
+		if err := l.roll(0); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+
+	base := l.bases[len(l.bases)-1]
+	f, err := os.OpenFile(l.segmentPath(base), os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	count, size, err := scanSegment(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	// Discard whatever follows the last complete record.
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info, err := f.Stat(); err == nil {
+		l.size -= info.Size()
+	}
+	l.size += size
+	l.active = f
+	l.activeSize = size
+	l.next = base + count
+	return l, nil
+}
+
+// scanSegment returns the number of complete records of a segment, and their size.
+func scanSegment(f *os.File) (int64, int64, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+	r := bufio.NewReader(f)
+	var count, size int64
+	for {
+		record, err := readRecord(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errCorruptedRecord) {
+				return count, size, nil
+			}
+			return 0, 0, err
+		}
+		count++
+		size += recordHeaderSize + int64(len(record))
+	}
+}
+
+func (l *Log) segmentPath(base int64) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%020d%s", base, segmentSuffix))
+}
+
+// roll starts a new segment whose first record is base. It must be called with the lock held.
+func (l *Log) roll(base int64) error {
+	f, err := os.OpenFile(l.segmentPath(base), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if l.active != nil {
+		l.active.Close()
+	}
+	l.active = f
+	l.activeSize = 0
+	l.bases = append(l.bases, base)
+	return syncDir(l.dir)
+}
+
+// Append writes the records to the log, and syncs them to disk before returning. It returns the
+// offset of the first record.
+func (l *Log) Append(records ...[]byte) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, errors.New("log is closed")
+	}
+
+	if l.activeSize >= l.maxSegmentSize {
+		if err := l.roll(l.next); err != nil {
+			return 0, err
+		}
+	}
+
+	var size int64
+	for _, record := range records {
+		if len(record) > maxRecordSize {
+			return 0, fmt.Errorf("record of %d bytes exceeds the maximum size of %d bytes", len(record), maxRecordSize)
+		}
+		size += recordHeaderSize + int64(len(record))
+	}
+	if l.maxSize > 0 && l.size+size > l.maxSize {
+		return 0, ErrLogFull
+	}
+
+	w := bufio.NewWriter(l.active)
+	for _, record := range records {
+		var header [recordHeaderSize]byte
+		binary.BigEndian.PutUint32(header[:4], uint32(len(record)))
+		binary.BigEndian.PutUint32(header[4:], crc32.Checksum(record, crcTable))
+		if _, err := w.Write(header[:]); err != nil {
+			return 0, err
+		}
+		if _, err := w.Write(record); err != nil {
+			return 0, err
+		}
+	}
+	err := w.Flush()
+	if err == nil {
+		err = l.active.Sync()
+	}
+	if err != nil {
+		// Don't leave a partial write behind the next records.
+		_ = l.active.Truncate(l.activeSize)
+		_, _ = l.active.Seek(l.activeSize, io.SeekStart)
+		return 0, err
+	}
+
+	first := l.next
+	l.activeSize += size
+	l.size += size
+	l.next += int64(len(records))
+	close(l.appended)
+	l.appended = make(chan struct{})
+	return first, nil
+}
+
+// Next returns the offset of the next record appended to the log.
+func (l *Log) Next() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.next
+}
+
+// First returns the offset of the first record kept in the log.
+func (l *Log) First() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bases[0]
+}
+
+// DeleteBefore deletes the segments holding only records before offset. The active segment is
+// never deleted.
+func (l *Log) DeleteBefore(offset int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for len(l.bases) > 1 && l.bases[1] <= offset {
+		path := l.segmentPath(l.bases[0])
+		var size int64
+		if info, err := os.Stat(path); err == nil {
+			size = info.Size()
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		l.size -= size
+		l.bases = l.bases[1:]
+	}
+	return nil
+}
+
+// Close closes the log, the readers waiting for records return an error.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	close(l.appended)
+	return l.active.Close()
+}
+
+// state returns the offset of the next record, the channel closed when records are appended and
+// the segment holding offset.
+func (l *Log) state(offset int64) (next int64, appended <-chan struct{}, base int64, closed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	base = l.bases[0]
+	for _, b := range l.bases {
+		if b > offset {
+			break
+		}
+		base = b
+	}
+	return l.next, l.appended, base, l.closed
+}
+
+// Reader reads the records of a log in order.
+type Reader struct {
+	log    *Log
+	offset int64
+
+	// The segment currently read, and the offset of the record at its read position.
+	file   *os.File
+	r      *bufio.Reader
+	base   int64
+	cursor int64
+}
+
+// NewReader returns a Reader starting at offset. If the records at offset were deleted, it
+// starts at the first record available.
+func (l *Log) NewReader(offset int64) *Reader {
+	return &Reader{log: l, offset: offset}
+}
+
+// Offset returns the offset of the next record read.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Read returns the next record, waiting for it to be appended if needed, until ctx is done.
+func (r *Reader) Read(ctx context.Context) ([]byte, error) {
+	for {
+		next, appended, base, closed := r.log.state(r.offset)
+		if closed {
+			return nil, errors.New("log is closed")
+		}
+		if r.offset < base {
+			// The records were deleted.
+			r.offset = base
+		}
+		if r.offset >= next {
+			select {
+			case <-appended:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		if err := r.seek(base); err != nil {
+			return nil, err
+		}
+		record, err := readRecord(r.r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// The record is in the next segment.
+				r.closeFile()
+				r.base = r.offset
+				continue
+			}
+			return nil, err
+		}
+		r.offset++
+		r.cursor++
+		return record, nil
+	}
+}
+
+// seek positions the reader on the record at its offset, in the segment starting at base.
+func (r *Reader) seek(base int64) error {
+	if r.file != nil && r.base == base && r.cursor == r.offset {
+		return nil
+	}
+	r.closeFile()
+	f, err := os.Open(r.log.segmentPath(base))
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.r = bufio.NewReader(f)
+	r.base = base
+	r.cursor = base
+	for r.cursor < r.offset {
+		if err := skipRecord(r.r); err != nil {
+			return err
+		}
+		r.cursor++
+	}
+	return nil
+}
+
+func (r *Reader) closeFile() {
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+		r.r = nil
+	}
+}
+
+// Close releases the resources of the reader.
+func (r *Reader) Close() {
+	r.closeFile()
+}
+
+var errCorruptedRecord = errors.New("corrupted record")
+
+func readRecord(r *bufio.Reader) ([]byte, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size > maxRecordSize {
+		return nil, errCorruptedRecord
+	}
+	record := make([]byte, size)
+	if _, err := io.ReadFull(r, record); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if crc32.Checksum(record, crcTable) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, errCorruptedRecord
+	}
+	return record, nil
+}
+
+func skipRecord(r *bufio.Reader) error {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	_, err := r.Discard(int(binary.BigEndian.Uint32(header[:4])))
+	return err
+}
+
+// syncDir syncs a directory, so that the files created in it survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
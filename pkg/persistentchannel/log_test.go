@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistentchannel
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestLog(t *testing.T, dir string) *Log {
+	t.Helper()
+	l, err := OpenLog(dir, 0)
+	if err != nil {
+		t.Fatal("OpenLog() =", err)
+	}
+	return l
+}
+
+func appendRecords(t *testing.T, l *Log, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		if _, err := l.Append([]byte(fmt.Sprint("record-", i))); err != nil {
+			t.Fatal("Append() =", err)
+		}
+	}
+}
+
+func readRecords(t *testing.T, r *Reader, from, to int) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for i := from; i < to; i++ {
+		b, err := r.Read(ctx)
+		if err != nil {
+			t.Fatal("Read() =", err)
+		}
+		if got, want := string(b), fmt.Sprint("record-", i); got != want {
+			t.Fatalf("Read() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestLogAppendRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := openTestLog(t, dir)
+	l.maxSegmentSize = 64
+	appendRecords(t, l, 0, 10)
+	if got := l.Next(); got != 10 {
+		t.Errorf("Next() = %d, want 10", got)
+	}
+	if len(l.bases) < 2 {
+		t.Errorf("Expected the log to roll segments, got %v", l.bases)
+	}
+
+	r := l.NewReader(3)
+	readRecords(t, r, 3, 10)
+
+	// The reader waits for the next record.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = l.Append([]byte("record-10"))
+	}()
+	readRecords(t, r, 10, 11)
+	r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.NewReader(11).Read(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Read() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := l.Close(); err != nil {
+		t.Error("Close() =", err)
+	}
+}
+
+func TestLogReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := openTestLog(t, dir)
+	l.maxSegmentSize = 64
+	appendRecords(t, l, 0, 5)
+	active := l.segmentPath(l.bases[len(l.bases)-1])
+	l.Close()
+
+	// Simulate a record partially written when the process stopped.
+	f, err := os.OpenFile(active, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte{0, 0, 0, 42, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	l = openTestLog(t, dir)
+	defer l.Close()
+	if got := l.Next(); got != 5 {
+		t.Errorf("Next() = %d, want 5", got)
+	}
+	appendRecords(t, l, 5, 7)
+	r := l.NewReader(0)
+	defer r.Close()
+	readRecords(t, r, 0, 7)
+}
+
+func TestLogDeleteBefore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := openTestLog(t, dir)
+	defer l.Close()
+	l.maxSegmentSize = 32
+	appendRecords(t, l, 0, 10)
+
+	if err := l.DeleteBefore(6); err != nil {
+		t.Fatal("DeleteBefore() =", err)
+	}
+	if l.bases[0] > 6 {
+		t.Errorf("Expected the segment holding record 6 to be kept, got %v", l.bases)
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	if len(segments) != len(l.bases) {
+		t.Errorf("Expected %d segments on disk, got %v", len(l.bases), segments)
+	}
+
+	// Reading deleted records starts at the first record available.
+	r := l.NewReader(0)
+	defer r.Close()
+	readRecords(t, r, int(l.bases[0]), 10)
+
+	// The active segment is never deleted.
+	if err := l.DeleteBefore(100); err != nil {
+		t.Fatal("DeleteBefore() =", err)
+	}
+	if len(l.bases) != 1 {
+		t.Errorf("Expected only the active segment to be kept, got %v", l.bases)
+	}
+}
+
+func TestLogMaxSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := openTestLog(t, dir)
+	l.maxSegmentSize = 32
+	// Each record uses 16 bytes.
+	l.maxSize = 80
+	appendRecords(t, l, 0, 5)
+	if _, err := l.Append([]byte("record-5")); err != ErrLogFull {
+		t.Fatalf("Append() = %v, want %v", err, ErrLogFull)
+	}
+
+	// The size is recovered when the log is opened again.
+	l.Close()
+	l, err = OpenLog(dir, 80)
+	if err != nil {
+		t.Fatal("OpenLog() =", err)
+	}
+	defer l.Close()
+	l.maxSegmentSize = 32
+	if _, err := l.Append([]byte("record-5")); err != ErrLogFull {
+		t.Fatalf("Append() = %v, want %v", err, ErrLogFull)
+	}
+
+	// Deleting the records makes room for new ones.
+	if err := l.DeleteBefore(4); err != nil {
+		t.Fatal("DeleteBefore() =", err)
+	}
+	appendRecords(t, l, 5, 7)
+}
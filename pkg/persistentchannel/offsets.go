@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package persistentchannel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// offsetStore stores the offset of the next record to deliver to each subscriber, one file per
+// subscriber. The offsets aren't synced to disk: after a crash, a subscriber may receive again
+// the last records delivered to it, which is acceptable for at-least-once delivery.
+type offsetStore struct {
+	dir string
+}
+
+func newOffsetStore(dir string) (*offsetStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &offsetStore{dir: dir}, nil
+}
+
+// load returns the offset of the subscriber, and whether it was found.
+func (s *offsetStore) load(key string) (int64, bool, error) {
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return offset, true, nil
+}
+
+// store replaces the offset of the subscriber. The file is replaced atomically, so that it's
+// never found partially written.
+func (s *offsetStore) store(key string, offset int64) error {
+	path := filepath.Join(s.dir, key)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strconv.FormatInt(offset, 10)), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *offsetStore) delete(key string) error {
+	if err := os.Remove(filepath.Join(s.dir, key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package access grants the ServiceAccounts of the data plane access to the resources
+// named by an object, through a Role and a RoleBinding in the namespace of the object, so
+// that the data plane does not need access to the resources of all the namespaces.
+package access
+
+import (
+	"context"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
+	"knative.dev/pkg/kmeta"
+
+	"knative.dev/eventing/pkg/reconciler/resources"
+)
+
+// Grant is the access of a ServiceAccount to the named resources of the namespace of an object.
+type Grant struct {
+	// Name is the name of the Role and of the RoleBinding.
+	Name string
+	// Resource is the core resource the access is granted to, e.g. "secrets".
+	Resource string
+	// Verbs are the verbs allowed on the resources.
+	Verbs []string
+	// ResourceNames are the names of the resources. When empty, the access is revoked: a
+	// Role without resourceNames would grant access to all the resources of the namespace.
+	ResourceNames []string
+	// ServiceAccount is the ServiceAccount granted the access.
+	ServiceAccount types.NamespacedName
+}
+
+// Reconciler reconciles the Roles and the RoleBindings of the Grants.
+type Reconciler struct {
+	KubeClientSet     kubernetes.Interface
+	RoleLister        rbacv1listers.RoleLister
+	RoleBindingLister rbacv1listers.RoleBindingLister
+}
+
+// Reconcile makes the Role and the RoleBinding of g, owned by owner, match g. They are deleted
+// when g has no ResourceNames.
+func (r *Reconciler) Reconcile(ctx context.Context, owner kmeta.OwnerRefable, g Grant) error {
+	if len(g.ResourceNames) == 0 {
+		return r.revoke(ctx, owner, g.Name)
+	}
+	if err := r.reconcileRole(ctx, owner, resources.MakeRole(owner, g.Name, g.Resource, g.Verbs, g.ResourceNames)); err != nil {
+		return err
+	}
+	return r.reconcileRoleBinding(ctx, owner, resources.MakeRoleBinding(owner, g.Name, g.ServiceAccount))
+}
+
+func (r *Reconciler) reconcileRole(ctx context.Context, owner kmeta.OwnerRefable, expected *rbacv1.Role) error {
+	role, err := r.RoleLister.Roles(expected.Namespace).Get(expected.Name)
+	if apierrs.IsNotFound(err) {
+		_, err = r.KubeClientSet.RbacV1().Roles(expected.Namespace).Create(ctx, expected, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(role, owner.GetObjectMeta()) {
+		return fmt.Errorf("role %s/%s is not owned by %s", role.Namespace, role.Name, owner.GetObjectMeta().GetName())
+	}
+	if equality.Semantic.DeepEqual(expected.Rules, role.Rules) {
+		return nil
+	}
+	role = role.DeepCopy()
+	role.Rules = expected.Rules
+	_, err = r.KubeClientSet.RbacV1().Roles(role.Namespace).Update(ctx, role, metav1.UpdateOptions{})
+	return err
+}
+
+func (r *Reconciler) reconcileRoleBinding(ctx context.Context, owner kmeta.OwnerRefable, expected *rbacv1.RoleBinding) error {
+	rb, err := r.RoleBindingLister.RoleBindings(expected.Namespace).Get(expected.Name)
+	if apierrs.IsNotFound(err) {
+		_, err = r.KubeClientSet.RbacV1().RoleBindings(expected.Namespace).Create(ctx, expected, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(rb, owner.GetObjectMeta()) {
+		return fmt.Errorf("rolebinding %s/%s is not owned by %s", rb.Namespace, rb.Name, owner.GetObjectMeta().GetName())
+	}
+	// The RoleRef is immutable, and always the Role of the same name.
+	if equality.Semantic.DeepEqual(expected.Subjects, rb.Subjects) {
+		return nil
+	}
+	rb = rb.DeepCopy()
+	rb.Subjects = expected.Subjects
+	_, err = r.KubeClientSet.RbacV1().RoleBindings(rb.Namespace).Update(ctx, rb, metav1.UpdateOptions{})
+	return err
+}
+
+// revoke deletes the Role and the RoleBinding name owned by owner, if any.
+func (r *Reconciler) revoke(ctx context.Context, owner kmeta.OwnerRefable, name string) error {
+	namespace := owner.GetObjectMeta().GetNamespace()
+	rb, err := r.RoleBindingLister.RoleBindings(namespace).Get(name)
+	if err != nil && !apierrs.IsNotFound(err) {
+		return err
+	}
+	if err == nil && metav1.IsControlledBy(rb, owner.GetObjectMeta()) {
+		err := r.KubeClientSet.RbacV1().RoleBindings(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrs.IsNotFound(err) {
+			return err
+		}
+	}
+	role, err := r.RoleLister.Roles(namespace).Get(name)
+	if err != nil && !apierrs.IsNotFound(err) {
+		return err
+	}
+	if err == nil && metav1.IsControlledBy(role, owner.GetObjectMeta()) {
+		err := r.KubeClientSet.RbacV1().Roles(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrs.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package access
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
+
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/reconciler/resources"
+	reconcilertesting "knative.dev/eventing/pkg/reconciler/testing/v1"
+)
+
+const (
+	testNS    = "test-namespace"
+	grantName = "test-trigger-secrets"
+)
+
+var sa = types.NamespacedName{Namespace: "knative-eventing", Name: "mt-broker-filter"}
+
+func TestReconcile(t *testing.T) {
+	owner := &eventingv1.Trigger{ObjectMeta: metav1.ObjectMeta{Namespace: testNS, Name: "test-trigger", UID: "uid"}}
+	other := &eventingv1.Trigger{ObjectMeta: metav1.ObjectMeta{Namespace: testNS, Name: "other", UID: "other-uid"}}
+	grant := func(names ...string) Grant {
+		return Grant{Name: grantName, Resource: "secrets", Verbs: []string{"get"}, ResourceNames: names, ServiceAccount: sa}
+	}
+
+	tests := map[string]struct {
+		objects   []runtime.Object
+		grant     Grant
+		wantRole  *rbacv1.Role
+		wantBound bool
+		wantErr   bool
+	}{
+		"create": {
+			grant:     grant("creds"),
+			wantRole:  resources.MakeRole(owner, grantName, "secrets", []string{"get"}, []string{"creds"}),
+			wantBound: true,
+		},
+		"update": {
+			objects: []runtime.Object{
+				resources.MakeRole(owner, grantName, "secrets", []string{"get"}, []string{"old-creds"}),
+				resources.MakeRoleBinding(owner, grantName, types.NamespacedName{Namespace: "old", Name: "old"}),
+			},
+			grant:     grant("creds"),
+			wantRole:  resources.MakeRole(owner, grantName, "secrets", []string{"get"}, []string{"creds"}),
+			wantBound: true,
+		},
+		"revoke": {
+			objects: []runtime.Object{
+				resources.MakeRole(owner, grantName, "secrets", []string{"get"}, []string{"creds"}),
+				resources.MakeRoleBinding(owner, grantName, sa),
+			},
+			grant: grant(),
+		},
+		"revoke nothing": {
+			grant: grant(),
+		},
+		"not owned": {
+			objects: []runtime.Object{
+				resources.MakeRole(other, grantName, "secrets", []string{"get"}, []string{"other-creds"}),
+			},
+			grant:    grant("creds"),
+			wantRole: resources.MakeRole(other, grantName, "secrets", []string{"get"}, []string{"other-creds"}),
+			wantErr:  true,
+		},
+		"revoke not owned": {
+			objects: []runtime.Object{
+				resources.MakeRole(other, grantName, "secrets", []string{"get"}, []string{"other-creds"}),
+			},
+			grant:    grant(),
+			wantRole: resources.MakeRole(other, grantName, "secrets", []string{"get"}, []string{"other-creds"}),
+		},
+	}
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			ctx := context.Background()
+			listers := reconcilertesting.NewListers(tc.objects)
+			client := fakekubeclientset.NewSimpleClientset(listers.GetKubeObjects()...)
+			r := &Reconciler{
+				KubeClientSet:     client,
+				RoleLister:        listers.GetRoleLister(),
+				RoleBindingLister: listers.GetRoleBindingLister(),
+			}
+
+			err := r.Reconcile(ctx, owner, tc.grant)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Reconcile() = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			role, err := client.RbacV1().Roles(testNS).Get(ctx, grantName, metav1.GetOptions{})
+			if tc.wantRole == nil {
+				if !apierrs.IsNotFound(err) {
+					t.Errorf("Expected no role, got %v (error %v)", role, err)
+				}
+			} else if diff := cmp.Diff(tc.wantRole, role); diff != "" {
+				t.Error("Unexpected role (-want, +got) =", diff)
+			}
+
+			rb, err := client.RbacV1().RoleBindings(testNS).Get(ctx, grantName, metav1.GetOptions{})
+			if !tc.wantBound {
+				if !apierrs.IsNotFound(err) {
+					t.Errorf("Expected no role binding, got %v (error %v)", rb, err)
+				}
+			} else if diff := cmp.Diff(resources.MakeRoleBinding(owner, grantName, sa), rb); diff != "" {
+				t.Error("Unexpected role binding (-want, +got) =", diff)
+			}
+		})
+	}
+}
@@ -28,20 +28,34 @@ import (
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/system"
 
+	v1 "knative.dev/eventing/pkg/apis/messaging/v1"
 	"knative.dev/eventing/pkg/client/injection/informers/messaging/v1/inmemorychannel"
 	inmemorychannelreconciler "knative.dev/eventing/pkg/client/injection/reconciler/messaging/v1/inmemorychannel"
+	"knative.dev/eventing/pkg/reconciler/access"
 	"knative.dev/pkg/client/injection/kube/informers/apps/v1/deployment"
 	"knative.dev/pkg/client/injection/kube/informers/core/v1/endpoints"
 	"knative.dev/pkg/client/injection/kube/informers/core/v1/service"
 	"knative.dev/pkg/client/injection/kube/informers/core/v1/serviceaccount"
+	"knative.dev/pkg/client/injection/kube/informers/rbac/v1/role"
 	"knative.dev/pkg/client/injection/kube/informers/rbac/v1/rolebinding"
 )
 
 // TODO: this should be passed in on the env.
 const dispatcherName = "imc-dispatcher"
 
+const (
+	// addressingHost addresses each channel by the host of its own Service, an ExternalName
+	// Service pointing to the dispatcher service.
+	addressingHost = "host"
+	// addressingPath addresses each channel by the path /namespace/name on the dispatcher
+	// service, for the ingress gateways and meshes rewriting the Host header.
+	addressingPath = "path"
+)
+
 type envConfig struct {
 	Image string `envconfig:"DISPATCHER_IMAGE" required:"true"`
+	// AddressingMode is how the channels are addressed, addressingHost or addressingPath.
+	AddressingMode string `envconfig:"CHANNEL_ADDRESSING_MODE" default:"host"`
 }
 
 // NewController initializes the controller and is called by the generated code.
@@ -56,6 +70,7 @@ func NewController(
 	serviceInformer := service.Get(ctx)
 	endpointsInformer := endpoints.Get(ctx)
 	serviceAccountInformer := serviceaccount.Get(ctx)
+	roleInformer := role.Get(ctx)
 	roleBindingInformer := rolebinding.Get(ctx)
 
 	r := &Reconciler{
@@ -68,6 +83,11 @@ func NewController(
 		endpointsLister:         endpointsInformer.Lister(),
 		serviceAccountLister:    serviceAccountInformer.Lister(),
 		roleBindingLister:       roleBindingInformer.Lister(),
+		access: &access.Reconciler{
+			KubeClientSet:     kubeclient.Get(ctx),
+			RoleLister:        roleInformer.Lister(),
+			RoleBindingLister: roleBindingInformer.Lister(),
+		},
 	}
 
 	env := &envConfig{}
@@ -81,6 +101,14 @@ func NewController(
 
 	r.dispatcherImage = env.Image
 
+	switch env.AddressingMode {
+	case addressingHost:
+	case addressingPath:
+		r.pathAddressing = true
+	default:
+		logger.Panicf("unable to process in-memory channel's required environment variables (unknown CHANNEL_ADDRESSING_MODE %q)", env.AddressingMode)
+	}
+
 	impl := inmemorychannelreconciler.NewImpl(ctx, r)
 
 	logger.Info("Setting up event handlers")
@@ -116,5 +144,15 @@ func NewController(
 		Handler:    controller.HandleAll(grCh),
 	})
 
+	// Reconcile the channel when the Role, or the RoleBinding, granting access to its Secrets changes.
+	roleInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(v1.Kind("InMemoryChannel")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+	roleBindingInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(v1.Kind("InMemoryChannel")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
 	return impl
 }
@@ -31,17 +31,21 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	appsv1listers "k8s.io/client-go/listers/apps/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/tools/cache"
 	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
 	pkgreconciler "knative.dev/pkg/reconciler"
 
 	"knative.dev/eventing/pkg/apis/eventing"
 	v1 "knative.dev/eventing/pkg/apis/messaging/v1"
 	inmemorychannelreconciler "knative.dev/eventing/pkg/client/injection/reconciler/messaging/v1/inmemorychannel"
 	listers "knative.dev/eventing/pkg/client/listers/messaging/v1"
+	"knative.dev/eventing/pkg/reconciler/access"
 	"knative.dev/eventing/pkg/reconciler/inmemorychannel/controller/resources"
 	"knative.dev/pkg/logging"
 )
@@ -70,6 +74,10 @@ func newRoleBindingWarn(err error) pkgreconciler.Event {
 	return pkgreconciler.NewEvent(corev1.EventTypeWarning, "DispatcherRoleBindingFailed", "Reconciling dispatcher RoleBinding failed: %s", err)
 }
 
+func newSecretAccessWarn(err error) pkgreconciler.Event {
+	return pkgreconciler.NewEvent(corev1.EventTypeWarning, "DispatcherSecretAccessFailed", "Granting the dispatcher access to the channel's Secrets failed: %s", err)
+}
+
 type Reconciler struct {
 	kubeClientSet kubernetes.Interface
 
@@ -82,6 +90,13 @@ type Reconciler struct {
 	endpointsLister         corev1listers.EndpointsLister
 	serviceAccountLister    corev1listers.ServiceAccountLister
 	roleBindingLister       rbacv1listers.RoleBindingLister
+
+	// access grants the dispatcher access to the Secrets of the subscribers of the channels.
+	access *access.Reconciler
+
+	// pathAddressing is whether the channels are addressed by path on the dispatcher service,
+	// instead of by the host of a Service per channel.
+	pathAddressing bool
 }
 
 // Check that our Reconciler implements Interface
@@ -94,7 +109,8 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, imc *v1.InMemoryChannel)
 	// 1. Dispatcher Deployment for it's readiness.
 	// 2. Dispatcher k8s Service for it's existence.
 	// 3. Dispatcher endpoints to ensure that there's something backing the Service.
-	// 4. k8s service representing the channel that will use ExternalName to point to the Dispatcher k8s service
+	// 4. k8s service representing the channel that will use ExternalName to point to the Dispatcher k8s service,
+	//    unless the channels are addressed by path on the Dispatcher k8s service.
 
 	scope, ok := imc.Annotations[eventing.ScopeAnnotationKey]
 	if !ok {
@@ -115,6 +131,12 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, imc *v1.InMemoryChannel)
 	}
 	imc.Status.PropagateDispatcherStatus(&d.Status)
 
+	// Grant the dispatcher access to the Secrets holding the credentials of the subscribers.
+	if err := r.reconcileSecretAccess(ctx, dispatcherNamespace, imc); err != nil {
+		logging.FromContext(ctx).Errorw("Failed to grant the dispatcher access to the InMemoryChannel Secrets", zap.Error(err))
+		return newSecretAccessWarn(err)
+	}
+
 	// Make sure the dispatcher service exists and propagate the status to the Channel in case it does not exist.
 	// We don't do anything with the service because it's status contains nothing useful, so just do
 	// an existence check. Then below we check the endpoints targeting it.
@@ -147,6 +169,24 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, imc *v1.InMemoryChannel)
 
 	imc.Status.MarkEndpointsTrue()
 
+	if r.pathAddressing {
+		// The Dispatcher service is shared by the channels, each one is addressed by
+		// /namespace/name on it. The Service of the channel, if it was addressed by host so far,
+		// is no longer needed.
+		if err := r.deleteChannelService(ctx, imc); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to delete channel service", zap.Error(err))
+			return err
+		}
+		imc.Status.MarkChannelServiceTrue()
+		imc.Status.SetAddress(&apis.URL{
+			Scheme: "http",
+			Host:   network.GetServiceHostname(dispatcherName, dispatcherNamespace),
+			Path:   fmt.Sprintf("/%s/%s", imc.Namespace, imc.Name),
+		})
+		logging.FromContext(ctx).Debugw("Reconciled InMemoryChannel", zap.Any("InMemoryChannel", imc))
+		return nil
+	}
+
 	// Reconcile the k8s service representing the actual Channel. It points to the Dispatcher service via
 	// ExternalName
 	svc, err := r.reconcileChannelService(ctx, dispatcherNamespace, imc)
@@ -256,6 +296,30 @@ func (r *Reconciler) reconcileRoleBinding(ctx context.Context, name string, ns s
 	return nil
 }
 
+// reconcileSecretAccess grants the ServiceAccount of the dispatcher in dispatcherNamespace access
+// to the Secrets of the subscribers of imc.
+func (r *Reconciler) reconcileSecretAccess(ctx context.Context, dispatcherNamespace string, imc *v1.InMemoryChannel) error {
+	secretNames := sets.NewString()
+	for _, sub := range imc.Spec.Subscribers {
+		if sub.Delivery == nil || sub.Delivery.Auth == nil {
+			continue
+		}
+		if sub.Delivery.Auth.SubscriberSecretName != "" {
+			secretNames.Insert(sub.Delivery.Auth.SubscriberSecretName)
+		}
+		if sub.Delivery.Auth.DeadLetterSinkSecretName != "" {
+			secretNames.Insert(sub.Delivery.Auth.DeadLetterSinkSecretName)
+		}
+	}
+	return r.access.Reconcile(ctx, imc, access.Grant{
+		Name:           kmeta.ChildName(imc.Name, "-dispatcher-secrets"),
+		Resource:       "secrets",
+		Verbs:          []string{"get"},
+		ResourceNames:  secretNames.List(),
+		ServiceAccount: types.NamespacedName{Namespace: dispatcherNamespace, Name: dispatcherName},
+	})
+}
+
 func (r *Reconciler) reconcileDispatcherService(ctx context.Context, scope, dispatcherNamespace string, imc *v1.InMemoryChannel) (*corev1.Service, error) {
 	svc, err := r.serviceLister.Services(dispatcherNamespace).Get(dispatcherName)
 	if err != nil {
@@ -328,3 +392,24 @@ func (r *Reconciler) reconcileChannelService(ctx context.Context, dispatcherName
 	}
 	return svc, nil
 }
+
+// deleteChannelService deletes the k8s service representing the channel, if it exists and is
+// owned by the channel.
+func (r *Reconciler) deleteChannelService(ctx context.Context, imc *v1.InMemoryChannel) error {
+	svc, err := r.serviceLister.Services(imc.Namespace).Get(resources.CreateChannelServiceName(imc.Name))
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		imc.Status.MarkChannelServiceUnknown("ChannelServiceGetFailed", fmt.Sprint("Unable to get the channel service: ", err))
+		return err
+	}
+	if !metav1.IsControlledBy(svc, imc) {
+		return nil
+	}
+	err = r.kubeClientSet.CoreV1().Services(imc.Namespace).Delete(ctx, svc.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrs.IsNotFound(err) {
+		imc.Status.MarkChannelServiceFailed("ChannelServiceFailed", fmt.Sprint("Channel Service failed: ", err))
+		return err
+	}
+	return nil
+}
@@ -40,7 +40,9 @@ import (
 	clientgotesting "k8s.io/client-go/testing"
 	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	v1 "knative.dev/eventing/pkg/apis/messaging/v1"
+	"knative.dev/eventing/pkg/reconciler/access"
 	"knative.dev/eventing/pkg/reconciler/inmemorychannel/controller/resources"
+	pkgresources "knative.dev/eventing/pkg/reconciler/resources"
 	. "knative.dev/eventing/pkg/reconciler/testing/v1"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
@@ -59,6 +61,8 @@ const (
 	imageName             = "test-image"
 
 	imcGeneration = 7
+
+	secretsRoleName = "test-imc-dispatcher-secrets"
 )
 
 func init() {
@@ -86,6 +90,22 @@ func TestAllCases(t *testing.T) {
 		ReplyURI:      apis.HTTP("sink2"),
 	}}
 
+	authSubscribers := []eventingduckv1.SubscriberSpec{{
+		UID:           subscriber1UID,
+		Generation:    subscriber1Generation,
+		SubscriberURI: apis.HTTP("call1"),
+		Delivery: &eventingduckv1.DeliverySpec{
+			Auth: &eventingduckv1.DeliveryAuth{SubscriberSecretName: "creds", DeadLetterSinkSecretName: "dls-creds"},
+		},
+	}, {
+		UID:           subscriber2UID,
+		Generation:    subscriber2Generation,
+		SubscriberURI: apis.HTTP("call2"),
+		Delivery: &eventingduckv1.DeliverySpec{
+			Auth: &eventingduckv1.DeliveryAuth{SubscriberSecretName: "creds"},
+		},
+	}}
+
 	subscriberStatuses := []eventingduckv1.SubscriberStatus{{
 		UID:                subscriber1UID,
 		ObservedGeneration: subscriber1Generation,
@@ -321,6 +341,71 @@ func TestAllCases(t *testing.T) {
 					WithInMemoryChannelAddress(channelServiceAddress),
 				),
 			}},
+		}, {
+			Name: "Works, grants the dispatcher access to the subscribers' Secrets",
+			Key:  imcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewInMemoryChannel(imcName, testNS,
+					WithInMemoryChannelSubscribers(authSubscribers)),
+				makeChannelService(NewInMemoryChannel(imcName, testNS)),
+			},
+			WantErr: false,
+			WantCreates: []runtime.Object{
+				pkgresources.MakeRole(NewInMemoryChannel(imcName, testNS), secretsRoleName, "secrets", []string{"get"}, []string{"creds", "dls-creds"}),
+				pkgresources.MakeRoleBinding(NewInMemoryChannel(imcName, testNS), secretsRoleName, types.NamespacedName{Namespace: testNS, Name: dispatcherName}),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewInMemoryChannel(imcName, testNS,
+					WithInitInMemoryChannelConditions,
+					WithInMemoryChannelDeploymentReady(),
+					WithInMemoryChannelServiceReady(),
+					WithInMemoryChannelEndpointsReady(),
+					WithInMemoryChannelChannelServiceReady(),
+					WithInMemoryChannelSubscribers(authSubscribers),
+					WithInMemoryChannelAddress(channelServiceAddress),
+				),
+			}},
+		}, {
+			Name: "Works, revokes the dispatcher access once no subscriber uses a Secret",
+			Key:  imcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewInMemoryChannel(imcName, testNS,
+					WithInMemoryChannelSubscribers(subscribers)),
+				makeChannelService(NewInMemoryChannel(imcName, testNS)),
+				pkgresources.MakeRole(NewInMemoryChannel(imcName, testNS), secretsRoleName, "secrets", []string{"get"}, []string{"creds"}),
+				pkgresources.MakeRoleBinding(NewInMemoryChannel(imcName, testNS), secretsRoleName, types.NamespacedName{Namespace: testNS, Name: dispatcherName}),
+			},
+			WantErr: false,
+			WantDeletes: []clientgotesting.DeleteActionImpl{{
+				ActionImpl: clientgotesting.ActionImpl{
+					Namespace: testNS,
+					Resource:  rbacv1.SchemeGroupVersion.WithResource("rolebindings"),
+				},
+				Name: secretsRoleName,
+			}, {
+				ActionImpl: clientgotesting.ActionImpl{
+					Namespace: testNS,
+					Resource:  rbacv1.SchemeGroupVersion.WithResource("roles"),
+				},
+				Name: secretsRoleName,
+			}},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewInMemoryChannel(imcName, testNS,
+					WithInitInMemoryChannelConditions,
+					WithInMemoryChannelDeploymentReady(),
+					WithInMemoryChannelServiceReady(),
+					WithInMemoryChannelEndpointsReady(),
+					WithInMemoryChannelChannelServiceReady(),
+					WithInMemoryChannelSubscribers(subscribers),
+					WithInMemoryChannelAddress(channelServiceAddress),
+				),
+			}},
 		}, {
 			Name: "Works, channel exists with subscribers, in status, not modified",
 			Key:  imcKey,
@@ -389,6 +474,11 @@ func TestAllCases(t *testing.T) {
 			deploymentLister:        listers.GetDeploymentLister(),
 			serviceLister:           listers.GetServiceLister(),
 			endpointsLister:         listers.GetEndpointsLister(),
+			access: &access.Reconciler{
+				KubeClientSet:     fakekubeclient.Get(ctx),
+				RoleLister:        listers.GetRoleLister(),
+				RoleBindingLister: listers.GetRoleBindingLister(),
+			},
 		}
 		return inmemorychannel.NewReconciler(ctx, logger,
 			fakeeventingclient.Get(ctx), listers.GetInMemoryChannelLister(),
@@ -478,6 +568,111 @@ func TestInNamespace(t *testing.T) {
 			endpointsLister:         listers.GetEndpointsLister(),
 			serviceAccountLister:    listers.GetServiceAccountLister(),
 			roleBindingLister:       listers.GetRoleBindingLister(),
+			access: &access.Reconciler{
+				KubeClientSet:     fakekubeclient.Get(ctx),
+				RoleLister:        listers.GetRoleLister(),
+				RoleBindingLister: listers.GetRoleBindingLister(),
+			},
+		}
+		return inmemorychannel.NewReconciler(ctx, logger,
+			fakeeventingclient.Get(ctx), listers.GetInMemoryChannelLister(),
+			controller.GetEventRecorder(ctx), r)
+	},
+		false,
+		logger,
+	))
+}
+
+func TestPathAddressing(t *testing.T) {
+	imcKey := testNS + "/" + imcName
+	table := TableTest{
+		{
+			Name: "Works, addressed by path on the dispatcher service",
+			Key:  imcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewInMemoryChannel(imcName, testNS),
+			},
+			WantErr: false,
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewInMemoryChannel(imcName, testNS,
+					WithInitInMemoryChannelConditions,
+					WithInMemoryChannelDeploymentReady(),
+					WithInMemoryChannelServiceReady(),
+					WithInMemoryChannelEndpointsReady(),
+					WithInMemoryChannelChannelServiceReady(),
+					WithInMemoryChannelPathAddress(dispatcherName+"."+testNS+".svc.cluster.local"),
+				),
+			}},
+		}, {
+			Name: "Switched from host addressing, channel service deleted",
+			Key:  imcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewInMemoryChannel(imcName, testNS),
+				makeChannelService(NewInMemoryChannel(imcName, testNS)),
+			},
+			WantErr: false,
+			WantDeletes: []clientgotesting.DeleteActionImpl{{
+				ActionImpl: clientgotesting.ActionImpl{
+					Namespace: testNS,
+					Resource:  corev1.SchemeGroupVersion.WithResource("services"),
+				},
+				Name: fmt.Sprintf("%s-kn-channel", imcName),
+			}},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewInMemoryChannel(imcName, testNS,
+					WithInitInMemoryChannelConditions,
+					WithInMemoryChannelDeploymentReady(),
+					WithInMemoryChannelServiceReady(),
+					WithInMemoryChannelEndpointsReady(),
+					WithInMemoryChannelChannelServiceReady(),
+					WithInMemoryChannelPathAddress(dispatcherName+"."+testNS+".svc.cluster.local"),
+				),
+			}},
+		}, {
+			Name: "Channel service not owned by the channel left in place",
+			Key:  imcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewInMemoryChannel(imcName, testNS),
+				makeChannelServiceNotOwnedByUs(NewInMemoryChannel(imcName, testNS)),
+			},
+			WantErr: false,
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewInMemoryChannel(imcName, testNS,
+					WithInitInMemoryChannelConditions,
+					WithInMemoryChannelDeploymentReady(),
+					WithInMemoryChannelServiceReady(),
+					WithInMemoryChannelEndpointsReady(),
+					WithInMemoryChannelChannelServiceReady(),
+					WithInMemoryChannelPathAddress(dispatcherName+"."+testNS+".svc.cluster.local"),
+				),
+			}},
+		},
+	}
+
+	logger := logtesting.TestLogger(t)
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			kubeClientSet:         fakekubeclient.Get(ctx),
+			systemNamespace:       testNS,
+			inmemorychannelLister: listers.GetInMemoryChannelLister(),
+			deploymentLister:      listers.GetDeploymentLister(),
+			serviceLister:         listers.GetServiceLister(),
+			endpointsLister:       listers.GetEndpointsLister(),
+			pathAddressing:        true,
+			access: &access.Reconciler{
+				KubeClientSet:     fakekubeclient.Get(ctx),
+				RoleLister:        listers.GetRoleLister(),
+				RoleBindingLister: listers.GetRoleBindingLister(),
+			},
 		}
 		return inmemorychannel.NewReconciler(ctx, logger,
 			fakeeventingclient.Get(ctx), listers.GetInMemoryChannelLister(),
@@ -24,6 +24,7 @@ import (
 
 	"k8s.io/client-go/tools/cache"
 
+	"knative.dev/eventing/pkg/channel/fanout"
 	"knative.dev/eventing/pkg/channel/multichannelfanout"
 	"knative.dev/eventing/pkg/kncloudevents"
 
@@ -36,6 +37,7 @@ import (
 	inmemorychannelreconciler "knative.dev/eventing/pkg/client/injection/reconciler/messaging/v1/inmemorychannel"
 
 	"go.uber.org/zap"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	pkgreconciler "knative.dev/pkg/reconciler"
@@ -47,6 +49,7 @@ import (
 	"knative.dev/eventing/pkg/channel"
 	eventingclient "knative.dev/eventing/pkg/client/injection/client"
 	inmemorychannelinformer "knative.dev/eventing/pkg/client/injection/informers/messaging/v1/inmemorychannel"
+	"knative.dev/eventing/pkg/deliveryauth"
 	"knative.dev/eventing/pkg/inmemorychannel"
 )
 
@@ -61,6 +64,35 @@ type envConfig struct {
 	// TODO: change this environment variable to something like "PodGroupName".
 	PodName       string `envconfig:"POD_NAME" required:"true"`
 	ContainerName string `envconfig:"CONTAINER_NAME" required:"true"`
+	// DrainTimeout is how long the dispatcher waits for the in-flight events on shutdown.
+	DrainTimeout time.Duration `envconfig:"DRAIN_TIMEOUT" default:"30s"`
+}
+
+type drainedKey struct{}
+
+// drainSignal is closed by the dispatcher started by NewController: started once it is started,
+// drained once it is stopped and drained of its in-flight events.
+type drainSignal struct {
+	started chan struct{}
+	drained chan struct{}
+}
+
+// WithDrainedSignal returns a context making the dispatcher started by NewController signal once
+// it is stopped and drained of its in-flight events, and the function waiting for it so that the
+// process can call it before exiting. The function returns right away if no dispatcher was started,
+// for instance when the process failed to set up before calling NewController.
+func WithDrainedSignal(ctx context.Context) (context.Context, func()) {
+	s := &drainSignal{
+		started: make(chan struct{}),
+		drained: make(chan struct{}),
+	}
+	return context.WithValue(ctx, drainedKey{}, s), func() {
+		select {
+		case <-s.started:
+			<-s.drained
+		default:
+		}
+	}
 }
 
 // NewController initializes the controller and is called by the generated code.
@@ -84,12 +116,15 @@ func NewController(
 	reporter := channel.NewStatsReporter(env.ContainerName, kmeta.ChildName(env.PodName, uuid.New().String()))
 
 	sh := multichannelfanout.NewMessageHandler(ctx, logger.Desugar(), channel.NewMessageDispatcher(logger.Desugar()), reporter)
+	inFlight := fanout.NewInFlightTracker()
 
 	args := &inmemorychannel.InMemoryMessageDispatcherArgs{
 		Port:         port,
 		ReadTimeout:  readTimeout,
 		WriteTimeout: writeTimeout,
 		Handler:      sh,
+		InFlight:     inFlight,
+		DrainTimeout: env.DrainTimeout,
 		Logger:       logger.Desugar(),
 	}
 	inMemoryDispatcher := inmemorychannel.NewMessageDispatcher(args)
@@ -99,12 +134,15 @@ func NewController(
 
 	r := &Reconciler{
 		multiChannelMessageHandler: sh,
+		inFlight:                   inFlight,
 		reporter:                   reporter,
 		messagingClientSet:         eventingclient.Get(ctx).MessagingV1(),
+		credentials:                deliveryauth.NewResolverFromClient(kubeclient.Get(ctx)),
 	}
 	impl := inmemorychannelreconciler.NewImpl(ctx, r, func(impl *controller.Impl) controller.Options {
 		return controller.Options{SkipStatusUpdates: true, FinalizerName: finalizerName}
 	})
+	r.enqueueKey = impl.EnqueueKey
 
 	globalSyncAfterDispatcherConfigUpdate := configmap.TypeFilter(channel.EventDispatcherConfig{})(func(key string, val interface{}) {
 		conf := val.(channel.EventDispatcherConfig)
@@ -131,11 +169,18 @@ func NewController(
 			}})
 
 	// Start the dispatcher.
+	signal, _ := ctx.Value(drainedKey{}).(*drainSignal)
+	if signal != nil {
+		close(signal.started)
+	}
 	go func() {
 		err := inMemoryDispatcher.Start(ctx)
 		if err != nil {
 			logging.FromContext(ctx).Errorw("Failed stopping inMemoryDispatcher.", zap.Error(err))
 		}
+		if signal != nil {
+			close(signal.drained)
+		}
 	}()
 
 	return impl
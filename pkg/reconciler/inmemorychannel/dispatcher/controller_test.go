@@ -17,8 +17,10 @@ limitations under the License.
 package dispatcher
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 	"knative.dev/pkg/logging"
@@ -67,3 +69,37 @@ func TestNewInNamespace(t *testing.T) {
 		t.Fatal("Expected NewController to return a non-nil value")
 	}
 }
+
+func TestWithDrainedSignal(t *testing.T) {
+	_, waitDrained := WithDrainedSignal(context.Background())
+	done := make(chan struct{})
+	go func() {
+		waitDrained()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the wait to return right away when no dispatcher was started")
+	}
+
+	ctx, cancel, _ := SetupFakeContextWithCancel(t)
+	ctx = logging.WithLogger(ctx, zap.NewNop().Sugar())
+	ctx, waitDrained = WithDrainedSignal(ctx)
+	os.Setenv("SCOPE", eventing.ScopeCluster)
+	os.Setenv("POD_NAME", "testpod")
+	os.Setenv("CONTAINER_NAME", "testcontainer")
+	NewController(ctx, &configmap.InformedWatcher{})
+	cancel()
+
+	done = make(chan struct{})
+	go func() {
+		waitDrained()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("Timed out waiting for the dispatcher to be drained")
+	}
+}
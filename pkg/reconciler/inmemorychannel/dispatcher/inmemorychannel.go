@@ -19,6 +19,8 @@ package dispatcher
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,6 +31,7 @@ import (
 	"go.uber.org/zap"
 
 	"knative.dev/pkg/apis/duck"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/reconciler"
@@ -39,15 +42,40 @@ import (
 	"knative.dev/eventing/pkg/channel/fanout"
 	"knative.dev/eventing/pkg/channel/multichannelfanout"
 	messagingv1 "knative.dev/eventing/pkg/client/clientset/versioned/typed/messaging/v1"
+	"knative.dev/eventing/pkg/deliveryauth"
 	"knative.dev/eventing/pkg/kncloudevents"
 )
 
+const (
+	// Name of the corev1.Events emitted when the circuit breaker of a subscriber changes state.
+	circuitBreakerOpened     = "CircuitBreakerOpened"
+	circuitBreakerHalfOpened = "CircuitBreakerHalfOpened"
+	circuitBreakerClosed     = "CircuitBreakerClosed"
+
+	// deliveryHealthInterval is the minimum interval between the updates of the delivery health of
+	// the subscribers of a channel.
+	deliveryHealthInterval = 30 * time.Second
+)
+
 // Reconciler reconciles InMemory Channels.
 type Reconciler struct {
 	eventDispatcherConfigStore *channel.EventDispatcherConfigStore
 	multiChannelMessageHandler multichannelfanout.MultiChannelMessageHandler
+	inFlight                   *fanout.InFlightTracker
 	reporter                   channel.StatsReporter
 	messagingClientSet         messagingv1.MessagingV1Interface
+	// credentials resolves the Secrets holding the credentials of the subscribers, it may be nil.
+	credentials *deliveryauth.Resolver
+
+	// enqueueKey requeues a channel, once the delivery health of its subscribers changed.
+	enqueueKey             func(types.NamespacedName)
+	deliveryHealthInterval time.Duration
+	mu                     sync.Mutex
+	// deliveryHealth holds the DeliveryHealthTracker of each channel.
+	deliveryHealth map[types.NamespacedName]*fanout.DeliveryHealthTracker
+	// channelKeys holds the key of the handler of each channel, so that the handler under the
+	// previous key is removed when the channel switches between host and path addressing.
+	channelKeys map[types.NamespacedName]string
 }
 
 func (r *Reconciler) ReconcileKind(ctx context.Context, imc *v1.InMemoryChannel) reconciler.Event {
@@ -65,20 +93,29 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, imc *v1.InMemoryChannel)
 	}
 
 	// First grab the MultiChannelFanoutMessage handler
-	handler := r.multiChannelMessageHandler.GetChannelHandler(config.HostName)
+	channelKey := multichannelfanout.MakeChannelKey(config.HostName, config.Path)
+	if previous := r.setChannelKey(imc, channelKey); previous != "" && previous != channelKey {
+		logging.FromContext(ctx).Infow("Channel address changed, removing the previous handler", zap.String("channelKey", previous))
+		r.multiChannelMessageHandler.DeleteChannelHandler(previous)
+	}
+	handler := r.multiChannelMessageHandler.GetChannelHandler(channelKey)
 	if handler == nil {
 		// No handler yet, create one.
+		fanoutConfig := config.FanoutConfig
+		fanoutConfig.InFlight = r.inFlight
+		fanoutConfig.Health = r.newDeliveryHealthTracker(imc)
+		fanoutConfig.CircuitBreakers = r.newCircuitBreakers(ctx, imc)
 		fanoutHandler, err := fanout.NewFanoutMessageHandler(
 			logging.FromContext(ctx).Desugar(),
 			channel.NewMessageDispatcher(logging.FromContext(ctx).Desugar()),
-			config.FanoutConfig,
+			fanoutConfig,
 			r.reporter,
 		)
 		if err != nil {
 			logging.FromContext(ctx).Error("Failed to create a new fanout.MessageHandler", err)
 			return err
 		}
-		r.multiChannelMessageHandler.SetChannelHandler(config.HostName, fanoutHandler)
+		r.multiChannelMessageHandler.SetChannelHandler(channelKey, fanoutHandler)
 	} else {
 		// Just update the config if necessary.
 		haveSubs := handler.GetSubscriptions(ctx)
@@ -88,22 +125,39 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, imc *v1.InMemoryChannel)
 			logging.FromContext(ctx).Info("Updating fanout config: ", zap.String("Diff", diff))
 			handler.SetSubscriptions(ctx, config.FanoutConfig.Subscriptions)
 		}
+		// The limits may have changed in the config-imc-event-dispatcher ConfigMap.
+		handler.SetQueueLimits(config.FanoutConfig.Workers, config.FanoutConfig.QueueSize)
 	}
 
-	// Then patch the subscribers to reflect that they are now ready to go
+	// Then patch the subscribers to reflect that they are now ready to go, and their delivery health
 	return r.patchSubscriberStatus(ctx, imc)
 }
 
 func (r *Reconciler) patchSubscriberStatus(ctx context.Context, imc *v1.InMemoryChannel) error {
 	after := imc.DeepCopy()
 
+	health := r.getDeliveryHealthTracker(imc)
 	after.Status.Subscribers = make([]eventingduckv1.SubscriberStatus, 0)
 	for _, sub := range imc.Spec.Subscribers {
-		after.Status.Subscribers = append(after.Status.Subscribers, eventingduckv1.SubscriberStatus{
+		status := eventingduckv1.SubscriberStatus{
 			UID:                sub.UID,
 			ObservedGeneration: sub.Generation,
 			Ready:              corev1.ConditionTrue,
-		})
+		}
+		if h, ok := health.Get(sub.UID); ok {
+			setDeliveryHealth(&status, h)
+		} else {
+			// Nothing was delivered since the dispatcher started, keep the health reported so far.
+			for _, previous := range imc.Status.Subscribers {
+				if previous.UID == sub.UID {
+					status.LastDeliveryTime = previous.LastDeliveryTime
+					status.LastFailureTime = previous.LastFailureTime
+					status.LastFailureResponseCode = previous.LastFailureResponseCode
+					status.ConsecutiveFailures = previous.ConsecutiveFailures
+				}
+			}
+		}
+		after.Status.Subscribers = append(after.Status.Subscribers, status)
 	}
 	jsonPatch, err := duck.CreatePatch(imc, after)
 	if err != nil {
@@ -139,17 +193,126 @@ func (r *Reconciler) newConfigForInMemoryChannel(imc *v1.InMemoryChannel) (*mult
 		subs[i] = *conf
 	}
 
+	workers, queueSize := r.queueLimits()
 	return &multichannelfanout.ChannelConfig{
 		Namespace: imc.Namespace,
 		Name:      imc.Name,
 		HostName:  imc.Status.Address.URL.Host,
+		Path:      imc.Status.Address.URL.Path,
 		FanoutConfig: fanout.Config{
 			AsyncHandler:  true,
 			Subscriptions: subs,
+			Workers:       workers,
+			QueueSize:     queueSize,
+			Credentials:   r.credentialsFunc(imc.Namespace),
 		},
 	}, nil
 }
 
+// credentialsFunc returns the fanout.CredentialsFunc resolving the Secrets of namespace.
+func (r *Reconciler) credentialsFunc(namespace string) fanout.CredentialsFunc {
+	if r.credentials == nil {
+		return nil
+	}
+	return func(secretName string) (*deliveryauth.Credentials, error) {
+		return r.credentials.Get(namespace, secretName)
+	}
+}
+
+// setDeliveryHealth sets the delivery health fields of status from h.
+func setDeliveryHealth(status *eventingduckv1.SubscriberStatus, h fanout.DeliveryHealth) {
+	if !h.LastDeliveryTime.IsZero() {
+		t := metav1.NewTime(h.LastDeliveryTime)
+		status.LastDeliveryTime = &t
+	}
+	if !h.LastFailureTime.IsZero() {
+		t := metav1.NewTime(h.LastFailureTime)
+		status.LastFailureTime = &t
+	}
+	status.LastFailureResponseCode = int32(h.LastFailureResponseCode)
+	status.ConsecutiveFailures = int32(h.ConsecutiveFailures)
+}
+
+// newDeliveryHealthTracker creates the DeliveryHealthTracker of the subscribers of the channel,
+// which requeues the channel to report their delivery health.
+func (r *Reconciler) newDeliveryHealthTracker(imc *v1.InMemoryChannel) *fanout.DeliveryHealthTracker {
+	key := types.NamespacedName{Namespace: imc.Namespace, Name: imc.Name}
+	interval := r.deliveryHealthInterval
+	if interval == 0 {
+		interval = deliveryHealthInterval
+	}
+	tracker := fanout.NewDeliveryHealthTracker(interval, func() {
+		if r.enqueueKey != nil {
+			r.enqueueKey(key)
+		}
+	})
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.deliveryHealth == nil {
+		r.deliveryHealth = make(map[types.NamespacedName]*fanout.DeliveryHealthTracker)
+	}
+	r.deliveryHealth[key] = tracker
+	return tracker
+}
+
+// getDeliveryHealthTracker returns the DeliveryHealthTracker of the channel, nil if it has none.
+func (r *Reconciler) getDeliveryHealthTracker(imc *v1.InMemoryChannel) *fanout.DeliveryHealthTracker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deliveryHealth[types.NamespacedName{Namespace: imc.Namespace, Name: imc.Name}]
+}
+
+// setChannelKey records the key of the handler of the channel, and returns the previous one.
+func (r *Reconciler) setChannelKey(imc *v1.InMemoryChannel, channelKey string) string {
+	key := types.NamespacedName{Namespace: imc.Namespace, Name: imc.Name}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.channelKeys == nil {
+		r.channelKeys = make(map[types.NamespacedName]string)
+	}
+	previous := r.channelKeys[key]
+	r.channelKeys[key] = channelKey
+	return previous
+}
+
+// newCircuitBreakers creates the circuit breakers of the subscribers of the channel. Their state
+// changes are reported as metrics, and as Events on the channel.
+func (r *Reconciler) newCircuitBreakers(ctx context.Context, imc *v1.InMemoryChannel) *channel.CircuitBreakers {
+	recorder := controller.GetEventRecorder(ctx)
+	args := &channel.ReportArgs{Ns: imc.Namespace}
+	return channel.NewCircuitBreakers(r.circuitBreakerConfig, func(destination string, state channel.CircuitState) {
+		logging.FromContext(ctx).Infow("Circuit breaker state changed", zap.String("destination", destination), zap.String("state", string(state)))
+		_ = r.reporter.ReportCircuitBreakerStateChange(args, destination, state)
+		if recorder == nil {
+			return
+		}
+		switch state {
+		case channel.CircuitOpen:
+			recorder.Eventf(imc, corev1.EventTypeWarning, circuitBreakerOpened, "Circuit breaker of subscriber %q opened", destination)
+		case channel.CircuitHalfOpen:
+			recorder.Eventf(imc, corev1.EventTypeNormal, circuitBreakerHalfOpened, "Circuit breaker of subscriber %q half-opened", destination)
+		case channel.CircuitClosed:
+			recorder.Eventf(imc, corev1.EventTypeNormal, circuitBreakerClosed, "Circuit breaker of subscriber %q closed", destination)
+		}
+	})
+}
+
+func (r *Reconciler) circuitBreakerConfig() channel.CircuitBreakerConfig {
+	if r.eventDispatcherConfigStore == nil {
+		return channel.CircuitBreakerConfig{}
+	}
+	return r.eventDispatcherConfigStore.GetConfig().CircuitBreaker
+}
+
+// queueLimits returns the number of workers and the size of the queue of each channel.
+func (r *Reconciler) queueLimits() (int, int) {
+	if r.eventDispatcherConfigStore == nil {
+		return 0, 0
+	}
+	config := r.eventDispatcherConfigStore.GetConfig()
+	return config.WorkersPerChannel, config.QueueSizePerChannel
+}
+
 func (r *Reconciler) deleteFunc(obj interface{}) {
 	if obj == nil {
 		return
@@ -162,9 +325,18 @@ func (r *Reconciler) deleteFunc(obj interface{}) {
 	if !ok || imc == nil {
 		return
 	}
+	key := types.NamespacedName{Namespace: imc.Namespace, Name: imc.Name}
+	r.mu.Lock()
+	delete(r.deliveryHealth, key)
+	channelKey, ok := r.channelKeys[key]
+	delete(r.channelKeys, key)
+	r.mu.Unlock()
+	if ok {
+		r.multiChannelMessageHandler.DeleteChannelHandler(channelKey)
+	}
 	if imc.Status.Address != nil && imc.Status.Address.URL != nil {
-		if hostName := imc.Status.Address.URL.Host; hostName != "" {
-			r.multiChannelMessageHandler.DeleteChannelHandler(hostName)
+		if channelKey := multichannelfanout.MakeChannelKey(imc.Status.Address.URL.Host, imc.Status.Address.URL.Path); channelKey != "" {
+			r.multiChannelMessageHandler.DeleteChannelHandler(channelKey)
 		}
 	}
 }
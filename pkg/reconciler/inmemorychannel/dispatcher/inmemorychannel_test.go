@@ -18,8 +18,20 @@ package dispatcher
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"knative.dev/pkg/logging"
 
 	"k8s.io/apimachinery/pkg/types"
 
@@ -33,6 +45,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	clientgotesting "k8s.io/client-go/testing"
 	"knative.dev/eventing/pkg/channel/fanout"
+	"knative.dev/eventing/pkg/channel/multichannelfanout"
+	"knative.dev/eventing/pkg/deliveryauth"
 
 	"k8s.io/utils/pointer"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
@@ -51,6 +65,7 @@ import (
 	. "knative.dev/pkg/reconciler/testing"
 
 	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/apis/eventing"
 	v1 "knative.dev/eventing/pkg/apis/messaging/v1"
 	. "knative.dev/eventing/pkg/reconciler/testing/v1"
 )
@@ -351,10 +366,12 @@ func TestReconciler_ReconcileKind(t *testing.T) {
 				WithInMemoryChannelSubscribers(subscribers),
 				WithInMemoryChannelAddress(channelServiceAddress)),
 			wantSubs: []fanout.Subscription{
-				{Subscriber: apis.HTTP("call1").URL(),
-					Reply: apis.HTTP("sink2").URL()},
-				{Subscriber: apis.HTTP("call2").URL(),
-					Reply: apis.HTTP("sink2").URL()},
+				{UID: subscriber1UID,
+					Subscriber: apis.HTTP("call1").URL(),
+					Reply:      apis.HTTP("sink2").URL()},
+				{UID: subscriber2UID,
+					Subscriber: apis.HTTP("call2").URL(),
+					Reply:      apis.HTTP("sink2").URL()},
 			},
 		},
 		"with one subscriber, one added": {
@@ -368,10 +385,12 @@ func TestReconciler_ReconcileKind(t *testing.T) {
 				WithInMemoryChannelAddress(channelServiceAddress)),
 			subs: []fanout.Subscription{*subscription1},
 			wantSubs: []fanout.Subscription{
-				{Subscriber: apis.HTTP("call1").URL(),
-					Reply: apis.HTTP("sink2").URL()},
-				{Subscriber: apis.HTTP("call2").URL(),
-					Reply: apis.HTTP("sink2").URL()},
+				{UID: subscriber1UID,
+					Subscriber: apis.HTTP("call1").URL(),
+					Reply:      apis.HTTP("sink2").URL()},
+				{UID: subscriber2UID,
+					Subscriber: apis.HTTP("call2").URL(),
+					Reply:      apis.HTTP("sink2").URL()},
 			},
 		},
 		"with two subscribers, none added": {
@@ -385,10 +404,12 @@ func TestReconciler_ReconcileKind(t *testing.T) {
 				WithInMemoryChannelAddress(channelServiceAddress)),
 			subs: []fanout.Subscription{*subscription1, *subscription2},
 			wantSubs: []fanout.Subscription{
-				{Subscriber: apis.HTTP("call1").URL(),
-					Reply: apis.HTTP("sink2").URL()},
-				{Subscriber: apis.HTTP("call2").URL(),
-					Reply: apis.HTTP("sink2").URL()},
+				{UID: subscriber1UID,
+					Subscriber: apis.HTTP("call1").URL(),
+					Reply:      apis.HTTP("sink2").URL()},
+				{UID: subscriber2UID,
+					Subscriber: apis.HTTP("call2").URL(),
+					Reply:      apis.HTTP("sink2").URL()},
 			},
 		},
 		"with two subscribers, one removed": {
@@ -402,8 +423,9 @@ func TestReconciler_ReconcileKind(t *testing.T) {
 				WithInMemoryChannelAddress(channelServiceAddress)),
 			subs: []fanout.Subscription{*subscription1, *subscription2},
 			wantSubs: []fanout.Subscription{
-				{Subscriber: apis.HTTP("call1").URL(),
-					Reply: apis.HTTP("sink2").URL()},
+				{UID: subscriber1UID,
+					Subscriber: apis.HTTP("call1").URL(),
+					Reply:      apis.HTTP("sink2").URL()},
 			},
 		},
 		"with two subscribers, one removed one added": {
@@ -417,10 +439,12 @@ func TestReconciler_ReconcileKind(t *testing.T) {
 				WithInMemoryChannelAddress(channelServiceAddress)),
 			subs: []fanout.Subscription{*subscription1, *subscription2},
 			wantSubs: []fanout.Subscription{
-				{Subscriber: apis.HTTP("call1").URL(),
-					Reply: apis.HTTP("sink2").URL()},
-				{Subscriber: apis.HTTP("call3").URL(),
-					Reply: apis.HTTP("sink2").URL()},
+				{UID: subscriber1UID,
+					Subscriber: apis.HTTP("call1").URL(),
+					Reply:      apis.HTTP("sink2").URL()},
+				{UID: subscriber3UID,
+					Subscriber: apis.HTTP("call3").URL(),
+					Reply:      apis.HTTP("sink2").URL()},
 			},
 		},
 		"with one subscriber, with delivery spec changed": {
@@ -436,7 +460,8 @@ func TestReconciler_ReconcileKind(t *testing.T) {
 				Reply:       apis.HTTP("sink2").URL(),
 				RetryConfig: &kncloudevents.RetryConfig{RetryMax: 2, BackoffPolicy: &exponential}}},
 			wantSubs: []fanout.Subscription{
-				{Subscriber: apis.HTTP("call1").URL(),
+				{UID: subscriber1UID,
+					Subscriber:  apis.HTTP("call1").URL(),
 					Reply:       apis.HTTP("sink2").URL(),
 					RetryConfig: &kncloudevents.RetryConfig{RetryMax: 3, BackoffPolicy: &linear}},
 			},
@@ -542,6 +567,61 @@ func TestReconciler_Deletion(t *testing.T) {
 	}
 }
 
+func TestReconciler_CircuitBreakers(t *testing.T) {
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer subscriber.Close()
+
+	logger := logtesting.TestLogger(t)
+	configStore := channel.NewEventDispatcherConfigStore(logger)
+	configStore.OnConfigChanged(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: channel.EventDispatcherConfigMap},
+		Data: map[string]string{
+			"CircuitBreakerFailureThreshold": "1",
+			"CircuitBreakerCoolDown":         "1h",
+		},
+	})
+	r := &Reconciler{
+		eventDispatcherConfigStore: configStore,
+		reporter:                   channel.NewStatsReporter("testcontainer", "testpod"),
+	}
+	recorder := record.NewFakeRecorder(10)
+	ctx := controller.WithEventRecorder(logging.WithLogger(context.Background(), logger), recorder)
+	imc := NewInMemoryChannel(imcName, testNS)
+
+	circuitBreakers := r.newCircuitBreakers(ctx, imc)
+	dispatcher := channel.NewMessageDispatcher(logger.Desugar())
+	destination, err := url.Parse(subscriber.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		event := cloudevents.NewEvent(cloudevents.VersionV1)
+		event.SetID("test-id")
+		event.SetType("test-type")
+		event.SetSource("test-source")
+		if _, err := dispatcher.DispatchMessage(channel.WithCircuitBreakers(ctx, circuitBreakers), binding.ToMessage(&event), nil, destination, nil, nil); err == nil {
+			t.Fatal("Expected the dispatch to fail")
+		}
+	}
+
+	want := fmt.Sprintf("Warning %s Circuit breaker of subscriber %q opened", circuitBreakerOpened, destination.String())
+	select {
+	case got := <-recorder.Events:
+		if got != want {
+			t.Errorf("Unexpected event %q, want %q", got, want)
+		}
+	default:
+		t.Error("Expected an event when the circuit breaker opened")
+	}
+	select {
+	case got := <-recorder.Events:
+		t.Error("Unexpected event:", got)
+	default:
+	}
+}
+
 func makePatch(namespace, name, patch string) clientgotesting.PatchActionImpl {
 	return clientgotesting.PatchActionImpl{
 		ActionImpl: clientgotesting.ActionImpl{
@@ -573,3 +653,264 @@ func (f *fakeMultiChannelHandler) DeleteChannelHandler(host string) {
 func (f *fakeMultiChannelHandler) GetChannelHandler(host string) fanout.MessageHandler {
 	return f.handlers[host]
 }
+
+func TestReconciler_DeliveryHealth(t *testing.T) {
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer subscriberServer.Close()
+
+	imc := NewInMemoryChannel(imcName, testNS,
+		WithInitInMemoryChannelConditions,
+		WithInMemoryChannelDeploymentReady(),
+		WithInMemoryChannelServiceReady(),
+		WithInMemoryChannelEndpointsReady(),
+		WithInMemoryChannelChannelServiceReady(),
+		WithInMemoryChannelSubscribers([]eventingduckv1.SubscriberSpec{{
+			UID:           subscriber1UID,
+			Generation:    subscriber1Generation,
+			SubscriberURI: apis.HTTP(subscriberServer.URL[7:]),
+		}}),
+		WithInMemoryChannelAddress(channelServiceAddress))
+	ctx, fakeEventingClient := fakeeventingclient.With(context.Background(), imc)
+	enqueued := make(chan types.NamespacedName, 1)
+	handler := newFakeMultiChannelHandler()
+	r := &Reconciler{
+		multiChannelMessageHandler: handler,
+		reporter:                   channel.NewStatsReporter("testcontainer", "testpod"),
+		messagingClientSet:         fakeEventingClient.MessagingV1(),
+		deliveryHealthInterval:     time.Millisecond,
+		enqueueKey: func(key types.NamespacedName) {
+			enqueued <- key
+		},
+	}
+	if err := r.ReconcileKind(ctx, imc); err != nil {
+		t.Fatal("ReconcileKind() =", err)
+	}
+
+	event := cloudevents.NewEvent(cloudevents.VersionV1)
+	event.SetID("test-id")
+	event.SetType("test-type")
+	event.SetSource("test-source")
+	req := httptest.NewRequest(http.MethodPost, "http://"+channelServiceAddress+"/", nil)
+	if err := cehttp.WriteRequest(ctx, binding.ToMessage(&event), req); err != nil {
+		t.Fatal("WriteRequest =", err)
+	}
+	handler.GetChannelHandler(channelServiceAddress).ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case key := <-enqueued:
+		if want := (types.NamespacedName{Namespace: testNS, Name: imcName}); key != want {
+			t.Errorf("Enqueued %v, want %v", key, want)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the channel to be requeued")
+	}
+
+	patched, err := fakeEventingClient.MessagingV1().InMemoryChannels(testNS).Get(ctx, imcName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal("Get() =", err)
+	}
+	if err := r.ReconcileKind(ctx, patched); err != nil {
+		t.Fatal("ReconcileKind() =", err)
+	}
+	patched, err = fakeEventingClient.MessagingV1().InMemoryChannels(testNS).Get(ctx, imcName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal("Get() =", err)
+	}
+	if len(patched.Status.Subscribers) != 1 {
+		t.Fatalf("Expected one subscriber status, got %+v", patched.Status.Subscribers)
+	}
+	got := patched.Status.Subscribers[0]
+	if got.ConsecutiveFailures != 1 || got.LastFailureResponseCode != http.StatusServiceUnavailable || got.LastFailureTime == nil || got.LastDeliveryTime != nil {
+		t.Errorf("Unexpected subscriber status %+v", got)
+	}
+	if got.Ready != corev1.ConditionTrue {
+		t.Errorf("Expected the subscriber to stay ready, got %v", got.Ready)
+	}
+}
+
+func TestReconciler_PathAddressing(t *testing.T) {
+	const dispatcherHost = "imc-dispatcher.knative-eventing.svc.cluster.local"
+	received := make(chan string, 2)
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.URL.Path
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer subscriberServer.Close()
+
+	// Both channels are addressed by path on the host of the dispatcher.
+	var imcs []*v1.InMemoryChannel
+	for _, name := range []string{"imc-a", "imc-b"} {
+		imcs = append(imcs, NewInMemoryChannel(name, testNS,
+			WithInitInMemoryChannelConditions,
+			WithInMemoryChannelDeploymentReady(),
+			WithInMemoryChannelServiceReady(),
+			WithInMemoryChannelEndpointsReady(),
+			WithInMemoryChannelChannelServiceReady(),
+			WithInMemoryChannelSubscribers([]eventingduckv1.SubscriberSpec{{
+				UID:           types.UID(name),
+				SubscriberURI: &apis.URL{Scheme: "http", Host: subscriberServer.URL[7:], Path: "/" + name},
+			}}),
+			WithInMemoryChannelPathAddress(dispatcherHost)))
+	}
+	ctx, fakeEventingClient := fakeeventingclient.With(context.Background(), imcs[0], imcs[1])
+	handler := multichannelfanout.NewMessageHandler(ctx, logtesting.TestLogger(t).Desugar(), channel.NewMessageDispatcher(nil), nil)
+	r := &Reconciler{
+		multiChannelMessageHandler: handler,
+		reporter:                   channel.NewStatsReporter("testcontainer", "testpod"),
+		messagingClientSet:         fakeEventingClient.MessagingV1(),
+	}
+	for _, imc := range imcs {
+		if err := r.ReconcileKind(ctx, imc); err != nil {
+			t.Fatal("ReconcileKind() =", err)
+		}
+	}
+
+	event := cloudevents.NewEvent(cloudevents.VersionV1)
+	event.SetID("test-id")
+	event.SetType("test-type")
+	event.SetSource("test-source")
+	req := httptest.NewRequest(http.MethodPost, "http://"+dispatcherHost+"/"+testNS+"/imc-b", nil)
+	if err := cehttp.WriteRequest(ctx, binding.ToMessage(&event), req); err != nil {
+		t.Fatal("WriteRequest =", err)
+	}
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("Unexpected status code %d", res.Code)
+	}
+	select {
+	case path := <-received:
+		if path != "/imc-b" {
+			t.Errorf("Event delivered to the subscriber %q, want /imc-b", path)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the event")
+	}
+
+	r.deleteFunc(imcs[1])
+	if handler.GetChannelHandler("/"+testNS+"/imc-b") != nil {
+		t.Error("Expected the handler of the deleted channel to be removed")
+	}
+	if handler.GetChannelHandler("/"+testNS+"/imc-a") == nil {
+		t.Error("Expected the handler of the other channel to be kept")
+	}
+}
+
+func TestReconciler_AddressingSwitch(t *testing.T) {
+	const dispatcherHost = "imc-dispatcher.knative-eventing.svc.cluster.local"
+	byHost := NewInMemoryChannel(imcName, testNS,
+		WithInitInMemoryChannelConditions,
+		WithInMemoryChannelDeploymentReady(),
+		WithInMemoryChannelServiceReady(),
+		WithInMemoryChannelEndpointsReady(),
+		WithInMemoryChannelChannelServiceReady(),
+		WithInMemoryChannelAddress(channelServiceAddress))
+	byPath := byHost.DeepCopy()
+	WithInMemoryChannelPathAddress(dispatcherHost)(byPath)
+
+	ctx, fakeEventingClient := fakeeventingclient.With(context.Background(), byHost)
+	handler := newFakeMultiChannelHandler()
+	r := &Reconciler{
+		multiChannelMessageHandler: handler,
+		reporter:                   channel.NewStatsReporter("testcontainer", "testpod"),
+		messagingClientSet:         fakeEventingClient.MessagingV1(),
+	}
+	pathKey := "/" + testNS + "/" + imcName
+
+	if err := r.ReconcileKind(ctx, byHost); err != nil {
+		t.Fatal("ReconcileKind() =", err)
+	}
+	if handler.GetChannelHandler(channelServiceAddress) == nil {
+		t.Fatal("Expected a handler addressed by host")
+	}
+
+	if err := r.ReconcileKind(ctx, byPath); err != nil {
+		t.Fatal("ReconcileKind() =", err)
+	}
+	if handler.GetChannelHandler(channelServiceAddress) != nil {
+		t.Error("Expected the handler addressed by host to be removed")
+	}
+	if handler.GetChannelHandler(pathKey) == nil {
+		t.Error("Expected a handler addressed by path")
+	}
+
+	if err := r.ReconcileKind(ctx, byHost); err != nil {
+		t.Fatal("ReconcileKind() =", err)
+	}
+	if handler.GetChannelHandler(pathKey) != nil {
+		t.Error("Expected the handler addressed by path to be removed")
+	}
+	if handler.GetChannelHandler(channelServiceAddress) == nil {
+		t.Error("Expected a handler addressed by host")
+	}
+}
+
+func TestReconciler_DeliveryAuth(t *testing.T) {
+	const dispatcherHost = "imc-dispatcher.knative-eventing.svc.cluster.local"
+	authorizations := make(chan string, 1)
+	subscriberServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations <- r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer subscriberServer.Close()
+
+	imc := NewInMemoryChannel(imcName, testNS,
+		WithInitInMemoryChannelConditions,
+		WithInMemoryChannelDeploymentReady(),
+		WithInMemoryChannelServiceReady(),
+		WithInMemoryChannelEndpointsReady(),
+		WithInMemoryChannelChannelServiceReady(),
+		WithInMemoryChannelSubscribers([]eventingduckv1.SubscriberSpec{{
+			UID:           "sub",
+			SubscriberURI: apis.HTTP(subscriberServer.URL[7:]),
+			Delivery: &eventingduckv1.DeliverySpec{
+				Auth: &eventingduckv1.DeliveryAuth{SubscriberSecretName: "creds"},
+			},
+		}}),
+		WithInMemoryChannelPathAddress(dispatcherHost))
+	kubeClient := fakekubeclientset.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       testNS,
+			Name:            "creds",
+			ResourceVersion: "1",
+			Labels:          map[string]string{eventing.DeliveryAuthLabelKey: "true"},
+		},
+		Data: map[string][]byte{deliveryauth.TokenKey: []byte("secret-token")},
+	})
+
+	ctx, fakeEventingClient := fakeeventingclient.With(context.Background(), imc)
+	handler := multichannelfanout.NewMessageHandler(ctx, logtesting.TestLogger(t).Desugar(), channel.NewMessageDispatcher(nil), nil)
+	r := &Reconciler{
+		multiChannelMessageHandler: handler,
+		reporter:                   channel.NewStatsReporter("testcontainer", "testpod"),
+		messagingClientSet:         fakeEventingClient.MessagingV1(),
+		credentials:                deliveryauth.NewResolverFromClient(kubeClient),
+	}
+	if err := r.ReconcileKind(ctx, imc); err != nil {
+		t.Fatal("ReconcileKind() =", err)
+	}
+
+	event := cloudevents.NewEvent(cloudevents.VersionV1)
+	event.SetID("test-id")
+	event.SetType("test-type")
+	event.SetSource("test-source")
+	req := httptest.NewRequest(http.MethodPost, "http://"+dispatcherHost+"/"+testNS+"/"+imcName, nil)
+	if err := cehttp.WriteRequest(ctx, binding.ToMessage(&event), req); err != nil {
+		t.Fatal("WriteRequest =", err)
+	}
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("Unexpected status code %d", res.Code)
+	}
+	select {
+	case authorization := <-authorizations:
+		if authorization != "Bearer secret-token" {
+			t.Errorf("Unexpected Authorization header %q", authorization)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the event")
+	}
+}
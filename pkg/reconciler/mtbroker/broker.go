@@ -23,10 +23,12 @@ import (
 
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 
@@ -38,17 +40,28 @@ import (
 	brokerreconciler "knative.dev/eventing/pkg/client/injection/reconciler/eventing/v1/broker"
 	messaginglisters "knative.dev/eventing/pkg/client/listers/messaging/v1"
 	"knative.dev/eventing/pkg/duck"
+	"knative.dev/eventing/pkg/reconciler/access"
 	"knative.dev/eventing/pkg/reconciler/mtbroker/resources"
 	"knative.dev/eventing/pkg/reconciler/names"
+	"knative.dev/eventing/pkg/reconciler/sugar/trigger/path"
 	"knative.dev/pkg/apis"
 	duckapis "knative.dev/pkg/apis/duck"
 	pkgduckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/network"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	"knative.dev/pkg/system"
 )
 
+const (
+	// Name of the corev1.Events emitted from the Broker reconciliation process.
+	subscriptionDeleteFailed = "SubscriptionDeleteFailed"
+	subscriptionCreateFailed = "SubscriptionCreateFailed"
+	secretAccessFailed       = "SecretAccessFailed"
+)
+
 type Reconciler struct {
 	eventingClientSet clientset.Interface
 	dynamicClientSet  dynamic.Interface
@@ -60,6 +73,11 @@ type Reconciler struct {
 
 	channelableTracker duck.ListableTracker
 
+	// access grants ingressServiceAccount, the ServiceAccount of the broker ingress, access to the
+	// Secret holding the HMAC key of the Broker.
+	access                *access.Reconciler
+	ingressServiceAccount types.NamespacedName
+
 	// If specified, only reconcile brokers with these labels
 	brokerClass string
 }
@@ -125,6 +143,15 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, b *eventingv1.Broker) pk
 	channelStatus := &duckv1.ChannelableStatus{AddressStatus: pkgduckv1.AddressStatus{Address: &pkgduckv1.Addressable{URL: triggerChan.Status.Address.URL}}}
 	b.Status.PropagateTriggerChannelReadiness(channelStatus)
 
+	if err := r.reconcileBrokerSubscription(ctx, b, &chanMan.ref); err != nil {
+		logging.FromContext(ctx).Errorw("Problem reconciling the broker subscription", zap.Error(err))
+		return fmt.Errorf("failed to reconcile broker subscription: %w", err)
+	}
+
+	if err := r.reconcileSecretAccess(ctx, b); err != nil {
+		return err
+	}
+
 	filterEndpoints, err := r.endpointsLister.Endpoints(system.Namespace()).Get(names.BrokerFilterName)
 	if err != nil {
 		logging.FromContext(ctx).Errorw("Problem getting endpoints for filter", zap.String("namespace", system.Namespace()), zap.Error(err))
@@ -258,6 +285,63 @@ func (r *Reconciler) reconcileChannel(ctx context.Context, channelResourceInterf
 	return channelable, nil
 }
 
+// reconcileBrokerSubscription subscribes the Broker 'b' to its trigger channel when its Triggers are
+// evaluated in one pass, and removes that subscription otherwise.
+func (r *Reconciler) reconcileBrokerSubscription(ctx context.Context, b *eventingv1.Broker, triggerChan *corev1.ObjectReference) error {
+	recorder := controller.GetEventRecorder(ctx)
+	sub, err := r.subscriptionLister.Subscriptions(b.Namespace).Get(resources.BrokerSubscriptionName(b))
+	if err != nil && !apierrs.IsNotFound(err) {
+		logging.FromContext(ctx).Errorw("Failed to get broker subscription", zap.Error(err))
+		return err
+	}
+
+	if !resources.IsPerBrokerDelivery(b) {
+		if err == nil && metav1.IsControlledBy(sub, b) {
+			logging.FromContext(ctx).Infow("Deleting broker subscription", zap.String("namespace", sub.Namespace), zap.String("name", sub.Name))
+			if err := r.eventingClientSet.MessagingV1().Subscriptions(b.Namespace).Delete(ctx, sub.Name, metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+				recorder.Eventf(b, corev1.EventTypeWarning, subscriptionDeleteFailed, "Delete Broker's subscription failed: %v", err)
+				return err
+			}
+		}
+		return nil
+	}
+
+	uri := &apis.URL{
+		Scheme: "http",
+		Host:   network.GetServiceHostname(names.BrokerFilterName, system.Namespace()),
+		Path:   path.GenerateBroker(b),
+	}
+	expected := resources.NewBrokerSubscription(b, triggerChan, uri, b.Spec.Delivery)
+
+	if apierrs.IsNotFound(err) {
+		logging.FromContext(ctx).Info("Creating broker subscription")
+		if _, err := r.eventingClientSet.MessagingV1().Subscriptions(b.Namespace).Create(ctx, expected, metav1.CreateOptions{}); err != nil {
+			recorder.Eventf(b, corev1.EventTypeWarning, subscriptionCreateFailed, "Create Broker's subscription failed: %v", err)
+			return err
+		}
+		return nil
+	}
+	if !metav1.IsControlledBy(sub, b) {
+		return fmt.Errorf("broker %q does not own subscription %q", b.Name, sub.Name)
+	}
+	if equality.Semantic.DeepDerivative(expected.Spec, sub.Spec) {
+		return nil
+	}
+
+	// Given that spec.channel is immutable, we cannot just update the Subscription. We delete
+	// it and re-create it instead.
+	logging.FromContext(ctx).Infow("Differing broker subscription", zap.Any("expected", expected.Spec), zap.Any("actual", sub.Spec))
+	if err := r.eventingClientSet.MessagingV1().Subscriptions(b.Namespace).Delete(ctx, sub.Name, metav1.DeleteOptions{}); err != nil {
+		recorder.Eventf(b, corev1.EventTypeWarning, subscriptionDeleteFailed, "Delete Broker's subscription failed: %v", err)
+		return err
+	}
+	if _, err := r.eventingClientSet.MessagingV1().Subscriptions(b.Namespace).Create(ctx, expected, metav1.CreateOptions{}); err != nil {
+		recorder.Eventf(b, corev1.EventTypeWarning, subscriptionCreateFailed, "Create Broker's subscription failed: %v", err)
+		return err
+	}
+	return nil
+}
+
 // TriggerChannelLabels are all the labels placed on the Trigger Channel for the given brokerName. This
 // should only be used by Broker and Trigger code.
 func TriggerChannelLabels(brokerName string) map[string]string {
@@ -266,3 +350,26 @@ func TriggerChannelLabels(brokerName string) map[string]string {
 		"eventing.knative.dev/brokerEverything": "true",
 	}
 }
+
+// reconcileSecretAccess grants the broker ingress access to the Secret holding the HMAC key of the
+// Broker 'b', when it authenticates its publishers with HMAC.
+func (r *Reconciler) reconcileSecretAccess(ctx context.Context, b *eventingv1.Broker) error {
+	var secretNames []string
+	annotations := b.GetAnnotations()
+	if annotations[eventing.BrokerIngressAuthAnnotationKey] == eventing.BrokerIngressAuthHMAC && annotations[eventing.BrokerIngressHMACSecretAnnotationKey] != "" {
+		secretNames = []string{annotations[eventing.BrokerIngressHMACSecretAnnotationKey]}
+	}
+	err := r.access.Reconcile(ctx, b, access.Grant{
+		Name:           kmeta.ChildName(b.Name, "-ingress-secrets"),
+		Resource:       "secrets",
+		Verbs:          []string{"get"},
+		ResourceNames:  secretNames,
+		ServiceAccount: r.ingressServiceAccount,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Errorw("Failed to grant the broker ingress access to the Broker's Secret", zap.Error(err))
+		controller.GetEventRecorder(ctx).Eventf(b, corev1.EventTypeWarning, secretAccessFailed, "Granting access to the Broker's Secret failed: %v", err)
+		return err
+	}
+	return nil
+}
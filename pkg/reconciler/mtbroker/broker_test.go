@@ -30,15 +30,20 @@ import (
 	clientgotesting "k8s.io/client-go/testing"
 	"knative.dev/eventing/pkg/apis/eventing"
 	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
 	fakeeventingclient "knative.dev/eventing/pkg/client/injection/client/fake"
 	"knative.dev/eventing/pkg/client/injection/ducks/duck/v1/channelable"
 	"knative.dev/eventing/pkg/client/injection/reconciler/eventing/v1/broker"
 	"knative.dev/eventing/pkg/duck"
+	"knative.dev/eventing/pkg/reconciler/access"
+	"knative.dev/eventing/pkg/reconciler/mtbroker/resources"
+	pkgresources "knative.dev/eventing/pkg/reconciler/resources"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	v1addr "knative.dev/pkg/client/injection/ducks/duck/v1/addressable"
 	v1a1addr "knative.dev/pkg/client/injection/ducks/duck/v1alpha1/addressable"
 	v1b1addr "knative.dev/pkg/client/injection/ducks/duck/v1beta1/addressable"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	fakedynamicclient "knative.dev/pkg/injection/clients/dynamicclient/fake"
@@ -62,6 +67,9 @@ const (
 	triggerChannelKind       = "InMemoryChannel"
 	triggerChannelName       = "test-broker-kne-trigger"
 
+	hmacSecretName        = "hmac"
+	brokerSecretsRoleName = "test-broker-ingress-secrets"
+
 	imcSpec = `
 apiVersion: "messaging.knative.dev/v1"
 kind: "InMemoryChannel"
@@ -333,6 +341,109 @@ func TestReconcile(t *testing.T) {
 					WithChannelKindAnnotation(triggerChannelKind),
 					WithChannelNameAnnotation(triggerChannelName)),
 			}},
+		}, {
+			Name: "Successful Reconciliation, grants the ingress access to the HMAC key",
+			Key:  testKey,
+			Objects: []runtime.Object{
+				NewBroker(brokerName, testNS,
+					WithBrokerClass(eventing.MTChannelBrokerClassValue),
+					WithBrokerIngressHMACSecret(hmacSecretName),
+					WithBrokerConfig(config()),
+					WithInitBrokerConditions),
+				createChannel(testNS, true),
+				imcConfigMap(),
+				NewEndpoints(filterServiceName, systemNS,
+					WithEndpointsLabels(FilterLabels()),
+					WithEndpointsAddresses(corev1.EndpointAddress{IP: "127.0.0.1"})),
+				NewEndpoints(ingressServiceName, systemNS,
+					WithEndpointsLabels(IngressLabels()),
+					WithEndpointsAddresses(corev1.EndpointAddress{IP: "127.0.0.1"})),
+			},
+			WantCreates: []runtime.Object{
+				pkgresources.MakeRole(NewBroker(brokerName, testNS), brokerSecretsRoleName, "secrets", []string{"get"}, []string{hmacSecretName}),
+				pkgresources.MakeRoleBinding(NewBroker(brokerName, testNS), brokerSecretsRoleName, types.NamespacedName{Namespace: systemNS, Name: "mt-broker-ingress"}),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewBroker(brokerName, testNS,
+					WithBrokerClass(eventing.MTChannelBrokerClassValue),
+					WithBrokerIngressHMACSecret(hmacSecretName),
+					WithBrokerConfig(config()),
+					WithBrokerReady,
+					WithBrokerAddressURI(brokerAddress),
+					WithChannelAddressAnnotation(triggerChannelURL),
+					WithChannelAPIVersionAnnotation(triggerChannelAPIVersion),
+					WithChannelKindAnnotation(triggerChannelKind),
+					WithChannelNameAnnotation(triggerChannelName)),
+			}},
+		}, {
+			Name: "Successful Reconciliation, per broker delivery",
+			Key:  testKey,
+			Objects: []runtime.Object{
+				NewBroker(brokerName, testNS,
+					WithBrokerClass(eventing.MTChannelBrokerClassValue),
+					WithBrokerDeliveryMode(eventing.BrokerDeliveryModePerBroker),
+					WithBrokerConfig(config()),
+					WithInitBrokerConditions),
+				createChannel(testNS, true),
+				imcConfigMap(),
+				NewEndpoints(filterServiceName, systemNS,
+					WithEndpointsLabels(FilterLabels()),
+					WithEndpointsAddresses(corev1.EndpointAddress{IP: "127.0.0.1"})),
+				NewEndpoints(ingressServiceName, systemNS,
+					WithEndpointsLabels(IngressLabels()),
+					WithEndpointsAddresses(corev1.EndpointAddress{IP: "127.0.0.1"})),
+			},
+			WantCreates: []runtime.Object{
+				makeBrokerSubscription(),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewBroker(brokerName, testNS,
+					WithBrokerClass(eventing.MTChannelBrokerClassValue),
+					WithBrokerDeliveryMode(eventing.BrokerDeliveryModePerBroker),
+					WithBrokerConfig(config()),
+					WithBrokerReady,
+					WithBrokerAddressURI(brokerAddress),
+					WithChannelAddressAnnotation(triggerChannelURL),
+					WithChannelAPIVersionAnnotation(triggerChannelAPIVersion),
+					WithChannelKindAnnotation(triggerChannelKind),
+					WithChannelNameAnnotation(triggerChannelName)),
+			}},
+		}, {
+			Name: "Successful Reconciliation, per trigger delivery deletes the broker subscription",
+			Key:  testKey,
+			Objects: []runtime.Object{
+				NewBroker(brokerName, testNS,
+					WithBrokerClass(eventing.MTChannelBrokerClassValue),
+					WithBrokerConfig(config()),
+					WithInitBrokerConditions),
+				createChannel(testNS, true),
+				imcConfigMap(),
+				makeBrokerSubscription(),
+				NewEndpoints(filterServiceName, systemNS,
+					WithEndpointsLabels(FilterLabels()),
+					WithEndpointsAddresses(corev1.EndpointAddress{IP: "127.0.0.1"})),
+				NewEndpoints(ingressServiceName, systemNS,
+					WithEndpointsLabels(IngressLabels()),
+					WithEndpointsAddresses(corev1.EndpointAddress{IP: "127.0.0.1"})),
+			},
+			WantDeletes: []clientgotesting.DeleteActionImpl{{
+				ActionImpl: clientgotesting.ActionImpl{
+					Namespace: testNS,
+					Resource:  messagingv1.SchemeGroupVersion.WithResource("subscriptions"),
+				},
+				Name: makeBrokerSubscription().Name,
+			}},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewBroker(brokerName, testNS,
+					WithBrokerClass(eventing.MTChannelBrokerClassValue),
+					WithBrokerConfig(config()),
+					WithBrokerReady,
+					WithBrokerAddressURI(brokerAddress),
+					WithChannelAddressAnnotation(triggerChannelURL),
+					WithChannelAPIVersionAnnotation(triggerChannelAPIVersion),
+					WithChannelKindAnnotation(triggerChannelKind),
+					WithChannelNameAnnotation(triggerChannelName)),
+			}},
 		}, {
 			Name: "Successful Reconciliation, status update fails",
 			Key:  testKey,
@@ -384,6 +495,12 @@ func TestReconcile(t *testing.T) {
 			endpointsLister:    listers.GetEndpointsLister(),
 			configmapLister:    listers.GetConfigMapLister(),
 			channelableTracker: duck.NewListableTracker(ctx, channelable.Get, func(types.NamespacedName) {}, 0),
+			access: &access.Reconciler{
+				KubeClientSet:     fakekubeclient.Get(ctx),
+				RoleLister:        listers.GetRoleLister(),
+				RoleBindingLister: listers.GetRoleBindingLister(),
+			},
+			ingressServiceAccount: types.NamespacedName{Namespace: systemNS, Name: "mt-broker-ingress"},
 		}
 		return broker.NewReconciler(ctx, logger,
 			fakeeventingclient.Get(ctx), listers.GetBrokerLister(),
@@ -523,6 +640,22 @@ func FilterLabels() map[string]string {
 	}
 }
 
+func makeBrokerSubscription() *messagingv1.Subscription {
+	b := NewBroker(brokerName, testNS,
+		WithBrokerClass(eventing.MTChannelBrokerClassValue),
+		WithBrokerConfig(config()))
+	return resources.NewBrokerSubscription(b, &corev1.ObjectReference{
+		APIVersion: triggerChannelAPIVersion,
+		Kind:       triggerChannelKind,
+		Name:       triggerChannelName,
+		Namespace:  testNS,
+	}, &apis.URL{
+		Scheme: "http",
+		Host:   network.GetServiceHostname(filterServiceName, systemNS),
+		Path:   fmt.Sprintf("/brokers/%s/%s", testNS, brokerName),
+	}, nil)
+}
+
 func IngressLabels() map[string]string {
 	return map[string]string{
 		"eventing.knative.dev/brokerRole": "ingress",
@@ -0,0 +1,121 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mteventtype
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	configmapinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap"
+	roleinformer "knative.dev/pkg/client/injection/kube/informers/rbac/v1/role"
+	rolebindinginformer "knative.dev/pkg/client/injection/kube/informers/rbac/v1/rolebinding"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	pkgreconciler "knative.dev/pkg/reconciler"
+	"knative.dev/pkg/system"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	eventingclient "knative.dev/eventing/pkg/client/injection/client"
+	brokerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1/broker"
+	eventtypeinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1beta1/eventtype"
+	broker "knative.dev/eventing/pkg/mtbroker"
+	"knative.dev/eventing/pkg/reconciler/access"
+	"knative.dev/eventing/pkg/reconciler/mtbroker/resources"
+	"knative.dev/eventing/pkg/reconciler/names"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler.
+	ReconcilerName = "RegisteredEventTypes"
+
+	// eventTypeWritesPerSecond and eventTypeWritesBurst limit the EventTypes created and deleted.
+	eventTypeWritesPerSecond = 5
+	eventTypeWritesBurst     = 20
+)
+
+// NewController initializes the controller registering the EventTypes of the events sent to the
+// Brokers, and is called by the generated code.
+// Registers event handlers to enqueue events
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	brokerInformer := brokerinformer.Get(ctx)
+	eventTypeInformer := eventtypeinformer.Get(ctx)
+	configmapInformer := configmapinformer.Get(ctx)
+	roleInformer := roleinformer.Get(ctx)
+	roleBindingInformer := rolebindinginformer.Get(ctx)
+
+	r := &Reconciler{
+		eventingClientSet: eventingclient.Get(ctx),
+		kubeClientSet:     kubeclient.Get(ctx),
+		brokerLister:      brokerInformer.Lister(),
+		eventTypeLister:   eventTypeInformer.Lister(),
+		configmapLister:   configmapInformer.Lister(),
+		access: &access.Reconciler{
+			KubeClientSet:     kubeclient.Get(ctx),
+			RoleLister:        roleInformer.Lister(),
+			RoleBindingLister: roleBindingInformer.Lister(),
+		},
+		ingressServiceAccount: types.NamespacedName{Namespace: system.Namespace(), Name: names.BrokerIngressServiceAccountName},
+		writeLimiter:          rate.NewLimiter(eventTypeWritesPerSecond, eventTypeWritesBurst),
+		now:                   time.Now,
+	}
+	impl := controller.NewImpl(r, logger, ReconcilerName)
+	r.enqueueAfter = impl.EnqueueKeyAfter
+
+	logger.Info("Setting up event handlers")
+
+	brokerInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: pkgreconciler.AnnotationFilterFunc(eventing.BrokerClassKey, eventing.MTChannelBrokerClassValue, false /*allowUnset*/),
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	// Reconcile the Broker when the event types observed by its ingress change.
+	configmapInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: pkgreconciler.LabelExistsFilterFunc(broker.ObservedEventTypesLabelKey),
+		Handler:    controller.HandleAll(impl.EnqueueLabelOfNamespaceScopedResource("", eventing.BrokerLabelKey)),
+	})
+
+	// Reconcile the Broker when the Role, or the RoleBinding, granting its ingress access to the
+	// ConfigMap changes.
+	roleInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(eventingv1.Kind("Broker")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+	roleBindingInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(eventingv1.Kind("Broker")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	// Reconcile the Broker when its registered EventTypes change.
+	eventTypeInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: pkgreconciler.ChainFilterFuncs(
+			pkgreconciler.LabelExistsFilterFunc(resources.RegisteredEventTypeLabelKey),
+			controller.FilterControllerGK(eventingv1.Kind("Broker"))),
+		Handler: controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	return impl
+}
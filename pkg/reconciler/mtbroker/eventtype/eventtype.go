@@ -0,0 +1,261 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mteventtype
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	clientset "knative.dev/eventing/pkg/client/clientset/versioned"
+	eventinglisters "knative.dev/eventing/pkg/client/listers/eventing/v1"
+	eventinglistersv1beta1 "knative.dev/eventing/pkg/client/listers/eventing/v1beta1"
+	broker "knative.dev/eventing/pkg/mtbroker"
+	"knative.dev/eventing/pkg/reconciler/access"
+	"knative.dev/eventing/pkg/reconciler/mtbroker/resources"
+)
+
+const (
+	// observedEventTypeTTL is how long the EventTypes of the events no longer sent are kept.
+	observedEventTypeTTL = 24 * time.Hour
+)
+
+type Reconciler struct {
+	eventingClientSet clientset.Interface
+	kubeClientSet     kubernetes.Interface
+
+	brokerLister    eventinglisters.BrokerLister
+	eventTypeLister eventinglistersv1beta1.EventTypeLister
+	configmapLister corev1listers.ConfigMapLister
+
+	// access grants ingressServiceAccount, the ServiceAccount of the broker ingress, access to the
+	// ConfigMaps of the event types observed for the Brokers.
+	access                *access.Reconciler
+	ingressServiceAccount types.NamespacedName
+
+	// writeLimiter limits the EventTypes created and deleted, across all the Brokers.
+	writeLimiter *rate.Limiter
+	// enqueueAfter reconciles the Broker again, to write the EventTypes the writeLimiter
+	// delayed and to delete the expired ones.
+	enqueueAfter func(types.NamespacedName, time.Duration)
+	now          func() time.Time
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Errorw("invalid resource key", zap.String("key", key))
+		return nil
+	}
+
+	b, err := r.brokerLister.Brokers(namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		// The EventTypes and the ConfigMap of the Broker are garbage collected with it.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !b.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	return r.reconcileEventTypes(ctx, b)
+}
+
+func (r *Reconciler) reconcileEventTypes(ctx context.Context, b *eventingv1.Broker) error {
+	logger := logging.FromContext(ctx)
+	key := types.NamespacedName{Namespace: b.Namespace, Name: b.Name}
+
+	observed, err := r.observedEventTypes(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	expected := make(map[string]*v1beta1.EventType, len(observed))
+	now := r.now()
+	var nextExpiry time.Time
+	for i := range observed {
+		o := &observed[i]
+		expiry := o.LastSeen.Add(observedEventTypeTTL)
+		if nextExpiry.IsZero() || expiry.Before(nextExpiry) {
+			nextExpiry = expiry
+		}
+		et := resources.MakeRegisteredEventType(b, o)
+		expected[et.Name] = et
+	}
+
+	current, err := r.eventTypeLister.EventTypes(b.Namespace).List(labels.SelectorFromSet(resources.RegisteredEventTypeLabels(b.Name)))
+	if err != nil {
+		return err
+	}
+	currentNames := make(map[string]bool, len(current))
+	var toDelete []string
+	for _, et := range current {
+		if !metav1.IsControlledBy(et, b) {
+			continue
+		}
+		currentNames[et.Name] = true
+		if _, ok := expected[et.Name]; !ok {
+			toDelete = append(toDelete, et.Name)
+		}
+	}
+	var toCreate []*v1beta1.EventType
+	for name, et := range expected {
+		if !currentNames[name] {
+			toCreate = append(toCreate, et)
+		}
+	}
+	// Write them in a predictable order.
+	sort.Strings(toDelete)
+	sort.Slice(toCreate, func(i, j int) bool { return toCreate[i].Name < toCreate[j].Name })
+
+	eventTypes := r.eventingClientSet.EventingV1beta1().EventTypes(b.Namespace)
+	for _, name := range toDelete {
+		if !r.writeLimiter.Allow() {
+			r.enqueueAfter(key, r.writeDelay())
+			return nil
+		}
+		if err := eventTypes.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			logger.Errorw("Error deleting eventType", zap.String("eventType", name), zap.Error(err))
+			return err
+		}
+	}
+	for _, et := range toCreate {
+		if !r.writeLimiter.Allow() {
+			r.enqueueAfter(key, r.writeDelay())
+			return nil
+		}
+		if _, err := eventTypes.Create(ctx, et, metav1.CreateOptions{}); err != nil && !apierrs.IsAlreadyExists(err) {
+			logger.Errorw("Error creating eventType", zap.Any("eventType", et), zap.Error(err))
+			return err
+		}
+	}
+
+	if !nextExpiry.IsZero() {
+		r.enqueueAfter(key, nextExpiry.Sub(now))
+	}
+	return nil
+}
+
+// observedEventTypes returns the event types observed by the ingress of b, none if b doesn't
+// register its EventTypes. The ConfigMap of b is created empty for its ingress to write the
+// event types to, the expired event types are removed from it, and it is deleted if b no
+// longer registers its EventTypes.
+func (r *Reconciler) observedEventTypes(ctx context.Context, b *eventingv1.Broker) ([]broker.ObservedEventType, error) {
+	name := broker.ObservedEventTypesConfigMapName(b.Name)
+	registers := registersEventTypes(b)
+	if err := r.reconcileIngressAccess(ctx, b, name, registers); err != nil {
+		return nil, err
+	}
+
+	configMaps := r.kubeClientSet.CoreV1().ConfigMaps(b.Namespace)
+	cm, err := r.configmapLister.ConfigMaps(b.Namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		if !registers {
+			return nil, nil
+		}
+		if _, err := configMaps.Create(ctx, broker.MakeObservedEventTypesConfigMap(b), metav1.CreateOptions{}); err != nil && !apierrs.IsAlreadyExists(err) {
+			return nil, err
+		}
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !metav1.IsControlledBy(cm, b) {
+		return nil, nil
+	}
+
+	if !registers {
+		if err := configMaps.Delete(ctx, cm.Name, metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	observed := broker.ObservedEventTypes(cm)
+	live := observed[:0]
+	expired := false
+	for _, o := range observed {
+		if r.now().Sub(o.LastSeen) >= observedEventTypeTTL {
+			expired = true
+		} else {
+			live = append(live, o)
+		}
+	}
+	if expired {
+		pruned := cm.DeepCopy()
+		pruned.Data = nil
+		for i := range live {
+			broker.SetObservedEventType(pruned, &live[i])
+		}
+		if _, err := configMaps.Update(ctx, pruned, metav1.UpdateOptions{}); err != nil {
+			// Pruning is best effort, the expired event types are skipped anyway.
+			logging.FromContext(ctx).Warnw("Failed to prune the expired event types", zap.Error(err))
+		}
+	}
+	return live, nil
+}
+
+// reconcileIngressAccess grants the broker ingress access to the ConfigMap name of the event
+// types observed for b, and revokes it once b no longer registers its EventTypes.
+func (r *Reconciler) reconcileIngressAccess(ctx context.Context, b *eventingv1.Broker, name string, registers bool) error {
+	var resourceNames []string
+	if registers {
+		resourceNames = []string{name}
+	}
+	err := r.access.Reconcile(ctx, b, access.Grant{
+		Name:           kmeta.ChildName(b.Name, "-ingress-eventtypes"),
+		Resource:       "configmaps",
+		Verbs:          []string{"get", "update"},
+		ResourceNames:  resourceNames,
+		ServiceAccount: r.ingressServiceAccount,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Errorw("Failed to grant the broker ingress access to the observed event types", zap.Error(err))
+	}
+	return err
+}
+
+// writeDelay returns about how long to wait for the writeLimiter to allow a write.
+func (r *Reconciler) writeDelay() time.Duration {
+	reservation := r.writeLimiter.Reserve()
+	defer reservation.Cancel()
+	if delay := reservation.Delay(); delay > 0 {
+		return delay
+	}
+	return time.Second
+}
+
+func registersEventTypes(b *eventingv1.Broker) bool {
+	annotations := b.GetAnnotations()
+	return annotations[eventing.BrokerClassKey] == eventing.MTChannelBrokerClassValue &&
+		annotations[eventing.BrokerEventTypeRegistrationAnnotationKey] == eventing.BrokerEventTypeRegistrationAuto
+}
@@ -0,0 +1,319 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mteventtype
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/kmeta"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	eventingfake "knative.dev/eventing/pkg/client/clientset/versioned/fake"
+	eventinglisters "knative.dev/eventing/pkg/client/listers/eventing/v1"
+	eventinglistersv1beta1 "knative.dev/eventing/pkg/client/listers/eventing/v1beta1"
+	broker "knative.dev/eventing/pkg/mtbroker"
+	"knative.dev/eventing/pkg/reconciler/access"
+	"knative.dev/eventing/pkg/reconciler/mtbroker/resources"
+	reconcilerresources "knative.dev/eventing/pkg/reconciler/resources"
+)
+
+const (
+	testNS     = "test-namespace"
+	brokerName = "test-broker"
+	roleName   = "test-broker-ingress-eventtypes"
+)
+
+var ingressServiceAccount = types.NamespacedName{Namespace: "knative-eventing", Name: "mt-broker-ingress"}
+
+var now = time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func TestReconcile(t *testing.T) {
+	recent := now.Add(-time.Hour)
+	expired := now.Add(-observedEventTypeTTL)
+
+	orderCreated := &broker.ObservedEventType{Type: "order.created", Source: "/orders", LastSeen: recent}
+	orderShipped := &broker.ObservedEventType{Type: "order.shipped", Source: "/orders", Schema: "/schemas/shipped", LastSeen: recent.Add(time.Minute)}
+	orderLost := &broker.ObservedEventType{Type: "order.lost", Source: "/orders", LastSeen: expired}
+
+	testCases := map[string]struct {
+		broker         *eventingv1.Broker
+		observed       []*broker.ObservedEventType
+		eventTypes     []*broker.ObservedEventType
+		granted        bool
+		burst          int
+		wantEventTypes []*broker.ObservedEventType
+		wantObserved   []string
+		wantConfigMap  bool
+		wantGranted    bool
+		wantEnqueue    time.Duration
+	}{
+		"register observed event types": {
+			broker:         makeBroker(eventing.BrokerEventTypeRegistrationAuto),
+			observed:       []*broker.ObservedEventType{orderCreated, orderShipped},
+			wantEventTypes: []*broker.ObservedEventType{orderCreated, orderShipped},
+			wantObserved:   []string{"order.created", "order.shipped"},
+			wantConfigMap:  true,
+			wantGranted:    true,
+			wantEnqueue:    observedEventTypeTTL - time.Hour,
+		},
+		"already registered": {
+			broker:         makeBroker(eventing.BrokerEventTypeRegistrationAuto),
+			observed:       []*broker.ObservedEventType{orderCreated},
+			eventTypes:     []*broker.ObservedEventType{orderCreated},
+			wantEventTypes: []*broker.ObservedEventType{orderCreated},
+			wantObserved:   []string{"order.created"},
+			wantConfigMap:  true,
+			wantGranted:    true,
+			wantEnqueue:    observedEventTypeTTL - time.Hour,
+		},
+		"expired event type": {
+			broker:         makeBroker(eventing.BrokerEventTypeRegistrationAuto),
+			observed:       []*broker.ObservedEventType{orderCreated, orderLost},
+			eventTypes:     []*broker.ObservedEventType{orderCreated, orderLost},
+			wantEventTypes: []*broker.ObservedEventType{orderCreated},
+			wantObserved:   []string{"order.created"},
+			wantConfigMap:  true,
+			wantGranted:    true,
+			wantEnqueue:    observedEventTypeTTL - time.Hour,
+		},
+		"registration disabled": {
+			broker:     makeBroker(eventing.BrokerEventTypeRegistrationNone),
+			observed:   []*broker.ObservedEventType{orderCreated},
+			eventTypes: []*broker.ObservedEventType{orderCreated},
+			granted:    true,
+		},
+		"no observed event types": {
+			broker:        makeBroker(eventing.BrokerEventTypeRegistrationAuto),
+			eventTypes:    []*broker.ObservedEventType{orderCreated},
+			wantConfigMap: true,
+			wantGranted:   true,
+		},
+		"rate limited": {
+			broker:         makeBroker(eventing.BrokerEventTypeRegistrationAuto),
+			observed:       []*broker.ObservedEventType{orderCreated, orderShipped},
+			burst:          1,
+			wantEventTypes: []*broker.ObservedEventType{firstByName(orderCreated, orderShipped)},
+			wantObserved:   []string{"order.created", "order.shipped"},
+			wantConfigMap:  true,
+			wantGranted:    true,
+			wantEnqueue:    -1,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			ctx := context.Background()
+			b := tc.broker
+
+			kubeClient := kubefake.NewSimpleClientset()
+			configMaps := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if tc.observed != nil {
+				cm := makeConfigMap(b, tc.observed...)
+				if _, err := kubeClient.CoreV1().ConfigMaps(testNS).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+				if err := configMaps.Add(cm); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			eventingClient := eventingfake.NewSimpleClientset()
+			eventTypes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, o := range tc.eventTypes {
+				et := resources.MakeRegisteredEventType(b, o)
+				if _, err := eventingClient.EventingV1beta1().EventTypes(testNS).Create(ctx, et, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+				if err := eventTypes.Add(et); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			roles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			roleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if tc.granted {
+				role := reconcilerresources.MakeRole(b, roleName, "configmaps", []string{"get", "update"}, []string{broker.ObservedEventTypesConfigMapName(brokerName)})
+				if _, err := kubeClient.RbacV1().Roles(testNS).Create(ctx, role, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+				if err := roles.Add(role); err != nil {
+					t.Fatal(err)
+				}
+				rb := reconcilerresources.MakeRoleBinding(b, roleName, ingressServiceAccount)
+				if _, err := kubeClient.RbacV1().RoleBindings(testNS).Create(ctx, rb, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+				if err := roleBindings.Add(rb); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			brokers := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if err := brokers.Add(b); err != nil {
+				t.Fatal(err)
+			}
+
+			burst := tc.burst
+			if burst == 0 {
+				burst = 100
+			}
+			var enqueued []time.Duration
+			r := &Reconciler{
+				eventingClientSet: eventingClient,
+				kubeClientSet:     kubeClient,
+				brokerLister:      eventinglisters.NewBrokerLister(brokers),
+				eventTypeLister:   eventinglistersv1beta1.NewEventTypeLister(eventTypes),
+				configmapLister:   corev1listers.NewConfigMapLister(configMaps),
+				access: &access.Reconciler{
+					KubeClientSet:     kubeClient,
+					RoleLister:        rbacv1listers.NewRoleLister(roles),
+					RoleBindingLister: rbacv1listers.NewRoleBindingLister(roleBindings),
+				},
+				ingressServiceAccount: ingressServiceAccount,
+				writeLimiter:          rate.NewLimiter(rate.Every(time.Hour), burst),
+				enqueueAfter: func(key types.NamespacedName, after time.Duration) {
+					if key != (types.NamespacedName{Namespace: testNS, Name: brokerName}) {
+						t.Error("Unexpected key enqueued:", key)
+					}
+					enqueued = append(enqueued, after)
+				},
+				now: func() time.Time { return now },
+			}
+
+			if err := r.Reconcile(ctx, testNS+"/"+brokerName); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+
+			list, err := eventingClient.EventingV1beta1().EventTypes(testNS).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, want []v1beta1.EventTypeSpec
+			for _, et := range list.Items {
+				got = append(got, et.Spec)
+			}
+			for _, o := range tc.wantEventTypes {
+				want = append(want, resources.MakeRegisteredEventType(b, o).Spec)
+			}
+			sortSpecs(got)
+			sortSpecs(want)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Error("Unexpected EventTypes (-want, +got):", diff)
+			}
+
+			cm, err := kubeClient.CoreV1().ConfigMaps(testNS).Get(ctx, broker.ObservedEventTypesConfigMapName(brokerName), metav1.GetOptions{})
+			if gotConfigMap := !apierrs.IsNotFound(err); gotConfigMap != tc.wantConfigMap {
+				t.Fatalf("Unexpected ConfigMap existence, want %t, got %t", tc.wantConfigMap, gotConfigMap)
+			}
+			if tc.wantConfigMap {
+				var gotObserved []string
+				for _, o := range broker.ObservedEventTypes(cm) {
+					gotObserved = append(gotObserved, o.Type)
+				}
+				sort.Strings(gotObserved)
+				if diff := cmp.Diff(tc.wantObserved, gotObserved); diff != "" {
+					t.Error("Unexpected observed event types (-want, +got):", diff)
+				}
+			}
+
+			_, roleErr := kubeClient.RbacV1().Roles(testNS).Get(ctx, roleName, metav1.GetOptions{})
+			_, rbErr := kubeClient.RbacV1().RoleBindings(testNS).Get(ctx, roleName, metav1.GetOptions{})
+			if gotGranted := roleErr == nil && rbErr == nil; gotGranted != tc.wantGranted {
+				t.Errorf("Unexpected ingress access, want %t, got %t (role error %v, role binding error %v)", tc.wantGranted, gotGranted, roleErr, rbErr)
+			}
+
+			switch {
+			case tc.wantEnqueue == 0 && len(enqueued) != 0:
+				t.Error("Unexpected enqueue after", enqueued)
+			case tc.wantEnqueue > 0 && (len(enqueued) != 1 || enqueued[0] != tc.wantEnqueue):
+				t.Errorf("Expected an enqueue after %v, got %v", tc.wantEnqueue, enqueued)
+			case tc.wantEnqueue < 0 && len(enqueued) != 1:
+				t.Error("Expected an enqueue, got", enqueued)
+			}
+		})
+	}
+}
+
+func TestReconcileMissingBroker(t *testing.T) {
+	brokers := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	r := &Reconciler{brokerLister: eventinglisters.NewBrokerLister(brokers)}
+	if err := r.Reconcile(context.Background(), testNS+"/"+brokerName); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+}
+
+func makeBroker(registration string) *eventingv1.Broker {
+	return &eventingv1.Broker{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNS,
+			Name:      brokerName,
+			UID:       "test-broker-uid",
+			Annotations: map[string]string{
+				eventing.BrokerClassKey:                           eventing.MTChannelBrokerClassValue,
+				eventing.BrokerEventTypeRegistrationAnnotationKey: registration,
+			},
+		},
+	}
+}
+
+func makeConfigMap(b *eventingv1.Broker, observed ...*broker.ObservedEventType) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: b.Namespace,
+			Name:      broker.ObservedEventTypesConfigMapName(b.Name),
+			Labels: map[string]string{
+				eventing.BrokerLabelKey:           b.Name,
+				broker.ObservedEventTypesLabelKey: "true",
+			},
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(b)},
+		},
+	}
+	for _, o := range observed {
+		broker.SetObservedEventType(cm, o)
+	}
+	return cm
+}
+
+// firstByName returns the event type whose EventType is created first.
+func firstByName(observed ...*broker.ObservedEventType) *broker.ObservedEventType {
+	b := makeBroker(eventing.BrokerEventTypeRegistrationAuto)
+	first := observed[0]
+	for _, o := range observed[1:] {
+		if resources.MakeRegisteredEventType(b, o).Name < resources.MakeRegisteredEventType(b, first).Name {
+			first = o
+		}
+	}
+	return first
+}
+
+func sortSpecs(specs []v1beta1.EventTypeSpec) {
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Type < specs[j].Type })
+}
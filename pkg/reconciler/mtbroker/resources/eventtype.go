@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"crypto/md5" //nolint:gosec // No strong cryptography needed.
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	broker "knative.dev/eventing/pkg/mtbroker"
+)
+
+const (
+	// RegisteredEventTypeLabelKey is the label key on the EventTypes registered from the events
+	// sent to their Broker.
+	RegisteredEventTypeLabelKey = eventing.GroupName + "/registered"
+)
+
+// RegisteredEventTypeLabels returns the labels of the EventTypes registered for the Broker
+// named brokerName.
+func RegisteredEventTypeLabels(brokerName string) map[string]string {
+	return map[string]string{
+		eventing.BrokerLabelKey:     brokerName,
+		RegisteredEventTypeLabelKey: "true",
+	}
+}
+
+// MakeRegisteredEventType returns the EventType of the event type o, observed by the ingress
+// of b.
+func MakeRegisteredEventType(b *eventingv1.Broker, o *broker.ObservedEventType) *v1beta1.EventType {
+	// Like for the EventTypes of the Sources, name it with the hash of its fields as a type
+	// may be too long for a name.
+	fixedName := fmt.Sprintf("%x", md5.Sum([]byte(o.Type+o.Source+o.Schema+string(b.GetUID())))) //nolint:gosec // No strong cryptography needed.
+	et := &v1beta1.EventType{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fixedName,
+			Namespace: b.Namespace,
+			Labels:    RegisteredEventTypeLabels(b.Name),
+			OwnerReferences: []metav1.OwnerReference{
+				*kmeta.NewControllerRef(b),
+			},
+		},
+		Spec: v1beta1.EventTypeSpec{
+			Type:        o.Type,
+			Broker:      b.Name,
+			Description: "Registered from the events sent to the Broker",
+		},
+	}
+	// The source and schema are URI-references, the invalid ones are left out.
+	if source, err := apis.ParseURL(o.Source); err == nil {
+		et.Spec.Source = source
+	}
+	if schema, err := apis.ParseURL(o.Schema); err == nil {
+		et.Spec.Schema = schema
+	}
+	return et
+}
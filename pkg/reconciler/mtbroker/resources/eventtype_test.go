@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"crypto/md5" //nolint:gosec // No strong cryptography needed.
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/ptr"
+
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	broker "knative.dev/eventing/pkg/mtbroker"
+)
+
+func TestMakeRegisteredEventType(t *testing.T) {
+	b := &eventingv1.Broker{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "brokers-namespace",
+			Name:      "my-broker",
+			UID:       "1234",
+		},
+	}
+	testCases := map[string]struct {
+		observed   broker.ObservedEventType
+		wantSource *apis.URL
+		wantSchema *apis.URL
+	}{
+		"source and schema": {
+			observed:   broker.ObservedEventType{Type: "order.created", Source: "/orders", Schema: "https://example.com/order.json"},
+			wantSource: &apis.URL{Path: "/orders"},
+			wantSchema: &apis.URL{Scheme: "https", Host: "example.com", Path: "/order.json"},
+		},
+		"no schema": {
+			observed:   broker.ObservedEventType{Type: "order.created", Source: "/orders"},
+			wantSource: &apis.URL{Path: "/orders"},
+		},
+		"invalid source": {
+			observed: broker.ObservedEventType{Type: "order.created", Source: "%zz"},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			o := tc.observed
+			want := &v1beta1.EventType{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%x", md5.Sum([]byte(o.Type+o.Source+o.Schema+"1234"))), //nolint:gosec // No strong cryptography needed.
+					Namespace: "brokers-namespace",
+					Labels: map[string]string{
+						"eventing.knative.dev/broker":     "my-broker",
+						"eventing.knative.dev/registered": "true",
+					},
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion:         "eventing.knative.dev/v1",
+						Kind:               "Broker",
+						Name:               "my-broker",
+						UID:                "1234",
+						BlockOwnerDeletion: ptr.Bool(true),
+						Controller:         ptr.Bool(true),
+					}},
+				},
+				Spec: v1beta1.EventTypeSpec{
+					Type:        o.Type,
+					Source:      tc.wantSource,
+					Schema:      tc.wantSchema,
+					Broker:      "my-broker",
+					Description: "Registered from the events sent to the Broker",
+				},
+			}
+			if diff := cmp.Diff(want, MakeRegisteredEventType(b, &o)); diff != "" {
+				t.Error("Unexpected EventType (-want, +got):", diff)
+			}
+		})
+	}
+}
@@ -60,11 +60,67 @@ func NewSubscription(t *eventingv1.Trigger, brokerTrigger, brokerRef *corev1.Obj
 					Namespace:  brokerRef.Namespace,
 				},
 			},
-			Delivery: delivery,
+			Delivery: filterDelivery(delivery),
 		},
 	}
 }
 
+// IsPerBrokerDelivery returns true if the Broker 'b' subscribes once to its trigger channel and
+// evaluates all its Triggers in one pass, rather than subscribing each Trigger.
+func IsPerBrokerDelivery(b *eventingv1.Broker) bool {
+	return b.GetAnnotations()[eventing.BrokerDeliveryModeAnnotationKey] == eventing.BrokerDeliveryModePerBroker
+}
+
+// BrokerSubscriptionName returns the name of the Subscription through which the Broker 'b' receives
+// the events of its trigger channel, when its Triggers are evaluated in one pass.
+func BrokerSubscriptionName(b *eventingv1.Broker) string {
+	return kmeta.ChildName(fmt.Sprintf("%s-broker-", b.Name), string(b.GetUID()))
+}
+
+// NewBrokerSubscription returns a placeholder subscription for broker 'b', from brokerTrigger to 'uri'.
+// The subscription has no reply, as the filter forwards the subscribers' replies to the Broker itself.
+func NewBrokerSubscription(b *eventingv1.Broker, brokerTrigger *corev1.ObjectReference, uri *apis.URL, delivery *eventingduckv1.DeliverySpec) *messagingv1.Subscription {
+	return &messagingv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: b.Namespace,
+			Name:      BrokerSubscriptionName(b),
+			OwnerReferences: []metav1.OwnerReference{
+				*kmeta.NewControllerRef(b),
+			},
+			Labels: map[string]string{
+				eventing.BrokerLabelKey: b.Name,
+			},
+		},
+		Spec: messagingv1.SubscriptionSpec{
+			Channel: corev1.ObjectReference{
+				APIVersion: brokerTrigger.APIVersion,
+				Kind:       brokerTrigger.Kind,
+				Name:       brokerTrigger.Name,
+			},
+			Subscriber: &duckv1.Destination{
+				URI: uri,
+			},
+			Delivery: filterDelivery(delivery),
+		},
+	}
+}
+
+// filterDelivery returns the delivery of a Subscription whose subscriber is the filter. The filter
+// authenticates to the subscriber of the Trigger itself, so only the credentials of the dead letter
+// sink are kept.
+func filterDelivery(delivery *eventingduckv1.DeliverySpec) *eventingduckv1.DeliverySpec {
+	if delivery == nil || delivery.Auth == nil {
+		return delivery
+	}
+	delivery = delivery.DeepCopy()
+	if delivery.Auth.DeadLetterSinkSecretName == "" {
+		delivery.Auth = nil
+	} else {
+		delivery.Auth.SubscriberSecretName = ""
+	}
+	return delivery
+}
+
 // SubscriptionLabels generates the labels present on the Subscription linking this Trigger to the
 // Broker's Channels.
 func SubscriptionLabels(t *eventingv1.Trigger) map[string]string {
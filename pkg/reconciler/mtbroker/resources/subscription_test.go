@@ -103,3 +103,102 @@ func TestNewSubscription(t *testing.T) {
 		t.Error("unexpected diff (-want, +got) =", diff)
 	}
 }
+
+func TestNewBrokerSubscription(t *testing.T) {
+	var TrueValue = true
+	broker := &eventingv1.Broker{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "b-namespace",
+			Name:      "b-name",
+			UID:       "b-uid",
+		},
+	}
+	triggerChannelRef := &corev1.ObjectReference{
+		Name:       "tc-name",
+		Kind:       "tc-kind",
+		APIVersion: "tc-apiVersion",
+	}
+	delivery := &eventingduckv1.DeliverySpec{
+		DeadLetterSink: &duckv1.Destination{
+			URI: apis.HTTP("dlc.example.com"),
+		},
+	}
+	got := NewBrokerSubscription(broker, triggerChannelRef, apis.HTTP("example.com"), delivery)
+	want := &messagingv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "b-namespace",
+			Name:      "b-name-broker-b-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion:         "eventing.knative.dev/v1",
+				Kind:               "Broker",
+				Name:               "b-name",
+				UID:                "b-uid",
+				Controller:         &TrueValue,
+				BlockOwnerDeletion: &TrueValue,
+			}},
+			Labels: map[string]string{
+				eventing.BrokerLabelKey: "b-name",
+			},
+		},
+		Spec: messagingv1.SubscriptionSpec{
+			Channel: corev1.ObjectReference{
+				Name:       "tc-name",
+				Kind:       "tc-kind",
+				APIVersion: "tc-apiVersion",
+			},
+			Subscriber: &duckv1.Destination{
+				URI: apis.HTTP("example.com"),
+			},
+			Delivery: &eventingduckv1.DeliverySpec{
+				DeadLetterSink: &duckv1.Destination{
+					URI: apis.HTTP("dlc.example.com"),
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected difference (-want, +got): %v", diff)
+	}
+}
+
+func TestNewSubscriptionDeliveryAuth(t *testing.T) {
+	trigger := &eventingv1.Trigger{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "t-namespace",
+			Name:      "t-name",
+		},
+		Spec: eventingv1.TriggerSpec{
+			Broker: "broker-name",
+		},
+	}
+	triggerChannelRef := &corev1.ObjectReference{Name: "tc-name"}
+	brokerRef := &corev1.ObjectReference{Name: "broker-name"}
+
+	tests := []struct {
+		name string
+		auth *eventingduckv1.DeliveryAuth
+		want *eventingduckv1.DeliveryAuth
+	}{{
+		name: "subscriber and dead letter sink",
+		auth: &eventingduckv1.DeliveryAuth{SubscriberSecretName: "subscriber-auth", DeadLetterSinkSecretName: "dls-auth"},
+		want: &eventingduckv1.DeliveryAuth{DeadLetterSinkSecretName: "dls-auth"},
+	}, {
+		name: "subscriber only",
+		auth: &eventingduckv1.DeliveryAuth{SubscriberSecretName: "subscriber-auth"},
+		want: nil,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delivery := &eventingduckv1.DeliverySpec{Auth: test.auth}
+			original := delivery.DeepCopy()
+			// The filter authenticates to the subscriber, not the channel dispatcher.
+			got := NewSubscription(trigger, triggerChannelRef, brokerRef, apis.HTTP("example.com"), delivery)
+			if diff := cmp.Diff(test.want, got.Spec.Delivery.Auth); diff != "" {
+				t.Error("unexpected auth (-want, +got) =", diff)
+			}
+			if diff := cmp.Diff(original, delivery); diff != "" {
+				t.Error("the delivery of the Trigger was modified (-want, +got) =", diff)
+			}
+		})
+	}
+}
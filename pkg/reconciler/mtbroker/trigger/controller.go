@@ -21,9 +21,11 @@ import (
 
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
 	"knative.dev/eventing/pkg/apis/eventing"
 	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
 	eventingclient "knative.dev/eventing/pkg/client/injection/client"
 	brokerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1/broker"
 	triggerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1/trigger"
@@ -31,14 +33,20 @@ import (
 	brokerreconciler "knative.dev/eventing/pkg/client/injection/reconciler/eventing/v1/broker"
 	triggerreconciler "knative.dev/eventing/pkg/client/injection/reconciler/eventing/v1/trigger"
 	"knative.dev/eventing/pkg/duck"
+	"knative.dev/eventing/pkg/reconciler/access"
+	"knative.dev/eventing/pkg/reconciler/names"
 	"knative.dev/pkg/client/injection/ducks/duck/v1/source"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	configmapinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap"
+	roleinformer "knative.dev/pkg/client/injection/kube/informers/rbac/v1/role"
+	rolebindinginformer "knative.dev/pkg/client/injection/kube/informers/rbac/v1/rolebinding"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/injection/clients/dynamicclient"
 	"knative.dev/pkg/logging"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	"knative.dev/pkg/resolver"
+	"knative.dev/pkg/system"
 )
 
 // NewController initializes the controller and is called by the generated code
@@ -52,6 +60,8 @@ func NewController(
 	brokerInformer := brokerinformer.Get(ctx)
 	subscriptionInformer := subscriptioninformer.Get(ctx)
 	configmapInformer := configmapinformer.Get(ctx)
+	roleInformer := roleinformer.Get(ctx)
+	roleBindingInformer := rolebindinginformer.Get(ctx)
 
 	r := &Reconciler{
 		eventingClientSet:  eventingclient.Get(ctx),
@@ -60,6 +70,12 @@ func NewController(
 		brokerLister:       brokerInformer.Lister(),
 		triggerLister:      triggerInformer.Lister(),
 		configmapLister:    configmapInformer.Lister(),
+		access: &access.Reconciler{
+			KubeClientSet:     kubeclient.Get(ctx),
+			RoleLister:        roleInformer.Lister(),
+			RoleBindingLister: roleBindingInformer.Lister(),
+		},
+		filterServiceAccount: types.NamespacedName{Namespace: system.Namespace(), Name: names.BrokerFilterServiceAccountName},
 	}
 	impl := triggerreconciler.NewImpl(ctx, r)
 	r.impl = impl
@@ -73,22 +89,23 @@ func NewController(
 
 	// Filter Brokers and enqueue associated Triggers
 	brokerFilter := pkgreconciler.AnnotationFilterFunc(brokerreconciler.ClassAnnotationKey, eventing.MTChannelBrokerClassValue, false /*allowUnset*/)
+	enqueueTriggersOfBroker := func(namespace, name string) {
+		selector := labels.SelectorFromSet(map[string]string{eventing.BrokerLabelKey: name})
+		triggers, err := triggerInformer.Lister().Triggers(namespace).List(selector)
+		if err != nil {
+			logger.Warn("Failed to list triggers", zap.String("namespace", namespace), zap.String("broker", name), zap.Error(err))
+			return
+		}
+
+		for _, trigger := range triggers {
+			impl.Enqueue(trigger)
+		}
+	}
 	brokerInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: brokerFilter,
 		Handler: controller.HandleAll(func(obj interface{}) {
-
 			if broker, ok := obj.(*eventingv1.Broker); ok {
-
-				selector := labels.SelectorFromSet(map[string]string{eventing.BrokerLabelKey: broker.Name})
-				triggers, err := triggerInformer.Lister().Triggers(broker.Namespace).List(selector)
-				if err != nil {
-					logger.Warn("Failed to list triggers", zap.Any("broker", broker), zap.Error(err))
-					return
-				}
-
-				for _, trigger := range triggers {
-					impl.Enqueue(trigger)
-				}
+				enqueueTriggersOfBroker(broker.Namespace, broker.Name)
 			}
 		}),
 	})
@@ -99,5 +116,25 @@ func NewController(
 		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
 	})
 
+	// Reconcile Trigger when the Role, or the RoleBinding, granting access to its Secret changes
+	roleInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(eventingv1.Kind("Trigger")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+	roleBindingInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(eventingv1.Kind("Trigger")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	// Reconcile the Triggers of a Broker when the Broker's Subscription changes
+	subscriptionInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(eventingv1.Kind("Broker")),
+		Handler: controller.HandleAll(func(obj interface{}) {
+			if sub, ok := obj.(*messagingv1.Subscription); ok {
+				enqueueTriggersOfBroker(sub.Namespace, sub.Labels[eventing.BrokerLabelKey])
+			}
+		}),
+	})
+
 	return impl
 }
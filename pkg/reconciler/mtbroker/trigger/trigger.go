@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 
@@ -36,11 +37,13 @@ import (
 	eventinglisters "knative.dev/eventing/pkg/client/listers/eventing/v1"
 	messaginglisters "knative.dev/eventing/pkg/client/listers/messaging/v1"
 	"knative.dev/eventing/pkg/duck"
+	"knative.dev/eventing/pkg/reconciler/access"
 	"knative.dev/eventing/pkg/reconciler/mtbroker/resources"
 	"knative.dev/eventing/pkg/reconciler/sugar/trigger/path"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/network"
 	pkgreconciler "knative.dev/pkg/reconciler"
@@ -55,6 +58,7 @@ const (
 	subscriptionDeleteFailed = "SubscriptionDeleteFailed"
 	subscriptionCreateFailed = "SubscriptionCreateFailed"
 	subscriptionGetFailed    = "SubscriptionGetFailed"
+	secretAccessFailed       = "SecretAccessFailed"
 )
 
 type Reconciler struct {
@@ -67,6 +71,11 @@ type Reconciler struct {
 	triggerLister      eventinglisters.TriggerLister
 	configmapLister    corev1listers.ConfigMapLister
 
+	// access grants filterServiceAccount, the ServiceAccount of the broker filter, access to
+	// the Secret of the subscriber of the Trigger.
+	access               *access.Reconciler
+	filterServiceAccount types.NamespacedName
+
 	// Dynamic tracker to track Sources. In particular, it tracks the dependency between Triggers and Sources.
 	sourceTracker duck.ListableTracker
 
@@ -104,6 +113,10 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, t *eventingv1.Trigger) p
 	}
 	t.Status.PropagateBrokerCondition(b.Status.GetTopLevelCondition())
 
+	if err := r.reconcileSecretAccess(ctx, t); err != nil {
+		return err
+	}
+
 	// If Broker is not ready, we're done, but once it becomes ready, we'll get requeued.
 	if !b.Status.IsReady() {
 		logging.FromContext(ctx).Errorw("Broker is not ready", zap.Any("Broker", b))
@@ -131,13 +144,30 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, t *eventingv1.Trigger) p
 	t.Status.SubscriberURI = subscriberURI
 	t.Status.MarkSubscriberResolvedSucceeded()
 
-	sub, err := r.subscribeToBrokerChannel(ctx, b, t, brokerTrigger)
-	if err != nil {
-		logging.FromContext(ctx).Errorw("Unable to Subscribe", zap.Error(err))
-		t.Status.MarkNotSubscribed("NotSubscribed", "%v", err)
-		return err
+	if resources.IsPerBrokerDelivery(b) {
+		// The Broker's Subscription is shared by all its Triggers, its delivery health isn't theirs
+		// and its delivery spec is the Broker's one.
+		t.Status.PropagateSubscriptionDeliveryHealth(nil)
+		if t.Spec.Delivery != nil {
+			t.Status.MarkDeliverySpecIgnored("BrokerDeliveryModePerBroker",
+				"Broker %q delivers events once per Broker, its delivery spec applies instead of the Trigger's", b.Name)
+		} else {
+			t.Status.ClearDeliverySpecIgnored()
+		}
+		if err := r.propagateBrokerSubscription(ctx, b, t); err != nil {
+			return err
+		}
+	} else {
+		t.Status.ClearDeliverySpecIgnored()
+		sub, err := r.subscribeToBrokerChannel(ctx, b, t, brokerTrigger)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Unable to Subscribe", zap.Error(err))
+			t.Status.MarkNotSubscribed("NotSubscribed", "%v", err)
+			return err
+		}
+		t.Status.PropagateSubscriptionCondition(sub.Status.GetTopLevelCondition())
+		t.Status.PropagateSubscriptionDeliveryHealth(sub.Status.GetCondition(messagingv1.SubscriptionConditionDeliveryHealthy))
 	}
-	t.Status.PropagateSubscriptionCondition(sub.Status.GetTopLevelCondition())
 
 	if err := r.checkDependencyAnnotation(ctx, t); err != nil {
 		return err
@@ -146,6 +176,28 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, t *eventingv1.Trigger) p
 	return nil
 }
 
+// reconcileSecretAccess grants the broker filter access to the Secret holding the credentials of
+// the subscriber of the Trigger 't', if any.
+func (r *Reconciler) reconcileSecretAccess(ctx context.Context, t *eventingv1.Trigger) error {
+	var secretNames []string
+	if t.Spec.Delivery != nil && t.Spec.Delivery.Auth != nil && t.Spec.Delivery.Auth.SubscriberSecretName != "" {
+		secretNames = []string{t.Spec.Delivery.Auth.SubscriberSecretName}
+	}
+	err := r.access.Reconcile(ctx, t, access.Grant{
+		Name:           kmeta.ChildName(t.Name, "-filter-secrets"),
+		Resource:       "secrets",
+		Verbs:          []string{"get"},
+		ResourceNames:  secretNames,
+		ServiceAccount: r.filterServiceAccount,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Errorw("Failed to grant the broker filter access to the Trigger's Secret", zap.Error(err))
+		controller.GetEventRecorder(ctx).Eventf(t, corev1.EventTypeWarning, secretAccessFailed, "Granting access to the Trigger's Secret failed: %v", err)
+		return err
+	}
+	return nil
+}
+
 // subscribeToBrokerChannel subscribes service 'svc' to the Broker's channels.
 func (r *Reconciler) subscribeToBrokerChannel(ctx context.Context, b *eventingv1.Broker, t *eventingv1.Trigger, brokerTrigger *corev1.ObjectReference) (*messagingv1.Subscription, error) {
 	recorder := controller.GetEventRecorder(ctx)
@@ -163,7 +215,12 @@ func (r *Reconciler) subscribeToBrokerChannel(ctx context.Context, b *eventingv1
 		Name:       b.Name,
 		Namespace:  b.Namespace,
 	}
-	expected := resources.NewSubscription(t, brokerTrigger, brokerObjRef, uri, b.Spec.Delivery)
+	// The Trigger's delivery spec, if any, overrides the Broker's one.
+	delivery := b.Spec.Delivery
+	if t.Spec.Delivery != nil {
+		delivery = t.Spec.Delivery
+	}
+	expected := resources.NewSubscription(t, brokerTrigger, brokerObjRef, uri, delivery)
 
 	sub, err := r.subscriptionLister.Subscriptions(t.Namespace).Get(expected.Name)
 	// If the resource doesn't exist, we'll create it.
@@ -189,6 +246,32 @@ func (r *Reconciler) subscribeToBrokerChannel(ctx context.Context, b *eventingv1
 	return sub, nil
 }
 
+// propagateBrokerSubscription reflects the readiness of the Broker's Subscription, through which the
+// Trigger receives events when the Broker evaluates all its Triggers in one pass. The Trigger's own
+// Subscription, if any, is removed.
+func (r *Reconciler) propagateBrokerSubscription(ctx context.Context, b *eventingv1.Broker, t *eventingv1.Trigger) error {
+	own, err := r.subscriptionLister.Subscriptions(t.Namespace).Get(resources.NewSubscription(t, &corev1.ObjectReference{}, &corev1.ObjectReference{}, nil, nil).Name)
+	if err == nil && metav1.IsControlledBy(own, t) {
+		logging.FromContext(ctx).Infow("Deleting subscription", zap.String("namespace", own.Namespace), zap.String("name", own.Name))
+		if err := r.eventingClientSet.MessagingV1().Subscriptions(t.Namespace).Delete(ctx, own.Name, metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			controller.GetEventRecorder(ctx).Eventf(t, corev1.EventTypeWarning, subscriptionDeleteFailed, "Delete Trigger's subscription failed: %v", err)
+			return err
+		}
+	}
+
+	sub, err := r.subscriptionLister.Subscriptions(b.Namespace).Get(resources.BrokerSubscriptionName(b))
+	if apierrs.IsNotFound(err) {
+		// Once the Broker creates its Subscription, we get requeued.
+		t.Status.MarkNotSubscribed("BrokerSubscriptionNotFound", "Broker %q is not subscribed to its trigger channel", b.Name)
+		return nil
+	} else if err != nil {
+		t.Status.MarkNotSubscribed("BrokerSubscriptionGetFailed", "Failed to get the Broker's subscription: %v", err)
+		return err
+	}
+	t.Status.PropagateSubscriptionCondition(sub.Status.GetTopLevelCondition())
+	return nil
+}
+
 func (r *Reconciler) reconcileSubscription(ctx context.Context, t *eventingv1.Trigger, expected, actual *messagingv1.Subscription) (*messagingv1.Subscription, error) {
 	// Update Subscription if it has changed.
 	if equality.Semantic.DeepDerivative(expected.Spec, actual.Spec) {
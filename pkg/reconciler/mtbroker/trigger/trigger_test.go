@@ -41,13 +41,16 @@ import (
 	"knative.dev/eventing/pkg/client/injection/ducks/duck/v1/channelable"
 	"knative.dev/eventing/pkg/client/injection/reconciler/eventing/v1/trigger"
 	"knative.dev/eventing/pkg/duck"
+	"knative.dev/eventing/pkg/reconciler/access"
 	"knative.dev/eventing/pkg/reconciler/mtbroker/resources"
+	pkgresources "knative.dev/eventing/pkg/reconciler/resources"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	v1addr "knative.dev/pkg/client/injection/ducks/duck/v1/addressable"
 	"knative.dev/pkg/client/injection/ducks/duck/v1/source"
 	v1a1addr "knative.dev/pkg/client/injection/ducks/duck/v1alpha1/addressable"
 	v1b1addr "knative.dev/pkg/client/injection/ducks/duck/v1beta1/addressable"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	fakedynamicclient "knative.dev/pkg/injection/clients/dynamicclient/fake"
@@ -82,6 +85,12 @@ const (
 	subscriberGroup   = "serving.knative.dev"
 	subscriberVersion = "v1"
 
+	brokerDLS  = "broker-dls.example.com"
+	triggerDLS = "trigger-dls.example.com"
+
+	subscriberSecretName   = "subscriber-creds"
+	triggerSecretsRoleName = "test-trigger-filter-secrets"
+
 	pingSourceName              = "test-ping-source"
 	testSchedule                = "*/2 * * * *"
 	testContentType             = cloudevents.TextPlain
@@ -245,6 +254,104 @@ func TestReconcile(t *testing.T) {
 					WithTriggerSubscribedUnknown("SubscriptionNotConfigured", "Subscription has not yet been reconciled."),
 					WithTriggerStatusSubscriberURI(subscriberURI)),
 			}},
+		}, {
+			Name: "Creates subscription with the Trigger's delivery",
+			Key:  testKey,
+			Objects: []runtime.Object{
+				NewBroker(brokerName, testNS,
+					WithBrokerClass(eventing.MTChannelBrokerClassValue),
+					WithBrokerConfig(config()),
+					WithBrokerDelivery(makeDelivery(brokerDLS, 5)),
+					WithInitBrokerConditions,
+					WithBrokerReady,
+					WithChannelAddressAnnotation(triggerChannelURL),
+					WithChannelAPIVersionAnnotation(triggerChannelAPIVersion),
+					WithChannelKindAnnotation(triggerChannelKind),
+					WithChannelNameAnnotation(triggerChannelName)),
+				NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI),
+					WithTriggerDelivery(makeDelivery(triggerDLS, 1))),
+			},
+			WantCreates: []runtime.Object{
+				resources.NewSubscription(makeTrigger(), createTriggerChannelRef(), makeBrokerRef(), makeServiceURI(), makeDelivery(triggerDLS, 1)),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI),
+					WithTriggerDelivery(makeDelivery(triggerDLS, 1)),
+					WithTriggerBrokerReady(),
+					WithTriggerDependencyReady(),
+					WithTriggerSubscriberResolvedSucceeded(),
+					WithTriggerSubscribedUnknown("SubscriptionNotConfigured", "Subscription has not yet been reconciled."),
+					WithTriggerStatusSubscriberURI(subscriberURI)),
+			}},
+		}, {
+			Name: "Grants the broker filter access to the subscriber's Secret",
+			Key:  testKey,
+			Objects: []runtime.Object{
+				NewBroker(brokerName, testNS,
+					WithBrokerClass(eventing.MTChannelBrokerClassValue),
+					WithBrokerConfig(config()),
+					WithInitBrokerConditions,
+					WithBrokerReady,
+					WithChannelAddressAnnotation(triggerChannelURL),
+					WithChannelAPIVersionAnnotation(triggerChannelAPIVersion),
+					WithChannelKindAnnotation(triggerChannelKind),
+					WithChannelNameAnnotation(triggerChannelName)),
+				NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI),
+					WithTriggerDelivery(makeAuthDelivery())),
+			},
+			WantCreates: []runtime.Object{
+				resources.NewSubscription(makeTrigger(), createTriggerChannelRef(), makeBrokerRef(), makeServiceURI(), makeAuthDelivery()),
+				pkgresources.MakeRole(makeTrigger(), triggerSecretsRoleName, "secrets", []string{"get"}, []string{subscriberSecretName}),
+				pkgresources.MakeRoleBinding(makeTrigger(), triggerSecretsRoleName, types.NamespacedName{Namespace: systemNS, Name: "mt-broker-filter"}),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI),
+					WithTriggerDelivery(makeAuthDelivery()),
+					WithTriggerBrokerReady(),
+					WithTriggerDependencyReady(),
+					WithTriggerSubscriberResolvedSucceeded(),
+					WithTriggerSubscribedUnknown("SubscriptionNotConfigured", "Subscription has not yet been reconciled."),
+					WithTriggerStatusSubscriberURI(subscriberURI)),
+			}},
+		}, {
+			Name: "Creates subscription with the Broker's delivery",
+			Key:  testKey,
+			Objects: []runtime.Object{
+				NewBroker(brokerName, testNS,
+					WithBrokerClass(eventing.MTChannelBrokerClassValue),
+					WithBrokerConfig(config()),
+					WithBrokerDelivery(makeDelivery(brokerDLS, 5)),
+					WithInitBrokerConditions,
+					WithBrokerReady,
+					WithChannelAddressAnnotation(triggerChannelURL),
+					WithChannelAPIVersionAnnotation(triggerChannelAPIVersion),
+					WithChannelKindAnnotation(triggerChannelKind),
+					WithChannelNameAnnotation(triggerChannelName)),
+				NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI)),
+			},
+			WantCreates: []runtime.Object{
+				resources.NewSubscription(makeTrigger(), createTriggerChannelRef(), makeBrokerRef(), makeServiceURI(), makeDelivery(brokerDLS, 5)),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI),
+					WithTriggerBrokerReady(),
+					WithTriggerDependencyReady(),
+					WithTriggerSubscriberResolvedSucceeded(),
+					WithTriggerSubscribedUnknown("SubscriptionNotConfigured", "Subscription has not yet been reconciled."),
+					WithTriggerStatusSubscriberURI(subscriberURI)),
+			}},
 		}, {
 			Name: "Subscription Create fails",
 			Key:  testKey,
@@ -514,6 +621,85 @@ func TestReconcile(t *testing.T) {
 					WithTriggerDependencyReady(),
 				),
 			}},
+		}, {
+			Name: "Per broker delivery, broker subscription ready, trigger subscription deleted",
+			Key:  testKey,
+			Objects: perBrokerObjectsReadyPlus([]runtime.Object{
+				makeReadySubscription(),
+				makeReadyBrokerSubscription(),
+				NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI),
+					WithInitTriggerConditions,
+				)}...),
+			WantErr: false,
+			WantDeletes: []clientgotesting.DeleteActionImpl{{
+				ActionImpl: clientgotesting.ActionImpl{
+					Namespace: testNS,
+					Resource:  messagingv1.SchemeGroupVersion.WithResource("subscriptions"),
+				},
+				Name: makeReadySubscription().Name,
+			}},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI),
+					WithInitTriggerConditions,
+					WithTriggerBrokerReady(),
+					WithTriggerSubscribed(),
+					WithTriggerStatusSubscriberURI(subscriberURI),
+					WithTriggerSubscriberResolvedSucceeded(),
+					WithTriggerDependencyReady(),
+				),
+			}},
+		}, {
+			Name: "Per broker delivery, trigger delivery spec ignored",
+			Key:  testKey,
+			Objects: perBrokerObjectsReadyPlus([]runtime.Object{
+				makeReadyBrokerSubscription(),
+				NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI),
+					WithTriggerDelivery(makeDelivery(triggerDLS, 1)),
+					WithInitTriggerConditions,
+				)}...),
+			WantErr: false,
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI),
+					WithTriggerDelivery(makeDelivery(triggerDLS, 1)),
+					WithInitTriggerConditions,
+					WithTriggerBrokerReady(),
+					WithTriggerDeliverySpecIgnored("BrokerDeliveryModePerBroker", `Broker "test-broker" delivers events once per Broker, its delivery spec applies instead of the Trigger's`),
+					WithTriggerSubscribed(),
+					WithTriggerStatusSubscriberURI(subscriberURI),
+					WithTriggerSubscriberResolvedSucceeded(),
+					WithTriggerDependencyReady(),
+				),
+			}},
+		}, {
+			Name: "Per broker delivery, broker subscription not found",
+			Key:  testKey,
+			Objects: perBrokerObjectsReadyPlus([]runtime.Object{
+				NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI),
+					WithInitTriggerConditions,
+				)}...),
+			WantErr: false,
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewTrigger(triggerName, testNS, brokerName,
+					WithTriggerUID(triggerUID),
+					WithTriggerSubscriberURI(subscriberURI),
+					WithInitTriggerConditions,
+					WithTriggerBrokerReady(),
+					WithTriggerNotSubscribed("BrokerSubscriptionNotFound", `Broker "test-broker" is not subscribed to its trigger channel`),
+					WithTriggerStatusSubscriberURI(subscriberURI),
+					WithTriggerSubscriberResolvedSucceeded(),
+					WithTriggerDependencyReady(),
+				),
+			}},
 		}, {
 			Name: "Dependency doesn't exist",
 			Key:  testKey,
@@ -670,8 +856,14 @@ func TestReconcile(t *testing.T) {
 
 			brokerLister:    listers.GetBrokerLister(),
 			configmapLister: listers.GetConfigMapLister(),
-			sourceTracker:   duck.NewListableTracker(ctx, source.Get, func(types.NamespacedName) {}, 0),
-			uriResolver:     resolver.NewURIResolver(ctx, func(types.NamespacedName) {}),
+			access: &access.Reconciler{
+				KubeClientSet:     fakekubeclient.Get(ctx),
+				RoleLister:        listers.GetRoleLister(),
+				RoleBindingLister: listers.GetRoleBindingLister(),
+			},
+			filterServiceAccount: types.NamespacedName{Namespace: systemNS, Name: "mt-broker-filter"},
+			sourceTracker:        duck.NewListableTracker(ctx, source.Get, func(types.NamespacedName) {}, 0),
+			uriResolver:          resolver.NewURIResolver(ctx, func(types.NamespacedName) {}),
 		}
 		return trigger.NewReconciler(ctx, logger,
 			fakeeventingclient.Get(ctx), listers.GetTriggerLister(),
@@ -823,6 +1015,19 @@ func makeEmptyDelivery() *eventingduckv1.DeliverySpec {
 	return nil
 }
 
+func makeDelivery(deadLetterSink string, retry int32) *eventingduckv1.DeliverySpec {
+	return &eventingduckv1.DeliverySpec{
+		DeadLetterSink: &duckv1.Destination{URI: apis.HTTP(deadLetterSink)},
+		Retry:          &retry,
+	}
+}
+
+func makeAuthDelivery() *eventingduckv1.DeliverySpec {
+	return &eventingduckv1.DeliverySpec{
+		Auth: &eventingduckv1.DeliveryAuth{SubscriberSecretName: subscriberSecretName},
+	}
+}
+
 func allBrokerObjectsReadyPlus(objs ...runtime.Object) []runtime.Object {
 	brokerObjs := []runtime.Object{
 		NewBroker(brokerName, testNS,
@@ -848,6 +1053,23 @@ func allBrokerObjectsReadyPlus(objs ...runtime.Object) []runtime.Object {
 	return append(brokerObjs[:], objs...)
 }
 
+func perBrokerObjectsReadyPlus(objs ...runtime.Object) []runtime.Object {
+	brokerObjs := allBrokerObjectsReadyPlus(objs...)
+	WithBrokerDeliveryMode(eventing.BrokerDeliveryModePerBroker)(brokerObjs[0].(*eventingv1.Broker))
+	return brokerObjs
+}
+
+func makeReadyBrokerSubscription() *messagingv1.Subscription {
+	b := NewBroker(brokerName, testNS, WithBrokerDeliveryMode(eventing.BrokerDeliveryModePerBroker))
+	s := resources.NewBrokerSubscription(b, createTriggerChannelRef(), &apis.URL{
+		Scheme: "http",
+		Host:   network.GetServiceHostname("broker-filter", systemNS),
+		Path:   fmt.Sprintf("/brokers/%s/%s", testNS, brokerName),
+	}, nil)
+	s.Status = *eventingv1.TestHelper.ReadySubscriptionStatus()
+	return s
+}
+
 // Just so we can test subscription updates
 func makeDifferentReadySubscription() *messagingv1.Subscription {
 	s := makeFilterSubscription()
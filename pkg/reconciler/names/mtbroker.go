@@ -19,4 +19,8 @@ package names
 const (
 	BrokerFilterName  = "broker-filter"
 	BrokerIngressName = "broker-ingress"
+
+	// The ServiceAccounts of the broker filter and ingress in the system namespace.
+	BrokerFilterServiceAccountName  = "mt-broker-filter"
+	BrokerIngressServiceAccountName = "mt-broker-ingress"
 )
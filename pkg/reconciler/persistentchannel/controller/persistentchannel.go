@@ -0,0 +1,180 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/network"
+	pkgreconciler "knative.dev/pkg/reconciler"
+
+	v1 "knative.dev/eventing/pkg/apis/messaging/v1"
+	persistentchannelreconciler "knative.dev/eventing/pkg/client/injection/reconciler/messaging/v1/persistentchannel"
+	"knative.dev/eventing/pkg/reconciler/persistentchannel/controller/resources"
+)
+
+func newDeploymentWarn(err error) pkgreconciler.Event {
+	return pkgreconciler.NewEvent(corev1.EventTypeWarning, "DispatcherDeploymentFailed", "Reconciling dispatcher Deployment failed with: %s", err)
+}
+
+func newServiceWarn(err error) pkgreconciler.Event {
+	return pkgreconciler.NewEvent(corev1.EventTypeWarning, "DispatcherServiceFailed", "Reconciling dispatcher Service failed: %s", err)
+}
+
+type Reconciler struct {
+	kubeClientSet kubernetes.Interface
+
+	systemNamespace  string
+	deploymentLister appsv1listers.DeploymentLister
+	serviceLister    corev1listers.ServiceLister
+	endpointsLister  corev1listers.EndpointsLister
+}
+
+// Check that our Reconciler implements Interface
+var _ persistentchannelreconciler.Interface = (*Reconciler)(nil)
+
+func (r *Reconciler) ReconcileKind(ctx context.Context, pc *v1.PersistentChannel) pkgreconciler.Event {
+	logging.FromContext(ctx).Infow("Reconciling", zap.Any("PersistentChannel", pc))
+
+	// We reconcile the status of the Channel by looking at:
+	// 1. Dispatcher Deployment for it's readiness.
+	// 2. Dispatcher k8s Service for it's existence.
+	// 3. Dispatcher endpoints to ensure that there's something backing the Service.
+	// 4. k8s service representing the channel that will use ExternalName to point to the Dispatcher k8s service
+	//
+	// There is a single dispatcher, in the system namespace, as the events of all the channels
+	// are stored on its volume.
+
+	// Make sure the dispatcher deployment exists and propagate the status to the Channel
+	d, err := r.deploymentLister.Deployments(r.systemNamespace).Get(dispatcherName)
+	if err != nil {
+		if apierrs.IsNotFound(err) {
+			pc.Status.MarkDispatcherFailed("DispatcherDeploymentDoesNotExist", "Dispatcher Deployment does not exist")
+		} else {
+			logging.FromContext(ctx).Error("Unable to get the dispatcher Deployment", zap.Error(err))
+			pc.Status.MarkDispatcherFailed("DispatcherDeploymentGetFailed", "Failed to get dispatcher Deployment")
+		}
+		return newDeploymentWarn(err)
+	}
+	pc.Status.PropagateDispatcherStatus(&d.Status)
+
+	// Make sure the dispatcher service exists and propagate the status to the Channel in case it does not exist.
+	// We don't do anything with the service because it's status contains nothing useful, so just do
+	// an existence check. Then below we check the endpoints targeting it.
+	if _, err := r.serviceLister.Services(r.systemNamespace).Get(dispatcherName); err != nil {
+		if apierrs.IsNotFound(err) {
+			pc.Status.MarkServiceFailed("DispatcherServiceDoesNotExist", "Dispatcher Service does not exist")
+		} else {
+			logging.FromContext(ctx).Error("Unable to get the dispatcher service", zap.Error(err))
+			pc.Status.MarkServiceFailed("DispatcherServiceGetFailed", "Failed to get dispatcher service")
+		}
+		return newServiceWarn(err)
+	}
+	pc.Status.MarkServiceTrue()
+
+	// Get the Dispatcher Service Endpoints and propagate the status to the Channel
+	// endpoints has the same name as the service, so not a bug.
+	e, err := r.endpointsLister.Endpoints(r.systemNamespace).Get(dispatcherName)
+	if err != nil {
+		if apierrs.IsNotFound(err) {
+			logging.FromContext(ctx).Error("Endpoints do not exist for dispatcher service")
+			pc.Status.MarkEndpointsFailed("DispatcherEndpointsDoesNotExist", "Dispatcher Endpoints does not exist")
+		} else {
+			logging.FromContext(ctx).Error("Unable to get the dispatcher endpoints", zap.Error(err))
+			pc.Status.MarkEndpointsUnknown("DispatcherEndpointsGetFailed", "Failed to get dispatcher endpoints")
+		}
+		return err
+	}
+
+	if len(e.Subsets) == 0 {
+		logging.FromContext(ctx).Error("No endpoints found for Dispatcher service", zap.Error(err))
+		pc.Status.MarkEndpointsFailed("DispatcherEndpointsNotReady", "There are no endpoints ready for Dispatcher service")
+		return errors.New("there are no endpoints ready for Dispatcher service")
+	}
+
+	pc.Status.MarkEndpointsTrue()
+
+	// Reconcile the k8s service representing the actual Channel. It points to the Dispatcher service via
+	// ExternalName
+	svc, err := r.reconcileChannelService(ctx, pc)
+	if err != nil {
+		logging.FromContext(ctx).Errorw("Failed to reconcile channel service", zap.Error(err))
+		return err
+	}
+	pc.Status.MarkChannelServiceTrue()
+	pc.Status.SetAddress(apis.HTTP(network.GetServiceHostname(svc.Name, svc.Namespace)))
+
+	logging.FromContext(ctx).Debugw("Reconciled PersistentChannel", zap.Any("PersistentChannel", pc))
+	return nil
+}
+
+func (r *Reconciler) reconcileChannelService(ctx context.Context, pc *v1.PersistentChannel) (*corev1.Service, error) {
+	expected, err := resources.NewK8sService(pc, resources.ExternalService(r.systemNamespace, dispatcherName))
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create the channel service object", zap.Error(err))
+		pc.Status.MarkChannelServiceFailed("ChannelServiceFailed", fmt.Sprint("Channel Service failed: ", err))
+		return nil, err
+	}
+
+	channelSvcName := resources.CreateChannelServiceName(pc.Name)
+
+	svc, err := r.serviceLister.Services(pc.Namespace).Get(channelSvcName)
+	if err != nil {
+		if apierrs.IsNotFound(err) {
+			svc, err = r.kubeClientSet.CoreV1().Services(pc.Namespace).Create(ctx, expected, metav1.CreateOptions{})
+			if err != nil {
+				logging.FromContext(ctx).Error("failed to create the channel service", zap.Error(err))
+				pc.Status.MarkChannelServiceFailed("ChannelServiceFailed", fmt.Sprint("Channel Service failed: ", err))
+				return nil, err
+			}
+			return svc, nil
+		}
+		logging.FromContext(ctx).Error("Unable to get the channel service", zap.Error(err))
+		pc.Status.MarkChannelServiceUnknown("ChannelServiceGetFailed", fmt.Sprint("Unable to get the channel service: ", err))
+		return nil, err
+	} else if !equality.Semantic.DeepEqual(svc.Spec, expected.Spec) {
+		svc = svc.DeepCopy()
+		svc.Spec = expected.Spec
+
+		svc, err = r.kubeClientSet.CoreV1().Services(pc.Namespace).Update(ctx, svc, metav1.UpdateOptions{})
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to update the channel service", zap.Error(err))
+			pc.Status.MarkChannelServiceFailed("ChannelServiceFailed", fmt.Sprint("Channel Service failed: ", err))
+			return nil, err
+		}
+	}
+
+	// Check to make sure that our PersistentChannel owns this service and if not, complain.
+	if !metav1.IsControlledBy(svc, pc) {
+		err := fmt.Errorf("persistentchannel: %s/%s does not own Service: %q", pc.Namespace, pc.Name, svc.Name)
+		pc.Status.MarkChannelServiceFailed("ChannelServiceFailed", fmt.Sprint("Channel Service failed: ", err))
+		return nil, err
+	}
+	return svc, nil
+}
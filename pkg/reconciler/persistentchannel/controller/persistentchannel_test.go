@@ -0,0 +1,499 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	fakeeventingclient "knative.dev/eventing/pkg/client/injection/client/fake"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+	"knative.dev/pkg/network"
+
+	"knative.dev/eventing/pkg/client/injection/reconciler/messaging/v1/persistentchannel"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	clientgotesting "k8s.io/client-go/testing"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	v1 "knative.dev/eventing/pkg/apis/messaging/v1"
+	"knative.dev/eventing/pkg/reconciler/persistentchannel/controller/resources"
+	. "knative.dev/eventing/pkg/reconciler/testing/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+	logtesting "knative.dev/pkg/logging/testing"
+	. "knative.dev/pkg/reconciler/testing"
+)
+
+const (
+	testNS                = "test-namespace"
+	pcName                = "test-pc"
+	channelServiceAddress = "test-pc-kn-channel.test-namespace.svc.cluster.local"
+
+	pcGeneration = 7
+)
+
+func init() {
+	// Add types to scheme
+	_ = v1.AddToScheme(scheme.Scheme)
+	_ = duckv1.AddToScheme(scheme.Scheme)
+}
+
+func TestAllCases(t *testing.T) {
+	pcKey := testNS + "/" + pcName
+	subscriber1UID := types.UID("2f9b5e8e-deb6-11e8-9f32-f2801f1b9fd1")
+	subscriber2UID := types.UID("34c5aec8-deb6-11e8-9f32-f2801f1b9fd1")
+	subscriber1Generation := int64(1)
+	subscriber2Generation := int64(2)
+
+	subscribers := []eventingduckv1.SubscriberSpec{{
+		UID:           subscriber1UID,
+		Generation:    subscriber1Generation,
+		SubscriberURI: apis.HTTP("call1"),
+		ReplyURI:      apis.HTTP("sink2"),
+	}, {
+		UID:           subscriber2UID,
+		Generation:    subscriber2Generation,
+		SubscriberURI: apis.HTTP("call2"),
+		ReplyURI:      apis.HTTP("sink2"),
+	}}
+
+	subscriberStatuses := []eventingduckv1.SubscriberStatus{{
+		UID:                subscriber1UID,
+		ObservedGeneration: subscriber1Generation,
+		Ready:              "True",
+	}, {
+		UID:                subscriber2UID,
+		ObservedGeneration: subscriber2Generation,
+		Ready:              "True",
+	}}
+
+	table := TableTest{
+		{
+			Name: "bad workqueue key",
+			// Make sure Reconcile handles bad keys.
+			Key: "too/many/parts",
+		}, {
+			Name: "key not found",
+			// Make sure Reconcile handles good keys that don't exist.
+			Key: "foo/not-found",
+		}, {
+			Name: "deleting",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeleted)},
+			WantErr: false,
+		}, {
+			Name: "deployment does not exist",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				NewPersistentChannel(pcName, testNS),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentFailed("DispatcherDeploymentDoesNotExist", "Dispatcher Deployment does not exist")),
+			}},
+			WantEvents: []string{
+				Eventf(corev1.EventTypeWarning, "DispatcherDeploymentFailed", `Reconciling dispatcher Deployment failed with: deployment.apps "persistent-channel-dispatcher" not found`),
+			},
+		}, {
+			Name: "the status of deployment is false",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				makeFalseDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewPersistentChannel(pcName, testNS),
+			},
+			WantErr: false,
+			WantCreates: []runtime.Object{
+				makeChannelService(NewPersistentChannel(pcName, testNS)),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentFailed("DispatcherDeploymentFalse", "The status of Dispatcher Deployment is False: Deployment Failed : Deployment Failed"),
+					WithPersistentChannelServiceReady(),
+					WithPersistentChannelEndpointsReady(),
+					WithPersistentChannelChannelServiceReady(),
+					WithPersistentChannelAddress(channelServiceAddress)),
+			}},
+		}, {
+			Name: "the status of deployment is unknown",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				makeUnknownDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewPersistentChannel(pcName, testNS),
+			},
+			WantErr: false,
+			WantCreates: []runtime.Object{
+				makeChannelService(NewPersistentChannel(pcName, testNS)),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentUnknown("DispatcherDeploymentUnknown", "The status of Dispatcher Deployment is Unknown: Deployment Unknown : Deployment Unknown"),
+					WithPersistentChannelServiceReady(),
+					WithPersistentChannelEndpointsReady(),
+					WithPersistentChannelChannelServiceReady(),
+					WithPersistentChannelAddress(channelServiceAddress)),
+			}},
+		}, {
+			Name: "Service does not exist",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				NewPersistentChannel(pcName, testNS),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelServicetNotReady("DispatcherServiceDoesNotExist", "Dispatcher Service does not exist")),
+			}},
+			WantEvents: []string{
+				Eventf(corev1.EventTypeWarning, "DispatcherServiceFailed", `Reconciling dispatcher Service failed: service "persistent-channel-dispatcher" not found`),
+			},
+		}, {
+			Name: "Endpoints does not exist",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				NewPersistentChannel(pcName, testNS),
+			},
+			WantErr: true,
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelServiceReady(),
+					WithPersistentChannelEndpointsNotReady("DispatcherEndpointsDoesNotExist", "Dispatcher Endpoints does not exist"),
+				),
+			}},
+			WantEvents: []string{
+				Eventf(corev1.EventTypeWarning, "InternalError", `endpoints "persistent-channel-dispatcher" not found`),
+			},
+		}, {
+			Name: "Endpoints not ready",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeEmptyEndpoints(),
+				NewPersistentChannel(pcName, testNS),
+			},
+			WantErr: true,
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelServiceReady(),
+					WithPersistentChannelEndpointsNotReady("DispatcherEndpointsNotReady", "There are no endpoints ready for Dispatcher service"),
+				),
+			}},
+			WantEvents: []string{
+				Eventf(corev1.EventTypeWarning, "InternalError", `there are no endpoints ready for Dispatcher service`),
+			},
+		}, {
+			Name: "Works, creates new channel",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewPersistentChannel(pcName, testNS,
+					WithPersistentChannelGeneration(pcGeneration)),
+			},
+			WantErr: false,
+			WantCreates: []runtime.Object{
+				makeChannelService(NewPersistentChannel(pcName, testNS)),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelGeneration(pcGeneration),
+					WithPersistentChannelStatusObservedGeneration(pcGeneration),
+					WithPersistentChannelServiceReady(),
+					WithPersistentChannelEndpointsReady(),
+					WithPersistentChannelChannelServiceReady(),
+					WithPersistentChannelAddress(channelServiceAddress),
+				),
+			}},
+		}, {
+			Name: "Works, channel exists",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewPersistentChannel(pcName, testNS),
+				makeChannelService(NewPersistentChannel(pcName, testNS)),
+			},
+			WantErr: false,
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelServiceReady(),
+					WithPersistentChannelEndpointsReady(),
+					WithPersistentChannelChannelServiceReady(),
+					WithPersistentChannelAddress(channelServiceAddress),
+				),
+			}},
+		}, {
+			Name: "channel exists, not owned by us",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewPersistentChannel(pcName, testNS),
+				makeChannelServiceNotOwnedByUs(NewPersistentChannel(pcName, testNS)),
+			},
+			WantErr: true,
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelServiceReady(),
+					WithPersistentChannelEndpointsReady(),
+					WithPersistentChannelChannelServiceNotReady("ChannelServiceFailed", `Channel Service failed: persistentchannel: test-namespace/test-pc does not own Service: "test-pc-kn-channel"`),
+				),
+			}},
+			WantEvents: []string{
+				Eventf(corev1.EventTypeWarning, "InternalError", `persistentchannel: test-namespace/test-pc does not own Service: "test-pc-kn-channel"`),
+			},
+		}, {
+			Name: "Works, channel exists with subscribers",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewPersistentChannel(pcName, testNS,
+					WithPersistentChannelSubscribers(subscribers)),
+				makeChannelService(NewPersistentChannel(pcName, testNS)),
+			},
+			WantErr: false,
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelServiceReady(),
+					WithPersistentChannelEndpointsReady(),
+					WithPersistentChannelChannelServiceReady(),
+					WithPersistentChannelSubscribers(subscribers),
+					WithPersistentChannelAddress(channelServiceAddress),
+				),
+			}},
+		}, {
+			Name: "Works, channel exists with subscribers, in status, not modified",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewPersistentChannel(pcName, testNS,
+					WithPersistentChannelSubscribers(subscribers),
+					WithPersistentChannelReadySubscriberAndGeneration(string(subscriber1UID), subscriber1Generation),
+					WithPersistentChannelReadySubscriberAndGeneration(string(subscriber2UID), subscriber2Generation)),
+				makeChannelService(NewPersistentChannel(pcName, testNS)),
+			},
+			WantErr: false,
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelServiceReady(),
+					WithPersistentChannelEndpointsReady(),
+					WithPersistentChannelChannelServiceReady(),
+					WithPersistentChannelSubscribers(subscribers),
+					WithPersistentChannelStatusSubscribers(subscriberStatuses),
+					WithPersistentChannelAddress(channelServiceAddress),
+				),
+			}},
+		}, {
+			Name: "channel does not exist, fails to create",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				makeReadyDeployment(),
+				makeService(),
+				makeReadyEndpoints(),
+				NewPersistentChannel(pcName, testNS),
+			},
+			WantErr: true,
+			WithReactors: []clientgotesting.ReactionFunc{
+				InduceFailure("create", "Services"),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewPersistentChannel(pcName, testNS,
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelServiceReady(),
+					WithPersistentChannelEndpointsReady(),
+					WithPersistentChannelChannelServiceNotReady("ChannelServiceFailed", "Channel Service failed: inducing failure for create services"),
+				),
+			}},
+			WantCreates: []runtime.Object{
+				makeChannelService(NewPersistentChannel(pcName, testNS)),
+			},
+			WantEvents: []string{
+				Eventf(corev1.EventTypeWarning, "InternalError", "inducing failure for create services"),
+			},
+		},
+	}
+
+	logger := logtesting.TestLogger(t)
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			kubeClientSet:    fakekubeclient.Get(ctx),
+			systemNamespace:  testNS,
+			deploymentLister: listers.GetDeploymentLister(),
+			serviceLister:    listers.GetServiceLister(),
+			endpointsLister:  listers.GetEndpointsLister(),
+		}
+		return persistentchannel.NewReconciler(ctx, logger,
+			fakeeventingclient.Get(ctx), listers.GetPersistentChannelLister(),
+			controller.GetEventRecorder(ctx), r)
+	},
+		false,
+		logger,
+	))
+}
+
+func makeDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNS,
+			Name:      dispatcherName,
+		},
+		Status: appsv1.DeploymentStatus{},
+	}
+}
+
+func makeReadyDeployment() *appsv1.Deployment {
+	d := makeDeployment()
+	d.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}}
+	return d
+}
+
+func makeFalseDeployment() *appsv1.Deployment {
+	d := makeDeployment()
+	d.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionFalse, Reason: "Deployment Failed", Message: "Deployment Failed"}}
+	return d
+}
+
+func makeUnknownDeployment() *appsv1.Deployment {
+	d := makeDeployment()
+	d.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionUnknown, Reason: "Deployment Unknown", Message: "Deployment Unknown"}}
+	return d
+}
+
+func makeService() *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNS,
+			Name:      dispatcherName,
+		},
+	}
+}
+
+func makeChannelService(pc *v1.PersistentChannel) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNS,
+			Name:      fmt.Sprintf("%s-kn-channel", pcName),
+			Labels: map[string]string{
+				resources.MessagingRoleLabel: resources.MessagingRole,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*kmeta.NewControllerRef(pc),
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: network.GetServiceHostname(dispatcherName, testNS),
+		},
+	}
+}
+
+func makeChannelServiceNotOwnedByUs(pc *v1.PersistentChannel) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNS,
+			Name:      fmt.Sprintf("%s-kn-channel", pcName),
+			Labels: map[string]string{
+				resources.MessagingRoleLabel: resources.MessagingRole,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: network.GetServiceHostname(dispatcherName, testNS),
+		},
+	}
+}
+
+func makeEmptyEndpoints() *corev1.Endpoints {
+	return &corev1.Endpoints{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Endpoints",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNS,
+			Name:      dispatcherName,
+		},
+	}
+}
+
+func makeReadyEndpoints() *corev1.Endpoints {
+	e := makeEmptyEndpoints()
+	e.Subsets = []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "1.1.1.1"}}}}
+	return e
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/cache"
+
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/tracing"
+	tracingconfig "knative.dev/pkg/tracing/config"
+
+	"knative.dev/eventing/pkg/channel"
+	"knative.dev/eventing/pkg/channel/multichannelfanout"
+	eventingclient "knative.dev/eventing/pkg/client/injection/client"
+	persistentchannelinformer "knative.dev/eventing/pkg/client/injection/informers/messaging/v1/persistentchannel"
+	persistentchannelreconciler "knative.dev/eventing/pkg/client/injection/reconciler/messaging/v1/persistentchannel"
+	"knative.dev/eventing/pkg/inmemorychannel"
+	"knative.dev/eventing/pkg/persistentchannel"
+)
+
+const (
+	readTimeout   = 15 * time.Minute
+	writeTimeout  = 15 * time.Minute
+	port          = 8080
+	finalizerName = "persistent-channel-dispatcher"
+)
+
+type envConfig struct {
+	PodName       string `envconfig:"POD_NAME" required:"true"`
+	ContainerName string `envconfig:"CONTAINER_NAME" required:"true"`
+	// DataDir is where the volume storing the events is mounted.
+	DataDir string `envconfig:"DATA_DIR" required:"true"`
+	// MaxChannelLogSize bounds the bytes stored for each channel, 0 meaning unbounded.
+	MaxChannelLogSize int64 `envconfig:"MAX_CHANNEL_LOG_SIZE" default:"0"`
+}
+
+// NewController initializes the controller and is called by the generated code.
+// Registers event handlers to enqueue events.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+
+	// Setup trace publishing.
+	iw := cmw.(*configmap.InformedWatcher)
+	if err := tracing.SetupDynamicPublishing(logger, iw, "persistent-channel-dispatcher", tracingconfig.ConfigName); err != nil {
+		logger.Fatalw("Error setting up trace publishing", zap.Error(err))
+	}
+	var env envConfig
+	if err := envconfig.Process("", &env); err != nil {
+		logger.Fatalw("Failed to process env var", zap.Error(err))
+	}
+
+	reporter := channel.NewStatsReporter(env.ContainerName, kmeta.ChildName(env.PodName, uuid.New().String()))
+	dispatcher := channel.NewMessageDispatcher(logger.Desugar())
+
+	sh := multichannelfanout.NewMessageHandler(ctx, logger.Desugar(), dispatcher, reporter)
+
+	// The HTTP server of the in-memory channels routes the events to the channel handlers.
+	args := &inmemorychannel.InMemoryMessageDispatcherArgs{
+		Port:         port,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		Handler:      sh,
+		Logger:       logger.Desugar(),
+	}
+	messageDispatcher := inmemorychannel.NewMessageDispatcher(args)
+
+	r := &Reconciler{
+		logger:                     logger.Desugar(),
+		dataDir:                    env.DataDir,
+		maxLogSize:                 env.MaxChannelLogSize,
+		multiChannelMessageHandler: sh,
+		dispatcher:                 dispatcher,
+		reporter:                   reporter,
+		messagingClientSet:         eventingclient.Get(ctx).MessagingV1(),
+		handlers:                   make(map[string]*persistentchannel.ChannelHandler),
+	}
+	impl := persistentchannelreconciler.NewImpl(ctx, r, func(impl *controller.Impl) controller.Options {
+		return controller.Options{SkipStatusUpdates: true, FinalizerName: finalizerName}
+	})
+
+	logging.FromContext(ctx).Info("Setting up event handlers")
+
+	// Watch for persistent channels.
+	persistentchannelinformer.Get(ctx).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    impl.Enqueue,
+		UpdateFunc: controller.PassNew(impl.Enqueue),
+		DeleteFunc: r.deleteFunc,
+	})
+
+	// Start the dispatcher.
+	go func() {
+		err := messageDispatcher.Start(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed stopping the persistent channel dispatcher.", zap.Error(err))
+		}
+		// The events are already on disk, the deliveries resume on restart.
+		r.closeHandlers()
+	}()
+
+	return impl
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"go.uber.org/zap"
+
+	"knative.dev/pkg/apis/duck"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/reconciler"
+
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	v1 "knative.dev/eventing/pkg/apis/messaging/v1"
+	"knative.dev/eventing/pkg/channel"
+	"knative.dev/eventing/pkg/channel/fanout"
+	"knative.dev/eventing/pkg/channel/multichannelfanout"
+	messagingv1 "knative.dev/eventing/pkg/client/clientset/versioned/typed/messaging/v1"
+	"knative.dev/eventing/pkg/kncloudevents"
+	"knative.dev/eventing/pkg/persistentchannel"
+)
+
+// Reconciler reconciles Persistent Channels.
+type Reconciler struct {
+	logger *zap.Logger
+	// dataDir is the directory of the volume storing the events of the channels.
+	dataDir string
+	// maxLogSize bounds the bytes stored for each channel, 0 meaning unbounded.
+	maxLogSize                 int64
+	multiChannelMessageHandler multichannelfanout.MultiChannelMessageHandler
+	dispatcher                 channel.MessageDispatcher
+	reporter                   channel.StatsReporter
+	messagingClientSet         messagingv1.MessagingV1Interface
+
+	mu sync.Mutex
+	// handlers holds the handler of each channel, by host name.
+	handlers map[string]*persistentchannel.ChannelHandler
+}
+
+func (r *Reconciler) ReconcileKind(ctx context.Context, pc *v1.PersistentChannel) reconciler.Event {
+	logging.FromContext(ctx).Infow("Reconciling", zap.Any("PersistentChannel", pc))
+
+	if !pc.Status.IsReady() {
+		logging.FromContext(ctx).Debug("PersistentChannel is not ready, skipping")
+		return nil
+	}
+
+	config, err := newConfigForPersistentChannel(pc)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error creating config for persistent channels", zap.Error(err))
+		return err
+	}
+
+	r.mu.Lock()
+	handler, ok := r.handlers[config.HostName]
+	if !ok {
+		// No handler yet, create one, resuming the deliveries stored in its directory.
+		handler, err = persistentchannel.NewChannelHandler(
+			logging.FromContext(ctx).Desugar(),
+			r.channelDir(pc),
+			r.maxLogSize,
+			channel.ChannelReference{Namespace: pc.Namespace, Name: pc.Name},
+			r.dispatcher,
+			r.reporter,
+		)
+		if err != nil {
+			r.mu.Unlock()
+			logging.FromContext(ctx).Error("Failed to create a new persistentchannel.ChannelHandler", zap.Error(err))
+			return err
+		}
+		handler.SetSubscriptions(ctx, config.FanoutConfig.Subscriptions)
+		r.handlers[config.HostName] = handler
+		r.multiChannelMessageHandler.SetChannelHandler(config.HostName, handler)
+	} else {
+		// Just update the config if necessary.
+		haveSubs := handler.GetSubscriptions(ctx)
+
+		// Ignore the closures, we stash the values that we can tell from if the values have actually changed.
+		if diff := cmp.Diff(config.FanoutConfig.Subscriptions, haveSubs, cmpopts.IgnoreFields(kncloudevents.RetryConfig{}, "Backoff", "CheckRetry")); diff != "" {
+			logging.FromContext(ctx).Info("Updating fanout config: ", zap.String("Diff", diff))
+			handler.SetSubscriptions(ctx, config.FanoutConfig.Subscriptions)
+		}
+	}
+	r.mu.Unlock()
+
+	// Then patch the subscribers to reflect that they are now ready to go
+	return r.patchSubscriberStatus(ctx, pc)
+}
+
+func (r *Reconciler) patchSubscriberStatus(ctx context.Context, pc *v1.PersistentChannel) error {
+	after := pc.DeepCopy()
+
+	after.Status.Subscribers = make([]eventingduckv1.SubscriberStatus, 0)
+	for _, sub := range pc.Spec.Subscribers {
+		after.Status.Subscribers = append(after.Status.Subscribers, eventingduckv1.SubscriberStatus{
+			UID:                sub.UID,
+			ObservedGeneration: sub.Generation,
+			Ready:              corev1.ConditionTrue,
+		})
+	}
+	jsonPatch, err := duck.CreatePatch(pc, after)
+	if err != nil {
+		return fmt.Errorf("creating JSON patch: %w", err)
+	}
+	// If there is nothing to patch, we are good, just return.
+	// Empty patch is [], hence we check for that.
+	if len(jsonPatch) == 0 {
+		return nil
+	}
+
+	patch, err := jsonPatch.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("marshaling JSON patch: %w", err)
+	}
+	patched, err := r.messagingClientSet.PersistentChannels(pc.Namespace).Patch(ctx, pc.Name, types.JSONPatchType, patch, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return fmt.Errorf("Failed patching: %w", err)
+	}
+	logging.FromContext(ctx).Debugw("Patched resource", zap.Any("patch", patch), zap.Any("patched", patched))
+	return nil
+}
+
+// newConfigForPersistentChannel creates a new Config for a single persistent channel.
+func newConfigForPersistentChannel(pc *v1.PersistentChannel) (*multichannelfanout.ChannelConfig, error) {
+	subs := make([]fanout.Subscription, len(pc.Spec.Subscribers))
+
+	for i, sub := range pc.Spec.Subscribers {
+		conf, err := fanout.SubscriberSpecToFanoutConfig(sub)
+		if err != nil {
+			return nil, err
+		}
+		subs[i] = *conf
+	}
+
+	return &multichannelfanout.ChannelConfig{
+		Namespace: pc.Namespace,
+		Name:      pc.Name,
+		HostName:  pc.Status.Address.URL.Host,
+		FanoutConfig: fanout.Config{
+			Subscriptions: subs,
+		},
+	}, nil
+}
+
+// channelDir returns the directory storing the events of the channel. It's unique to this
+// incarnation of the channel, so that a channel recreated with the same name doesn't receive
+// the events left by the previous one.
+func (r *Reconciler) channelDir(pc *v1.PersistentChannel) string {
+	return filepath.Join(r.dataDir, pc.Namespace, pc.Name, string(pc.UID))
+}
+
+// closeHandlers stops the deliveries of all the channels, which resume from their stored offsets
+// once the dispatcher restarts.
+func (r *Reconciler) closeHandlers() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hostName, handler := range r.handlers {
+		if err := handler.Close(); err != nil {
+			r.logger.Warn("Failed to close the channel handler", zap.String("hostName", hostName), zap.Error(err))
+		}
+		delete(r.handlers, hostName)
+	}
+}
+
+func (r *Reconciler) deleteFunc(obj interface{}) {
+	if obj == nil {
+		return
+	}
+	acc, err := kmeta.DeletionHandlingAccessor(obj)
+	if err != nil {
+		return
+	}
+	pc, ok := acc.(*v1.PersistentChannel)
+	if !ok || pc == nil {
+		return
+	}
+	if pc.Status.Address == nil || pc.Status.Address.URL == nil {
+		return
+	}
+	hostName := pc.Status.Address.URL.Host
+	if hostName == "" {
+		return
+	}
+	r.multiChannelMessageHandler.DeleteChannelHandler(hostName)
+
+	r.mu.Lock()
+	handler, ok := r.handlers[hostName]
+	delete(r.handlers, hostName)
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	// The channel is gone, and so are its events.
+	if err := handler.Delete(); err != nil {
+		r.logger.Warn("Failed to delete the events of the channel", zap.String("hostName", hostName), zap.Error(err))
+		return
+	}
+	// Clean up the parent directories if they're left empty.
+	_ = os.Remove(filepath.Dir(r.channelDir(pc)))
+	_ = os.Remove(filepath.Join(r.dataDir, pc.Namespace))
+}
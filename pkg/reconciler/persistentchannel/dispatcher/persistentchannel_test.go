@@ -0,0 +1,309 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	clientgotesting "k8s.io/client-go/testing"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	logtesting "knative.dev/pkg/logging/testing"
+	. "knative.dev/pkg/reconciler/testing"
+
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	v1 "knative.dev/eventing/pkg/apis/messaging/v1"
+	"knative.dev/eventing/pkg/channel"
+	"knative.dev/eventing/pkg/channel/fanout"
+	fakeeventingclient "knative.dev/eventing/pkg/client/injection/client/fake"
+	"knative.dev/eventing/pkg/client/injection/reconciler/messaging/v1/persistentchannel"
+	"knative.dev/eventing/pkg/kncloudevents"
+	pchandler "knative.dev/eventing/pkg/persistentchannel"
+	. "knative.dev/eventing/pkg/reconciler/testing/v1"
+)
+
+const (
+	testNS                = "test-namespace"
+	pcName                = "test-pc"
+	pcUID                 = types.UID("8a7d2f8e-1f7b-4c3e-9a56-0c2bb8d9f001")
+	channelServiceAddress = "test-pc-kn-channel.test-namespace.svc.cluster.local"
+	twoSubscriberPatch    = `[{"op":"add","path":"/status/subscribers","value":[{"observedGeneration":1,"ready":"True","uid":"2f9b5e8e-deb6-11e8-9f32-f2801f1b9fd1"},{"observedGeneration":2,"ready":"True","uid":"34c5aec8-deb6-11e8-9f32-f2801f1b9fd1"}]}]`
+)
+
+var (
+	subscriber1UID = types.UID("2f9b5e8e-deb6-11e8-9f32-f2801f1b9fd1")
+	subscriber2UID = types.UID("34c5aec8-deb6-11e8-9f32-f2801f1b9fd1")
+
+	subscriber1 = eventingduckv1.SubscriberSpec{
+		UID:           subscriber1UID,
+		Generation:    1,
+		SubscriberURI: apis.HTTP("call1"),
+		ReplyURI:      apis.HTTP("sink2"),
+	}
+	subscriber2 = eventingduckv1.SubscriberSpec{
+		UID:           subscriber2UID,
+		Generation:    2,
+		SubscriberURI: apis.HTTP("call2"),
+		ReplyURI:      apis.HTTP("sink2"),
+	}
+
+	subscribers = []eventingduckv1.SubscriberSpec{subscriber1, subscriber2}
+)
+
+func init() {
+	// Add types to scheme
+	_ = v1.AddToScheme(scheme.Scheme)
+}
+
+func newTestReconciler(t *testing.T, dataDir string) *Reconciler {
+	return &Reconciler{
+		logger:                     logtesting.TestLogger(t).Desugar(),
+		dataDir:                    dataDir,
+		multiChannelMessageHandler: newFakeMultiChannelHandler(),
+		dispatcher:                 channel.NewMessageDispatcher(nil),
+		reporter:                   channel.NewStatsReporter("testcontainer", "testpod"),
+		handlers:                   make(map[string]*pchandler.ChannelHandler),
+	}
+}
+
+func TestAllCases(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "persistentchannel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	const pcKey = testNS + "/" + pcName
+	table := TableTest{
+		{
+			Name: "bad workqueue key",
+			// Make sure Reconcile handles bad keys.
+			Key: "too/many/parts",
+		}, {
+			Name: "pc not ready",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				NewPersistentChannel(pcName, testNS, WithInitPersistentChannelConditions),
+			},
+		}, {
+			Name: "with subscribers",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				NewPersistentChannel(pcName, testNS,
+					WithPersistentChannelUID(pcUID),
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelReady(channelServiceAddress),
+					WithPersistentChannelSubscribers(subscribers)),
+			},
+			WantPatches: []clientgotesting.PatchActionImpl{
+				makePatch(testNS, pcName, twoSubscriberPatch),
+			},
+		}, {
+			Name: "with subscribers, patch fails",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				NewPersistentChannel(pcName, testNS,
+					WithPersistentChannelUID(pcUID),
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelReady(channelServiceAddress),
+					WithPersistentChannelSubscribers(subscribers)),
+			},
+			WithReactors: []clientgotesting.ReactionFunc{
+				InduceFailure("patch", "persistentchannels/status"),
+			},
+			WantPatches: []clientgotesting.PatchActionImpl{
+				makePatch(testNS, pcName, twoSubscriberPatch),
+			},
+			WantEvents: []string{
+				Eventf(corev1.EventTypeWarning, "InternalError", "Failed patching: inducing failure for patch persistentchannels"),
+			},
+			WantErr: true,
+		}, {
+			Name: "with subscribers, no patch",
+			Key:  pcKey,
+			Objects: []runtime.Object{
+				NewPersistentChannel(pcName, testNS,
+					WithPersistentChannelUID(pcUID),
+					WithInitPersistentChannelConditions,
+					WithPersistentChannelDeploymentReady(),
+					WithPersistentChannelReady(channelServiceAddress),
+					WithPersistentChannelSubscribers(subscribers),
+					WithPersistentChannelReadySubscriberAndGeneration(string(subscriber1UID), 1),
+					WithPersistentChannelReadySubscriberAndGeneration(string(subscriber2UID), 2)),
+			},
+		},
+	}
+
+	logger := logtesting.TestLogger(t)
+	var reconcilers []*Reconciler
+	defer func() {
+		for _, r := range reconcilers {
+			r.closeHandlers()
+		}
+	}()
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := newTestReconciler(t, dataDir)
+		r.messagingClientSet = fakeeventingclient.Get(ctx).MessagingV1()
+		reconcilers = append(reconcilers, r)
+		return persistentchannel.NewReconciler(ctx, logger,
+			fakeeventingclient.Get(ctx), listers.GetPersistentChannelLister(),
+			controller.GetEventRecorder(ctx), r, controller.Options{SkipStatusUpdates: true, FinalizerName: finalizerName})
+	}, false, logger))
+}
+
+func TestReconciler_ReconcileKind(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "persistentchannel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	pc := NewPersistentChannel(pcName, testNS,
+		WithPersistentChannelUID(pcUID),
+		WithInitPersistentChannelConditions,
+		WithPersistentChannelDeploymentReady(),
+		WithPersistentChannelReady(channelServiceAddress),
+		WithPersistentChannelSubscribers(subscribers))
+	ctx, fakeEventingClient := fakeeventingclient.With(context.Background(), pc)
+	r := newTestReconciler(t, dataDir)
+	r.messagingClientSet = fakeEventingClient.MessagingV1()
+	defer r.closeHandlers()
+
+	wantSubs := []fanout.Subscription{
+		{UID: subscriber1UID,
+			Subscriber: apis.HTTP("call1").URL(),
+			Reply:      apis.HTTP("sink2").URL()},
+		{UID: subscriber2UID,
+			Subscriber: apis.HTTP("call2").URL(),
+			Reply:      apis.HTTP("sink2").URL()},
+	}
+	// Reconcile twice, to create the handler, then to update it.
+	for _, subs := range [][]eventingduckv1.SubscriberSpec{{subscriber1}, subscribers} {
+		pc.Spec.Subscribers = subs
+		if err := r.ReconcileKind(ctx, pc); err != nil {
+			t.Fatal("ReconcileKind() =", err)
+		}
+	}
+
+	handler := r.multiChannelMessageHandler.GetChannelHandler(channelServiceAddress)
+	if handler == nil {
+		t.Fatal("Did not get handler")
+	}
+	if diff := cmp.Diff(wantSubs, handler.GetSubscriptions(context.TODO()), cmpopts.IgnoreFields(kncloudevents.RetryConfig{}, "Backoff", "CheckRetry")); diff != "" {
+		t.Error("unexpected subs (+want/-got)", diff)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, testNS, pcName, string(pcUID))); err != nil {
+		t.Error("Expected the channel directory to be created:", err)
+	}
+}
+
+func TestReconciler_Deletion(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "persistentchannel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	pc := NewPersistentChannel(pcName, testNS,
+		WithPersistentChannelUID(pcUID),
+		WithInitPersistentChannelConditions,
+		WithPersistentChannelDeploymentReady(),
+		WithPersistentChannelReady(channelServiceAddress))
+	ctx, fakeEventingClient := fakeeventingclient.With(context.Background(), pc)
+	r := newTestReconciler(t, dataDir)
+	r.messagingClientSet = fakeEventingClient.MessagingV1()
+	if err := r.ReconcileKind(ctx, pc); err != nil {
+		t.Fatal("ReconcileKind() =", err)
+	}
+
+	r.deleteFunc(pc)
+	if r.multiChannelMessageHandler.GetChannelHandler(channelServiceAddress) != nil {
+		t.Error("Got handler")
+	}
+	if len(r.handlers) != 0 {
+		t.Error("Expected the channel handler to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, testNS)); !os.IsNotExist(err) {
+		t.Error("Expected the channel directory to be deleted, got", err)
+	}
+}
+
+func TestReconciler_InvalidInputs(t *testing.T) {
+	testCases := map[string]struct {
+		pc interface{}
+	}{
+		"nil": {},
+		"With no address": {
+			pc: NewPersistentChannel(pcName, testNS, WithPersistentChannelDeleted),
+		},
+		"With invalid type": {
+			pc: &subscriber1,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			r := newTestReconciler(t, "")
+			r.deleteFunc(tc.pc)
+		})
+	}
+}
+
+func makePatch(namespace, name, patch string) clientgotesting.PatchActionImpl {
+	return clientgotesting.PatchActionImpl{
+		ActionImpl: clientgotesting.ActionImpl{
+			Namespace: namespace,
+		},
+		Name:  name,
+		Patch: []byte(patch),
+	}
+}
+
+type fakeMultiChannelHandler struct {
+	handlers map[string]fanout.MessageHandler
+}
+
+func newFakeMultiChannelHandler() *fakeMultiChannelHandler {
+	return &fakeMultiChannelHandler{handlers: make(map[string]fanout.MessageHandler, 1)}
+}
+
+func (f *fakeMultiChannelHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {}
+
+func (f *fakeMultiChannelHandler) SetChannelHandler(host string, handler fanout.MessageHandler) {
+	f.handlers[host] = handler
+}
+
+func (f *fakeMultiChannelHandler) DeleteChannelHandler(host string) {
+	delete(f.handlers, host)
+}
+
+func (f *fakeMultiChannelHandler) GetChannelHandler(host string) fanout.MessageHandler {
+	return f.handlers[host]
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/kmeta"
+)
+
+// MakeRole creates a Role object for the given referable object, allowing verbs on the core
+// resource named resourceNames in its namespace.
+func MakeRole(obj kmeta.OwnerRefable, name, resource string, verbs, resourceNames []string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: obj.GetObjectMeta().GetNamespace(),
+			Name:      name,
+			OwnerReferences: []metav1.OwnerReference{
+				*kmeta.NewControllerRef(obj),
+			},
+		},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{""},
+			Resources:     []string{resource},
+			Verbs:         verbs,
+			ResourceNames: resourceNames,
+		}},
+	}
+}
+
+// MakeRoleBinding creates a RoleBinding object for the given referable object, binding the Role
+// name of its namespace to the ServiceAccount sa.
+func MakeRoleBinding(obj kmeta.OwnerRefable, name string, sa types.NamespacedName) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: obj.GetObjectMeta().GetNamespace(),
+			Name:      name,
+			OwnerReferences: []metav1.OwnerReference{
+				*kmeta.NewControllerRef(obj),
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     name,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      "ServiceAccount",
+			Namespace: sa.Namespace,
+			Name:      sa.Name,
+		}},
+	}
+}
@@ -148,6 +148,7 @@ func (r Reconciler) checkChannelStatusForSubscription(ctx context.Context, chann
 	case corev1.ConditionFalse:
 		sub.Status.MarkChannelFailed(subscriptionNotMarkedReadyByChannel, "Subscription marked by Channel as False")
 	}
+	sub.Status.PropagateDeliveryHealth(&ss)
 
 	return nil
 }
@@ -308,10 +309,14 @@ func (r *Reconciler) getSubStatusV1(subscription *v1.Subscription, channel *even
 		if sub.UID == subscription.GetUID() &&
 			sub.ObservedGeneration == subscription.GetGeneration() {
 			return eventingduckv1.SubscriberStatus{
-				UID:                sub.UID,
-				ObservedGeneration: sub.ObservedGeneration,
-				Ready:              sub.Ready,
-				Message:            sub.Message,
+				UID:                     sub.UID,
+				ObservedGeneration:      sub.ObservedGeneration,
+				Ready:                   sub.Ready,
+				Message:                 sub.Message,
+				LastDeliveryTime:        sub.LastDeliveryTime,
+				LastFailureTime:         sub.LastFailureTime,
+				LastFailureResponseCode: sub.LastFailureResponseCode,
+				ConsecutiveFailures:     sub.ConsecutiveFailures,
 			}, nil
 		}
 	}
@@ -591,13 +596,24 @@ func deliverySpec(sub *v1.Subscription) *eventingduckv1beta1.DeliverySpec {
 			},
 		}
 	}
-	if sub.Spec.Delivery != nil && (sub.Spec.Delivery.BackoffDelay != nil || sub.Spec.Delivery.Retry != nil || sub.Spec.Delivery.BackoffPolicy != nil) {
+	if sub.Spec.Delivery != nil && (sub.Spec.Delivery.BackoffDelay != nil || sub.Spec.Delivery.Retry != nil || sub.Spec.Delivery.BackoffPolicy != nil || sub.Spec.Delivery.BackoffMaxDelay != nil || sub.Spec.Delivery.BackoffJitter != nil || sub.Spec.Delivery.Timeout != nil ||
+		len(sub.Spec.Delivery.RetryableStatusCodes) != 0 || len(sub.Spec.Delivery.NonRetryableStatusCodes) != 0 || sub.Spec.Delivery.DeadLetterEnrichment != nil ||
+		sub.Spec.Delivery.Ordering != nil || sub.Spec.Delivery.OrderingKey != nil || sub.Spec.Delivery.Auth != nil) {
 		if delivery == nil {
 			delivery = &eventingduckv1beta1.DeliverySpec{}
 		}
 		delivery.BackoffPolicy = (*eventingduckv1beta1.BackoffPolicyType)(sub.Spec.Delivery.BackoffPolicy)
 		delivery.Retry = sub.Spec.Delivery.Retry
 		delivery.BackoffDelay = sub.Spec.Delivery.BackoffDelay
+		delivery.BackoffMaxDelay = sub.Spec.Delivery.BackoffMaxDelay
+		delivery.BackoffJitter = (*eventingduckv1beta1.BackoffJitterType)(sub.Spec.Delivery.BackoffJitter)
+		delivery.Timeout = sub.Spec.Delivery.Timeout
+		delivery.RetryableStatusCodes = sub.Spec.Delivery.RetryableStatusCodes
+		delivery.NonRetryableStatusCodes = sub.Spec.Delivery.NonRetryableStatusCodes
+		delivery.DeadLetterEnrichment = sub.Spec.Delivery.DeadLetterEnrichment
+		delivery.Ordering = (*eventingduckv1beta1.DeliveryOrderingType)(sub.Spec.Delivery.Ordering)
+		delivery.OrderingKey = sub.Spec.Delivery.OrderingKey
+		delivery.Auth = (*eventingduckv1beta1.DeliveryAuth)(sub.Spec.Delivery.Auth)
 	}
 	return delivery
 }
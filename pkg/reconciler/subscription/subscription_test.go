@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"k8s.io/utils/pointer"
 	"knative.dev/pkg/injection/clients/dynamicclient"
@@ -141,6 +142,8 @@ func init() {
 
 func TestAllCases(t *testing.T) {
 	linear := eventingduck.BackoffPolicyLinear
+	lastDeliveryTime := metav1.NewTime(time.Date(2020, 10, 1, 9, 0, 0, 0, time.UTC))
+	lastFailureTime := metav1.NewTime(time.Date(2020, 10, 1, 10, 0, 0, 0, time.UTC))
 
 	table := TableTest{
 		{
@@ -217,6 +220,82 @@ func TestAllCases(t *testing.T) {
 					MarkSubscriptionReady,
 				),
 			}},
+		}, {
+			Name: "subscription goes ready, delivery failing",
+			Objects: []runtime.Object{
+				NewSubscription(subscriptionName, testNS,
+					WithSubscriptionUID(subscriptionUID),
+					WithSubscriptionChannel(imcV1GVK, channelName),
+					WithSubscriptionSubscriberRef(subscriberGVK, subscriberName, testNS),
+					WithSubscriptionReply(imcV1GVK, replyName, testNS),
+					WithInitSubscriptionConditions,
+					WithSubscriptionFinalizers(finalizerName),
+					MarkReferencesResolved,
+					MarkAddedToChannel,
+					WithSubscriptionPhysicalSubscriptionSubscriber(subscriberURI),
+					WithSubscriptionPhysicalSubscriptionReply(replyURI),
+				),
+				// Subscriber
+				NewUnstructured(subscriberGVK, subscriberName, testNS,
+					WithUnstructuredAddressable(subscriberDNS),
+				),
+				// Reply
+				NewInMemoryChannel(replyName, testNS,
+					WithInitInMemoryChannelConditions,
+					WithInMemoryChannelAddress(replyDNS),
+				),
+				// Channel
+				NewInMemoryChannel(channelName, testNS,
+					WithInitInMemoryChannelConditions,
+					WithInMemoryChannelReady(channelDNS),
+					WithInMemoryChannelSubscribers([]eventingduck.SubscriberSpec{{
+						UID:           subscriptionUID,
+						Generation:    0,
+						SubscriberURI: subscriberURI,
+						ReplyURI:      replyURI,
+					}, {
+						UID:           "34c5aec8-deb6-11e8-9f32-f2801f1b9fd1",
+						Generation:    1,
+						SubscriberURI: apis.HTTP("call2"),
+						ReplyURI:      apis.HTTP("sink2"),
+					}}),
+					WithInMemoryChannelStatusSubscribers([]eventingduck.SubscriberStatus{{
+						UID:                     subscriptionUID,
+						ObservedGeneration:      0,
+						Ready:                   "True",
+						LastDeliveryTime:        &lastDeliveryTime,
+						LastFailureTime:         &lastFailureTime,
+						LastFailureResponseCode: 503,
+						ConsecutiveFailures:     12,
+					}, {
+						UID:                "34c5aec8-deb6-11e8-9f32-f2801f1b9fd1",
+						ObservedGeneration: 1,
+						Ready:              "True",
+					}}),
+				),
+			},
+			Key:     testNS + "/" + subscriptionName,
+			WantErr: false,
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: NewSubscription(subscriptionName, testNS,
+					WithSubscriptionUID(subscriptionUID),
+					WithSubscriptionChannel(imcV1GVK, channelName),
+					WithSubscriptionSubscriberRef(subscriberGVK, subscriberName, testNS),
+					WithSubscriptionReply(imcV1GVK, replyName, testNS),
+					WithInitSubscriptionConditions,
+					WithSubscriptionFinalizers(finalizerName),
+					WithSubscriptionPhysicalSubscriptionSubscriber(subscriberURI),
+					WithSubscriptionPhysicalSubscriptionReply(replyURI),
+					// - Status Update -
+					MarkSubscriptionReady,
+					WithSubscriptionDeliveryHealth(eventingduck.SubscriberStatus{
+						LastDeliveryTime:        &lastDeliveryTime,
+						LastFailureTime:         &lastFailureTime,
+						LastFailureResponseCode: 503,
+						ConsecutiveFailures:     12,
+					}),
+				),
+			}},
 		}, {
 			Name: "channel does not exist",
 			Objects: []runtime.Object{
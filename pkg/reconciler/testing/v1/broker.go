@@ -19,6 +19,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	"knative.dev/eventing/pkg/apis/eventing"
 	v1 "knative.dev/eventing/pkg/apis/eventing/v1"
 	"knative.dev/eventing/pkg/client/injection/reconciler/eventing/v1/broker"
@@ -176,6 +177,39 @@ func WithBrokerClass(bc string) BrokerOption {
 	}
 }
 
+// WithBrokerDelivery sets the delivery spec of the Broker.
+func WithBrokerDelivery(delivery *eventingduckv1.DeliverySpec) BrokerOption {
+	return func(b *v1.Broker) {
+		b.Spec.Delivery = delivery
+	}
+}
+
+// WithBrokerDeliveryMode sets the delivery mode annotation of the Broker.
+func WithBrokerDeliveryMode(mode string) BrokerOption {
+	return func(b *v1.Broker) {
+		annotations := b.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string, 1)
+		}
+		annotations[eventing.BrokerDeliveryModeAnnotationKey] = mode
+		b.SetAnnotations(annotations)
+	}
+}
+
+// WithBrokerIngressHMACSecret sets the annotations of the Broker authenticating its publishers
+// with the HMAC key of the Secret secretName.
+func WithBrokerIngressHMACSecret(secretName string) BrokerOption {
+	return func(b *v1.Broker) {
+		annotations := b.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string, 2)
+		}
+		annotations[eventing.BrokerIngressAuthAnnotationKey] = eventing.BrokerIngressAuthHMAC
+		annotations[eventing.BrokerIngressHMACSecretAnnotationKey] = secretName
+		b.SetAnnotations(annotations)
+	}
+}
+
 func WithChannelAddressAnnotation(address string) BrokerOption {
 	return func(b *v1.Broker) {
 		if b.Status.Annotations == nil {
@@ -145,6 +145,17 @@ func WithInMemoryChannelAddress(a string) InMemoryChannelOption {
 	}
 }
 
+// WithInMemoryChannelPathAddress sets the address of the channel addressed by path on host.
+func WithInMemoryChannelPathAddress(host string) InMemoryChannelOption {
+	return func(imc *v1.InMemoryChannel) {
+		imc.Status.SetAddress(&apis.URL{
+			Scheme: "http",
+			Host:   host,
+			Path:   "/" + imc.Namespace + "/" + imc.Name,
+		})
+	}
+}
+
 func WithInMemoryChannelReady(host string) InMemoryChannelOption {
 	return func(imc *v1.InMemoryChannel) {
 		imc.Status.SetAddress(&apis.URL{
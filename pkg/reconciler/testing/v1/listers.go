@@ -134,6 +134,10 @@ func (l *Listers) GetInMemoryChannelLister() messaginglisters.InMemoryChannelLis
 	return messaginglisters.NewInMemoryChannelLister(l.indexerFor(&messagingv1.InMemoryChannel{}))
 }
 
+func (l *Listers) GetPersistentChannelLister() messaginglisters.PersistentChannelLister {
+	return messaginglisters.NewPersistentChannelLister(l.indexerFor(&messagingv1.PersistentChannel{}))
+}
+
 func (l *Listers) GetMessagingChannelLister() messaginglisters.ChannelLister {
 	return messaginglisters.NewChannelLister(l.indexerFor(&messagingv1.Channel{}))
 }
@@ -178,6 +182,10 @@ func (l *Listers) GetServiceLister() corev1listers.ServiceLister {
 	return corev1listers.NewServiceLister(l.indexerFor(&corev1.Service{}))
 }
 
+func (l *Listers) GetRoleLister() rbacv1listers.RoleLister {
+	return rbacv1listers.NewRoleLister(l.indexerFor(&rbacv1.Role{}))
+}
+
 func (l *Listers) GetRoleBindingLister() rbacv1listers.RoleBindingLister {
 	return rbacv1listers.NewRoleBindingLister(l.indexerFor(&rbacv1.RoleBinding{}))
 }
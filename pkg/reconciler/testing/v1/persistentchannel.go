@@ -0,0 +1,183 @@
+/*
+Copyright 2020 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	duckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/apis/messaging"
+	v1 "knative.dev/eventing/pkg/apis/messaging/v1"
+	"knative.dev/pkg/apis"
+)
+
+// PersistentChannelOption enables further configuration of a v1.PersistentChannel.
+type PersistentChannelOption func(*v1.PersistentChannel)
+
+// NewPersistentChannel creates a v1.PersistentChannel with PersistentChannelOption .
+func NewPersistentChannel(name, namespace string, pcopt ...PersistentChannelOption) *v1.PersistentChannel {
+	pc := &v1.PersistentChannel{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: map[string]string{messaging.SubscribableDuckVersionAnnotation: "v1"},
+		},
+		Spec: v1.PersistentChannelSpec{},
+	}
+	for _, opt := range pcopt {
+		opt(pc)
+	}
+	pc.SetDefaults(context.Background())
+	return pc
+}
+
+func WithInitPersistentChannelConditions(pc *v1.PersistentChannel) {
+	pc.Status.InitializeConditions()
+}
+
+func WithPersistentChannelGeneration(gen int64) PersistentChannelOption {
+	return func(s *v1.PersistentChannel) {
+		s.Generation = gen
+	}
+}
+
+func WithPersistentChannelStatusObservedGeneration(gen int64) PersistentChannelOption {
+	return func(s *v1.PersistentChannel) {
+		s.Status.ObservedGeneration = gen
+	}
+}
+
+func WithPersistentChannelUID(uid types.UID) PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.UID = uid
+	}
+}
+
+func WithPersistentChannelDeleted(pc *v1.PersistentChannel) {
+	deleteTime := metav1.NewTime(time.Unix(1e9, 0))
+	pc.ObjectMeta.SetDeletionTimestamp(&deleteTime)
+}
+
+func WithPersistentChannelSubscribers(subscribers []duckv1.SubscriberSpec) PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Spec.Subscribers = subscribers
+	}
+}
+
+func WithPersistentChannelDeploymentFailed(reason, message string) PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.MarkDispatcherFailed(reason, message)
+	}
+}
+
+func WithPersistentChannelDeploymentUnknown(reason, message string) PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.MarkDispatcherUnknown(reason, message)
+	}
+}
+
+func WithPersistentChannelFinalizers(finalizers ...string) PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Finalizers = finalizers
+	}
+}
+
+func WithPersistentChannelDeploymentReady() PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.PropagateDispatcherStatus(&appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}}})
+	}
+}
+
+func WithPersistentChannelServicetNotReady(reason, message string) PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.MarkServiceFailed(reason, message)
+	}
+}
+
+func WithPersistentChannelServiceReady() PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.MarkServiceTrue()
+	}
+}
+
+func WithPersistentChannelChannelServiceNotReady(reason, message string) PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.MarkChannelServiceFailed(reason, message)
+	}
+}
+
+func WithPersistentChannelChannelServiceReady() PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.MarkChannelServiceTrue()
+	}
+}
+
+func WithPersistentChannelEndpointsNotReady(reason, message string) PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.MarkEndpointsFailed(reason, message)
+	}
+}
+
+func WithPersistentChannelEndpointsReady() PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.MarkEndpointsTrue()
+	}
+}
+
+func WithPersistentChannelAddress(a string) PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.SetAddress(&apis.URL{
+			Scheme: "http",
+			Host:   a,
+		})
+	}
+}
+
+func WithPersistentChannelReady(host string) PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.SetAddress(&apis.URL{
+			Scheme: "http",
+			Host:   host,
+		})
+		pc.Status.MarkChannelServiceTrue()
+		pc.Status.MarkEndpointsTrue()
+		pc.Status.MarkServiceTrue()
+	}
+}
+
+func WithPersistentChannelReadySubscriber(uid string) PersistentChannelOption {
+	return WithPersistentChannelReadySubscriberAndGeneration(uid, 0)
+}
+
+func WithPersistentChannelReadySubscriberAndGeneration(uid string, observedGeneration int64) PersistentChannelOption {
+	return func(c *v1.PersistentChannel) {
+		c.Status.Subscribers = append(c.Status.Subscribers, duckv1.SubscriberStatus{
+			UID:                types.UID(uid),
+			ObservedGeneration: observedGeneration,
+			Ready:              corev1.ConditionTrue,
+		})
+	}
+}
+
+func WithPersistentChannelStatusSubscribers(subscriberStatuses []duckv1.SubscriberStatus) PersistentChannelOption {
+	return func(pc *v1.PersistentChannel) {
+		pc.Status.Subscribers = subscriberStatuses
+	}
+}
@@ -193,6 +193,13 @@ func MarkSubscriptionReady(s *v1.Subscription) {
 	s.Status.MarkAddedToChannel()
 }
 
+// WithSubscriptionDeliveryHealth propagates the delivery health of the subscriber reported by the channel.
+func WithSubscriptionDeliveryHealth(ss eventingduckv1.SubscriberStatus) SubscriptionOption {
+	return func(s *v1.Subscription) {
+		s.Status.PropagateDeliveryHealth(&ss)
+	}
+}
+
 func MarkAddedToChannel(s *v1.Subscription) {
 	s.Status.MarkAddedToChannel()
 }
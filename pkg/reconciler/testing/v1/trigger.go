@@ -23,6 +23,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	v1 "knative.dev/eventing/pkg/apis/eventing/v1"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
@@ -56,6 +57,13 @@ func WithTriggerSubscriberURI(rawurl string) TriggerOption {
 	}
 }
 
+// WithTriggerDelivery sets the delivery spec of the Trigger.
+func WithTriggerDelivery(delivery *eventingduckv1.DeliverySpec) TriggerOption {
+	return func(t *v1.Trigger) {
+		t.Spec.Delivery = delivery
+	}
+}
+
 func WithTriggerSubscriberRef(gvk metav1.GroupVersionKind, name, namespace string) TriggerOption {
 	return func(t *v1.Trigger) {
 		t.Spec.Subscriber = duckv1.Destination{
@@ -178,6 +186,13 @@ func WithDependencyAnnotation(dependencyAnnotation string) TriggerOption {
 	}
 }
 
+// WithTriggerDeliverySpecIgnored marks the delivery spec of the Trigger as ignored.
+func WithTriggerDeliverySpecIgnored(reason, message string) TriggerOption {
+	return func(t *v1.Trigger) {
+		t.Status.MarkDeliverySpecIgnored(reason, message)
+	}
+}
+
 func WithTriggerDependencyReady() TriggerOption {
 	return func(t *v1.Trigger) {
 		t.Status.MarkDependencySucceeded()
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// secretGetTimeout bounds the time spent getting a Secret from the API server.
+	secretGetTimeout = 10 * time.Second
+
+	// maxSecretCacheEntries bounds the number of Secrets, and errors, a SecretCache holds.
+	maxSecretCacheEntries = 1024
+)
+
+// SecretCache gets Secrets by name and caches them for a TTL. Unlike an informer it needs
+// only the get permission on the Secrets it reads, so that a data plane component can be
+// granted access to the named Secrets it uses rather than to all the Secrets of the cluster.
+type SecretCache struct {
+	secrets clientcorev1.SecretsGetter
+	ttl     time.Duration
+	now     func() time.Time
+
+	mutex   sync.Mutex
+	entries map[types.NamespacedName]secretCacheEntry
+}
+
+type secretCacheEntry struct {
+	secret  *corev1.Secret
+	err     error
+	expires time.Time
+}
+
+// NewSecretCache creates a SecretCache getting the Secrets from secrets and caching them for
+// ttl. A ttl of zero disables the caching.
+func NewSecretCache(secrets clientcorev1.SecretsGetter, ttl time.Duration) *SecretCache {
+	return &SecretCache{
+		secrets: secrets,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[types.NamespacedName]secretCacheEntry),
+	}
+}
+
+// Get returns the Secret name in namespace. The returned Secret is shared and must not be
+// modified. The NotFound and Forbidden errors are cached as well, so that a Secret being
+// created, or one being granted access to, is seen after the TTL at most.
+func (c *SecretCache) Get(namespace, name string) (*corev1.Secret, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+
+	c.mutex.Lock()
+	e, ok := c.entries[key]
+	c.mutex.Unlock()
+	if ok && c.now().Before(e.expires) {
+		return e.secret, e.err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretGetTimeout)
+	defer cancel()
+	secret, err := c.secrets.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrs.IsNotFound(err) && !apierrs.IsForbidden(err) {
+			return nil, err
+		}
+		secret = nil
+	}
+	if c.ttl > 0 {
+		c.mutex.Lock()
+		c.store(key, secretCacheEntry{secret: secret, err: err, expires: c.now().Add(c.ttl)})
+		c.mutex.Unlock()
+	}
+	return secret, err
+}
+
+// store adds the entry e, dropping the expired entries, or all of them, once the cache is full.
+// c.mutex must be held.
+func (c *SecretCache) store(key types.NamespacedName, e secretCacheEntry) {
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxSecretCacheEntries {
+		now := c.now()
+		for k, old := range c.entries {
+			if !now.Before(old.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxSecretCacheEntries {
+			c.entries = make(map[types.NamespacedName]secretCacheEntry)
+		}
+	}
+	c.entries[key] = e
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+)
+
+func TestSecretCache(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	gets := 0
+	client.PrependReactor("get", "secrets", func(clientgotesting.Action) (bool, runtime.Object, error) {
+		gets++
+		return false, nil, nil
+	})
+
+	now := time.Unix(0, 0)
+	cache := NewSecretCache(client.CoreV1(), time.Minute)
+	cache.now = func() time.Time { return now }
+
+	// A missing Secret is cached until the TTL expires.
+	if _, err := cache.Get("ns", "creds"); !apierrs.IsNotFound(err) {
+		t.Fatal("Expected a NotFound error, got", err)
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "creds"}}
+	if _, err := client.CoreV1().Secrets("ns").Create(context.Background(), secret, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Get("ns", "creds"); !apierrs.IsNotFound(err) {
+		t.Fatal("Expected the cached NotFound error, got", err)
+	}
+	if gets != 1 {
+		t.Errorf("Expected 1 get, got %d", gets)
+	}
+
+	now = now.Add(time.Minute)
+	got, err := cache.Get("ns", "creds")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got.Name != "creds" {
+		t.Errorf("Expected the secret creds, got %q", got.Name)
+	}
+	if _, err := cache.Get("ns", "creds"); err != nil || gets != 2 {
+		t.Errorf("Expected the cached secret after 2 gets, got %d gets and error %v", gets, err)
+	}
+}
+
+func TestSecretCacheErrors(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var getErr error
+	client.PrependReactor("get", "secrets", func(clientgotesting.Action) (bool, runtime.Object, error) {
+		return true, nil, getErr
+	})
+	cache := NewSecretCache(client.CoreV1(), time.Minute)
+
+	// Transient errors are not cached.
+	getErr = errors.New("connection refused")
+	if _, err := cache.Get("ns", "creds"); err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+	getErr = apierrs.NewForbidden(schema.GroupResource{Resource: "secrets"}, "creds", errors.New("no role"))
+	if _, err := cache.Get("ns", "creds"); !apierrs.IsForbidden(err) {
+		t.Fatal("Expected a Forbidden error, got", err)
+	}
+	getErr = nil
+	if _, err := cache.Get("ns", "creds"); !apierrs.IsForbidden(err) {
+		t.Fatal("Expected the cached Forbidden error, got", err)
+	}
+}
+
+func TestSecretCacheBounded(t *testing.T) {
+	cache := NewSecretCache(fake.NewSimpleClientset().CoreV1(), time.Minute)
+	for i := 0; i <= maxSecretCacheEntries; i++ {
+		cache.Get("ns", fmt.Sprint("creds-", i))
+	}
+	if len(cache.entries) > maxSecretCacheEntries {
+		t.Errorf("Expected at most %d entries, got %d", maxSecretCacheEntries, len(cache.entries))
+	}
+}
+
+func TestSecretCacheDisabled(t *testing.T) {
+	cache := NewSecretCache(fake.NewSimpleClientset().CoreV1(), 0)
+	cache.Get("ns", "creds")
+	if len(cache.entries) != 0 {
+		t.Errorf("Expected no entries with a zero TTL, got %d", len(cache.entries))
+	}
+}